@@ -0,0 +1,764 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/transport/grpc"
+	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	"github.com/urfave/cli/v3"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	defaultAdminAddress = "localhost:50051"
+	defaultAdminTimeout = 10 * time.Second
+
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// adminCommands talk to a running registry instead of running one.
+var adminCommands = []*cli.Command{&relaysCmd, &relayCmd, &agentsCmd, &agentCmd, &placementCmd, &statusCmd, &auditCmd}
+
+var relaysCmd = cli.Command{
+	Name:  "relays",
+	Usage: "inspect the relays of a running registry",
+	Commands: []*cli.Command{{
+		Name:   "list",
+		Usage:  "list live relays",
+		Flags:  adminClientFlags(),
+		Action: adminAction(runRelaysList),
+	}},
+}
+
+var relayCmd = cli.Command{
+	Name:  "relay",
+	Usage: "act on a relay of a running registry",
+	Commands: []*cli.Command{{
+		Name:      "drain",
+		Usage:     "remove a relay and the agents placed on it (admin service)",
+		ArgsUsage: "<relay-id>",
+		Flags:     adminClientFlags(),
+		Action:    adminAction(runRelayDrain),
+	}},
+}
+
+var agentsCmd = cli.Command{
+	Name:  "agents",
+	Usage: "inspect the agents of a running registry",
+	Commands: []*cli.Command{{
+		Name:  "list",
+		Usage: "list live agents",
+		Flags: adminClientFlags(&cli.StringFlag{
+			Name:  AdminRelayFlag,
+			Usage: "only list the agents placed on this relay (admin service)",
+		}),
+		Action: adminAction(runAgentsList),
+	}},
+}
+
+var agentCmd = cli.Command{
+	Name:  "agent",
+	Usage: "act on an agent of a running registry",
+	Commands: []*cli.Command{{
+		Name:      "evict",
+		Usage:     "remove an agent and its placement (admin service)",
+		ArgsUsage: "<agent-id>",
+		Flags:     adminClientFlags(),
+		Action:    adminAction(runAgentEvict),
+	}},
+}
+
+var placementCmd = cli.Command{
+	Name:  "placement",
+	Usage: "inspect agent placements of a running registry",
+	Commands: []*cli.Command{
+		{
+			Name:      "get",
+			Usage:     "show the relay an agent is placed on",
+			ArgsUsage: "<agent-id>",
+			Flags:     adminClientFlags(),
+			Action:    adminAction(runPlacementGet),
+		},
+		{
+			Name:      "history",
+			Usage:     "show an agent's recent placement changes, newest first (admin service, --placement-history-enabled)",
+			ArgsUsage: "<agent-id>",
+			Flags:     adminClientFlags(),
+			Action:    adminAction(runPlacementHistory),
+		},
+	},
+}
+
+var statusCmd = cli.Command{
+	Name:   "status",
+	Usage:  "show the backend, sweep and cache status of a running registry (admin service)",
+	Flags:  adminClientFlags(),
+	Action: adminAction(runStatus),
+}
+
+var auditCmd = cli.Command{
+	Name:  "audit",
+	Usage: "read the audit log of a running registry (admin service, --audit-enabled)",
+	Commands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "list recent audit events, newest first",
+			Flags: adminClientFlags(
+				&cli.StringFlag{
+					Name:  AdminRelayFlag,
+					Usage: "only list events for this relay, including agents moved off it",
+				},
+				&cli.StringFlag{
+					Name:  AdminAgentFlag,
+					Usage: "only list events for this agent",
+				},
+				&cli.StringFlag{
+					Name:  AdminAuditTypeFlag,
+					Usage: "only list events of this type, e.g. agent_placed",
+				},
+				&cli.IntFlag{
+					Name:  AdminLimitFlag,
+					Usage: "maximum number of events to list (0 for all kept in memory)",
+				},
+			),
+			Action: adminAction(runAuditList),
+		},
+		{
+			Name:   "watch",
+			Usage:  "print audit events as they are recorded; --timeout stops watching",
+			Flags:  withoutTimeout(adminClientFlags()),
+			Action: adminAction(runAuditWatch),
+		},
+	},
+}
+
+// adminClientFlags returns the connection and output flags shared by the
+// commands that talk to a running registry, followed by extra.
+func adminClientFlags(extra ...cli.Flag) []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:  AdminAddressFlag,
+			Usage: "grpc address of the registry",
+			Value: defaultAdminAddress,
+		},
+		&cli.StringFlag{
+			Name:    AdminClientTokenFlag,
+			Usage:   "admin token, sent as a bearer token",
+			Sources: cli.EnvVars(registry.EnvPrefix + "_ADMIN_TOKEN"),
+		},
+		&cli.StringFlag{
+			Name:  AdminNamespaceFlag,
+			Usage: "tenant namespace to act on; defaults to the caller's tenant, or default",
+		},
+		&cli.BoolFlag{
+			Name:  AdminTLSFlag,
+			Usage: "connect over tls; implied by the other tls flags",
+		},
+		&cli.StringFlag{
+			Name:  AdminTLSCAFlag,
+			Usage: "ca certificate to verify the registry with instead of the system roots",
+		},
+		&cli.StringFlag{
+			Name:  AdminTLSServerNameFlag,
+			Usage: "name to verify the registry certificate against instead of the address host",
+		},
+		&cli.BoolFlag{
+			Name:  AdminTLSSkipVerifyFlag,
+			Usage: "do not verify the registry certificate",
+		},
+		&cli.StringFlag{
+			Name:  AdminTLSClientCertFlag,
+			Usage: "client certificate for mutual tls",
+		},
+		&cli.StringFlag{
+			Name:  AdminTLSClientKeyFlag,
+			Usage: "client key for mutual tls",
+		},
+		&cli.DurationFlag{
+			Name:  AdminTimeoutFlag,
+			Usage: "timeout for the whole command (0 for none)",
+			Value: defaultAdminTimeout,
+		},
+		&cli.StringFlag{
+			Name:    AdminOutputFlag,
+			Aliases: []string{"o"},
+			Usage:   "output format: table or json",
+			Value:   outputTable,
+		},
+	}
+
+	return append(flags, extra...)
+}
+
+// withoutTimeout defaults the timeout flag of flags to none, for commands
+// that run until interrupted.
+func withoutTimeout(flags []cli.Flag) []cli.Flag {
+	for _, flag := range flags {
+		if timeout, ok := flag.(*cli.DurationFlag); ok && timeout.Name == AdminTimeoutFlag {
+			timeout.Value = 0
+		}
+	}
+	return flags
+}
+
+// adminClient is a connection to a running registry.
+type adminClient struct {
+	conn   *gogrpc.ClientConn
+	api    registryv1.AeroRegistryClient
+	output string
+	out    io.Writer
+}
+
+// adminAction wraps run with the connection, timeout, admin token and
+// namespace set up from the shared admin client flags.
+func adminAction(run func(ctx context.Context, cmd *cli.Command, c *adminClient) error) cli.ActionFunc {
+	return func(ctx context.Context, cmd *cli.Command) error {
+		output := cmd.String(AdminOutputFlag)
+		if output != outputTable && output != outputJSON {
+			return fmt.Errorf("%w: %q", ErrInvalidOutput, output)
+		}
+
+		creds, err := adminTransportCredentials(cmd)
+		if err != nil {
+			return err
+		}
+
+		address := cmd.String(AdminAddressFlag)
+		conn, err := gogrpc.NewClient(address, gogrpc.WithTransportCredentials(creds))
+		if err != nil {
+			return fmt.Errorf("connect to %s: %w", address, err)
+		}
+		defer conn.Close()
+
+		if timeout := cmd.Duration(AdminTimeoutFlag); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		if token := cmd.String(AdminClientTokenFlag); token != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		}
+		if namespace := cmd.String(AdminNamespaceFlag); namespace != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, grpc.NamespaceMetadataKey, namespace)
+		}
+
+		return run(ctx, cmd, &adminClient{
+			conn:   conn,
+			api:    registryv1.NewAeroRegistryClient(conn),
+			output: output,
+			out:    cmd.Root().Writer,
+		})
+	}
+}
+
+// adminTransportCredentials builds the transport credentials selected by
+// the tls flags. Without any of them the connection is plaintext.
+func adminTransportCredentials(cmd *cli.Command) (credentials.TransportCredentials, error) {
+	tlsFlags := []string{AdminTLSCAFlag, AdminTLSServerNameFlag, AdminTLSSkipVerifyFlag, AdminTLSClientCertFlag, AdminTLSClientKeyFlag}
+	if !cmd.Bool(AdminTLSFlag) && !slices.ContainsFunc(tlsFlags, cmd.IsSet) {
+		return insecure.NewCredentials(), nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cmd.String(AdminTLSServerNameFlag),
+		InsecureSkipVerify: cmd.Bool(AdminTLSSkipVerifyFlag),
+	}
+
+	if caPath := cmd.String(AdminTLSCAFlag); caPath != "" {
+		pem, err := os.ReadFile(caPath)
+		if err != nil {
+			return nil, fmt.Errorf("read tls ca: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificates found in %s", ErrInvalidTLSConfig, caPath)
+		}
+	}
+
+	certPath, keyPath := cmd.String(AdminTLSClientCertFlag), cmd.String(AdminTLSClientKeyFlag)
+	if (certPath == "") != (keyPath == "") {
+		return nil, fmt.Errorf("%w: --%s and --%s must be set together", ErrInvalidTLSConfig, AdminTLSClientCertFlag, AdminTLSClientKeyFlag)
+	}
+	if certPath != "" {
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("load tls client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(cfg), nil
+}
+
+// invokeAdmin calls an admin service method with the given request fields.
+func (c *adminClient) invokeAdmin(ctx context.Context, method string, fields map[string]any) (*structpb.Struct, error) {
+	req, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &structpb.Struct{}
+	err = c.conn.Invoke(ctx, grpc.AdminMethodPath(method), req, resp)
+	if status.Code(err) == codes.Unimplemented {
+		return nil, fmt.Errorf("%s needs the admin service; start the registry with --%s: %w", method, AdminEnabledFlag, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+
+	return resp, nil
+}
+
+// watchAdmin calls a streaming admin service method and passes each
+// response to handle until the stream ends.
+func (c *adminClient) watchAdmin(ctx context.Context, method string, fields map[string]any, handle func(*structpb.Struct) error) error {
+	req, err := structpb.NewStruct(fields)
+	if err != nil {
+		return err
+	}
+
+	stream, err := c.conn.NewStream(ctx, &gogrpc.StreamDesc{ServerStreams: true}, grpc.AdminMethodPath(method))
+	if err == nil {
+		err = stream.SendMsg(req)
+	}
+	if err == nil {
+		err = stream.CloseSend()
+	}
+
+	for err == nil {
+		resp := &structpb.Struct{}
+		if err = stream.RecvMsg(resp); err == nil {
+			err = handle(resp)
+		}
+	}
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return nil
+	case status.Code(err) == codes.Unimplemented:
+		return fmt.Errorf("%s needs the admin service; start the registry with --%s: %w", method, AdminEnabledFlag, err)
+	default:
+		return fmt.Errorf("%s: %w", method, err)
+	}
+}
+
+// write prints v as indented JSON, or calls table to print it as a table.
+func (c *adminClient) write(v any, table func(w io.Writer)) error {
+	if c.output == outputJSON {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+// writeStruct prints an admin response, as JSON or as a table of its
+// fields with nested fields flattened into dotted keys.
+func (c *adminClient) writeStruct(resp *structpb.Struct) error {
+	return c.write(resp.AsMap(), func(w io.Writer) {
+		fields := map[string]string{}
+		flattenFields("", resp.AsMap(), fields)
+
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s\t%s\n", key, fields[key])
+		}
+	})
+}
+
+func flattenFields(prefix string, in map[string]any, out map[string]string) {
+	for key, value := range in {
+		switch v := value.(type) {
+		case map[string]any:
+			flattenFields(prefix+key+".", v, out)
+		case float64:
+			out[prefix+key] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			out[prefix+key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// singleArg returns the one positional argument of cmd.
+func singleArg(cmd *cli.Command, name string) (string, error) {
+	if cmd.Args().Len() != 1 || cmd.Args().First() == "" {
+		return "", fmt.Errorf("%w: expected exactly one %s", ErrMissingArgument, name)
+	}
+	return cmd.Args().First(), nil
+}
+
+type relayOutput struct {
+	RelayID       string     `json:"relay_id"`
+	Address       string     `json:"address"`
+	GRPCPort      int32      `json:"grpc_port"`
+	LastHeartbeat *time.Time `json:"last_heartbeat,omitempty"`
+}
+
+type agentOutput struct {
+	AgentID       string     `json:"agent_id"`
+	LastHeartbeat *time.Time `json:"last_heartbeat,omitempty"`
+	agentMetadataOutput
+}
+
+// agentMetadataOutput is the metadata an agent reported when it
+// registered. The ListAgents RPC cannot carry it, so it is only filled in
+// for agents listed by relay and for placements.
+type agentMetadataOutput struct {
+	Kind         string     `json:"kind,omitempty"`
+	Version      string     `json:"version,omitempty"`
+	Capabilities []string   `json:"capabilities,omitempty"`
+	SessionStart *time.Time `json:"session_start,omitempty"`
+}
+
+func agentMetadataFromStruct(fields map[string]*structpb.Value) agentMetadataOutput {
+	out := agentMetadataOutput{
+		Kind:         fields["kind"].GetStringValue(),
+		Version:      fields["version"].GetStringValue(),
+		SessionStart: unixMilliTime(int64(fields["session_start_unix_ms"].GetNumberValue())),
+	}
+	for _, capability := range fields["capabilities"].GetListValue().GetValues() {
+		out.Capabilities = append(out.Capabilities, capability.GetStringValue())
+	}
+	return out
+}
+
+// agentMetadataFromHeader reads the metadata GetAgentPlacement returns in
+// its response headers.
+func agentMetadataFromHeader(header metadata.MD) agentMetadataOutput {
+	value := func(key string) string {
+		if values := header.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	out := agentMetadataOutput{
+		Kind:    value(grpc.AgentKindMetadataKey),
+		Version: value(grpc.AgentVersionMetadataKey),
+	}
+	if capabilities := value(grpc.AgentCapabilitiesMetadataKey); capabilities != "" {
+		out.Capabilities = strings.Split(capabilities, ",")
+	}
+	if ms, err := strconv.ParseInt(value(grpc.AgentSessionStartMetadataKey), 10, 64); err == nil {
+		out.SessionStart = unixMilliTime(ms)
+	}
+	return out
+}
+
+type placementChangeOutput struct {
+	RelayID string     `json:"relay_id"`
+	Reason  string     `json:"reason"`
+	At      *time.Time `json:"at,omitempty"`
+}
+
+type auditEventOutput struct {
+	Seq             uint64     `json:"seq"`
+	Time            *time.Time `json:"time,omitempty"`
+	Type            string     `json:"type"`
+	Namespace       string     `json:"namespace,omitempty"`
+	RelayID         string     `json:"relay_id,omitempty"`
+	AgentID         string     `json:"agent_id,omitempty"`
+	PreviousRelayID string     `json:"previous_relay_id,omitempty"`
+	Action          string     `json:"action,omitempty"`
+	Caller          string     `json:"caller,omitempty"`
+	RequestID       string     `json:"request_id,omitempty"`
+}
+
+func auditEventFromStruct(s *structpb.Struct) auditEventOutput {
+	fields := s.GetFields()
+	return auditEventOutput{
+		Seq:             uint64(fields["seq"].GetNumberValue()),
+		Time:            unixMilliTime(int64(fields["time_unix_ms"].GetNumberValue())),
+		Type:            fields["type"].GetStringValue(),
+		Namespace:       fields["namespace"].GetStringValue(),
+		RelayID:         fields["relay_id"].GetStringValue(),
+		AgentID:         fields["agent_id"].GetStringValue(),
+		PreviousRelayID: fields["previous_relay_id"].GetStringValue(),
+		Action:          fields["action"].GetStringValue(),
+		Caller:          fields["caller"].GetStringValue(),
+		RequestID:       fields["request_id"].GetStringValue(),
+	}
+}
+
+const auditTableHeader = "SEQ\tTIME\tTYPE\tNAMESPACE\tRELAY\tAGENT\tPREVIOUS RELAY\tACTION\tCALLER\tREQUEST"
+
+func (e auditEventOutput) tableRow() string {
+	return strings.Join([]string{
+		strconv.FormatUint(e.Seq, 10),
+		formatOptionalTime(e.Time),
+		e.Type,
+		orDash(e.Namespace),
+		orDash(e.RelayID),
+		orDash(e.AgentID),
+		orDash(e.PreviousRelayID),
+		orDash(e.Action),
+		orDash(e.Caller),
+		orDash(e.RequestID),
+	}, "\t")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+type placementOutput struct {
+	AgentID   string     `json:"agent_id"`
+	RelayID   string     `json:"relay_id"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	agentMetadataOutput
+}
+
+// unixMilliTime converts a unix millisecond timestamp, where zero means
+// unset.
+func unixMilliTime(ms int64) *time.Time {
+	if ms == 0 {
+		return nil
+	}
+	t := time.UnixMilli(ms).UTC()
+	return &t
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func runRelaysList(ctx context.Context, cmd *cli.Command, c *adminClient) error {
+	resp, err := c.api.ListRelays(ctx, &registryv1.ListRelaysRequest{})
+	if err != nil {
+		return fmt.Errorf("list relays: %w", err)
+	}
+
+	relays := make([]relayOutput, len(resp.GetRelays()))
+	for i, relay := range resp.GetRelays() {
+		relays[i] = relayOutput{
+			RelayID:       relay.GetRelayId(),
+			Address:       relay.GetAddress(),
+			GRPCPort:      relay.GetGrpcPort(),
+			LastHeartbeat: unixMilliTime(relay.GetLastHeartbeatUnixMs()),
+		}
+	}
+	slices.SortFunc(relays, func(a, b relayOutput) int { return strings.Compare(a.RelayID, b.RelayID) })
+
+	return c.write(relays, func(w io.Writer) {
+		fmt.Fprintln(w, "RELAY\tADDRESS\tGRPC PORT\tLAST HEARTBEAT")
+		for _, relay := range relays {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", relay.RelayID, relay.Address, relay.GRPCPort, formatOptionalTime(relay.LastHeartbeat))
+		}
+	})
+}
+
+func runRelayDrain(ctx context.Context, cmd *cli.Command, c *adminClient) error {
+	relayID, err := singleArg(cmd, "relay id")
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.invokeAdmin(ctx, grpc.AdminMethodDrainRelay, map[string]any{"relay_id": relayID})
+	if err != nil {
+		return err
+	}
+
+	return c.writeStruct(resp)
+}
+
+func runAgentsList(ctx context.Context, cmd *cli.Command, c *adminClient) error {
+	var agents []agentOutput
+
+	if relayID := cmd.String(AdminRelayFlag); relayID != "" {
+		resp, err := c.invokeAdmin(ctx, grpc.AdminMethodListRelayAgents, map[string]any{"relay_id": relayID})
+		if err != nil {
+			return err
+		}
+		for _, value := range resp.GetFields()["agents"].GetListValue().GetValues() {
+			fields := value.GetStructValue().GetFields()
+			agents = append(agents, agentOutput{
+				AgentID:             fields["agent_id"].GetStringValue(),
+				LastHeartbeat:       unixMilliTime(int64(fields["last_heartbeat_unix_ms"].GetNumberValue())),
+				agentMetadataOutput: agentMetadataFromStruct(fields),
+			})
+		}
+	} else {
+		resp, err := c.api.ListAgents(ctx, &registryv1.ListAgentsRequest{})
+		if err != nil {
+			return fmt.Errorf("list agents: %w", err)
+		}
+		for _, agent := range resp.GetAgents() {
+			agents = append(agents, agentOutput{
+				AgentID:       agent.GetAgentId(),
+				LastHeartbeat: unixMilliTime(agent.GetLastHeartbeatUnixMs()),
+			})
+		}
+	}
+	if agents == nil {
+		agents = []agentOutput{}
+	}
+	slices.SortFunc(agents, func(a, b agentOutput) int { return strings.Compare(a.AgentID, b.AgentID) })
+
+	return c.write(agents, func(w io.Writer) {
+		fmt.Fprintln(w, "AGENT\tKIND\tVERSION\tLAST HEARTBEAT")
+		for _, agent := range agents {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", agent.AgentID, orDash(agent.Kind), orDash(agent.Version), formatOptionalTime(agent.LastHeartbeat))
+		}
+	})
+}
+
+func runAgentEvict(ctx context.Context, cmd *cli.Command, c *adminClient) error {
+	agentID, err := singleArg(cmd, "agent id")
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.invokeAdmin(ctx, grpc.AdminMethodEvictAgent, map[string]any{"agent_id": agentID})
+	if err != nil {
+		return err
+	}
+
+	return c.writeStruct(resp)
+}
+
+func runPlacementGet(ctx context.Context, cmd *cli.Command, c *adminClient) error {
+	agentID, err := singleArg(cmd, "agent id")
+	if err != nil {
+		return err
+	}
+
+	var header metadata.MD
+	resp, err := c.api.GetAgentPlacement(ctx, &registryv1.GetAgentPlacementRequest{AgentId: agentID}, gogrpc.Header(&header))
+	if err != nil {
+		return fmt.Errorf("get placement: %w", err)
+	}
+
+	placement := placementOutput{
+		AgentID:             resp.GetPlacement().GetAgentId(),
+		RelayID:             resp.GetPlacement().GetRelayId(),
+		UpdatedAt:           unixMilliTime(resp.GetPlacement().GetLastUpdatedUnixMs()),
+		agentMetadataOutput: agentMetadataFromHeader(header),
+	}
+
+	return c.write(placement, func(w io.Writer) {
+		fmt.Fprintln(w, "AGENT\tRELAY\tKIND\tVERSION\tCAPABILITIES\tUPDATED")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", placement.AgentID, placement.RelayID, orDash(placement.Kind),
+			orDash(placement.Version), orDash(strings.Join(placement.Capabilities, ",")), formatOptionalTime(placement.UpdatedAt))
+	})
+}
+
+func runPlacementHistory(ctx context.Context, cmd *cli.Command, c *adminClient) error {
+	agentID, err := singleArg(cmd, "agent id")
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.invokeAdmin(ctx, grpc.AdminMethodPlacementHistory, map[string]any{"agent_id": agentID})
+	if err != nil {
+		return err
+	}
+
+	changes := []placementChangeOutput{}
+	for _, value := range resp.GetFields()["changes"].GetListValue().GetValues() {
+		fields := value.GetStructValue().GetFields()
+		changes = append(changes, placementChangeOutput{
+			RelayID: fields["relay_id"].GetStringValue(),
+			Reason:  fields["reason"].GetStringValue(),
+			At:      unixMilliTime(int64(fields["at_unix_ms"].GetNumberValue())),
+		})
+	}
+
+	return c.write(changes, func(w io.Writer) {
+		fmt.Fprintln(w, "TIME\tREASON\tRELAY")
+		for _, change := range changes {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", formatOptionalTime(change.At), change.Reason, orDash(change.RelayID))
+		}
+	})
+}
+
+func runStatus(ctx context.Context, cmd *cli.Command, c *adminClient) error {
+	resp, err := c.invokeAdmin(ctx, grpc.AdminMethodStatus, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.writeStruct(resp)
+}
+
+func runAuditList(ctx context.Context, cmd *cli.Command, c *adminClient) error {
+	resp, err := c.invokeAdmin(ctx, grpc.AdminMethodAuditLog, map[string]any{
+		"namespace": cmd.String(AdminNamespaceFlag),
+		"relay_id":  cmd.String(AdminRelayFlag),
+		"agent_id":  cmd.String(AdminAgentFlag),
+		"type":      cmd.String(AdminAuditTypeFlag),
+		"limit":     cmd.Int(AdminLimitFlag),
+	})
+	if err != nil {
+		return err
+	}
+
+	events := []auditEventOutput{}
+	for _, value := range resp.GetFields()["events"].GetListValue().GetValues() {
+		events = append(events, auditEventFromStruct(value.GetStructValue()))
+	}
+
+	return c.write(events, func(w io.Writer) {
+		fmt.Fprintln(w, auditTableHeader)
+		for _, event := range events {
+			fmt.Fprintln(w, event.tableRow())
+		}
+	})
+}
+
+// runAuditWatch prints one line per event, as JSON or as a table row, so
+// the output can be piped while the watch is running.
+func runAuditWatch(ctx context.Context, cmd *cli.Command, c *adminClient) error {
+	enc := json.NewEncoder(c.out)
+	if c.output == outputTable {
+		fmt.Fprintln(c.out, strings.ReplaceAll(auditTableHeader, "\t", "  "))
+	}
+
+	err := c.watchAdmin(ctx, grpc.AdminMethodWatchAudit, nil, func(resp *structpb.Struct) error {
+		event := auditEventFromStruct(resp)
+		if c.output == outputJSON {
+			return enc.Encode(event)
+		}
+		_, err := fmt.Fprintln(c.out, strings.ReplaceAll(event.tableRow(), "\t", "  "))
+		return err
+	})
+	if status.Code(err) == codes.DeadlineExceeded && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+	"github.com/Aero-Arc/aero-arc-registry/internal/transport/grpc"
+	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	"github.com/urfave/cli/v3"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const adminTestToken = "secret"
+
+// startAdminTestRegistry serves a memory-backed registry with the admin
+// service enabled on a loopback port and returns its address and backend.
+func startAdminTestRegistry(t *testing.T, adminEnabled bool) (string, *memory.Backend) {
+	t.Helper()
+
+	backend, err := memory.New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	cfg := &registry.Config{
+		Backend:          registry.BackendConfig{Type: registry.MemoryRegistryBackend},
+		GRPC:             registry.GRPCConfig{ListenAddress: "127.0.0.1", ListenPort: 50051},
+		TTL:              registry.TTLConfig{Relay: 30 * time.Second, Agent: 30 * time.Second},
+		Admin:            registry.AdminConfig{Enabled: adminEnabled, Token: adminTestToken},
+		Audit:            registry.AuditConfig{Enabled: true, Sink: registry.AuditSinkNone},
+		PlacementHistory: registry.PlacementHistoryConfig{Enabled: true},
+		Tenants:          []registry.TenantConfig{{Namespace: "fleet-a"}},
+	}
+	reg, err := registry.New(cfg, backend)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	server, err := grpc.New(reg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if adminEnabled {
+		server.EnableAdmin(nil)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.GracefulStop)
+
+	ctx := context.Background()
+	for _, relayID := range []string{"relay-2", "relay-1"} {
+		if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: relayID, Address: "10.0.0.1", GRPCPort: 7000}); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+	for agentID, relayID := range map[string]string{"agent-1": "relay-1", "agent-2": "relay-1", "agent-3": "relay-2"} {
+		if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: agentID}, relayID); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	return lis.Addr().String(), backend
+}
+
+func runAdminCommand(address string, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := &cli.Command{
+		Name:     "aero-arc-registry",
+		Writer:   &out,
+		Commands: adminCommands,
+	}
+
+	// The address flag goes right after the command path, ahead of any
+	// positional argument.
+	path := min(2, len(args))
+	if args[0] == "status" {
+		path = 1
+	}
+	args = append(append(append([]string{}, args[:path]...), "--"+AdminAddressFlag, address), args[path:]...)
+
+	err := cmd.Run(context.Background(), append([]string{"aero-arc-registry"}, args...))
+	return out.String(), err
+}
+
+// tableFields parses key/value table output.
+func tableFields(out string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		fields[key] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+func TestAdminCommandsReadState(t *testing.T) {
+	address, backend := startAdminTestRegistry(t, true)
+
+	out, err := runAdminCommand(address, "relays", "list")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "RELAY") || !strings.HasPrefix(lines[1], "relay-1") || !strings.HasPrefix(lines[2], "relay-2") {
+		t.Fatalf("expected a sorted relay table, got %q", out)
+	}
+
+	out, err = runAdminCommand(address, "agents", "list", "--"+AdminRelayFlag, "relay-1", "--"+AdminClientTokenFlag, adminTestToken, "-o", "json")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	var agents []agentOutput
+	if err := json.Unmarshal([]byte(out), &agents); err != nil {
+		t.Fatalf("expected json output, got %q: %v", out, err)
+	}
+	if len(agents) != 2 || agents[0].AgentID != "agent-1" || agents[1].AgentID != "agent-2" {
+		t.Fatalf("expected the agents of relay-1, got %+v", agents)
+	}
+
+	out, err = runAdminCommand(address, "placement", "get", "--"+AdminOutputFlag, "json", "agent-3")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	var placement placementOutput
+	if err := json.Unmarshal([]byte(out), &placement); err != nil {
+		t.Fatalf("expected json output, got %q: %v", out, err)
+	}
+	if placement.RelayID != "relay-2" || placement.UpdatedAt == nil {
+		t.Fatalf("expected agent-3 placed on relay-2, got %+v", placement)
+	}
+
+	agent := registry.Agent{ID: "agent-3", Metadata: registry.AgentMetadata{
+		Kind:         registry.AgentKindDrone,
+		Version:      "px4-1.14.3",
+		Capabilities: []string{"mission_upload", "video"},
+	}}
+	if err := backend.RegisterAgent(context.Background(), registry.DefaultNamespace, agent, "relay-2"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	out, err = runAdminCommand(address, "placement", "get", "agent-3")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 2 ||
+		strings.Join(strings.Fields(lines[1])[:5], " ") != "agent-3 relay-2 drone px4-1.14.3 mission_upload,video" {
+		t.Fatalf("expected agent-3's metadata in the placement table, got %q", out)
+	}
+
+	out, err = runAdminCommand(address, "agents", "list", "--"+AdminRelayFlag, "relay-2", "--"+AdminClientTokenFlag, adminTestToken, "-o", "json")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	agents = nil
+	if err := json.Unmarshal([]byte(out), &agents); err != nil {
+		t.Fatalf("expected json output, got %q: %v", out, err)
+	}
+	if len(agents) != 1 || agents[0].Kind != "drone" || agents[0].Version != "px4-1.14.3" || len(agents[0].Capabilities) != 2 {
+		t.Fatalf("expected agent-3 listed with its metadata, got %+v", agents)
+	}
+
+	out, err = runAdminCommand(address, "status", "--"+AdminClientTokenFlag, adminTestToken)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	fields := tableFields(out)
+	for key, want := range map[string]string{"backend": "memory", "relays": "2", "agents": "3", "ttl.runs": "0"} {
+		if fields[key] != want {
+			t.Fatalf("expected status %s to be %q, got %q", key, want, out)
+		}
+	}
+}
+
+func TestAdminNamespaceFlag(t *testing.T) {
+	address, backend := startAdminTestRegistry(t, true)
+
+	if err := backend.RegisterRelay(context.Background(), "fleet-a", registry.Relay{ID: "relay-a", Address: "10.0.1.1", GRPCPort: 7000}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if _, err := runAdminCommand(address, "relays", "list", "--"+AdminNamespaceFlag, "fleet-a"); err == nil || !strings.Contains(err.Error(), "PermissionDenied") {
+		t.Fatalf("expected PermissionDenied without the admin token, got %v", err)
+	}
+
+	out, err := runAdminCommand(address, "relays", "list", "--"+AdminNamespaceFlag, "fleet-a", "--"+AdminClientTokenFlag, adminTestToken)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "relay-a") {
+		t.Fatalf("expected only the fleet-a relay, got %q", out)
+	}
+
+	if _, err := runAdminCommand(address, "relays", "list", "--"+AdminNamespaceFlag, "fleet-z"); err == nil {
+		t.Fatalf("expected an error for an unknown namespace")
+	}
+}
+
+func TestAdminCommandsMutateState(t *testing.T) {
+	address, backend := startAdminTestRegistry(t, true)
+	ctx := context.Background()
+
+	out, err := runAdminCommand(address, "relay", "drain", "--"+AdminClientTokenFlag, adminTestToken, "relay-1")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if fields := tableFields(out); fields["agents_removed"] != "2" {
+		t.Fatalf("expected two agents removed, got %q", out)
+	}
+	if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1"); !errors.Is(err, registry.ErrNotFound) {
+		t.Fatalf("expected drained agent to be removed, got %v", err)
+	}
+	relays, err := backend.ListRelays(ctx, registry.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(relays) != 1 || relays[0].ID != "relay-2" {
+		t.Fatalf("expected only relay-2 to remain, got %+v", relays)
+	}
+
+	if _, err := runAdminCommand(address, "agent", "evict", "--"+AdminClientTokenFlag, adminTestToken, "agent-3"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-3"); !errors.Is(err, registry.ErrNotFound) {
+		t.Fatalf("expected evicted agent to be removed, got %v", err)
+	}
+
+	if _, err := runAdminCommand(address, "agent", "evict", "--"+AdminClientTokenFlag, adminTestToken, "agent-3"); err == nil || !strings.Contains(err.Error(), "NotFound") {
+		t.Fatalf("expected NotFound for an unknown agent, got %v", err)
+	}
+}
+
+func TestAdminAuditCommands(t *testing.T) {
+	address, _ := startAdminTestRegistry(t, true)
+
+	if _, err := runAdminCommand(address, "relay", "drain", "--"+AdminClientTokenFlag, adminTestToken, "relay-2"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	out, err := runAdminCommand(address, "audit", "list", "--"+AdminClientTokenFlag, adminTestToken, "--"+AdminRelayFlag, "relay-2", "-o", "json")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	var events []auditEventOutput
+	if err := json.Unmarshal([]byte(out), &events); err != nil {
+		t.Fatalf("expected json output, got %q: %v", out, err)
+	}
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	if got, want := strings.Join(types, ","), "admin_action,relay_removed,agent_removed"; got != want {
+		t.Fatalf("expected events %s, got %s", want, got)
+	}
+	if events[0].Action != grpc.AdminMethodDrainRelay || events[2].AgentID != "agent-3" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	out, err = runAdminCommand(address, "audit", "list", "--"+AdminClientTokenFlag, adminTestToken, "--"+AdminLimitFlag, "1")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "SEQ") || !strings.Contains(lines[1], "DrainRelay") {
+		t.Fatalf("expected a header and the newest event, got %q", out)
+	}
+}
+
+func TestAdminPlacementHistoryCommand(t *testing.T) {
+	address, _ := startAdminTestRegistry(t, true)
+
+	if _, err := runAdminCommand(address, "agent", "evict", "--"+AdminClientTokenFlag, adminTestToken, "agent-3"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	out, err := runAdminCommand(address, "placement", "history", "--"+AdminClientTokenFlag, adminTestToken, "agent-3")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "TIME") || !strings.Contains(lines[1], "evicted") || !strings.HasSuffix(lines[1], "relay-2") {
+		t.Fatalf("expected a header and the eviction from relay-2, got %q", out)
+	}
+}
+
+func TestAdminAuditWatch(t *testing.T) {
+	address, _ := startAdminTestRegistry(t, true)
+
+	type result struct {
+		out string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := runAdminCommand(address, "audit", "watch", "--"+AdminClientTokenFlag, adminTestToken, "-o", "json", "--"+AdminTimeoutFlag, "1s")
+		done <- result{out, err}
+	}()
+
+	// The watch subscribes some time after it starts, so keep registering
+	// relays until it ends.
+	conn, err := gogrpc.NewClient(address, gogrpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	defer conn.Close()
+	client := registryv1.NewAeroRegistryClient(conn)
+
+	var res result
+	for i := 0; ; i++ {
+		select {
+		case res = <-done:
+		case <-time.After(50 * time.Millisecond):
+			if _, err := client.RegisterRelay(context.Background(), &registryv1.RegisterRelayRequest{
+				Relay: &registryv1.Relay{RelayId: fmt.Sprintf("relay-w%d", i), Address: "10.0.0.2"},
+			}); err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			continue
+		}
+		break
+	}
+
+	if res.err != nil {
+		t.Fatalf("expected the watch to end cleanly at its timeout, got %v", res.err)
+	}
+	lines := strings.Split(strings.TrimSpace(res.out), "\n")
+	var event auditEventOutput
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+		t.Fatalf("expected json lines, got %q: %v", res.out, err)
+	}
+	if event.Type != "relay_registered" || !strings.HasPrefix(event.RelayID, "relay-w") {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
+
+func TestAdminCommandErrors(t *testing.T) {
+	address, _ := startAdminTestRegistry(t, true)
+	disabledAddress, _ := startAdminTestRegistry(t, false)
+
+	tests := []struct {
+		name    string
+		address string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "missing token",
+			address: address,
+			args:    []string{"status"},
+			wantErr: "Unauthenticated",
+		},
+		{
+			name:    "admin service disabled",
+			address: disabledAddress,
+			args:    []string{"relay", "drain", "relay-1"},
+			wantErr: "--" + AdminEnabledFlag,
+		},
+		{
+			name:    "missing argument",
+			address: address,
+			args:    []string{"placement", "get"},
+			wantErr: ErrMissingArgument.Error(),
+		},
+		{
+			name:    "invalid output",
+			address: address,
+			args:    []string{"relays", "list", "-o", "yaml"},
+			wantErr: ErrInvalidOutput.Error(),
+		},
+		{
+			name:    "client cert without key",
+			address: address,
+			args:    []string{"relays", "list", "--" + AdminTLSClientCertFlag, "client.crt"},
+			wantErr: ErrInvalidTLSConfig.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := runAdminCommand(test.address, test.args...)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
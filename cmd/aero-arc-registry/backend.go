@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/consul"
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/etcd"
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/raft"
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/redis"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/shadow"
 )
 
 func buildBackendFromConfig(cfg *registry.Config) (registry.Backend, error) {
@@ -18,7 +23,28 @@ func buildBackendFromConfig(cfg *registry.Config) (registry.Backend, error) {
 		return etcd.New(cfg.Backend.Etcd)
 	case registry.MemoryRegistryBackend:
 		return memory.New(cfg.Backend.Memory)
+	case registry.RaftRegistryBackend:
+		return raft.New(cfg.Backend.Raft)
+	case registry.ShadowRegistryBackend:
+		return buildShadowBackend(cfg.Backend)
 	default:
 		return nil, ErrUnhandledBackend
 	}
 }
+
+// buildShadowBackend builds the primary and shadow backends and combines
+// them.
+func buildShadowBackend(cfg registry.BackendConfig) (registry.Backend, error) {
+	primary, err := buildBackendFromConfig(&registry.Config{Backend: cfg.Primary.BackendConfig()})
+	if err != nil {
+		return nil, fmt.Errorf("build primary backend: %w", err)
+	}
+
+	shadowed, err := buildBackendFromConfig(&registry.Config{Backend: cfg.Shadow.BackendConfig()})
+	if err != nil {
+		_ = primary.Close(context.Background())
+		return nil, fmt.Errorf("build shadow backend: %w", err)
+	}
+
+	return shadow.New(primary, shadowed, cfg.Shadowing), nil
+}
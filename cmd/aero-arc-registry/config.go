@@ -2,51 +2,407 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
 	"github.com/urfave/cli/v3"
 )
 
+// flagBinding maps a cli flag onto the registry config field it controls.
+type flagBinding struct {
+	name  string
+	apply func(cmd *cli.Command, cfg *registry.Config) error
+}
+
+var flagBindings = []flagBinding{
+	{BackendFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		backendType, err := registry.ParseRegistryBackend(cmd.String(BackendFlag))
+		if err != nil {
+			return err
+		}
+		cfg.Backend.Type = backendType
+		return nil
+	}},
+	{GRPCListenAddrFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.GRPC.ListenAddress = cmd.String(GRPCListenAddrFlag)
+		return nil
+	}},
+	{GRPCListenPortFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.GRPC.ListenPort = cmd.Int(GRPCListenPortFlag)
+		return nil
+	}},
+	{TLSEnabledFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.GRPC.TLS.Enabled = cmd.Bool(TLSEnabledFlag)
+		return nil
+	}},
+	{TLSCertPathFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.GRPC.TLS.CertPath = cmd.String(TLSCertPathFlag)
+		return nil
+	}},
+	{TLSKeyPathFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.GRPC.TLS.KeyPath = cmd.String(TLSKeyPathFlag)
+		return nil
+	}},
+	{TLSClientCAPathFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.GRPC.TLS.ClientCAPath = cmd.String(TLSClientCAPathFlag)
+		return nil
+	}},
+	{RelayTTLFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.TTL.Relay = cmd.Duration(RelayTTLFlag)
+		return nil
+	}},
+	{AgentTTLFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.TTL.Agent = cmd.Duration(AgentTTLFlag)
+		return nil
+	}},
+	{SweepIntervalFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.TTL.SweepInterval = cmd.Duration(SweepIntervalFlag)
+		return nil
+	}},
+	{AdaptiveSweepFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.TTL.AdaptiveSweep = cmd.Bool(AdaptiveSweepFlag)
+		return nil
+	}},
+	{MinSweepIntervalFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.TTL.MinSweepInterval = cmd.Duration(MinSweepIntervalFlag)
+		return nil
+	}},
+	{MaxSweepIntervalFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.TTL.MaxSweepInterval = cmd.Duration(MaxSweepIntervalFlag)
+		return nil
+	}},
+	{SweepCoordinationFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.TTL.Coordination.Enabled = cmd.Bool(SweepCoordinationFlag)
+		return nil
+	}},
+	{ReplicaIDFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.TTL.Coordination.ReplicaID = cmd.String(ReplicaIDFlag)
+		return nil
+	}},
+	{SweeperLeaseTTLFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.TTL.Coordination.LeaseTTL = cmd.Duration(SweeperLeaseTTLFlag)
+		return nil
+	}},
+	{LogLevelFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Log.Level = cmd.String(LogLevelFlag)
+		return nil
+	}},
+	{AdminEnabledFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Admin.Enabled = cmd.Bool(AdminEnabledFlag)
+		return nil
+	}},
+	{AdminTokenFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Admin.Token = cmd.String(AdminTokenFlag)
+		return nil
+	}},
+	{ShutDownTimeoutFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Shutdown.Timeout = cmd.Duration(ShutDownTimeoutFlag)
+		return nil
+	}},
+	{PreStopDelayFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Shutdown.PreStopDelay = cmd.Duration(PreStopDelayFlag)
+		return nil
+	}},
+	{MaxRelaysFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Limits.MaxRelays = cmd.Int(MaxRelaysFlag)
+		return nil
+	}},
+	{MaxAgentsFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Limits.MaxAgents = cmd.Int(MaxAgentsFlag)
+		return nil
+	}},
+	{MaxAgentsPerRelayFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Limits.MaxAgentsPerRelay = cmd.Int(MaxAgentsPerRelayFlag)
+		return nil
+	}},
+	{HeartbeatRateFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.RateLimit.Heartbeat.Rate = cmd.Float(HeartbeatRateFlag)
+		return nil
+	}},
+	{HeartbeatBurstFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.RateLimit.Heartbeat.Burst = cmd.Int(HeartbeatBurstFlag)
+		return nil
+	}},
+	{RegistrationRateFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.RateLimit.Registration.Rate = cmd.Float(RegistrationRateFlag)
+		return nil
+	}},
+	{RegistrationBurstFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.RateLimit.Registration.Burst = cmd.Int(RegistrationBurstFlag)
+		return nil
+	}},
+	{ListRateFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.RateLimit.List.Rate = cmd.Float(ListRateFlag)
+		return nil
+	}},
+	{ListBurstFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.RateLimit.List.Burst = cmd.Int(ListBurstFlag)
+		return nil
+	}},
+	{TracingEnabledFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Tracing.Enabled = cmd.Bool(TracingEnabledFlag)
+		return nil
+	}},
+	{TracingEndpointFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Tracing.Endpoint = cmd.String(TracingEndpointFlag)
+		return nil
+	}},
+	{TracingInsecureFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Tracing.Insecure = cmd.Bool(TracingInsecureFlag)
+		return nil
+	}},
+	{TracingServiceNameFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Tracing.ServiceName = cmd.String(TracingServiceNameFlag)
+		return nil
+	}},
+	{TracingSampleRatioFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Tracing.SampleRatio = cmd.Float(TracingSampleRatioFlag)
+		return nil
+	}},
+	{CacheEnabledFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Backend.Cache.Enabled = cmd.Bool(CacheEnabledFlag)
+		return nil
+	}},
+	{CacheRelayTTLFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Backend.Cache.RelayTTL = cmd.Duration(CacheRelayTTLFlag)
+		return nil
+	}},
+	{CachePlacementTTLFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Backend.Cache.PlacementTTL = cmd.Duration(CachePlacementTTLFlag)
+		return nil
+	}},
+	{CacheMaxPlacementsFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Backend.Cache.MaxPlacements = cmd.Int(CacheMaxPlacementsFlag)
+		return nil
+	}},
+	{ResilienceEnabledFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Backend.Resilience.Enabled = cmd.Bool(ResilienceEnabledFlag)
+		return nil
+	}},
+	{BackendCallTimeoutFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Backend.Resilience.CallTimeout = cmd.Duration(BackendCallTimeoutFlag)
+		return nil
+	}},
+	{BackendMaxAttemptsFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Backend.Resilience.MaxAttempts = cmd.Int(BackendMaxAttemptsFlag)
+		return nil
+	}},
+	{BackendRetryBackoffFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Backend.Resilience.RetryBackoff = cmd.Duration(BackendRetryBackoffFlag)
+		return nil
+	}},
+	{BackendFailureThreshFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Backend.Resilience.FailureThreshold = cmd.Int(BackendFailureThreshFlag)
+		return nil
+	}},
+	{BackendOpenTimeoutFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Backend.Resilience.OpenTimeout = cmd.Duration(BackendOpenTimeoutFlag)
+		return nil
+	}},
+	{DegradedEnabledFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Degraded.Enabled = cmd.Bool(DegradedEnabledFlag)
+		return nil
+	}},
+	{DegradedMaxStalenessFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Degraded.MaxStaleness = cmd.Duration(DegradedMaxStalenessFlag)
+		return nil
+	}},
+	{DegradedMaxHeartbeatsFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Degraded.MaxBufferedHeartbeats = cmd.Int(DegradedMaxHeartbeatsFlag)
+		return nil
+	}},
+	{HTTPEnabledFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.HTTP.Enabled = cmd.Bool(HTTPEnabledFlag)
+		return nil
+	}},
+	{HTTPListenAddressFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.HTTP.ListenAddress = cmd.String(HTTPListenAddressFlag)
+		return nil
+	}},
+	{HTTPListenPortFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.HTTP.ListenPort = cmd.Int(HTTPListenPortFlag)
+		return nil
+	}},
+	{HTTPAllowedOriginsFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.HTTP.AllowedOrigins = cmd.StringSlice(HTTPAllowedOriginsFlag)
+		return nil
+	}},
+	{HTTPEventsIntervalFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.HTTP.EventsInterval = cmd.Duration(HTTPEventsIntervalFlag)
+		return nil
+	}},
+	{AuditEnabledFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Audit.Enabled = cmd.Bool(AuditEnabledFlag)
+		return nil
+	}},
+	{AuditSinkFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Audit.Sink = cmd.String(AuditSinkFlag)
+		return nil
+	}},
+	{AuditFilePathFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Audit.FilePath = cmd.String(AuditFilePathFlag)
+		return nil
+	}},
+	{AuditBufferSizeFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.Audit.BufferSize = cmd.Int(AuditBufferSizeFlag)
+		return nil
+	}},
+	{HistoryEnabledFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.PlacementHistory.Enabled = cmd.Bool(HistoryEnabledFlag)
+		return nil
+	}},
+	{HistoryEntriesFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.PlacementHistory.Entries = cmd.Int(HistoryEntriesFlag)
+		return nil
+	}},
+	{HistoryRetentionFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		cfg.PlacementHistory.Retention = cmd.Duration(HistoryRetentionFlag)
+		return nil
+	}},
+	{RedisAddrFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		redisConfig(cfg).Address = cmd.String(RedisAddrFlag)
+		return nil
+	}},
+	{RedisPortFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		redisConfig(cfg).Port = cmd.Int(RedisPortFlag)
+		return nil
+	}},
+	{RedisUsernameFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		redisConfig(cfg).Username = cmd.String(RedisUsernameFlag)
+		return nil
+	}},
+	{RedisPasswordFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		redisConfig(cfg).Password = cmd.String(RedisPasswordFlag)
+		return nil
+	}},
+	{RedisDBFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		redisConfig(cfg).DB = cmd.Int(RedisDBFlag)
+		return nil
+	}},
+	{MemorySnapshotPathFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		memoryConfig(cfg).SnapshotPath = cmd.String(MemorySnapshotPathFlag)
+		return nil
+	}},
+	{MemorySnapshotIntervalFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		memoryConfig(cfg).SnapshotInterval = cmd.Duration(MemorySnapshotIntervalFlag)
+		return nil
+	}},
+	{MemoryWALPathFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		memoryConfig(cfg).WALPath = cmd.String(MemoryWALPathFlag)
+		return nil
+	}},
+	{MemoryWALFlushIntervalFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		memoryConfig(cfg).WALFlushInterval = cmd.Duration(MemoryWALFlushIntervalFlag)
+		return nil
+	}},
+	{RaftNodeIDFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		raftConfig(cfg).NodeID = cmd.String(RaftNodeIDFlag)
+		return nil
+	}},
+	{RaftBindAddrFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		raftConfig(cfg).BindAddress = cmd.String(RaftBindAddrFlag)
+		return nil
+	}},
+	{RaftAdvertiseAddrFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		raftConfig(cfg).AdvertiseAddress = cmd.String(RaftAdvertiseAddrFlag)
+		return nil
+	}},
+	{RaftDataDirFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		raftConfig(cfg).DataDir = cmd.String(RaftDataDirFlag)
+		return nil
+	}},
+	{RaftPeersFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		raftConfig(cfg).Peers = cmd.StringSlice(RaftPeersFlag)
+		return nil
+	}},
+	{RaftApplyTimeoutFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		raftConfig(cfg).ApplyTimeout = cmd.Duration(RaftApplyTimeoutFlag)
+		return nil
+	}},
+	{RaftHeartbeatFlushFlag, func(cmd *cli.Command, cfg *registry.Config) error {
+		raftConfig(cfg).HeartbeatFlushInterval = cmd.Duration(RaftHeartbeatFlushFlag)
+		return nil
+	}},
+}
+
+// buildConfigFromCLI assembles the registry configuration from, in
+// increasing order of precedence: flag defaults, the optional --config
+// file, AERO_REGISTRY_* environment variables and explicitly set flags.
 func buildConfigFromCLI(cmd *cli.Command) (*registry.Config, error) {
-	backendType, err := registry.ParseRegistryBackend(cmd.String(BackendFlag))
-	if err != nil {
+	registryConfig := &registry.Config{}
+
+	if err := applyFlagBindings(cmd, registryConfig, false); err != nil {
+		return nil, err
+	}
+
+	if path := cmd.String(ConfigFileFlag); path != "" {
+		if err := registry.LoadConfigFile(path, registryConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := registry.ApplyEnvOverrides(registryConfig, os.LookupEnv); err != nil {
 		return nil, err
 	}
 
-	registryConfig := &registry.Config{
-		Backend: registry.BackendConfig{
-			Type: backendType,
-		},
-		GRPC: registry.GRPCConfig{
-			ListenAddress: cmd.String(GRPCListenAddrFlag),
-			ListenPort:    cmd.Int(GRPCListenPortFlag),
-			TLS: registry.TLSConfig{
-				Enabled:  cmd.Bool(TLSEnabledFlag),
-				CertPath: cmd.String(TLSCertPathFlag),
-				KeyPath:  cmd.String(TLSKeyPathFlag),
-			},
-		},
-		TTL: registry.TTLConfig{
-			Relay: cmd.Duration(RelayTTLFlag),
-			Agent: cmd.Duration(AgentTTLFlag),
-		},
+	if err := applyFlagBindings(cmd, registryConfig, true); err != nil {
+		return nil, err
 	}
 
 	switch registryConfig.Backend.Type {
-	case registry.RedisRegistryBackend:
-		registryConfig.Backend.Redis = &registry.RedisConfig{
-			Address:  cmd.String(RedisAddrFlag),
-			Port:     cmd.Int(RedisPortFlag),
-			Username: cmd.String(RedisUsernameFlag),
-			Password: cmd.String(RedisPasswordFlag),
-			DB:       cmd.Int(RedisDBFlag),
-		}
-	case registry.EtcdRegistryBackend:
-	case registry.ConsulRegistryBackend:
-	case registry.MemoryRegistryBackend:
+	case registry.RedisRegistryBackend,
+		registry.EtcdRegistryBackend,
+		registry.ConsulRegistryBackend,
+		registry.MemoryRegistryBackend,
+		registry.RaftRegistryBackend,
+		registry.ShadowRegistryBackend:
 	default:
 		return nil, fmt.Errorf("%w: %s", ErrUnhandledBackend, registryConfig.Backend.Type)
 	}
 
+	if err := registryConfig.Validate(); err != nil {
+		return nil, err
+	}
+
 	return registryConfig, nil
 }
+
+// applyFlagBindings copies flag values into cfg. When onlySet is true only
+// flags explicitly set by the operator are applied, so they override values
+// loaded from the config file or environment.
+func applyFlagBindings(cmd *cli.Command, cfg *registry.Config, onlySet bool) error {
+	for _, binding := range flagBindings {
+		if onlySet && !cmd.IsSet(binding.name) {
+			continue
+		}
+		if err := binding.apply(cmd, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func redisConfig(cfg *registry.Config) *registry.RedisConfig {
+	if cfg.Backend.Redis == nil {
+		cfg.Backend.Redis = &registry.RedisConfig{}
+	}
+
+	return cfg.Backend.Redis
+}
+
+func memoryConfig(cfg *registry.Config) *registry.MemoryConfig {
+	if cfg.Backend.Memory == nil {
+		cfg.Backend.Memory = &registry.MemoryConfig{}
+	}
+
+	return cfg.Backend.Memory
+}
+
+func raftConfig(cfg *registry.Config) *registry.RaftConfig {
+	if cfg.Backend.Raft == nil {
+		cfg.Backend.Raft = &registry.RaftConfig{}
+	}
+
+	return cfg.Backend.Raft
+}
@@ -2,19 +2,110 @@ package main
 
 // cli flag names
 const (
-	BackendFlag           = "backend"
-	GRPCListenAddrFlag    = "grpc-listen-address"
-	GRPCListenPortFlag    = "grpc-listen-port"
-	TLSEnabledFlag        = "tls-enabled"
-	TLSKeyPathFlag        = "tls-key-path"
-	TLSCertPathFlag       = "tls-cert-path"
-	RelayTTLFlag          = "relay-ttl"
-	AgentTTLFlag          = "agent-ttl"
-	HeartbeatIntervalFlag = "heartbeat-interval"
-	RedisAddrFlag         = "redis-addr"
-	RedisPortFlag         = "redis-port"
-	RedisUsernameFlag     = "redis-user"
-	RedisPasswordFlag     = "redis-password"
-	RedisDBFlag           = "redis-db"
-	ShutDownTimeoutFlag   = "shutdown-timeout"
+	ConfigFileFlag             = "config"
+	BackendFlag                = "backend"
+	GRPCListenAddrFlag         = "grpc-listen-address"
+	GRPCListenPortFlag         = "grpc-listen-port"
+	TLSEnabledFlag             = "tls-enabled"
+	TLSKeyPathFlag             = "tls-key-path"
+	TLSCertPathFlag            = "tls-cert-path"
+	TLSClientCAPathFlag        = "tls-client-ca-path"
+	RelayTTLFlag               = "relay-ttl"
+	AgentTTLFlag               = "agent-ttl"
+	HeartbeatIntervalFlag      = "heartbeat-interval"
+	RedisAddrFlag              = "redis-addr"
+	RedisPortFlag              = "redis-port"
+	RedisUsernameFlag          = "redis-user"
+	RedisPasswordFlag          = "redis-password"
+	RedisDBFlag                = "redis-db"
+	MemorySnapshotPathFlag     = "memory-snapshot-path"
+	MemorySnapshotIntervalFlag = "memory-snapshot-interval"
+	MemoryWALPathFlag          = "memory-wal-path"
+	MemoryWALFlushIntervalFlag = "memory-wal-flush-interval"
+	RaftNodeIDFlag             = "raft-node-id"
+	RaftBindAddrFlag           = "raft-bind-address"
+	RaftAdvertiseAddrFlag      = "raft-advertise-address"
+	RaftDataDirFlag            = "raft-data-dir"
+	RaftPeersFlag              = "raft-peers"
+	RaftApplyTimeoutFlag       = "raft-apply-timeout"
+	RaftHeartbeatFlushFlag     = "raft-heartbeat-flush-interval"
+	ShutDownTimeoutFlag        = "shutdown-timeout"
+	PreStopDelayFlag           = "shutdown-pre-stop-delay"
+	LogLevelFlag               = "log-level"
+	AdminEnabledFlag           = "admin-enabled"
+	AdminTokenFlag             = "admin-token"
+	SweepIntervalFlag          = "ttl-sweep-interval"
+	AdaptiveSweepFlag          = "ttl-adaptive-sweep"
+	MinSweepIntervalFlag       = "ttl-min-sweep-interval"
+	MaxSweepIntervalFlag       = "ttl-max-sweep-interval"
+	SweepCoordinationFlag      = "ttl-sweep-coordination"
+	ReplicaIDFlag              = "replica-id"
+	SweeperLeaseTTLFlag        = "ttl-sweeper-lease-ttl"
+	MaxRelaysFlag              = "max-relays"
+	MaxAgentsFlag              = "max-agents"
+	MaxAgentsPerRelayFlag      = "max-agents-per-relay"
+	HeartbeatRateFlag          = "rate-limit-heartbeat"
+	HeartbeatBurstFlag         = "rate-limit-heartbeat-burst"
+	RegistrationRateFlag       = "rate-limit-registration"
+	RegistrationBurstFlag      = "rate-limit-registration-burst"
+	ListRateFlag               = "rate-limit-list"
+	ListBurstFlag              = "rate-limit-list-burst"
+	TracingEnabledFlag         = "tracing-enabled"
+	TracingEndpointFlag        = "tracing-endpoint"
+	TracingInsecureFlag        = "tracing-insecure"
+	TracingServiceNameFlag     = "tracing-service-name"
+	TracingSampleRatioFlag     = "tracing-sample-ratio"
+	CacheEnabledFlag           = "cache-enabled"
+	CacheRelayTTLFlag          = "cache-relay-ttl"
+	CachePlacementTTLFlag      = "cache-placement-ttl"
+	CacheMaxPlacementsFlag     = "cache-max-placements"
+	ResilienceEnabledFlag      = "backend-resilience"
+	BackendCallTimeoutFlag     = "backend-call-timeout"
+	BackendMaxAttemptsFlag     = "backend-max-attempts"
+	BackendRetryBackoffFlag    = "backend-retry-backoff"
+	BackendFailureThreshFlag   = "backend-failure-threshold"
+	BackendOpenTimeoutFlag     = "backend-open-timeout"
+	DegradedEnabledFlag        = "degraded-enabled"
+	DegradedMaxStalenessFlag   = "degraded-max-staleness"
+	DegradedMaxHeartbeatsFlag  = "degraded-max-buffered-heartbeats"
+	HTTPEnabledFlag            = "http-enabled"
+	HTTPListenAddressFlag      = "http-listen-address"
+	HTTPListenPortFlag         = "http-listen-port"
+	HTTPAllowedOriginsFlag     = "http-allowed-origins"
+	HTTPEventsIntervalFlag     = "http-events-interval"
+	AuditEnabledFlag           = "audit-enabled"
+	AuditSinkFlag              = "audit-sink"
+	AuditFilePathFlag          = "audit-file-path"
+	AuditBufferSizeFlag        = "audit-buffer-size"
+	HistoryEnabledFlag         = "placement-history-enabled"
+	HistoryEntriesFlag         = "placement-history-entries"
+	HistoryRetentionFlag       = "placement-history-retention"
+)
+
+// migrate subcommand flag names
+const (
+	MigrateSourceConfigFlag      = "source-config"
+	MigrateDestinationConfigFlag = "destination-config"
+	MigrateDualWriteFlag         = "dual-write"
+	MigrateSyncIntervalFlag      = "sync-interval"
+	MigrateDiffOnlyFlag          = "diff-only"
+)
+
+// admin client subcommand flag names
+const (
+	AdminAddressFlag       = "address"
+	AdminClientTokenFlag   = "token"
+	AdminNamespaceFlag     = "namespace"
+	AdminTLSFlag           = "tls"
+	AdminTLSCAFlag         = "tls-ca"
+	AdminTLSServerNameFlag = "tls-server-name"
+	AdminTLSSkipVerifyFlag = "tls-insecure-skip-verify"
+	AdminTLSClientCertFlag = "tls-client-cert"
+	AdminTLSClientKeyFlag  = "tls-client-key"
+	AdminTimeoutFlag       = "timeout"
+	AdminOutputFlag        = "output"
+	AdminRelayFlag         = "relay"
+	AdminAgentFlag         = "agent"
+	AdminAuditTypeFlag     = "type"
+	AdminLimitFlag         = "limit"
 )
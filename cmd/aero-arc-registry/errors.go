@@ -2,4 +2,11 @@ package main
 
 import "errors"
 
-var ErrUnhandledBackend = errors.New("unhandled registry backend")
+var (
+	ErrUnhandledBackend  = errors.New("unhandled registry backend")
+	ErrBackendsDiffer    = errors.New("source and destination backends differ")
+	ErrDualWriteUnshared = errors.New("dual-write needs a source shared with running registries")
+	ErrMissingArgument   = errors.New("missing argument")
+	ErrInvalidOutput     = errors.New("output must be table or json")
+	ErrInvalidTLSConfig  = errors.New("invalid tls options")
+)
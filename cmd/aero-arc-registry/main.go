@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"log/slog"
@@ -12,18 +13,29 @@ import (
 	"time"
 
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/cached"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/resilience"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/traced"
+	"github.com/Aero-Arc/aero-arc-registry/internal/telemetry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/transport/gateway"
 	"github.com/Aero-Arc/aero-arc-registry/internal/transport/grpc"
 	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
 	gogrpc "google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 )
 
 var homeDir, _ = os.UserHomeDir()
+var hostname, _ = os.Hostname()
 
 var registryCmd = cli.Command{
-	Usage:  "run the aero arc registry process",
-	Action: RunRegistry,
+	Usage:    "run the aero arc registry process",
+	Action:   RunRegistry,
+	Commands: append([]*cli.Command{&migrateCmd}, adminCommands...),
 	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  ConfigFileFlag,
+			Usage: "path to a yaml or toml config file; AERO_REGISTRY_* env vars and explicit flags override it",
+		},
 		&cli.StringFlag{
 			Name:  BackendFlag,
 			Value: "memory",
@@ -54,6 +66,10 @@ var registryCmd = cli.Command{
 			Usage: "path to tls crt file",
 			Value: fmt.Sprintf("%s/%s", homeDir, registry.DebugTLSCertPath),
 		},
+		&cli.StringFlag{
+			Name:  TLSClientCAPathFlag,
+			Usage: "path to a ca bundle; when set, callers must present a client certificate it signed",
+		},
 		&cli.DurationFlag{
 			Name:  RelayTTLFlag,
 			Usage: "ttl for relay health",
@@ -94,10 +110,312 @@ var registryCmd = cli.Command{
 			Usage: "specified redis db to use",
 			Value: 0,
 		},
+		&cli.StringFlag{
+			Name:  MemorySnapshotPathFlag,
+			Usage: "file the memory backend snapshots its state to and restores from on startup",
+			Value: "",
+		},
+		&cli.DurationFlag{
+			Name:  MemorySnapshotIntervalFlag,
+			Usage: "interval between memory backend snapshots (0 only snapshots on shutdown)",
+			Value: 0,
+		},
+		&cli.StringFlag{
+			Name:  MemoryWALPathFlag,
+			Usage: "write-ahead log for memory backend mutations between snapshots (requires snapshots)",
+			Value: "",
+		},
+		&cli.DurationFlag{
+			Name:  MemoryWALFlushIntervalFlag,
+			Usage: "how often batched heartbeats are appended to the memory write-ahead log",
+			Value: time.Second,
+		},
+		&cli.StringFlag{
+			Name:  RaftNodeIDFlag,
+			Usage: "unique id of this node in the raft cluster",
+			Value: hostname,
+		},
+		&cli.StringFlag{
+			Name:  RaftBindAddrFlag,
+			Usage: "host:port the raft backend listens on for replication and forwarded writes",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  RaftAdvertiseAddrFlag,
+			Usage: "host:port other raft nodes use to reach this node (defaults to the bind address)",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  RaftDataDirFlag,
+			Usage: "directory holding the raft log and snapshots",
+			Value: "",
+		},
+		&cli.StringSliceFlag{
+			Name:  RaftPeersFlag,
+			Usage: "raft cluster members as node-id=host:port, including this node",
+		},
+		&cli.DurationFlag{
+			Name:  RaftApplyTimeoutFlag,
+			Usage: "how long a write may wait to commit through raft",
+			Value: 5 * time.Second,
+		},
+		&cli.DurationFlag{
+			Name:  RaftHeartbeatFlushFlag,
+			Usage: "how often batched heartbeats are committed through raft",
+			Value: time.Second,
+		},
+		&cli.DurationFlag{
+			Name:  SweepIntervalFlag,
+			Usage: "base interval between ttl sweeps, shorter than the relay/agent ttl (0 uses a quarter of the shortest ttl)",
+			Value: 0,
+		},
+		&cli.BoolFlag{
+			Name:  AdaptiveSweepFlag,
+			Usage: "adapt the ttl sweep interval to near-expiry load and sweep health",
+			Value: false,
+		},
+		&cli.DurationFlag{
+			Name:  MinSweepIntervalFlag,
+			Usage: "lower bound for the adaptive ttl sweep interval (0 uses a quarter of the base interval)",
+			Value: 0,
+		},
+		&cli.DurationFlag{
+			Name:  MaxSweepIntervalFlag,
+			Usage: "upper bound for the adaptive ttl sweep interval (0 uses four times the base interval)",
+			Value: 0,
+		},
+		&cli.BoolFlag{
+			Name:  SweepCoordinationFlag,
+			Usage: "elect a single ttl sweeper across replicas sharing a backend",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  ReplicaIDFlag,
+			Usage: "identity of this registry replica used for sweeper election",
+			Value: hostname,
+		},
+		&cli.DurationFlag{
+			Name:  SweeperLeaseTTLFlag,
+			Usage: "ttl of the sweeper lease (0 derives it from the relay/agent ttl)",
+			Value: 0,
+		},
+		&cli.StringFlag{
+			Name:  LogLevelFlag,
+			Usage: "minimum log level: debug, info, warn or error",
+			Value: "info",
+		},
+		&cli.BoolFlag{
+			Name:  AdminEnabledFlag,
+			Usage: "register the admin grpc service (config reload, on-demand ttl sweep)",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  AdminTokenFlag,
+			Usage: "bearer token required on admin rpcs; empty disables admin auth",
+			Value: "",
+		},
 		&cli.DurationFlag{
 			Name:  ShutDownTimeoutFlag,
 			Usage: "timeout that is enforced during a graceful shutdown",
-			Value: time.Second * 30,
+			Value: registry.DefaultShutdownTimeout,
+		},
+		&cli.DurationFlag{
+			Name:  PreStopDelayFlag,
+			Usage: "how long to keep serving after reporting NOT_SERVING on shutdown",
+			Value: 0,
+		},
+		&cli.IntFlag{
+			Name:  MaxRelaysFlag,
+			Usage: "maximum number of registered relays; 0 disables the limit",
+			Value: 0,
+		},
+		&cli.IntFlag{
+			Name:  MaxAgentsFlag,
+			Usage: "maximum number of registered agents; 0 disables the limit",
+			Value: 0,
+		},
+		&cli.IntFlag{
+			Name:  MaxAgentsPerRelayFlag,
+			Usage: "maximum number of agents placed on one relay; 0 disables the limit",
+			Value: 0,
+		},
+		&cli.FloatFlag{
+			Name:  HeartbeatRateFlag,
+			Usage: "heartbeat requests per second allowed per client; 0 disables the limit",
+			Value: 0,
+		},
+		&cli.IntFlag{
+			Name:  HeartbeatBurstFlag,
+			Usage: "heartbeat requests a client may burst; 0 derives it from the rate",
+			Value: 0,
+		},
+		&cli.FloatFlag{
+			Name:  RegistrationRateFlag,
+			Usage: "registration requests per second allowed per client; 0 disables the limit",
+			Value: 0,
+		},
+		&cli.IntFlag{
+			Name:  RegistrationBurstFlag,
+			Usage: "registration requests a client may burst; 0 derives it from the rate",
+			Value: 0,
+		},
+		&cli.FloatFlag{
+			Name:  ListRateFlag,
+			Usage: "list requests per second allowed per client; 0 disables the limit",
+			Value: 0,
+		},
+		&cli.IntFlag{
+			Name:  ListBurstFlag,
+			Usage: "list requests a client may burst; 0 derives it from the rate",
+			Value: 0,
+		},
+		&cli.BoolFlag{
+			Name:  TracingEnabledFlag,
+			Usage: "export opentelemetry traces to an otlp/grpc collector",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  TracingEndpointFlag,
+			Usage: "host:port of the otlp/grpc trace collector",
+			Value: "localhost:4317",
+		},
+		&cli.BoolFlag{
+			Name:  TracingInsecureFlag,
+			Usage: "disable tls on the connection to the trace collector",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  TracingServiceNameFlag,
+			Usage: "service.name reported on exported spans",
+			Value: registry.DefaultTracingServiceName,
+		},
+		&cli.FloatFlag{
+			Name:  TracingSampleRatioFlag,
+			Usage: "fraction of new traces to sample, between 0 and 1",
+			Value: 1,
+		},
+		&cli.BoolFlag{
+			Name:  CacheEnabledFlag,
+			Usage: "cache relay lists and agent placements in front of the backend",
+			Value: false,
+		},
+		&cli.DurationFlag{
+			Name:  CacheRelayTTLFlag,
+			Usage: "how long a cached relay list may be served",
+			Value: registry.DefaultCacheRelayTTL,
+		},
+		&cli.DurationFlag{
+			Name:  CachePlacementTTLFlag,
+			Usage: "how long a cached agent placement may be served",
+			Value: registry.DefaultCachePlacementTTL,
+		},
+		&cli.IntFlag{
+			Name:  CacheMaxPlacementsFlag,
+			Usage: "maximum number of cached agent placements",
+			Value: registry.DefaultCacheMaxPlacements,
+		},
+		&cli.BoolFlag{
+			Name:  ResilienceEnabledFlag,
+			Usage: "apply timeouts, retries and circuit breaking to backend calls",
+			Value: false,
+		},
+		&cli.DurationFlag{
+			Name:  BackendCallTimeoutFlag,
+			Usage: "timeout for each backend call attempt",
+			Value: registry.DefaultBackendCallTimeout,
+		},
+		&cli.IntFlag{
+			Name:  BackendMaxAttemptsFlag,
+			Usage: "attempts for idempotent backend calls, including the first",
+			Value: registry.DefaultBackendMaxAttempts,
+		},
+		&cli.DurationFlag{
+			Name:  BackendRetryBackoffFlag,
+			Usage: "delay before the first backend retry, doubled for each further retry",
+			Value: registry.DefaultBackendRetryBackoff,
+		},
+		&cli.IntFlag{
+			Name:  BackendFailureThreshFlag,
+			Usage: "consecutive backend failures that open the circuit",
+			Value: registry.DefaultBackendFailureThreshold,
+		},
+		&cli.DurationFlag{
+			Name:  BackendOpenTimeoutFlag,
+			Usage: "how long the backend circuit stays open before probing",
+			Value: registry.DefaultBackendOpenTimeout,
+		},
+		&cli.BoolFlag{
+			Name:  DegradedEnabledFlag,
+			Usage: "serve last-known state and buffer heartbeats while the backend is unavailable",
+			Value: false,
+		},
+		&cli.DurationFlag{
+			Name:  DegradedMaxStalenessFlag,
+			Usage: "maximum age of last-known state served during an outage (0 for no limit)",
+			Value: 0,
+		},
+		&cli.IntFlag{
+			Name:  DegradedMaxHeartbeatsFlag,
+			Usage: "maximum relays and agents whose heartbeats are buffered during an outage",
+			Value: registry.DefaultMaxBufferedHeartbeats,
+		},
+		&cli.BoolFlag{
+			Name:  HTTPEnabledFlag,
+			Usage: "serve the read-only HTTP/JSON gateway for dashboards",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  HTTPListenAddressFlag,
+			Usage: "the address the HTTP gateway will listen on",
+			Value: "0.0.0.0",
+		},
+		&cli.IntFlag{
+			Name:  HTTPListenPortFlag,
+			Usage: "the port the HTTP gateway will listen on",
+			Value: 8080,
+		},
+		&cli.StringSliceFlag{
+			Name:  HTTPAllowedOriginsFlag,
+			Usage: "browser origins allowed to call the HTTP gateway (* for any)",
+		},
+		&cli.DurationFlag{
+			Name:  HTTPEventsIntervalFlag,
+			Usage: "how often the HTTP gateway's change stream checks for changes",
+			Value: registry.DefaultHTTPEventsInterval,
+		},
+		&cli.BoolFlag{
+			Name:  AuditEnabledFlag,
+			Usage: "record an audit log of relay and agent ownership changes and admin actions",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  AuditSinkFlag,
+			Usage: "where audit events are written: slog, file or none (memory only)",
+			Value: registry.AuditSinkSlog,
+		},
+		&cli.StringFlag{
+			Name:  AuditFilePathFlag,
+			Usage: "the JSON lines file the file audit sink appends to",
+		},
+		&cli.IntFlag{
+			Name:  AuditBufferSizeFlag,
+			Usage: "how many recent audit events are kept in memory for the AuditLog admin RPC",
+			Value: registry.DefaultAuditBufferSize,
+		},
+		&cli.BoolFlag{
+			Name:  HistoryEnabledFlag,
+			Usage: "keep a recent placement history per agent for the PlacementHistory admin RPC",
+			Value: false,
+		},
+		&cli.IntFlag{
+			Name:  HistoryEntriesFlag,
+			Usage: "how many placement changes are kept per agent",
+			Value: registry.DefaultPlacementHistoryEntries,
+		},
+		&cli.DurationFlag{
+			Name:  HistoryRetentionFlag,
+			Usage: "how long placement changes are kept",
+			Value: registry.DefaultPlacementHistoryRetention,
 		},
 	},
 }
@@ -111,65 +429,169 @@ func RunRegistry(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	logLevel := new(slog.LevelVar)
+	if level, err := cfg.Log.SlogLevel(); err == nil {
+		logLevel.Set(level)
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+
+	shutdownTracing, err := telemetry.SetupTracing(ctx, cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tracingFlushTimeout)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Warn("flushing traces failed", "error", err)
+		}
+	}()
+
 	backend, err := buildBackendFromConfig(cfg)
 	if err != nil {
 		return err
 	}
+	if cfg.Tracing.Enabled {
+		backend = traced.New(backend, otel.GetTracerProvider(), cfg.Backend.Type)
+	}
+	if cfg.Backend.Resilience.Enabled {
+		backend = resilience.New(backend, cfg.Backend.Resilience)
+	}
+	if cfg.Backend.Cache.Enabled {
+		backend, err = cached.New(backend, cfg.Backend.Cache)
+		if err != nil {
+			return err
+		}
+	}
 
-	aeroRegistry, err := registry.New(cfg, backend)
+	var registryOpts []registry.Option
+	if cfg.Audit.Enabled {
+		sink, err := registry.NewAuditSink(cfg.Audit)
+		if err != nil {
+			return err
+		}
+		if sink != nil {
+			// Deferred so events from requests drained and a sweep finished
+			// during shutdownRegistry are still written.
+			defer func() {
+				if err := sink.Close(); err != nil {
+					slog.Warn("closing audit sink failed", "error", err)
+				}
+			}()
+			registryOpts = append(registryOpts, registry.WithAuditSink(sink))
+		}
+	}
+
+	aeroRegistry, err := registry.New(cfg, backend, registryOpts...)
 	if err != nil {
 		return err
 	}
 
+	// A restored snapshot may hold entries that expired while the registry
+	// was down; sweep them before serving so they are not handed out.
+	if cfg.Backend.Memory != nil && cfg.Backend.Memory.SnapshotPath != "" {
+		if err := aeroRegistry.SweepNow(ctx); err != nil {
+			slog.Warn("initial ttl sweep after snapshot restore failed", "error", err)
+		}
+	}
+
 	var opts []gogrpc.ServerOption
+	var certs *grpc.CertReloader
 
 	if cfg.GRPC.TLS.Enabled {
-		creds, err := credentials.NewServerTLSFromFile(
+		certs, err = grpc.NewCertReloader(
 			cfg.GRPC.TLS.CertPath,
 			cfg.GRPC.TLS.KeyPath,
+			cfg.GRPC.TLS.ClientCAPath,
 		)
 		if err != nil {
 			return err
 		}
 
-		opts = append(opts, gogrpc.Creds(creds))
+		opts = append(opts, gogrpc.Creds(certs.TransportCredentials()))
 	}
 
-	grpcServer, err := grpc.New(aeroRegistry, opts...)
+	grpcServer, err := grpc.New(aeroRegistry, grpc.WithServerOptions(opts...))
 	if err != nil {
 		return err
 	}
 
-	aeroRegistry.RunTTL(signalCtx)
+	reloader := &configReloader{
+		cmd:      cmd,
+		registry: aeroRegistry,
+		logLevel: logLevel,
+		certs:    certs,
+	}
+	if cfg.Admin.Enabled {
+		grpcServer.EnableAdmin(reloader)
+	}
+	reloadOnSIGHUP(signalCtx, reloader)
+
+	// The TTL loop runs on the parent context so a shutdown signal does not
+	// abort a sweep mid-pass; shutdownRegistry stops it explicitly.
+	aeroRegistry.RunTTL(ctx)
 
 	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d",
 		cfg.GRPC.ListenAddress,
 		cfg.GRPC.ListenPort,
 	))
 	if err != nil {
+		_ = aeroRegistry.StopTTL(ctx)
 		return err
 	}
 
-	go func() {
-		<-signalCtx.Done()
-		slog.Info("shutting down grpc server")
-		grpcServer.GracefulStop()
+	var httpServer *gateway.Server
+	httpServeErr := make(chan error, 1)
+	if cfg.HTTP.Enabled {
+		var tlsConfig *tls.Config
+		if certs != nil {
+			tlsConfig = certs.TLSConfig()
+		}
+		httpServer = gateway.New(aeroRegistry, tlsConfig)
 
-		slog.Info("shutting down backend")
-		if err := backend.Close(context.Background()); err != nil {
-			slog.Error("failed to close backend", "error", err)
+		httpLis, err := net.Listen("tcp", fmt.Sprintf("%s:%d",
+			cfg.HTTP.ListenAddress,
+			cfg.HTTP.ListenPort,
+		))
+		if err != nil {
+			_ = lis.Close()
+			_ = aeroRegistry.StopTTL(ctx)
+			return err
 		}
+
+		go func() {
+			httpServeErr <- httpServer.Serve(httpLis)
+		}()
+
+		slog.Info("Registry HTTP gateway listening",
+			"address", cfg.HTTP.ListenAddress,
+			"port", cfg.HTTP.ListenPort,
+		)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- grpcServer.Serve(lis)
 	}()
 
 	slog.Info("Registry gRPC server listening",
 		"address", cfg.GRPC.ListenAddress,
 		"port", cfg.GRPC.ListenPort,
 	)
-	if err := grpcServer.Serve(lis); err != nil {
+
+	select {
+	case err := <-serveErr:
+		shutdownRegistry(ctx, aeroRegistry.Config().Shutdown, grpcServer, httpServer, aeroRegistry, backend)
+		return err
+	case err := <-httpServeErr:
+		shutdownRegistry(ctx, aeroRegistry.Config().Shutdown, grpcServer, httpServer, aeroRegistry, backend)
 		return err
+	case <-signalCtx.Done():
 	}
 
-	return nil
+	shutdownRegistry(ctx, aeroRegistry.Config().Shutdown, grpcServer, httpServer, aeroRegistry, backend)
+
+	return <-serveErr
 }
 
 func main() {
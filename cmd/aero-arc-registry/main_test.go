@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -12,6 +14,7 @@ import (
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/etcd"
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/redis"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/shadow"
 	"github.com/urfave/cli/v3"
 )
 
@@ -63,6 +66,83 @@ func TestBuildConfigFromCLI(t *testing.T) {
 		}
 	})
 
+	t.Run("sweep scheduling flags map ttl config", func(t *testing.T) {
+		cmd := newTestCLICommand()
+		_ = cmd.Set(SweepIntervalFlag, "5s")
+		_ = cmd.Set(AdaptiveSweepFlag, "true")
+		_ = cmd.Set(MinSweepIntervalFlag, "1s")
+		_ = cmd.Set(MaxSweepIntervalFlag, "20s")
+
+		cfg, err := buildConfigFromCLI(cmd)
+		if err != nil {
+			t.Fatalf("buildConfigFromCLI() error = %v", err)
+		}
+		if cfg.TTL.SweepInterval != 5*time.Second || !cfg.TTL.AdaptiveSweep {
+			t.Fatalf("unexpected sweep config: %+v", cfg.TTL)
+		}
+		if cfg.TTL.MinSweepInterval != time.Second || cfg.TTL.MaxSweepInterval != 20*time.Second {
+			t.Fatalf("unexpected sweep bounds: %+v", cfg.TTL)
+		}
+	})
+
+	t.Run("sweep coordination flags map ttl coordination config", func(t *testing.T) {
+		cmd := newTestCLICommand()
+		_ = cmd.Set(SweepCoordinationFlag, "true")
+		_ = cmd.Set(ReplicaIDFlag, "replica-a")
+		_ = cmd.Set(SweeperLeaseTTLFlag, "90s")
+
+		cfg, err := buildConfigFromCLI(cmd)
+		if err != nil {
+			t.Fatalf("buildConfigFromCLI() error = %v", err)
+		}
+		coordination := cfg.TTL.Coordination
+		if !coordination.Enabled || coordination.ReplicaID != "replica-a" || coordination.LeaseTTL != 90*time.Second {
+			t.Fatalf("unexpected coordination config: %+v", coordination)
+		}
+	})
+
+	t.Run("config file, env and explicit flags layer in order", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "registry.yaml")
+		contents := "grpc:\n  listen_port: 6000\n  listen_address: 10.0.0.1\nttl:\n  relay: 40s\n  agent: 20s\n"
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatalf("write config file: %v", err)
+		}
+		t.Setenv("AERO_REGISTRY_TTL_AGENT", "25s")
+		t.Setenv("AERO_REGISTRY_GRPC_LISTEN_PORT", "6001")
+
+		cmd := newTestCLICommand()
+		_ = cmd.Set(ConfigFileFlag, path)
+		_ = cmd.Set(GRPCListenPortFlag, "6002")
+
+		cfg, err := buildConfigFromCLI(cmd)
+		if err != nil {
+			t.Fatalf("buildConfigFromCLI() error = %v", err)
+		}
+		if cfg.GRPC.ListenAddress != "10.0.0.1" || cfg.TTL.Relay != 40*time.Second {
+			t.Fatalf("expected file values over flag defaults, got grpc=%+v ttl=%+v", cfg.GRPC, cfg.TTL)
+		}
+		if cfg.TTL.Agent != 25*time.Second {
+			t.Fatalf("expected env to override file agent ttl, got %v", cfg.TTL.Agent)
+		}
+		if cfg.GRPC.ListenPort != 6002 {
+			t.Fatalf("expected explicit flag to override env and file port, got %d", cfg.GRPC.ListenPort)
+		}
+	})
+
+	t.Run("invalid config file values fail validation", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "registry.toml")
+		if err := os.WriteFile(path, []byte("[ttl]\nrelay = \"0s\"\n"), 0o600); err != nil {
+			t.Fatalf("write config file: %v", err)
+		}
+
+		cmd := newTestCLICommand()
+		_ = cmd.Set(ConfigFileFlag, path)
+
+		if _, err := buildConfigFromCLI(cmd); !errors.Is(err, registry.ErrTTLRelayInvalid) {
+			t.Fatalf("expected ErrTTLRelayInvalid, got %v", err)
+		}
+	})
+
 	t.Run("unsupported backend returns error", func(t *testing.T) {
 		cmd := newTestCLICommand()
 		_ = cmd.Set(BackendFlag, "unsupported")
@@ -130,6 +210,26 @@ func TestBuildBackendFromConfig(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "shadow backend",
+			cfg: &registry.Config{
+				Backend: registry.BackendConfig{
+					Type:    registry.ShadowRegistryBackend,
+					Primary: &registry.BackendTarget{Type: registry.MemoryRegistryBackend},
+					Shadow:  &registry.BackendTarget{Type: registry.MemoryRegistryBackend},
+				},
+			},
+			assert: func(t *testing.T, b registry.Backend) {
+				shadowed, ok := b.(*shadow.Backend)
+				if !ok {
+					t.Fatalf("expected *shadow.Backend, got %T", b)
+				}
+				if _, ok := shadowed.Unwrap().(*memory.Backend); !ok {
+					t.Fatalf("expected *memory.Backend primary, got %T", shadowed.Unwrap())
+				}
+				_ = shadowed.Close(context.Background())
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -182,12 +282,14 @@ func TestRunRegistryEarlyErrors(t *testing.T) {
 func newTestCLICommand() *cli.Command {
 	return &cli.Command{
 		Flags: []cli.Flag{
+			&cli.StringFlag{Name: ConfigFileFlag},
 			&cli.StringFlag{Name: BackendFlag, Value: "memory"},
 			&cli.StringFlag{Name: GRPCListenAddrFlag, Value: "0.0.0.0"},
 			&cli.IntFlag{Name: GRPCListenPortFlag, Value: 50051},
 			&cli.BoolFlag{Name: TLSEnabledFlag, Value: false},
 			&cli.StringFlag{Name: TLSKeyPathFlag, Value: "/tmp/test.key"},
 			&cli.StringFlag{Name: TLSCertPathFlag, Value: "/tmp/test.crt"},
+			&cli.StringFlag{Name: TLSClientCAPathFlag},
 			&cli.DurationFlag{Name: RelayTTLFlag, Value: 30 * time.Second},
 			&cli.DurationFlag{Name: AgentTTLFlag, Value: 30 * time.Second},
 			&cli.StringFlag{Name: RedisAddrFlag, Value: "localhost"},
@@ -195,6 +297,68 @@ func newTestCLICommand() *cli.Command {
 			&cli.StringFlag{Name: RedisUsernameFlag, Value: "default"},
 			&cli.StringFlag{Name: RedisPasswordFlag, Value: ""},
 			&cli.IntFlag{Name: RedisDBFlag, Value: 0},
+			&cli.StringFlag{Name: MemorySnapshotPathFlag, Value: ""},
+			&cli.DurationFlag{Name: MemorySnapshotIntervalFlag, Value: 0},
+			&cli.StringFlag{Name: MemoryWALPathFlag, Value: ""},
+			&cli.DurationFlag{Name: MemoryWALFlushIntervalFlag, Value: time.Second},
+			&cli.StringFlag{Name: RaftNodeIDFlag, Value: "node-test"},
+			&cli.StringFlag{Name: RaftBindAddrFlag, Value: ""},
+			&cli.StringFlag{Name: RaftAdvertiseAddrFlag, Value: ""},
+			&cli.StringFlag{Name: RaftDataDirFlag, Value: ""},
+			&cli.StringSliceFlag{Name: RaftPeersFlag},
+			&cli.DurationFlag{Name: RaftApplyTimeoutFlag, Value: 5 * time.Second},
+			&cli.DurationFlag{Name: RaftHeartbeatFlushFlag, Value: time.Second},
+			&cli.DurationFlag{Name: SweepIntervalFlag, Value: 0},
+			&cli.BoolFlag{Name: AdaptiveSweepFlag, Value: false},
+			&cli.DurationFlag{Name: MinSweepIntervalFlag, Value: 0},
+			&cli.DurationFlag{Name: MaxSweepIntervalFlag, Value: 0},
+			&cli.BoolFlag{Name: SweepCoordinationFlag, Value: false},
+			&cli.StringFlag{Name: LogLevelFlag, Value: "info"},
+			&cli.BoolFlag{Name: AdminEnabledFlag, Value: false},
+			&cli.StringFlag{Name: AdminTokenFlag, Value: ""},
+			&cli.StringFlag{Name: ReplicaIDFlag, Value: "replica-test"},
+			&cli.DurationFlag{Name: SweeperLeaseTTLFlag, Value: 0},
+			&cli.DurationFlag{Name: ShutDownTimeoutFlag, Value: 30 * time.Second},
+			&cli.DurationFlag{Name: PreStopDelayFlag, Value: 0},
+			&cli.IntFlag{Name: MaxRelaysFlag, Value: 0},
+			&cli.IntFlag{Name: MaxAgentsFlag, Value: 0},
+			&cli.IntFlag{Name: MaxAgentsPerRelayFlag, Value: 0},
+			&cli.FloatFlag{Name: HeartbeatRateFlag, Value: 0},
+			&cli.IntFlag{Name: HeartbeatBurstFlag, Value: 0},
+			&cli.FloatFlag{Name: RegistrationRateFlag, Value: 0},
+			&cli.IntFlag{Name: RegistrationBurstFlag, Value: 0},
+			&cli.FloatFlag{Name: ListRateFlag, Value: 0},
+			&cli.IntFlag{Name: ListBurstFlag, Value: 0},
+			&cli.BoolFlag{Name: TracingEnabledFlag, Value: false},
+			&cli.StringFlag{Name: TracingEndpointFlag, Value: "localhost:4317"},
+			&cli.BoolFlag{Name: TracingInsecureFlag, Value: false},
+			&cli.StringFlag{Name: TracingServiceNameFlag, Value: registry.DefaultTracingServiceName},
+			&cli.FloatFlag{Name: TracingSampleRatioFlag, Value: 1},
+			&cli.BoolFlag{Name: CacheEnabledFlag, Value: false},
+			&cli.DurationFlag{Name: CacheRelayTTLFlag, Value: registry.DefaultCacheRelayTTL},
+			&cli.DurationFlag{Name: CachePlacementTTLFlag, Value: registry.DefaultCachePlacementTTL},
+			&cli.IntFlag{Name: CacheMaxPlacementsFlag, Value: registry.DefaultCacheMaxPlacements},
+			&cli.BoolFlag{Name: ResilienceEnabledFlag, Value: false},
+			&cli.DurationFlag{Name: BackendCallTimeoutFlag, Value: registry.DefaultBackendCallTimeout},
+			&cli.IntFlag{Name: BackendMaxAttemptsFlag, Value: registry.DefaultBackendMaxAttempts},
+			&cli.DurationFlag{Name: BackendRetryBackoffFlag, Value: registry.DefaultBackendRetryBackoff},
+			&cli.IntFlag{Name: BackendFailureThreshFlag, Value: registry.DefaultBackendFailureThreshold},
+			&cli.DurationFlag{Name: BackendOpenTimeoutFlag, Value: registry.DefaultBackendOpenTimeout},
+			&cli.BoolFlag{Name: DegradedEnabledFlag, Value: false},
+			&cli.DurationFlag{Name: DegradedMaxStalenessFlag, Value: 0},
+			&cli.IntFlag{Name: DegradedMaxHeartbeatsFlag, Value: registry.DefaultMaxBufferedHeartbeats},
+			&cli.BoolFlag{Name: HTTPEnabledFlag, Value: false},
+			&cli.StringFlag{Name: HTTPListenAddressFlag, Value: "0.0.0.0"},
+			&cli.IntFlag{Name: HTTPListenPortFlag, Value: 8080},
+			&cli.StringSliceFlag{Name: HTTPAllowedOriginsFlag},
+			&cli.DurationFlag{Name: HTTPEventsIntervalFlag, Value: registry.DefaultHTTPEventsInterval},
+			&cli.BoolFlag{Name: AuditEnabledFlag, Value: false},
+			&cli.StringFlag{Name: AuditSinkFlag, Value: registry.AuditSinkSlog},
+			&cli.StringFlag{Name: AuditFilePathFlag},
+			&cli.IntFlag{Name: AuditBufferSizeFlag, Value: registry.DefaultAuditBufferSize},
+			&cli.BoolFlag{Name: HistoryEnabledFlag, Value: false},
+			&cli.IntFlag{Name: HistoryEntriesFlag, Value: registry.DefaultPlacementHistoryEntries},
+			&cli.DurationFlag{Name: HistoryRetentionFlag, Value: registry.DefaultPlacementHistoryRetention},
 		},
 	}
 }
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/migrate"
+	"github.com/urfave/cli/v3"
+)
+
+// defaultMigrateSyncInterval is how often the destination catches up with
+// the source during a dual-write window.
+const defaultMigrateSyncInterval = time.Second
+
+var migrateCmd = cli.Command{
+	Name:   "migrate",
+	Usage:  "copy relays, agents and placements from one backend to another",
+	Action: RunMigrate,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     MigrateSourceConfigFlag,
+			Usage:    "config file whose backend section describes the backend to copy from",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     MigrateDestinationConfigFlag,
+			Usage:    "config file whose backend section describes the backend to copy to",
+			Required: true,
+		},
+		&cli.DurationFlag{
+			Name:  MigrateDualWriteFlag,
+			Usage: "after the initial copy, keep mirroring source writes into the destination for this long (0 disables); needs a source shared with running registries (redis, etcd or consul)",
+			Value: 0,
+		},
+		&cli.DurationFlag{
+			Name:  MigrateSyncIntervalFlag,
+			Usage: "how often the destination catches up with the source during the dual-write window",
+			Value: defaultMigrateSyncInterval,
+		},
+		&cli.BoolFlag{
+			Name:  MigrateDiffOnlyFlag,
+			Usage: "only report the differences between the backends without copying",
+			Value: false,
+		},
+	},
+}
+
+// RunMigrate copies the state of the source backend into the destination,
+// optionally keeps the destination in step for a dual-write window, and
+// reports the remaining differences. It fails with ErrBackendsDiffer when
+// the backends still disagree.
+func RunMigrate(ctx context.Context, cmd *cli.Command) error {
+	signalCtx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	srcCfg, err := loadMigrationConfig(cmd.String(MigrateSourceConfigFlag))
+	if err != nil {
+		return fmt.Errorf("open source backend: %w", err)
+	}
+
+	window := cmd.Duration(MigrateDualWriteFlag)
+	if window > 0 && !sharedBackend(srcCfg.Backend.Type) {
+		return fmt.Errorf("%w: %s source", ErrDualWriteUnshared, srcCfg.Backend.Type)
+	}
+
+	src, err := openMigrationSource(srcCfg)
+	if err != nil {
+		return fmt.Errorf("open source backend: %w", err)
+	}
+	defer closeMigrationBackend(ctx, "source", src)
+
+	dstCfg, err := loadMigrationConfig(cmd.String(MigrateDestinationConfigFlag))
+	if err != nil {
+		return fmt.Errorf("open destination backend: %w", err)
+	}
+
+	dst, err := buildBackendFromConfig(dstCfg)
+	if err != nil {
+		return fmt.Errorf("open destination backend: %w", err)
+	}
+	defer closeMigrationBackend(ctx, "destination", dst)
+
+	out := cmd.Root().Writer
+
+	if !cmd.Bool(MigrateDiffOnlyFlag) {
+		result, err := migrate.Copy(signalCtx, src, dst, migrate.Options{})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "copied %d relays and %d agents\n", result.RelaysCopied, result.AgentsCopied)
+		if result.Restamped {
+			fmt.Fprintln(out, "destination cannot import timestamps; copied entries carry the time of the copy")
+		}
+
+		if window > 0 {
+			if err := runDualWrite(signalCtx, src, dst, window, cmd.Duration(MigrateSyncIntervalFlag)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Report even after an interrupted dual-write window.
+	report, err := migrate.Diff(context.WithoutCancel(signalCtx), src, dst)
+	if err != nil {
+		return err
+	}
+	writeMigrationReport(out, report)
+
+	if len(report.Mismatches) > 0 {
+		return fmt.Errorf("%w: %d mismatches", ErrBackendsDiffer, len(report.Mismatches))
+	}
+
+	return nil
+}
+
+// runDualWrite mirrors source writes into the destination until window
+// elapses or ctx is done, then runs a final pass that also carries over
+// removals.
+func runDualWrite(ctx context.Context, src, dst registry.Backend, window, interval time.Duration) error {
+	if interval <= 0 {
+		interval = defaultMigrateSyncInterval
+	}
+
+	slog.Info("dual-write window started; mirroring source writes into the destination",
+		"window", window,
+		"sync_interval", interval,
+	)
+
+	windowCtx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+	migrate.Sync(windowCtx, src, dst, interval)
+
+	result, err := migrate.Copy(context.WithoutCancel(ctx), src, dst, migrate.Options{Prune: true})
+	if err != nil {
+		return err
+	}
+	slog.Info("dual-write window ended",
+		"relays_copied", result.RelaysCopied,
+		"agents_copied", result.AgentsCopied,
+		"relays_removed", result.RelaysRemoved,
+		"agents_removed", result.AgentsRemoved,
+	)
+
+	return nil
+}
+
+// loadMigrationConfig loads the config file at path. Only the backend
+// section is used and validated.
+func loadMigrationConfig(path string) (*registry.Config, error) {
+	cfg := &registry.Config{}
+	if err := registry.LoadConfigFile(path, cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Backend.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// openMigrationSource builds the backend to copy from. A memory source is
+// opened read-only so the migration leaves its snapshot and write-ahead log
+// as they were. Memory and Raft sources fail with an ErrConflict error while
+// a running registry holds their files.
+func openMigrationSource(cfg *registry.Config) (registry.Backend, error) {
+	if cfg.Backend.Type == registry.MemoryRegistryBackend {
+		return memory.OpenReadOnly(cfg.Backend.Memory)
+	}
+
+	return buildBackendFromConfig(cfg)
+}
+
+// sharedBackend reports whether running registries and the migration tool
+// can use a backend of type t at the same time. Only then does a
+// dual-write window see the writes registries keep making.
+func sharedBackend(t registry.RegistryBackend) bool {
+	switch t {
+	case registry.RedisRegistryBackend, registry.EtcdRegistryBackend, registry.ConsulRegistryBackend:
+		return true
+	default:
+		return false
+	}
+}
+
+func closeMigrationBackend(ctx context.Context, role string, backend registry.Backend) {
+	closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), backendCloseTimeout)
+	defer cancel()
+
+	if err := backend.Close(closeCtx); err != nil {
+		slog.Error("failed to close backend", "role", role, "error", err)
+	}
+}
+
+func writeMigrationReport(w io.Writer, report migrate.Report) {
+	fmt.Fprintf(w, "source: %d relays, %d agents\n", report.SourceRelays, report.SourceAgents)
+	fmt.Fprintf(w, "destination: %d relays, %d agents\n", report.DestinationRelays, report.DestinationAgents)
+
+	if len(report.Mismatches) == 0 {
+		fmt.Fprintln(w, "no mismatches")
+		return
+	}
+
+	fmt.Fprintf(w, "%d mismatches:\n", len(report.Mismatches))
+	for _, mismatch := range report.Mismatches {
+		fmt.Fprintf(w, "  %s\n", mismatch)
+	}
+}
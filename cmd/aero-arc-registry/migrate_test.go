@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+	"github.com/urfave/cli/v3"
+)
+
+func writeMemoryBackendConfig(t *testing.T, dir, name string) (configPath, snapshotPath string) {
+	t.Helper()
+
+	snapshotPath = filepath.Join(dir, name+".snapshot")
+	configPath = filepath.Join(dir, name+".yaml")
+	config := fmt.Sprintf("backend:\n  type: memory\n  memory:\n    snapshot_path: %s\n", snapshotPath)
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	return configPath, snapshotPath
+}
+
+func openSnapshot(t *testing.T, path string) *memory.Backend {
+	t.Helper()
+
+	backend, err := memory.New(&registry.MemoryConfig{SnapshotPath: path})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	return backend
+}
+
+func runMigrateCommand(args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := &cli.Command{
+		Name:     "aero-arc-registry",
+		Writer:   &out,
+		Commands: []*cli.Command{&migrateCmd},
+	}
+
+	err := cmd.Run(context.Background(), append([]string{"aero-arc-registry", "migrate"}, args...))
+	return out.String(), err
+}
+
+func TestRunMigrate(t *testing.T) {
+	dir := t.TempDir()
+	srcConfig, srcSnapshot := writeMemoryBackendConfig(t, dir, "source")
+	dstConfig, dstSnapshot := writeMemoryBackendConfig(t, dir, "destination")
+
+	ctx := context.Background()
+	lastSeen := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+
+	src := openSnapshot(t, srcSnapshot)
+	if err := src.ImportRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1", Address: "10.0.0.1", GRPCPort: 9000, LastSeen: lastSeen}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := src.ImportAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1", LastHeartbeat: lastSeen}, registry.AgentPlacement{AgentID: "agent-1", RelayID: "relay-1", UpdatedAt: lastSeen}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := src.Close(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	out, err := runMigrateCommand("--"+MigrateSourceConfigFlag, srcConfig, "--"+MigrateDestinationConfigFlag, dstConfig, "--"+MigrateDiffOnlyFlag)
+	if !errors.Is(err, ErrBackendsDiffer) {
+		t.Fatalf("expected ErrBackendsDiffer before copying, got %v", err)
+	}
+	if !strings.Contains(out, "relay relay-1 missing") {
+		t.Fatalf("expected missing relay in report, got %q", out)
+	}
+
+	out, err = runMigrateCommand("--"+MigrateSourceConfigFlag, srcConfig, "--"+MigrateDestinationConfigFlag, dstConfig)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v (output %q)", err, out)
+	}
+	if !strings.Contains(out, "copied 1 relays and 1 agents") || !strings.Contains(out, "no mismatches") {
+		t.Fatalf("expected copy summary and clean report, got %q", out)
+	}
+
+	dst := openSnapshot(t, dstSnapshot)
+	placement, err := dst.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1")
+	if err != nil {
+		t.Fatalf("expected copied placement, got %v", err)
+	}
+	if placement.RelayID != "relay-1" || !placement.UpdatedAt.Equal(lastSeen) {
+		t.Fatalf("expected placement on relay-1 updated at %v, got %+v", lastSeen, placement)
+	}
+}
+
+func TestRunMigrateInvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	srcConfig, _ := writeMemoryBackendConfig(t, dir, "source")
+	dstConfig := filepath.Join(dir, "destination.yaml")
+	if err := os.WriteFile(dstConfig, []byte("backend:\n  type: redis\n"), 0o600); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	_, err := runMigrateCommand("--"+MigrateSourceConfigFlag, srcConfig, "--"+MigrateDestinationConfigFlag, dstConfig)
+	if !errors.Is(err, registry.ErrRedisConfigNil) {
+		t.Fatalf("expected ErrRedisConfigNil, got %v", err)
+	}
+}
+
+func TestRunMigrateRefusesLiveSource(t *testing.T) {
+	dir := t.TempDir()
+	srcConfig, srcSnapshot := writeMemoryBackendConfig(t, dir, "source")
+	dstConfig, _ := writeMemoryBackendConfig(t, dir, "destination")
+
+	src := openSnapshot(t, srcSnapshot)
+	defer src.Close(context.Background())
+
+	_, err := runMigrateCommand("--"+MigrateSourceConfigFlag, srcConfig, "--"+MigrateDestinationConfigFlag, dstConfig)
+	if !errors.Is(err, registry.ErrConflict) {
+		t.Fatalf("expected ErrConflict while a registry holds the source, got %v", err)
+	}
+}
+
+func TestRunMigrateDualWriteNeedsSharedSource(t *testing.T) {
+	dir := t.TempDir()
+	srcConfig, _ := writeMemoryBackendConfig(t, dir, "source")
+	dstConfig, _ := writeMemoryBackendConfig(t, dir, "destination")
+
+	_, err := runMigrateCommand("--"+MigrateSourceConfigFlag, srcConfig, "--"+MigrateDestinationConfigFlag, dstConfig, "--"+MigrateDualWriteFlag, "1m")
+	if !errors.Is(err, ErrDualWriteUnshared) {
+		t.Fatalf("expected ErrDualWriteUnshared, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/transport/grpc"
+	"github.com/urfave/cli/v3"
+)
+
+// configReloader re-reads the registry configuration from its sources
+// (config file, environment, flags) and hot-applies the safe subset.
+type configReloader struct {
+	mu       sync.Mutex
+	cmd      *cli.Command
+	registry *registry.Registry
+	logLevel *slog.LevelVar
+	certs    *grpc.CertReloader
+}
+
+var _ grpc.Reloader = (*configReloader)(nil)
+
+func (c *configReloader) Reload(ctx context.Context) (*registry.ReloadResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	next, err := buildConfigFromCLI(c.cmd)
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "config reload rejected; invalid configuration",
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	if err := c.registry.ValidateReload(next); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "config reload rejected; invalid configuration",
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	// Certificates are reloaded on every reload, not only when the paths
+	// change, so rotated files at the same path are picked up. Load them
+	// after the registry accepted the config and before applying anything,
+	// so a bad pair or a rejected config leaves both untouched. The client
+	// CA path needs a restart, so the running one is reloaded.
+	if c.certs != nil {
+		clientCAPath := c.registry.Config().GRPC.TLS.ClientCAPath
+		if err := c.certs.Reload(next.GRPC.TLS.CertPath, next.GRPC.TLS.KeyPath, clientCAPath); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "config reload rejected; failed to load tls certificate",
+				slog.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		slog.LogAttrs(ctx, slog.LevelInfo, "tls certificate reloaded",
+			slog.String("cert_path", next.GRPC.TLS.CertPath),
+		)
+	}
+
+	result, err := c.registry.ApplyConfig(ctx, next)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := c.registry.Config()
+	if level, err := applied.Log.SlogLevel(); err == nil {
+		c.logLevel.Set(level)
+	}
+
+	return result, nil
+}
+
+// reloadOnSIGHUP reloads configuration whenever the process receives SIGHUP
+// until ctx is cancelled.
+func reloadOnSIGHUP(ctx context.Context, reloader *configReloader) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				slog.LogAttrs(ctx, slog.LevelInfo, "received SIGHUP; reloading configuration")
+				if _, err := reloader.Reload(ctx); err != nil {
+					slog.LogAttrs(ctx, slog.LevelError, "config reload failed",
+						slog.String("error", err.Error()),
+					)
+				}
+			}
+		}
+	}()
+}
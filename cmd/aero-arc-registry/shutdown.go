@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/transport/gateway"
+	"github.com/Aero-Arc/aero-arc-registry/internal/transport/grpc"
+)
+
+// backendCloseTimeout bounds closing the backend once traffic has drained.
+const backendCloseTimeout = 5 * time.Second
+
+// tracingFlushTimeout bounds exporting buffered spans on exit.
+const tracingFlushTimeout = 5 * time.Second
+
+// shutdownRegistry stops the registry in dependency order: report
+// NOT_SERVING, wait out the pre-stop delay, drain in-flight gRPC and HTTP
+// gateway requests, stop the TTL loop (letting a running sweep finish), and
+// only then close the backend. Draining and the TTL wait share the shutdown
+// timeout. httpServer is nil when the gateway is disabled.
+func shutdownRegistry(
+	ctx context.Context,
+	cfg registry.ShutdownConfig,
+	server *grpc.Server,
+	httpServer *gateway.Server,
+	aeroRegistry *registry.Registry,
+	backend registry.Backend,
+) {
+	slog.Info("marking registry not serving")
+	server.MarkNotServing()
+
+	if cfg.PreStopDelay > 0 {
+		slog.Info("waiting pre-stop delay", "delay", cfg.PreStopDelay)
+		time.Sleep(cfg.PreStopDelay)
+	}
+
+	drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.EffectiveTimeout())
+	defer cancel()
+
+	slog.Info("shutting down grpc server", "timeout", cfg.EffectiveTimeout())
+	if err := server.Shutdown(drainCtx); err != nil {
+		slog.Warn("grpc drain timed out; in-flight requests were cancelled", "error", err)
+	}
+
+	if httpServer != nil {
+		slog.Info("shutting down http gateway")
+		if err := httpServer.Shutdown(drainCtx); err != nil {
+			slog.Warn("http gateway drain timed out", "error", err)
+		}
+	}
+
+	slog.Info("stopping ttl loop")
+	if err := aeroRegistry.StopTTL(drainCtx); err != nil {
+		slog.Warn("ttl sweep did not finish before shutdown timeout", "error", err)
+	}
+
+	closeCtx, cancelClose := context.WithTimeout(context.WithoutCancel(ctx), backendCloseTimeout)
+	defer cancelClose()
+
+	slog.Info("shutting down backend")
+	if err := backend.Close(closeCtx); err != nil {
+		slog.Error("failed to close backend", "error", err)
+	}
+}
@@ -0,0 +1,247 @@
+package registry
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// AuditEventType classifies an audit event.
+type AuditEventType string
+
+// Audit event types.
+const (
+	AuditRelayRegistered AuditEventType = "relay_registered"
+	AuditRelayRemoved    AuditEventType = "relay_removed"
+	AuditRelayExpired    AuditEventType = "relay_expired"
+	AuditAgentPlaced     AuditEventType = "agent_placed"
+	AuditAgentRemoved    AuditEventType = "agent_removed"
+	AuditAgentExpired    AuditEventType = "agent_expired"
+	AuditAdminAction     AuditEventType = "admin_action"
+)
+
+// AuditEvent records one ownership or lifecycle change, or an admin
+// action. Events made by a TTL sweep carry no caller.
+type AuditEvent struct {
+	// Seq orders the events of this replica, starting at 1.
+	Seq uint64 `json:"seq"`
+
+	// Time is when the change was made.
+	Time time.Time `json:"time"`
+
+	Type AuditEventType `json:"type"`
+
+	// Namespace is the namespace of the changed relay or agent, or the one
+	// an admin action was scoped to.
+	Namespace string `json:"namespace,omitempty"`
+
+	RelayID string `json:"relay_id,omitempty"`
+	AgentID string `json:"agent_id,omitempty"`
+
+	// PreviousRelayID is the relay an agent was placed on before an
+	// agent_placed event; empty for a new agent.
+	PreviousRelayID string `json:"previous_relay_id,omitempty"`
+
+	// Action is the admin method of an admin_action event.
+	Action string `json:"action,omitempty"`
+
+	// Caller and RequestID identify the request that made the change.
+	Caller    string `json:"caller,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// AuditSink receives every recorded audit event, in order. Write errors
+// are logged and do not fail the change being audited.
+type AuditSink interface {
+	WriteAudit(ctx context.Context, event AuditEvent) error
+	Close() error
+}
+
+// AuditFilter selects events from the recent audit history. Zero fields
+// match everything.
+type AuditFilter struct {
+	Namespace string
+	RelayID   string
+	AgentID   string
+	Type      AuditEventType
+
+	// Limit caps the number of events returned.
+	Limit int
+}
+
+func (f AuditFilter) matches(event AuditEvent) bool {
+	if f.Namespace != "" && event.Namespace != f.Namespace {
+		return false
+	}
+	if f.RelayID != "" && event.RelayID != f.RelayID && event.PreviousRelayID != f.RelayID {
+		return false
+	}
+	if f.AgentID != "" && event.AgentID != f.AgentID {
+		return false
+	}
+
+	return f.Type == "" || event.Type == f.Type
+}
+
+// Caller identifies who made a request, for audit events.
+type Caller struct {
+	Identity  string
+	RequestID string
+}
+
+type callerKey struct{}
+
+// WithCaller returns a context whose audited changes are attributed to
+// caller.
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// WithAuditSink sets the sink audit events are written to when the audit
+// log is enabled. Without one, events are only kept in memory.
+func WithAuditSink(sink AuditSink) Option {
+	return func(r *Registry) {
+		r.audit.sink = sink
+	}
+}
+
+// auditLog holds the recent audit history and the subscribers streaming
+// it. It is configured once in New; the audit config cannot be reloaded.
+type auditLog struct {
+	enabled bool
+	size    int
+	sink    AuditSink
+
+	mu          sync.Mutex
+	seq         uint64
+	recent      []AuditEvent
+	subscribers map[*AuditSubscription]struct{}
+}
+
+// AuditSubscription streams audit events as they are recorded.
+type AuditSubscription struct {
+	events chan AuditEvent
+	log    *auditLog
+	once   sync.Once
+}
+
+// Events delivers the recorded events. It is closed when the subscription
+// is cancelled or falls so far behind that events were dropped.
+func (s *AuditSubscription) Events() <-chan AuditEvent {
+	return s.events
+}
+
+// Cancel ends the subscription.
+func (s *AuditSubscription) Cancel() {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+	s.closeLocked()
+}
+
+func (s *AuditSubscription) closeLocked() {
+	s.once.Do(func() {
+		delete(s.log.subscribers, s)
+		close(s.events)
+	})
+}
+
+// AuditEnabled reports whether audit events are recorded.
+func (r *Registry) AuditEnabled() bool {
+	return r.audit.enabled
+}
+
+// Audit records event, filling in its sequence number, time, and the
+// namespace and caller from ctx. It is a no-op when the audit log is
+// disabled.
+func (r *Registry) Audit(ctx context.Context, event AuditEvent) {
+	if !r.audit.enabled {
+		return
+	}
+
+	event.Namespace = NamespaceFromContext(ctx)
+
+	if caller, ok := ctx.Value(callerKey{}).(Caller); ok {
+		event.Caller = caller.Identity
+		event.RequestID = caller.RequestID
+	}
+
+	r.audit.mu.Lock()
+	defer r.audit.mu.Unlock()
+
+	r.audit.seq++
+	event.Seq = r.audit.seq
+	event.Time = time.Now()
+
+	r.audit.recent = append(r.audit.recent, event)
+	if len(r.audit.recent) > r.audit.size {
+		r.audit.recent = r.audit.recent[len(r.audit.recent)-r.audit.size:]
+	}
+
+	for sub := range r.audit.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			slog.LogAttrs(ctx, slog.LevelWarn, "audit subscriber fell behind; closing it",
+				slog.String("method", "Audit"),
+			)
+			sub.closeLocked()
+		}
+	}
+
+	// The sink is written under the lock so it sees events in order.
+	if r.audit.sink != nil {
+		if err := r.audit.sink.WriteAudit(ctx, event); err != nil {
+			slog.LogAttrs(ctx, slog.LevelWarn, "writing audit event failed",
+				slog.String("method", "Audit"),
+				slog.Uint64("seq", event.Seq),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// AuditLog returns the recent audit events matching filter, newest first.
+func (r *Registry) AuditLog(filter AuditFilter) []AuditEvent {
+	r.audit.mu.Lock()
+	defer r.audit.mu.Unlock()
+
+	events := []AuditEvent{}
+	for i := len(r.audit.recent) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(events) >= filter.Limit {
+			break
+		}
+		if filter.matches(r.audit.recent[i]) {
+			events = append(events, r.audit.recent[i])
+		}
+	}
+
+	return events
+}
+
+// SubscribeAudit streams audit events recorded from now on. buffer is the
+// number of events a slow subscriber may lag behind before it is closed.
+func (r *Registry) SubscribeAudit(buffer int) *AuditSubscription {
+	sub := &AuditSubscription{
+		events: make(chan AuditEvent, buffer),
+		log:    &r.audit,
+	}
+
+	r.audit.mu.Lock()
+	defer r.audit.mu.Unlock()
+
+	if r.audit.subscribers == nil {
+		r.audit.subscribers = make(map[*AuditSubscription]struct{})
+	}
+	r.audit.subscribers[sub] = struct{}{}
+
+	return sub
+}
+
+func (r *Registry) configureAudit(cfg AuditConfig) {
+	r.audit.enabled = cfg.Enabled
+	r.audit.size = cfg.BufferSize
+	if r.audit.size == 0 {
+		r.audit.size = DefaultAuditBufferSize
+	}
+}
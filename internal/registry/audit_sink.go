@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+)
+
+// NewAuditSink opens the sink selected by cfg. It returns nil for the
+// "none" sink.
+func NewAuditSink(cfg AuditConfig) (AuditSink, error) {
+	switch cfg.Sink {
+	case AuditSinkNone:
+		return nil, nil
+	case AuditSinkFile:
+		return NewFileAuditSink(cfg.FilePath)
+	default:
+		return SlogAuditSink{}, nil
+	}
+}
+
+// SlogAuditSink writes audit events to the default slog logger.
+type SlogAuditSink struct{}
+
+func (SlogAuditSink) WriteAudit(ctx context.Context, event AuditEvent) error {
+	attrs := []slog.Attr{
+		slog.Uint64("seq", event.Seq),
+		slog.String("type", string(event.Type)),
+	}
+	for _, field := range []struct{ key, value string }{
+		{"namespace", event.Namespace},
+		{"relay_id", event.RelayID},
+		{"agent_id", event.AgentID},
+		{"previous_relay_id", event.PreviousRelayID},
+		{"action", event.Action},
+		{"caller", event.Caller},
+		{"request_id", event.RequestID},
+	} {
+		if field.value != "" {
+			attrs = append(attrs, slog.String(field.key, field.value))
+		}
+	}
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "audit event", attrs...)
+	return nil
+}
+
+func (SlogAuditSink) Close() error {
+	return nil
+}
+
+// FileAuditSink appends audit events to a file as JSON lines.
+type FileAuditSink struct {
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewFileAuditSink opens path for appending, creating it if needed.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileAuditSink{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// WriteAudit is called with the audit lock held, so writes do not
+// interleave.
+func (s *FileAuditSink) WriteAudit(ctx context.Context, event AuditEvent) error {
+	return s.enc.Encode(event)
+}
+
+func (s *FileAuditSink) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,151 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newAuditTestRegistry(t *testing.T, backend Backend, audit AuditConfig, opts ...Option) *Registry {
+	t.Helper()
+
+	reg, err := New(&Config{
+		Backend: BackendConfig{Type: MemoryRegistryBackend},
+		GRPC:    GRPCConfig{ListenAddress: "127.0.0.1", ListenPort: 50051},
+		TTL:     TTLConfig{Relay: 30 * time.Second, Agent: 30 * time.Second},
+		Audit:   audit,
+	}, backend, opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return reg
+}
+
+func TestAuditRecordsLifecycle(t *testing.T) {
+	backend := newTTLCleanupBackend()
+	reg := newAuditTestRegistry(t, backend, AuditConfig{Enabled: true, Sink: AuditSinkNone})
+	ctx := WithCaller(context.Background(), Caller{Identity: "peer:10.0.0.9", RequestID: "req-1"})
+
+	if err := reg.RegisterRelay(ctx, Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("RegisterRelay() error = %v", err)
+	}
+	if err := reg.RegisterAgent(ctx, Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("RegisterAgent() error = %v", err)
+	}
+	backend.placements["agent-1"] = "relay-1"
+	if err := reg.RegisterAgent(ctx, Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("RegisterAgent() error = %v", err)
+	}
+	if err := reg.RegisterAgent(ctx, Agent{ID: "agent-1"}, "relay-2"); err != nil {
+		t.Fatalf("RegisterAgent() error = %v", err)
+	}
+	if err := reg.RemoveAgents(ctx, []string{"agent-1"}); err != nil {
+		t.Fatalf("RemoveAgents() error = %v", err)
+	}
+
+	now := time.Now()
+	backend.relays["relay-stale"] = Relay{ID: "relay-stale", LastSeen: now.Add(-time.Minute)}
+	backend.agents["agent-2"] = Agent{ID: "agent-2", LastHeartbeat: now}
+	backend.placements["agent-2"] = "relay-stale"
+	backend.relayAgents["relay-stale"] = map[string]struct{}{"agent-2": {}}
+	if err := reg.runTTLCleanup(context.Background(), now); err != nil {
+		t.Fatalf("runTTLCleanup() error = %v", err)
+	}
+
+	want := []AuditEvent{
+		{Seq: 6, Type: AuditRelayExpired, Namespace: DefaultNamespace, RelayID: "relay-stale"},
+		{Seq: 5, Type: AuditAgentExpired, Namespace: DefaultNamespace, AgentID: "agent-2", RelayID: "relay-stale"},
+		{Seq: 4, Type: AuditAgentRemoved, Namespace: DefaultNamespace, AgentID: "agent-1", RelayID: "relay-1", Caller: "peer:10.0.0.9", RequestID: "req-1"},
+		{Seq: 3, Type: AuditAgentPlaced, Namespace: DefaultNamespace, AgentID: "agent-1", RelayID: "relay-2", PreviousRelayID: "relay-1", Caller: "peer:10.0.0.9", RequestID: "req-1"},
+		{Seq: 2, Type: AuditAgentPlaced, Namespace: DefaultNamespace, AgentID: "agent-1", RelayID: "relay-1", Caller: "peer:10.0.0.9", RequestID: "req-1"},
+		{Seq: 1, Type: AuditRelayRegistered, Namespace: DefaultNamespace, RelayID: "relay-1", Caller: "peer:10.0.0.9", RequestID: "req-1"},
+	}
+
+	got := reg.AuditLog(AuditFilter{})
+	if len(got) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i].Time.IsZero() {
+			t.Fatalf("expected event %d to carry a time, got %+v", i, got[i])
+		}
+		got[i].Time = time.Time{}
+		if got[i] != want[i] {
+			t.Fatalf("unexpected event %d: got %+v want %+v", i, got[i], want[i])
+		}
+	}
+
+	if events := reg.AuditLog(AuditFilter{AgentID: "agent-1", Limit: 2}); len(events) != 2 || events[0].Seq != 4 || events[1].Seq != 3 {
+		t.Fatalf("expected the 2 newest agent-1 events, got %+v", events)
+	}
+	if events := reg.AuditLog(AuditFilter{RelayID: "relay-1"}); len(events) != 4 {
+		t.Fatalf("expected relay-1 events including the move away from it, got %+v", events)
+	}
+	if events := reg.AuditLog(AuditFilter{Type: AuditRelayExpired}); len(events) != 1 || events[0].RelayID != "relay-stale" {
+		t.Fatalf("expected one relay expiry, got %+v", events)
+	}
+}
+
+func TestAuditBufferAndSubscriptions(t *testing.T) {
+	reg := newAuditTestRegistry(t, newTTLCleanupBackend(), AuditConfig{Enabled: true, Sink: AuditSinkNone, BufferSize: 2})
+	ctx := context.Background()
+
+	sub := reg.SubscribeAudit(1)
+	for _, relayID := range []string{"relay-1", "relay-2", "relay-3"} {
+		reg.Audit(ctx, AuditEvent{Type: AuditRelayRegistered, RelayID: relayID})
+	}
+
+	if events := reg.AuditLog(AuditFilter{}); len(events) != 2 || events[0].RelayID != "relay-3" || events[1].RelayID != "relay-2" {
+		t.Fatalf("expected the 2 newest events, got %+v", events)
+	}
+
+	event, ok := <-sub.Events()
+	if !ok || event.RelayID != "relay-1" {
+		t.Fatalf("expected the first event, got %+v", event)
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Fatalf("expected a subscriber that fell behind to be closed")
+	}
+	sub.Cancel()
+}
+
+func TestAuditDisabled(t *testing.T) {
+	reg := newAuditTestRegistry(t, newTTLCleanupBackend(), AuditConfig{})
+
+	if err := reg.RegisterRelay(context.Background(), Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("RegisterRelay() error = %v", err)
+	}
+	if events := reg.AuditLog(AuditFilter{}); len(events) != 0 {
+		t.Fatalf("expected no events, got %+v", events)
+	}
+}
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewAuditSink(AuditConfig{Enabled: true, Sink: AuditSinkFile, FilePath: path})
+	if err != nil {
+		t.Fatalf("NewAuditSink() error = %v", err)
+	}
+
+	reg := newAuditTestRegistry(t, newTTLCleanupBackend(), AuditConfig{Enabled: true, Sink: AuditSinkFile, FilePath: path}, WithAuditSink(sink))
+	reg.Audit(context.Background(), AuditEvent{Type: AuditAdminAction, Action: "DrainRelay", RelayID: "relay-1"})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var event AuditEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("expected one json line, got %q: %v", data, err)
+	}
+	if event.Seq != 1 || event.Type != AuditAdminAction || event.Action != "DrainRelay" || event.RelayID != "relay-1" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
@@ -0,0 +1,36 @@
+package registry
+
+// AvailabilityReporter is implemented by backend decorators that track
+// whether the backend they wrap is reachable, such as a circuit breaker.
+type AvailabilityReporter interface {
+	// BackendAvailable reports false while backend calls fail fast.
+	BackendAvailable() bool
+
+	// OnAvailabilityChange registers fn to be called, from the goroutine
+	// that observed it, whenever availability changes.
+	OnAvailabilityChange(fn func(available bool))
+}
+
+// BackendAvailable reports whether the backend is currently reachable. It
+// is always true when no decorator tracks availability.
+func (r *Registry) BackendAvailable() bool {
+	reporter, ok := FindBackend[AvailabilityReporter](r.backend)
+	if !ok {
+		return true
+	}
+
+	return reporter.BackendAvailable()
+}
+
+// OnBackendAvailabilityChange registers fn to be called whenever backend
+// availability changes. It reports false, without registering fn, when no
+// decorator tracks availability.
+func (r *Registry) OnBackendAvailabilityChange(fn func(available bool)) bool {
+	reporter, ok := FindBackend[AvailabilityReporter](r.backend)
+	if !ok {
+		return false
+	}
+
+	reporter.OnAvailabilityChange(fn)
+	return true
+}
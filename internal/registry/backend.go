@@ -2,37 +2,119 @@ package registry
 
 import (
 	"context"
+	"slices"
 	"time"
 )
 
 // Backend defines the persistence and coordination contract
 // required by the registry control plane.
+//
+// Relays and agents are scoped to a namespace: IDs are unique per
+// namespace, list operations only return entries of the given namespace,
+// and an agent can only be placed on a relay of its own namespace.
 type Backend interface {
 	// Relay lifecycle
-	RegisterRelay(ctx context.Context, relay Relay) error
-	HeartbeatRelay(ctx context.Context, relayID string) error
-	ListRelays(ctx context.Context) ([]Relay, error)
+	RegisterRelay(ctx context.Context, namespace string, relay Relay) error
+	HeartbeatRelay(ctx context.Context, namespace, relayID string) error
+	ListRelays(ctx context.Context, namespace string) ([]Relay, error)
 	// TODO(registry-ttl): add indexed stale query APIs for scale:
-	// ListStaleRelays(ctx context.Context, before time.Time) ([]Relay, error)
+	// ListStaleRelays(ctx context.Context, namespace string, before time.Time) ([]Relay, error)
 
 	// Agent lifecycle
-	RegisterAgent(ctx context.Context, agent Agent, relayID string) error
-	HeartbeatAgent(ctx context.Context, agentID string) error
-	GetAgentPlacement(ctx context.Context, agentID string) (*AgentPlacement, error)
-	ListAgents(ctx context.Context) ([]Agent, error)
+	RegisterAgent(ctx context.Context, namespace string, agent Agent, relayID string) error
+	HeartbeatAgent(ctx context.Context, namespace, agentID string) error
+	GetAgentPlacement(ctx context.Context, namespace, agentID string) (*AgentPlacement, error)
+	ListAgents(ctx context.Context, namespace string) ([]Agent, error)
 	// TODO(registry-ttl): add indexed stale query + batch placement APIs:
-	// ListStaleAgents(ctx context.Context, before time.Time) ([]Agent, error)
-	// GetAgentPlacements(ctx context.Context, agentIDs []string) (map[string]*AgentPlacement, error)
+	// ListStaleAgents(ctx context.Context, namespace string, before time.Time) ([]Agent, error)
+	// GetAgentPlacements(ctx context.Context, namespace string, agentIDs []string) (map[string]*AgentPlacement, error)
 
 	// Control Plane Helpers
-	ListRelayAgents(ctx context.Context, relayID string) ([]*Agent, error)
-	RemoveAgents(ctx context.Context, agentIDs []string) error
-	RemoveRelay(ctx context.Context, relayID string) error
+	ListRelayAgents(ctx context.Context, namespace, relayID string) ([]*Agent, error)
+	RemoveAgents(ctx context.Context, namespace string, agentIDs []string) error
+	RemoveRelay(ctx context.Context, namespace, relayID string) error
+
+	// ListNamespaces returns the namespaces holding at least one relay or
+	// agent, sorted.
+	ListNamespaces(ctx context.Context) ([]string, error)
+
+	// Coordination
+	// Leases let registry replicas sharing a backend agree on a single owner
+	// for cluster-wide duties such as the TTL sweeper. Backends that cannot
+	// coordinate return ErrNotImplemented.
+	AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (*Lease, error)
+	RenewLease(ctx context.Context, lease Lease, ttl time.Duration) (*Lease, error)
+	ReleaseLease(ctx context.Context, lease Lease) error
 
 	// Shutdown
 	Close(ctx context.Context) error
 }
 
+// BackendWrapper is implemented by Backend decorators, such as tracing or
+// caching layers, so the registry can reach optional capabilities (for
+// example CapacityLimiter) of the backend they wrap.
+type BackendWrapper interface {
+	Unwrap() Backend
+}
+
+// FindBackend returns the first backend in the chain starting at backend,
+// following BackendWrapper.Unwrap, that implements T.
+func FindBackend[T any](backend Backend) (T, bool) {
+	for backend != nil {
+		if found, ok := backend.(T); ok {
+			return found, true
+		}
+
+		wrapper, ok := backend.(BackendWrapper)
+		if !ok {
+			break
+		}
+		backend = wrapper.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
+
+// ChangeNotifier is implemented by backends that can report writes,
+// including those made through other registry replicas, so caches in front
+// of them can be invalidated before their staleness bound expires.
+type ChangeNotifier interface {
+	// WatchChanges delivers a Change for every write until ctx is done,
+	// then closes the channel.
+	WatchChanges(ctx context.Context) (<-chan Change, error)
+}
+
+// StateImporter is implemented by backends that can write entries with
+// the timestamps they carry instead of the current time, so state copied
+// from another backend keeps its heartbeat ages.
+type StateImporter interface {
+	// ImportRelay registers relay in namespace with relay.LastSeen as its
+	// last heartbeat.
+	ImportRelay(ctx context.Context, namespace string, relay Relay) error
+
+	// ImportAgent registers agent in namespace with placement, keeping
+	// agent.LastHeartbeat and placement.UpdatedAt. The relay must already
+	// be registered.
+	ImportAgent(ctx context.Context, namespace string, agent Agent, placement AgentPlacement) error
+}
+
+// Change describes a backend write reported by a ChangeNotifier. A Change
+// with neither RelayID nor AgentIDs set means any entry, in any namespace,
+// may have changed, for example after a watch reconnected.
+type Change struct {
+	// Namespace is the namespace of the written entries. Empty means
+	// DefaultNamespace.
+	Namespace string
+
+	// RelayID is set when a relay was registered or removed. Heartbeats
+	// need not be reported.
+	RelayID string
+
+	// AgentIDs lists agents whose placement was written or removed.
+	AgentIDs []string
+}
+
 // Relay represents a relay instance registered with the registry.
 type Relay struct {
 	ID       string
@@ -45,11 +127,67 @@ type Relay struct {
 type Agent struct {
 	ID            string
 	LastHeartbeat time.Time
+	Metadata      AgentMetadata
+}
+
+// AgentKind classifies an agent.
+type AgentKind string
+
+// Agent kinds. An empty kind means the agent did not report one.
+const (
+	AgentKindDrone         AgentKind = "drone"
+	AgentKindGroundStation AgentKind = "ground_station"
+	AgentKindEdge          AgentKind = "edge"
+)
+
+// Valid reports whether k is empty or a known agent kind.
+func (k AgentKind) Valid() bool {
+	switch k {
+	case "", AgentKindDrone, AgentKindGroundStation, AgentKindEdge:
+		return true
+	default:
+		return false
+	}
+}
+
+// AgentMetadata describes an agent as it reported itself when it last
+// registered, so callers can route around incompatible agents. Every field
+// is optional. Backends replace it on each registration and do not change
+// it on heartbeats.
+type AgentMetadata struct {
+	Kind AgentKind
+
+	// Version is the agent's firmware or software version.
+	Version string
+
+	// Capabilities lists the features the agent supports, such as
+	// "mission_upload" or "video".
+	Capabilities []string
+
+	// SessionStart is when the agent's current session started, as
+	// reported by the agent.
+	SessionStart time.Time
+}
+
+// HasCapability reports whether the agent reported capability.
+func (m AgentMetadata) HasCapability(capability string) bool {
+	return slices.Contains(m.Capabilities, capability)
 }
 
 // AgentPlacement represents the association between an agent and a relay.
+// Metadata is the placed agent's metadata.
 type AgentPlacement struct {
 	AgentID   string
 	RelayID   string
 	UpdatedAt time.Time
+	Metadata  AgentMetadata
+}
+
+// Lease represents exclusive, time-bounded ownership of a named
+// coordination key by a single registry replica.
+type Lease struct {
+	Name      string
+	HolderID  string
+	Token     uint64
+	ExpiresAt time.Time
 }
@@ -0,0 +1,417 @@
+// Package cached provides a registry.Backend decorator that serves relay
+// lists and agent placements from memory for a short, bounded time.
+package cached
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// Backend wraps a registry.Backend and caches ListRelays and
+// GetAgentPlacement results per namespace. Entries are dropped when they
+// outlive their TTL, when a registration or removal for them goes through
+// this Backend, and, when the wrapped backend implements
+// registry.ChangeNotifier, when a change for them is reported. Heartbeats
+// do not drop entries. Errors are never cached.
+type Backend struct {
+	next          registry.Backend
+	relayTTL      time.Duration
+	placementTTL  time.Duration
+	maxPlacements int
+	now           func() time.Time
+
+	mu sync.Mutex
+	// relayFills and placementFills track the reads in flight per key so
+	// a read that raced with a write for the same key does not repopulate
+	// the cache with the value from before the write.
+	relays         map[string]relaysEntry
+	relayFills     fills[string]
+	placements     map[placementKey]placementEntry
+	placementFills fills[placementKey]
+
+	relayHits       atomic.Uint64
+	relayMisses     atomic.Uint64
+	placementHits   atomic.Uint64
+	placementMisses atomic.Uint64
+	invalidations   atomic.Uint64
+
+	stopWatch context.CancelFunc
+	watchDone chan struct{}
+	closing   atomic.Bool
+}
+
+type relaysEntry struct {
+	relays    []registry.Relay
+	fetchedAt time.Time
+}
+
+type placementKey struct {
+	namespace string
+	agentID   string
+}
+
+type placementEntry struct {
+	placement registry.AgentPlacement
+	fetchedAt time.Time
+}
+
+var _ registry.Backend = (*Backend)(nil)
+var _ registry.BackendWrapper = (*Backend)(nil)
+var _ registry.CacheStatsReporter = (*Backend)(nil)
+
+// New returns a caching decorator for next. Unset cfg fields fall back to
+// the registry defaults. When next, or a backend it wraps, implements
+// registry.ChangeNotifier, New subscribes to its changes until Close.
+func New(next registry.Backend, cfg registry.CacheConfig) (*Backend, error) {
+	b := &Backend{
+		next:           next,
+		relayTTL:       cfg.RelayTTL,
+		placementTTL:   cfg.PlacementTTL,
+		maxPlacements:  cfg.MaxPlacements,
+		now:            time.Now,
+		relays:         make(map[string]relaysEntry),
+		relayFills:     make(fills[string]),
+		placements:     make(map[placementKey]placementEntry),
+		placementFills: make(fills[placementKey]),
+	}
+	if b.relayTTL == 0 {
+		b.relayTTL = registry.DefaultCacheRelayTTL
+	}
+	if b.placementTTL == 0 {
+		b.placementTTL = registry.DefaultCachePlacementTTL
+	}
+	if b.maxPlacements == 0 {
+		b.maxPlacements = registry.DefaultCacheMaxPlacements
+	}
+
+	if notifier, ok := registry.FindBackend[registry.ChangeNotifier](next); ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		changes, err := notifier.WatchChanges(ctx)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("watch backend changes: %w", err)
+		}
+
+		b.stopWatch = cancel
+		b.watchDone = make(chan struct{})
+		go b.watch(changes)
+	}
+
+	return b, nil
+}
+
+// Unwrap returns the decorated backend.
+func (b *Backend) Unwrap() registry.Backend {
+	return b.next
+}
+
+// CacheStats returns a snapshot of the cache counters.
+func (b *Backend) CacheStats() registry.CacheStats {
+	b.mu.Lock()
+	placements := len(b.placements)
+	b.mu.Unlock()
+
+	return registry.CacheStats{
+		RelayHits:       b.relayHits.Load(),
+		RelayMisses:     b.relayMisses.Load(),
+		PlacementHits:   b.placementHits.Load(),
+		PlacementMisses: b.placementMisses.Load(),
+		Invalidations:   b.invalidations.Load(),
+		Placements:      placements,
+	}
+}
+
+func (b *Backend) RegisterRelay(ctx context.Context, namespace string, relay registry.Relay) error {
+	defer b.invalidateRelays(namespace)
+	return b.next.RegisterRelay(ctx, namespace, relay)
+}
+
+// HeartbeatRelay leaves the cached relay list in place, so its LastSeen
+// lags by at most the cache's relay TTL. Relays heartbeat far more often
+// than that TTL, so dropping the list on each beat would defeat the cache.
+func (b *Backend) HeartbeatRelay(ctx context.Context, namespace, relayID string) error {
+	return b.next.HeartbeatRelay(ctx, namespace, relayID)
+}
+
+func (b *Backend) ListRelays(ctx context.Context, namespace string) ([]registry.Relay, error) {
+	now := b.now()
+
+	b.mu.Lock()
+	if entry, ok := b.relays[namespace]; ok && now.Sub(entry.fetchedAt) < b.relayTTL {
+		relays := slices.Clone(entry.relays)
+		b.mu.Unlock()
+		b.relayHits.Add(1)
+		return relays, nil
+	}
+	gen := b.relayFills.begin(namespace)
+	b.mu.Unlock()
+
+	b.relayMisses.Add(1)
+	relays, err := b.next.ListRelays(ctx, namespace)
+
+	b.mu.Lock()
+	if b.relayFills.end(namespace, gen) && err == nil {
+		b.relays[namespace] = relaysEntry{relays: slices.Clone(relays), fetchedAt: now}
+	}
+	b.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return relays, nil
+}
+
+func (b *Backend) RemoveRelay(ctx context.Context, namespace, relayID string) error {
+	defer func() {
+		b.invalidateRelays(namespace)
+		b.invalidateRelayPlacements(namespace, relayID)
+	}()
+	return b.next.RemoveRelay(ctx, namespace, relayID)
+}
+
+func (b *Backend) RegisterAgent(ctx context.Context, namespace string, agent registry.Agent, relayID string) error {
+	defer b.invalidatePlacements(namespace, []string{agent.ID})
+	return b.next.RegisterAgent(ctx, namespace, agent, relayID)
+}
+
+// HeartbeatAgent leaves the cached placement in place, so its UpdatedAt
+// lags by at most the cache's placement TTL.
+func (b *Backend) HeartbeatAgent(ctx context.Context, namespace, agentID string) error {
+	return b.next.HeartbeatAgent(ctx, namespace, agentID)
+}
+
+func (b *Backend) GetAgentPlacement(ctx context.Context, namespace, agentID string) (*registry.AgentPlacement, error) {
+	now := b.now()
+	key := placementKey{namespace, agentID}
+
+	b.mu.Lock()
+	if entry, ok := b.placements[key]; ok && now.Sub(entry.fetchedAt) < b.placementTTL {
+		b.mu.Unlock()
+		b.placementHits.Add(1)
+		placement := entry.placement
+		return &placement, nil
+	}
+	gen := b.placementFills.begin(key)
+	b.mu.Unlock()
+
+	b.placementMisses.Add(1)
+	placement, err := b.next.GetAgentPlacement(ctx, namespace, agentID)
+
+	b.mu.Lock()
+	if b.placementFills.end(key, gen) && err == nil {
+		b.storePlacementLocked(key, placementEntry{placement: *placement, fetchedAt: now}, now)
+	}
+	b.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return placement, nil
+}
+
+func (b *Backend) ListAgents(ctx context.Context, namespace string) ([]registry.Agent, error) {
+	return b.next.ListAgents(ctx, namespace)
+}
+
+func (b *Backend) ListRelayAgents(ctx context.Context, namespace, relayID string) ([]*registry.Agent, error) {
+	return b.next.ListRelayAgents(ctx, namespace, relayID)
+}
+
+func (b *Backend) RemoveAgents(ctx context.Context, namespace string, agentIDs []string) error {
+	defer b.invalidatePlacements(namespace, agentIDs)
+	return b.next.RemoveAgents(ctx, namespace, agentIDs)
+}
+
+func (b *Backend) ListNamespaces(ctx context.Context) ([]string, error) {
+	return b.next.ListNamespaces(ctx)
+}
+
+func (b *Backend) AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (*registry.Lease, error) {
+	return b.next.AcquireLease(ctx, name, holderID, ttl)
+}
+
+func (b *Backend) RenewLease(ctx context.Context, lease registry.Lease, ttl time.Duration) (*registry.Lease, error) {
+	return b.next.RenewLease(ctx, lease, ttl)
+}
+
+func (b *Backend) ReleaseLease(ctx context.Context, lease registry.Lease) error {
+	return b.next.ReleaseLease(ctx, lease)
+}
+
+// Close stops watching for backend changes and closes the wrapped backend.
+func (b *Backend) Close(ctx context.Context) error {
+	if b.stopWatch != nil {
+		b.closing.Store(true)
+		b.stopWatch()
+		select {
+		case <-b.watchDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return b.next.Close(ctx)
+}
+
+// storePlacementLocked caches entry, first evicting expired entries and
+// then an arbitrary one if the cache is full.
+func (b *Backend) storePlacementLocked(key placementKey, entry placementEntry, now time.Time) {
+	if _, exists := b.placements[key]; !exists && len(b.placements) >= b.maxPlacements {
+		for cachedKey, cached := range b.placements {
+			if now.Sub(cached.fetchedAt) >= b.placementTTL {
+				delete(b.placements, cachedKey)
+			}
+		}
+		for cachedKey := range b.placements {
+			if len(b.placements) < b.maxPlacements {
+				break
+			}
+			delete(b.placements, cachedKey)
+		}
+	}
+
+	b.placements[key] = entry
+}
+
+func (b *Backend) invalidateRelays(namespace string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.relayFills.invalidate(namespace)
+	if _, ok := b.relays[namespace]; ok {
+		delete(b.relays, namespace)
+		b.invalidations.Add(1)
+	}
+}
+
+func (b *Backend) invalidatePlacements(namespace string, agentIDs []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, agentID := range agentIDs {
+		key := placementKey{namespace, agentID}
+		b.placementFills.invalidate(key)
+		if _, ok := b.placements[key]; ok {
+			delete(b.placements, key)
+			b.invalidations.Add(1)
+		}
+	}
+}
+
+func (b *Backend) invalidateRelayPlacements(namespace, relayID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The relay of a placement still being read is not known yet, so every
+	// read in the namespace is discarded.
+	b.placementFills.invalidateFunc(func(key placementKey) bool {
+		return key.namespace == namespace
+	})
+	for key, entry := range b.placements {
+		if key.namespace == namespace && entry.placement.RelayID == relayID {
+			delete(b.placements, key)
+			b.invalidations.Add(1)
+		}
+	}
+}
+
+func (b *Backend) invalidateAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.relayFills.invalidateFunc(func(string) bool { return true })
+	b.placementFills.invalidateFunc(func(placementKey) bool { return true })
+	b.invalidations.Add(uint64(len(b.relays) + len(b.placements)))
+	clear(b.relays)
+	clear(b.placements)
+}
+
+func (b *Backend) watch(changes <-chan registry.Change) {
+	defer close(b.watchDone)
+
+	for change := range changes {
+		if change.RelayID == "" && len(change.AgentIDs) == 0 {
+			b.invalidateAll()
+			continue
+		}
+
+		namespace := change.Namespace
+		if namespace == "" {
+			namespace = registry.DefaultNamespace
+		}
+		if change.RelayID != "" {
+			b.invalidateRelays(namespace)
+		}
+		if len(change.AgentIDs) > 0 {
+			b.invalidatePlacements(namespace, change.AgentIDs)
+		}
+	}
+
+	if !b.closing.Load() {
+		// Without notifications other replicas' writes are only picked up
+		// once cached entries expire.
+		b.invalidateAll()
+		slog.LogAttrs(context.Background(), slog.LevelWarn, "backend change watch ended; cache staleness is now bounded by ttl only",
+			slog.String("method", "cached.watch"),
+		)
+	}
+}
+
+// fills tracks a generation for each key with a read in flight. Entries are
+// dropped once their last read ends, so the map stays as small as the
+// number of concurrent misses. The caller's mutex guards it.
+type fills[K comparable] map[K]*fill
+
+type fill struct {
+	gen      uint64
+	inFlight int
+}
+
+// begin records a read of key and returns the generation to pass to end.
+func (f fills[K]) begin(key K) uint64 {
+	entry, ok := f[key]
+	if !ok {
+		entry = &fill{}
+		f[key] = entry
+	}
+	entry.inFlight++
+
+	return entry.gen
+}
+
+// end records that the read begun at gen finished and reports whether key
+// was not invalidated in the meantime.
+func (f fills[K]) end(key K, gen uint64) bool {
+	entry := f[key]
+	entry.inFlight--
+	if entry.inFlight == 0 {
+		delete(f, key)
+	}
+
+	return entry.gen == gen
+}
+
+// invalidate discards the reads of key in flight.
+func (f fills[K]) invalidate(key K) {
+	if entry, ok := f[key]; ok {
+		entry.gen++
+	}
+}
+
+// invalidateFunc discards the reads in flight of every key match accepts.
+func (f fills[K]) invalidateFunc(match func(K) bool) {
+	for key, entry := range f {
+		if match(key) {
+			entry.gen++
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package cached
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+)
+
+// countingBackend counts the reads that reach the wrapped backend.
+type countingBackend struct {
+	registry.Backend
+	listRelays   atomic.Int64
+	getPlacement atomic.Int64
+
+	// duringGetPlacement, when set, runs while a GetAgentPlacement read is
+	// in flight.
+	duringGetPlacement func()
+}
+
+func (c *countingBackend) ListRelays(ctx context.Context, namespace string) ([]registry.Relay, error) {
+	c.listRelays.Add(1)
+	return c.Backend.ListRelays(ctx, namespace)
+}
+
+func (c *countingBackend) GetAgentPlacement(ctx context.Context, namespace, agentID string) (*registry.AgentPlacement, error) {
+	c.getPlacement.Add(1)
+	if c.duringGetPlacement != nil {
+		c.duringGetPlacement()
+	}
+	return c.Backend.GetAgentPlacement(ctx, namespace, agentID)
+}
+
+// notifyingBackend reports changes pushed onto changes by the test.
+type notifyingBackend struct {
+	*countingBackend
+	changes chan registry.Change
+}
+
+func (n *notifyingBackend) WatchChanges(ctx context.Context) (<-chan registry.Change, error) {
+	out := make(chan registry.Change)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case change := <-n.changes:
+				out <- change
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func newCountingBackend(t *testing.T) *countingBackend {
+	t.Helper()
+
+	mem, err := memory.New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	return &countingBackend{Backend: mem}
+}
+
+func TestListRelaysCachedUntilTTL(t *testing.T) {
+	ctx := context.Background()
+	next := newCountingBackend(t)
+	if err := next.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	backend, err := New(next, registry.CacheConfig{RelayTTL: time.Second})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	now := time.Now()
+	backend.now = func() time.Time { return now }
+
+	for range 3 {
+		relays, err := backend.ListRelays(ctx, registry.DefaultNamespace)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if len(relays) != 1 {
+			t.Fatalf("expected 1 relay, got %d", len(relays))
+		}
+	}
+	if got := next.listRelays.Load(); got != 1 {
+		t.Fatalf("expected 1 backend read, got %d", got)
+	}
+
+	now = now.Add(time.Second)
+	if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := next.listRelays.Load(); got != 2 {
+		t.Fatalf("expected expired entry to be re-read, got %d backend reads", got)
+	}
+
+	stats := backend.CacheStats()
+	if stats.RelayHits != 2 || stats.RelayMisses != 2 {
+		t.Fatalf("expected 2 hits and 2 misses, got %+v", stats)
+	}
+}
+
+func TestLocalWritesInvalidate(t *testing.T) {
+	ctx := context.Background()
+	next := newCountingBackend(t)
+	backend, err := New(next, registry.CacheConfig{RelayTTL: time.Hour, PlacementTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-2"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-3"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	relays, err := backend.ListRelays(ctx, registry.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(relays) != 3 {
+		t.Fatalf("expected registration to invalidate cached relays, got %d relays", len(relays))
+	}
+
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-2"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	placement, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if placement.RelayID != "relay-2" {
+		t.Fatalf("expected placement on relay-2, got %q", placement.RelayID)
+	}
+
+	if err := backend.RemoveRelay(ctx, registry.DefaultNamespace, "relay-2"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if stats := backend.CacheStats(); stats.Placements != 0 {
+		t.Fatalf("expected relay removal to drop its placements, got %+v", stats)
+	}
+}
+
+func TestWritesDiscardOnlyTheirOwnKeysReads(t *testing.T) {
+	ctx := context.Background()
+	next := newCountingBackend(t)
+	if err := next.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	for _, id := range []string{"agent-1", "agent-2"} {
+		if err := next.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: id}, "relay-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	backend, err := New(next, registry.CacheConfig{PlacementTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	// A registration of another agent while agent-1 is read must not stop
+	// the read from filling the cache.
+	next.duringGetPlacement = func() {
+		if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-2"}, "relay-1"); err != nil {
+			t.Errorf("expected nil error, got %v", err)
+		}
+	}
+	if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if stats := backend.CacheStats(); stats.Placements != 1 {
+		t.Fatalf("expected the read of agent-1 to be cached, got %+v", stats)
+	}
+
+	// A registration of the agent being read discards the read.
+	next.duringGetPlacement = func() {
+		if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-2"}, "relay-1"); err != nil {
+			t.Errorf("expected nil error, got %v", err)
+		}
+	}
+	if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-2"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if stats := backend.CacheStats(); stats.Placements != 1 {
+		t.Fatalf("expected the read of agent-2 to be discarded, got %+v", stats)
+	}
+	if len(backend.placementFills) != 0 {
+		t.Fatalf("expected no reads tracked once they end, got %d", len(backend.placementFills))
+	}
+}
+
+func TestHeartbeatsKeepCachedEntries(t *testing.T) {
+	ctx := context.Background()
+	next := newCountingBackend(t)
+	backend, err := New(next, registry.CacheConfig{RelayTTL: time.Hour, PlacementTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	for range 5 {
+		if err := backend.HeartbeatRelay(ctx, registry.DefaultNamespace, "relay-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if err := backend.HeartbeatAgent(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	stats := backend.CacheStats()
+	if stats.RelayHits != 4 || stats.RelayMisses != 1 || stats.PlacementHits != 4 || stats.PlacementMisses != 1 {
+		t.Fatalf("expected steady heartbeats to keep cached entries, got %+v", stats)
+	}
+}
+
+func TestGetAgentPlacementDoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	next := newCountingBackend(t)
+	backend, err := New(next, registry.CacheConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	for range 2 {
+		if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "missing"); err == nil {
+			t.Fatal("expected error for unknown agent")
+		}
+	}
+	if got := next.getPlacement.Load(); got != 2 {
+		t.Fatalf("expected every lookup to reach the backend, got %d", got)
+	}
+}
+
+func TestMaxPlacementsBound(t *testing.T) {
+	ctx := context.Background()
+	next := newCountingBackend(t)
+	if err := next.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	for _, id := range []string{"agent-1", "agent-2", "agent-3"} {
+		if err := next.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: id}, "relay-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	backend, err := New(next, registry.CacheConfig{MaxPlacements: 2})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	for _, id := range []string{"agent-1", "agent-2", "agent-3"} {
+		if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, id); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	if stats := backend.CacheStats(); stats.Placements != 2 {
+		t.Fatalf("expected 2 cached placements, got %d", stats.Placements)
+	}
+}
+
+func TestChangeNotificationsInvalidate(t *testing.T) {
+	ctx := context.Background()
+	next := &notifyingBackend{countingBackend: newCountingBackend(t), changes: make(chan registry.Change)}
+	if err := next.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := next.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	backend, err := New(next, registry.CacheConfig{RelayTTL: time.Hour, PlacementTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	t.Cleanup(func() { _ = backend.Close(ctx) })
+
+	if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	// A write made through another replica.
+	if err := next.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-2"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	next.changes <- registry.Change{RelayID: "relay-2"}
+	next.changes <- registry.Change{AgentIDs: []string{"agent-1"}}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for backend.CacheStats().Placements != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected change to invalidate cached placement, got %+v", backend.CacheStats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	relays, err := backend.ListRelays(ctx, registry.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(relays) != 2 {
+		t.Fatalf("expected change to invalidate cached relays, got %d relays", len(relays))
+	}
+}
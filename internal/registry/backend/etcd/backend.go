@@ -3,6 +3,7 @@ package etcd
 
 import (
 	"context"
+	"time"
 
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
 )
@@ -15,43 +16,59 @@ func New(cfg *registry.EtcdConfig) (*Backend, error) {
 	return &Backend{cfg: cfg}, nil
 }
 
-func (b *Backend) RegisterRelay(ctx context.Context, relay registry.Relay) error {
+func (b *Backend) RegisterRelay(ctx context.Context, namespace string, relay registry.Relay) error {
 	return registry.ErrNotImplemented
 }
 
-func (b *Backend) HeartbeatRelay(ctx context.Context, relayID string) error {
+func (b *Backend) HeartbeatRelay(ctx context.Context, namespace, relayID string) error {
 	return registry.ErrNotImplemented
 }
 
-func (b *Backend) ListRelays(ctx context.Context) ([]registry.Relay, error) {
+func (b *Backend) ListRelays(ctx context.Context, namespace string) ([]registry.Relay, error) {
 	return nil, registry.ErrNotImplemented
 }
 
-func (b *Backend) RemoveRelay(ctx context.Context, relayID string) error {
+func (b *Backend) RemoveRelay(ctx context.Context, namespace, relayID string) error {
 	return registry.ErrNotImplemented
 }
 
-func (b *Backend) RegisterAgent(ctx context.Context, agent registry.Agent, relayID string) error {
+func (b *Backend) RegisterAgent(ctx context.Context, namespace string, agent registry.Agent, relayID string) error {
 	return registry.ErrNotImplemented
 }
 
-func (b *Backend) HeartbeatAgent(ctx context.Context, agentID string) error {
+func (b *Backend) HeartbeatAgent(ctx context.Context, namespace, agentID string) error {
 	return registry.ErrNotImplemented
 }
 
-func (b *Backend) GetAgentPlacement(ctx context.Context, agentID string) (*registry.AgentPlacement, error) {
+func (b *Backend) GetAgentPlacement(ctx context.Context, namespace, agentID string) (*registry.AgentPlacement, error) {
 	return nil, registry.ErrNotImplemented
 }
 
-func (b *Backend) ListAgents(ctx context.Context) ([]registry.Agent, error) {
+func (b *Backend) ListAgents(ctx context.Context, namespace string) ([]registry.Agent, error) {
 	return nil, registry.ErrNotImplemented
 }
 
-func (b *Backend) ListRelayAgents(ctx context.Context, relayID string) ([]*registry.Agent, error) {
+func (b *Backend) ListRelayAgents(ctx context.Context, namespace, relayID string) ([]*registry.Agent, error) {
 	return nil, registry.ErrNotImplemented
 }
 
-func (b *Backend) RemoveAgents(ctx context.Context, agentIDs []string) error {
+func (b *Backend) RemoveAgents(ctx context.Context, namespace string, agentIDs []string) error {
+	return registry.ErrNotImplemented
+}
+
+func (b *Backend) ListNamespaces(ctx context.Context) ([]string, error) {
+	return nil, registry.ErrNotImplemented
+}
+
+func (b *Backend) AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (*registry.Lease, error) {
+	return nil, registry.ErrNotImplemented
+}
+
+func (b *Backend) RenewLease(ctx context.Context, lease registry.Lease, ttl time.Duration) (*registry.Lease, error) {
+	return nil, registry.ErrNotImplemented
+}
+
+func (b *Backend) ReleaseLease(ctx context.Context, lease registry.Lease) error {
 	return registry.ErrNotImplemented
 }
 
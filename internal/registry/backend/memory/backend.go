@@ -1,8 +1,17 @@
-// Package memory provides a stub in-memory backend implementation.
+// Package memory provides an in-process registry backend. Relays, agents
+// and placements are kept in memory per namespace, with capacity limits and
+// sweeper leases. State can optionally be persisted to periodic snapshots
+// and a write-ahead log, and is exposed as deterministic mutations for
+// replicated backends to ship between nodes.
 package memory
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -10,20 +19,71 @@ import (
 )
 
 type Backend struct {
-	cfg         *registry.MemoryConfig
-	relays      map[string]*relayEntry
-	agents      map[string]*agentEntry
-	placements  map[string]*registry.AgentPlacement
-	relayAgents map[string]map[string]*agentEntry
+	cfg *registry.MemoryConfig
+
+	// namespaces holds the state of every namespace an entry was ever
+	// registered in. The map is written while holding both relayMu and
+	// agentMu, so either lock is enough to read it.
+	namespaces map[string]*namespaceState
 
 	// Lock order: relayMu -> agentMu -> entry.mu
-	// - relays map guarded by relayMu
-	// - agents/placements guarded by agentMu
+	// - relays maps guarded by relayMu
+	// - agents/placements maps guarded by agentMu
 	// - individual relay/agent fields guarded by entry.mu
 	relayMu sync.RWMutex
 	agentMu sync.RWMutex
+
+	// limits and tenantLimits are written while holding both relayMu and
+	// agentMu, so either lock is enough to read them.
+	limits       registry.CapacityLimits
+	tenantLimits map[string]registry.CapacityLimits
+
+	// leases and leaseToken are guarded by leaseMu, which is never held
+	// together with the relay/agent locks.
+	leases     map[string]*registry.Lease
+	leaseToken uint64
+	leaseMu    sync.Mutex
+
+	// snapshotMu serialises snapshot writes. snapshotStop and snapshotDone
+	// control the periodic snapshot goroutine and are nil when it is not
+	// running.
+	snapshotMu   sync.Mutex
+	snapshotStop chan struct{}
+	snapshotDone chan struct{}
+	closeOnce    sync.Once
+
+	// wal is the optional write-ahead log; nil when disabled. walStop and
+	// walDone control the heartbeat batch flush goroutine.
+	wal     *wal
+	walStop chan struct{}
+	walDone chan struct{}
+
+	// lock holds the state lock on the persisted files until Close; nil
+	// when nothing is persisted.
+	lock *os.File
+}
+
+// namespaceState holds the relays and agents of one namespace.
+type namespaceState struct {
+	relays      map[string]*relayEntry
+	agents      map[string]*agentEntry
+	placements  map[string]*registry.AgentPlacement
+	relayAgents map[string]map[string]*agentEntry
+}
+
+func newNamespaceState() *namespaceState {
+	return &namespaceState{
+		relays:      make(map[string]*relayEntry),
+		agents:      make(map[string]*agentEntry),
+		placements:  make(map[string]*registry.AgentPlacement),
+		relayAgents: make(map[string]map[string]*agentEntry),
+	}
 }
 
+// emptyNamespace stands in for namespaces nothing was registered in. Its
+// maps are nil: reading them is safe and it is never written to.
+var emptyNamespace = &namespaceState{}
+
 type relayEntry struct {
 	mu    sync.Mutex
 	relay *registry.Relay
@@ -35,24 +95,207 @@ type agentEntry struct {
 }
 
 func New(cfg *registry.MemoryConfig) (*Backend, error) {
+	b := newBackend(cfg)
+
+	if cfg == nil || cfg.SnapshotPath == "" {
+		return b, nil
+	}
+
+	lock, err := lockFile(stateLockPath(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("lock memory backend: %w", err)
+	}
+
+	walSeq, err := b.restoreSnapshot()
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	if cfg.WALPath != "" {
+		w, records, err := openWAL(cfg.WALPath)
+		if err != nil {
+			lock.Close()
+			return nil, err
+		}
+
+		b.wal = w
+		b.replayWAL(records, walSeq)
+
+		flushInterval := cfg.WALFlushInterval
+		if flushInterval <= 0 {
+			flushInterval = defaultWALFlushInterval
+		}
+
+		b.walStop = make(chan struct{})
+		b.walDone = make(chan struct{})
+		go b.runWALFlush(flushInterval, b.walStop, b.walDone)
+	}
+
+	b.lock = lock
+
+	if cfg.SnapshotInterval > 0 {
+		b.snapshotStop = make(chan struct{})
+		b.snapshotDone = make(chan struct{})
+		go b.runSnapshots(cfg.SnapshotInterval, b.snapshotStop, b.snapshotDone)
+	}
+
+	return b, nil
+}
+
+// OpenReadOnly loads the state persisted at cfg's snapshot and write-ahead
+// log paths without taking them over: the log is neither truncated,
+// flushed nor compacted, and Close writes no snapshot. Writes change only
+// the in-memory copy. Like New it holds the state lock until Close, so it
+// fails with an ErrConflict error while a running backend uses the files.
+func OpenReadOnly(cfg *registry.MemoryConfig) (*Backend, error) {
+	if cfg == nil || cfg.SnapshotPath == "" {
+		return newBackend(cfg), nil
+	}
+
+	lock, err := lockFile(stateLockPath(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("lock memory backend: %w", err)
+	}
+
+	b := newBackend(cfg)
+	walSeq, err := b.restoreSnapshot()
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	if cfg.WALPath != "" {
+		data, err := os.ReadFile(cfg.WALPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			lock.Close()
+			return nil, fmt.Errorf("read memory wal: %w", err)
+		}
+
+		// A torn tail is ignored rather than truncated; the file is left
+		// as it was.
+		records, _ := decodeWAL(data)
+		b.applyRecords(records, walSeq)
+	}
+
+	// Without paths Snapshot and Close leave the files alone.
+	b.cfg = &registry.MemoryConfig{}
+	b.lock = lock
+
+	return b, nil
+}
+
+func newBackend(cfg *registry.MemoryConfig) *Backend {
 	return &Backend{
-		cfg:         cfg,
-		relays:      make(map[string]*relayEntry),
-		agents:      make(map[string]*agentEntry),
-		placements:  make(map[string]*registry.AgentPlacement),
-		relayAgents: make(map[string]map[string]*agentEntry),
-	}, nil
+		cfg:        cfg,
+		namespaces: make(map[string]*namespaceState),
+		leases:     make(map[string]*registry.Lease),
+	}
+}
+
+// stateLockPath is the file whose lock gives one process the persisted
+// state in cfg: next to the write-ahead log when there is one, otherwise
+// next to the snapshot.
+func stateLockPath(cfg *registry.MemoryConfig) string {
+	if cfg.WALPath != "" {
+		return cfg.WALPath + ".lock"
+	}
+
+	return cfg.SnapshotPath + ".lock"
+}
+
+// namespaceLocked returns the state of namespace, or emptyNamespace when
+// nothing was registered in it. Caller must hold relayMu or agentMu.
+func (b *Backend) namespaceLocked(namespace string) *namespaceState {
+	if ns, ok := b.namespaces[namespace]; ok {
+		return ns
+	}
+
+	return emptyNamespace
+}
+
+// ensureNamespace returns the state of namespace, creating it if needed.
+// Caller must not hold relayMu or agentMu.
+func (b *Backend) ensureNamespace(namespace string) *namespaceState {
+	b.relayMu.RLock()
+	ns, ok := b.namespaces[namespace]
+	b.relayMu.RUnlock()
+	if ok {
+		return ns
+	}
+
+	b.relayMu.Lock()
+	defer b.relayMu.Unlock()
+	b.agentMu.Lock()
+	defer b.agentMu.Unlock()
+
+	if ns, ok := b.namespaces[namespace]; ok {
+		return ns
+	}
+
+	ns = newNamespaceState()
+	b.namespaces[namespace] = ns
+
+	return ns
 }
 
-func (b *Backend) RegisterRelay(ctx context.Context, relay registry.Relay) error {
+func (b *Backend) RegisterRelay(ctx context.Context, namespace string, relay registry.Relay) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
 
+	now := time.Now()
+	m := Mutation{Op: MutationRegisterRelay, Namespace: namespace, Relay: &MutationRelay{ID: relay.ID, Address: relay.Address, GRPCPort: relay.GRPCPort}, At: now}
+
+	return b.logged(m, func() error {
+		return b.registerRelay(namespace, relay, now)
+	})
+}
+
+// SetCapacityLimits replaces the registry-wide limits and the
+// per-namespace limits enforced on new registrations. Entries already
+// registered are kept when a limit is lowered.
+func (b *Backend) SetCapacityLimits(limits registry.CapacityLimits, tenants map[string]registry.CapacityLimits) {
+	b.relayMu.Lock()
+	b.agentMu.Lock()
+	b.limits = limits
+	b.tenantLimits = tenants
+	b.agentMu.Unlock()
+	b.relayMu.Unlock()
+}
+
+// ListNamespaces returns the namespaces holding at least one relay or
+// agent, sorted.
+func (b *Backend) ListNamespaces(ctx context.Context) ([]string, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	b.relayMu.RLock()
+	defer b.relayMu.RUnlock()
+	b.agentMu.RLock()
+	defer b.agentMu.RUnlock()
+
+	namespaces := make([]string, 0, len(b.namespaces))
+	for namespace, ns := range b.namespaces {
+		if len(ns.relays) > 0 || len(ns.agents) > 0 {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	slices.Sort(namespaces)
+
+	return namespaces, nil
+}
+
+func (b *Backend) registerRelay(namespace string, relay registry.Relay, now time.Time) error {
+	ns := b.ensureNamespace(namespace)
+
 	b.relayMu.RLock()
-	entry, exists := b.relays[relay.ID]
+	entry, exists := ns.relays[relay.ID]
 	b.relayMu.RUnlock()
 
 	if exists {
@@ -63,7 +306,7 @@ func (b *Backend) RegisterRelay(ctx context.Context, relay registry.Relay) error
 		entry.relay.ID = relay.ID
 		entry.relay.Address = relay.Address
 		entry.relay.GRPCPort = relay.GRPCPort
-		entry.relay.LastSeen = time.Now()
+		entry.relay.LastSeen = now
 
 		return nil
 	}
@@ -73,38 +316,57 @@ func (b *Backend) RegisterRelay(ctx context.Context, relay registry.Relay) error
 			ID:       relay.ID,
 			Address:  relay.Address,
 			GRPCPort: relay.GRPCPort,
-			LastSeen: time.Now(),
+			LastSeen: now,
 		},
 	}
 
 	b.relayMu.Lock()
-	if existing, ok := b.relays[relay.ID]; ok {
+	if existing, ok := ns.relays[relay.ID]; ok {
 		b.relayMu.Unlock()
 
 		existing.mu.Lock()
 		defer existing.mu.Unlock()
-		existing.relay.LastSeen = time.Now()
+		existing.relay.LastSeen = now
 
 		return nil
 	}
 
-	b.relays[relay.ID] = newEntry
+	if err := b.admitRelayLocked(namespace); err != nil {
+		b.relayMu.Unlock()
+		return err
+	}
+
+	ns.relays[relay.ID] = newEntry
 	b.relayMu.Unlock()
 
 	return nil
 }
 
-func (b *Backend) HeartbeatRelay(ctx context.Context, relayID string) error {
-	b.relayMu.RLock()
-	relayEntry, exists := b.relays[relayID]
-	b.relayMu.RUnlock()
+// admitRelayLocked checks the registry-wide and namespace relay limits for
+// a new relay in namespace. Caller must hold relayMu.
+func (b *Backend) admitRelayLocked(namespace string) error {
+	if limit := b.tenantLimits[namespace].MaxRelays; limit > 0 && len(b.namespaceLocked(namespace).relays) >= limit {
+		return fmt.Errorf("%w: namespace %s (max %d)", errRelayCapacity, namespace, limit)
+	}
 
-	if !exists {
-		return errRelayNotRegistered
+	if limit := b.limits.MaxRelays; limit > 0 {
+		total := 0
+		for _, ns := range b.namespaces {
+			total += len(ns.relays)
+		}
+		if total >= limit {
+			return fmt.Errorf("%w (max %d)", errRelayCapacity, limit)
+		}
+	}
+
+	return nil
+}
+
+func (b *Backend) HeartbeatRelay(ctx context.Context, namespace, relayID string) error {
+	now := time.Now()
+	if err := b.loggedRelayHeartbeat(namespace, relayID, now); err != nil {
+		return err
 	}
-	relayEntry.mu.Lock()
-	relayEntry.relay.LastSeen = time.Now()
-	relayEntry.mu.Unlock()
 
 	select {
 	case <-ctx.Done():
@@ -115,7 +377,22 @@ func (b *Backend) HeartbeatRelay(ctx context.Context, relayID string) error {
 	return nil
 }
 
-func (b *Backend) ListRelays(ctx context.Context) ([]registry.Relay, error) {
+func (b *Backend) heartbeatRelay(namespace, relayID string, now time.Time) error {
+	b.relayMu.RLock()
+	relayEntry, exists := b.namespaceLocked(namespace).relays[relayID]
+	b.relayMu.RUnlock()
+
+	if !exists {
+		return errRelayNotRegistered
+	}
+	relayEntry.mu.Lock()
+	relayEntry.relay.LastSeen = now
+	relayEntry.mu.Unlock()
+
+	return nil
+}
+
+func (b *Backend) ListRelays(ctx context.Context, namespace string) ([]registry.Relay, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -123,8 +400,9 @@ func (b *Backend) ListRelays(ctx context.Context) ([]registry.Relay, error) {
 	}
 
 	b.relayMu.RLock()
-	entries := make([]*relayEntry, 0, len(b.relays))
-	for _, entry := range b.relays {
+	ns := b.namespaceLocked(namespace)
+	entries := make([]*relayEntry, 0, len(ns.relays))
+	for _, entry := range ns.relays {
 		entries = append(entries, entry)
 	}
 	b.relayMu.RUnlock()
@@ -140,116 +418,176 @@ func (b *Backend) ListRelays(ctx context.Context) ([]registry.Relay, error) {
 	return relays, nil
 }
 
-func (b *Backend) RemoveRelay(ctx context.Context, relayID string) error {
+func (b *Backend) RemoveRelay(ctx context.Context, namespace, relayID string) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
 
+	return b.logged(Mutation{Op: MutationRemoveRelay, Namespace: namespace, RelayID: relayID}, func() error {
+		return b.removeRelay(namespace, relayID)
+	})
+}
+
+func (b *Backend) removeRelay(namespace, relayID string) error {
 	b.relayMu.Lock()
 	b.agentMu.Lock()
 
 	defer b.relayMu.Unlock()
 	defer b.agentMu.Unlock()
 
-	if _, exists := b.relays[relayID]; !exists {
+	ns := b.namespaceLocked(namespace)
+	if _, exists := ns.relays[relayID]; !exists {
 		return errRelayNotRegistered
 	}
-	delete(b.relays, relayID)
-	delete(b.relayAgents, relayID)
+	delete(ns.relays, relayID)
+	delete(ns.relayAgents, relayID)
 
 	return nil
 }
 
-func (b *Backend) RegisterAgent(ctx context.Context, agent registry.Agent, relayID string) error {
+func (b *Backend) RegisterAgent(ctx context.Context, namespace string, agent registry.Agent, relayID string) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
 
+	m := RegisterAgentMutation(namespace, agent, relayID, time.Now())
+
+	return b.logged(m, func() error {
+		return b.registerAgent(namespace, agent.ID, m.agentMetadata(), relayID, m.At)
+	})
+}
+
+func (b *Backend) registerAgent(namespace, agentID string, metadata registry.AgentMetadata, relayID string, now time.Time) error {
 	b.relayMu.RLock()
-	_, relayExists := b.relays[relayID]
+	ns := b.namespaceLocked(namespace)
+	_, relayExists := ns.relays[relayID]
 	b.relayMu.RUnlock()
 	if !relayExists {
 		return errRelayNotRegistered
 	}
 
-	b.agentMu.RLock()
-	entry, exists := b.agents[agent.ID]
-	b.agentMu.RUnlock()
-
-	now := time.Now()
-	if exists {
-		entry.mu.Lock()
-		entry.agent.LastHeartbeat = now
-		entry.mu.Unlock()
-
-		b.agentMu.Lock()
-		b.setPlacementLocked(agent.ID, relayID, entry, now)
-		b.agentMu.Unlock()
+	// Admission and placement happen under one agentMu critical section so
+	// concurrent registrations cannot overshoot the capacity limits.
+	b.agentMu.Lock()
+	defer b.agentMu.Unlock()
 
-		return nil
+	if err := b.admitAgentLocked(namespace, agentID, relayID); err != nil {
+		return err
 	}
 
-	newEntry := &agentEntry{
-		agent: &registry.Agent{
-			ID:            agent.ID,
-			LastHeartbeat: now,
-		},
+	entry, exists := ns.agents[agentID]
+	if !exists {
+		entry = &agentEntry{agent: &registry.Agent{ID: agentID}}
+		ns.agents[agentID] = entry
 	}
 
-	b.agentMu.Lock()
-	if existing, ok := b.agents[agent.ID]; ok {
-		b.agentMu.Unlock()
+	entry.mu.Lock()
+	entry.agent.LastHeartbeat = now
+	entry.agent.Metadata = metadata
+	entry.mu.Unlock()
 
-		existing.mu.Lock()
-		existing.agent.LastHeartbeat = now
-		existing.mu.Unlock()
+	ns.setPlacement(agentID, relayID, entry, now)
 
-		b.agentMu.Lock()
-		b.setPlacementLocked(agent.ID, relayID, existing, now)
-		b.agentMu.Unlock()
+	return nil
+}
+
+// admitAgentLocked checks the registry-wide and namespace agent limits for
+// placing agentID on relayID. Re-registering an agent already placed on
+// relayID is always admitted. Caller must hold agentMu.
+func (b *Backend) admitAgentLocked(namespace, agentID, relayID string) error {
+	ns := b.namespaceLocked(namespace)
+	tenant := b.tenantLimits[namespace]
+
+	if _, exists := ns.agents[agentID]; !exists {
+		if limit := tenant.MaxAgents; limit > 0 && len(ns.agents) >= limit {
+			return fmt.Errorf("%w: namespace %s (max %d)", errAgentCapacity, namespace, limit)
+		}
 
+		if limit := b.limits.MaxAgents; limit > 0 {
+			total := 0
+			for _, ns := range b.namespaces {
+				total += len(ns.agents)
+			}
+			if total >= limit {
+				return fmt.Errorf("%w (max %d)", errAgentCapacity, limit)
+			}
+		}
+	}
+
+	relayEntries := ns.relayAgents[relayID]
+	if _, placed := relayEntries[agentID]; placed {
 		return nil
 	}
 
-	b.agents[agent.ID] = newEntry
-	b.setPlacementLocked(agent.ID, relayID, newEntry, now)
-	b.agentMu.Unlock()
+	for _, limit := range []int{tenant.MaxAgentsPerRelay, b.limits.MaxAgentsPerRelay} {
+		if limit > 0 && len(relayEntries) >= limit {
+			return fmt.Errorf("%w: relay %s (max %d)", errRelayAgentCapacity, relayID, limit)
+		}
+	}
 
 	return nil
 }
 
-func (b *Backend) HeartbeatAgent(ctx context.Context, agentID string) error {
+func (b *Backend) HeartbeatAgent(ctx context.Context, namespace, agentID string) error {
+	now := time.Now()
+	if err := b.loggedAgentHeartbeat(namespace, agentID, now); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	return nil
+}
+
+func (b *Backend) heartbeatAgent(namespace, agentID string, now time.Time) error {
 	b.agentMu.RLock()
-	entry, exists := b.agents[agentID]
+	ns := b.namespaceLocked(namespace)
+	entry, exists := ns.agents[agentID]
 	b.agentMu.RUnlock()
 	if !exists {
 		return errAgentNotRegistered
 	}
 
 	entry.mu.Lock()
-	entry.agent.LastHeartbeat = time.Now()
+	entry.agent.LastHeartbeat = now
 	entry.mu.Unlock()
 
 	b.agentMu.Lock()
-	if placement, ok := b.placements[agentID]; ok {
-		placement.UpdatedAt = time.Now()
+	if placement, ok := ns.placements[agentID]; ok {
+		placement.UpdatedAt = now
 	}
 	b.agentMu.Unlock()
 
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-	}
-
 	return nil
 }
 
-func (b *Backend) GetAgentPlacement(ctx context.Context, agentID string) (*registry.AgentPlacement, error) {
+// HasRelay reports whether relayID is registered in namespace.
+func (b *Backend) HasRelay(namespace, relayID string) bool {
+	b.relayMu.RLock()
+	defer b.relayMu.RUnlock()
+
+	_, exists := b.namespaceLocked(namespace).relays[relayID]
+	return exists
+}
+
+// HasAgent reports whether agentID is registered in namespace.
+func (b *Backend) HasAgent(namespace, agentID string) bool {
+	b.agentMu.RLock()
+	defer b.agentMu.RUnlock()
+
+	_, exists := b.namespaceLocked(namespace).agents[agentID]
+	return exists
+}
+
+func (b *Backend) GetAgentPlacement(ctx context.Context, namespace, agentID string) (*registry.AgentPlacement, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -259,17 +597,23 @@ func (b *Backend) GetAgentPlacement(ctx context.Context, agentID string) (*regis
 	b.agentMu.RLock()
 	defer b.agentMu.RUnlock()
 
-	placement, exists := b.placements[agentID]
+	ns := b.namespaceLocked(namespace)
+	placement, exists := ns.placements[agentID]
 	if !exists {
 		return nil, errAgentNotRegistered
 	}
 
 	result := *placement
+	if entry, ok := ns.agents[agentID]; ok {
+		entry.mu.Lock()
+		result.Metadata = entry.agent.Metadata
+		entry.mu.Unlock()
+	}
 
 	return &result, nil
 }
 
-func (b *Backend) ListAgents(ctx context.Context) ([]registry.Agent, error) {
+func (b *Backend) ListAgents(ctx context.Context, namespace string) ([]registry.Agent, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -277,8 +621,9 @@ func (b *Backend) ListAgents(ctx context.Context) ([]registry.Agent, error) {
 	}
 
 	b.agentMu.RLock()
-	entries := make([]*agentEntry, 0, len(b.agents))
-	for _, agentEntry := range b.agents {
+	ns := b.namespaceLocked(namespace)
+	entries := make([]*agentEntry, 0, len(ns.agents))
+	for _, agentEntry := range ns.agents {
 		entries = append(entries, agentEntry)
 	}
 	b.agentMu.RUnlock()
@@ -295,7 +640,7 @@ func (b *Backend) ListAgents(ctx context.Context) ([]registry.Agent, error) {
 	return agents, nil
 }
 
-func (b *Backend) ListRelayAgents(ctx context.Context, relayID string) ([]*registry.Agent, error) {
+func (b *Backend) ListRelayAgents(ctx context.Context, namespace, relayID string) ([]*registry.Agent, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -303,14 +648,14 @@ func (b *Backend) ListRelayAgents(ctx context.Context, relayID string) ([]*regis
 	}
 
 	b.relayMu.RLock()
-	_, relayExists := b.relays[relayID]
+	_, relayExists := b.namespaceLocked(namespace).relays[relayID]
 	b.relayMu.RUnlock()
 	if !relayExists {
 		return nil, errRelayNotRegistered
 	}
 
 	b.agentMu.RLock()
-	relayAgentEntries := b.relayAgents[relayID]
+	relayAgentEntries := b.namespaceLocked(namespace).relayAgents[relayID]
 	entries := make([]*agentEntry, 0, len(relayAgentEntries))
 	for _, entry := range relayAgentEntries {
 		entries = append(entries, entry)
@@ -329,59 +674,177 @@ func (b *Backend) ListRelayAgents(ctx context.Context, relayID string) ([]*regis
 	return agents, nil
 }
 
-func (b *Backend) RemoveAgents(ctx context.Context, agentIDs []string) error {
+func (b *Backend) RemoveAgents(ctx context.Context, namespace string, agentIDs []string) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
 
+	return b.logged(Mutation{Op: MutationRemoveAgents, Namespace: namespace, AgentIDs: agentIDs}, func() error {
+		b.removeAgents(namespace, agentIDs)
+		return nil
+	})
+}
+
+func (b *Backend) removeAgents(namespace string, agentIDs []string) {
 	b.agentMu.Lock()
 	defer b.agentMu.Unlock()
 
+	ns := b.namespaceLocked(namespace)
 	for _, agentID := range agentIDs {
-		placement, hasPlacement := b.placements[agentID]
-		if hasPlacement {
-			if relayEntries, ok := b.relayAgents[placement.RelayID]; ok {
-				delete(relayEntries, agentID)
-				if len(relayEntries) == 0 {
-					delete(b.relayAgents, placement.RelayID)
-				}
-			}
-		}
-
-		delete(b.placements, agentID)
-		delete(b.agents, agentID)
+		ns.removePlacement(agentID)
+		delete(ns.agents, agentID)
 	}
-
-	return nil
 }
 
-func (b *Backend) setPlacementLocked(agentID, relayID string, entry *agentEntry, now time.Time) {
-	if oldPlacement, ok := b.placements[agentID]; ok {
-		if oldRelayEntries, ok := b.relayAgents[oldPlacement.RelayID]; ok {
-			delete(oldRelayEntries, agentID)
-			if len(oldRelayEntries) == 0 {
-				delete(b.relayAgents, oldPlacement.RelayID)
-			}
+// removePlacement removes the placement of agentID, if any. Caller must
+// hold agentMu.
+func (ns *namespaceState) removePlacement(agentID string) {
+	placement, ok := ns.placements[agentID]
+	if !ok {
+		return
+	}
+
+	if relayEntries, ok := ns.relayAgents[placement.RelayID]; ok {
+		delete(relayEntries, agentID)
+		if len(relayEntries) == 0 {
+			delete(ns.relayAgents, placement.RelayID)
 		}
 	}
 
-	b.placements[agentID] = &registry.AgentPlacement{
+	delete(ns.placements, agentID)
+}
+
+// setPlacement places agentID on relayID. Caller must hold agentMu.
+func (ns *namespaceState) setPlacement(agentID, relayID string, entry *agentEntry, now time.Time) {
+	ns.removePlacement(agentID)
+
+	ns.placements[agentID] = &registry.AgentPlacement{
 		AgentID:   agentID,
 		RelayID:   relayID,
 		UpdatedAt: now,
 	}
 
-	relayEntries, exists := b.relayAgents[relayID]
+	relayEntries, exists := ns.relayAgents[relayID]
 	if !exists {
 		relayEntries = make(map[string]*agentEntry)
-		b.relayAgents[relayID] = relayEntries
+		ns.relayAgents[relayID] = relayEntries
 	}
 
 	relayEntries[agentID] = entry
 }
 
-func (b *Backend) Close(ctx context.Context) error {
+func (b *Backend) AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (*registry.Lease, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	b.leaseMu.Lock()
+	defer b.leaseMu.Unlock()
+
+	now := time.Now()
+	current, exists := b.leases[name]
+	if exists && now.Before(current.ExpiresAt) {
+		if current.HolderID != holderID {
+			return nil, errLeaseHeld
+		}
+
+		current.ExpiresAt = now.Add(ttl)
+		result := *current
+
+		return &result, nil
+	}
+
+	b.leaseToken++
+	lease := &registry.Lease{
+		Name:      name,
+		HolderID:  holderID,
+		Token:     b.leaseToken,
+		ExpiresAt: now.Add(ttl),
+	}
+	b.leases[name] = lease
+
+	result := *lease
+
+	return &result, nil
+}
+
+func (b *Backend) RenewLease(ctx context.Context, lease registry.Lease, ttl time.Duration) (*registry.Lease, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	b.leaseMu.Lock()
+	defer b.leaseMu.Unlock()
+
+	now := time.Now()
+	current, exists := b.leases[lease.Name]
+	if !exists || current.Token != lease.Token || !now.Before(current.ExpiresAt) {
+		return nil, errLeaseNotHeld
+	}
+
+	current.ExpiresAt = now.Add(ttl)
+	result := *current
+
+	return &result, nil
+}
+
+func (b *Backend) ReleaseLease(ctx context.Context, lease registry.Lease) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	b.leaseMu.Lock()
+	defer b.leaseMu.Unlock()
+
+	current, exists := b.leases[lease.Name]
+	if !exists || current.Token != lease.Token {
+		return errLeaseNotHeld
+	}
+
+	delete(b.leases, lease.Name)
+
 	return nil
 }
+
+// Close stops background persistence, writes a final snapshot when
+// snapshots are enabled and closes the write-ahead log.
+func (b *Backend) Close(ctx context.Context) error {
+	var err error
+	b.closeOnce.Do(func() {
+		if b.snapshotStop != nil {
+			close(b.snapshotStop)
+			<-b.snapshotDone
+		}
+
+		if b.walStop != nil {
+			close(b.walStop)
+			<-b.walDone
+		}
+
+		err = b.Snapshot(ctx)
+
+		if b.wal != nil {
+			// If the final snapshot failed the log still holds every
+			// mutation since the previous one, so flush pending heartbeats
+			// before closing.
+			if err != nil {
+				err = errors.Join(err, b.flushHeartbeats())
+			}
+			err = errors.Join(err, b.wal.close())
+		}
+
+		if b.lock != nil {
+			err = errors.Join(err, b.lock.Close())
+		}
+	})
+
+	return err
+}
@@ -2,6 +2,10 @@ package memory
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
@@ -9,6 +13,7 @@ import (
 )
 
 var _ registry.Backend = (*Backend)(nil)
+var _ registry.StateImporter = (*Backend)(nil)
 
 func TestRelayLifecycle(t *testing.T) {
 	backend, err := New(&registry.MemoryConfig{})
@@ -19,11 +24,11 @@ func TestRelayLifecycle(t *testing.T) {
 	ctx := context.Background()
 	relay := registry.Relay{ID: "relay-1", Address: "127.0.0.1", GRPCPort: 9000}
 
-	if err := backend.RegisterRelay(ctx, relay); err != nil {
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, relay); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	relays, err := backend.ListRelays(ctx)
+	relays, err := backend.ListRelays(ctx, registry.DefaultNamespace)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -35,11 +40,11 @@ func TestRelayLifecycle(t *testing.T) {
 	}
 
 	relayHeartbeatStart := time.Now()
-	if err := backend.HeartbeatRelay(ctx, relay.ID); err != nil {
+	if err := backend.HeartbeatRelay(ctx, registry.DefaultNamespace, relay.ID); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	relays, err = backend.ListRelays(ctx)
+	relays, err = backend.ListRelays(ctx, registry.DefaultNamespace)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -47,11 +52,11 @@ func TestRelayLifecycle(t *testing.T) {
 		t.Fatalf("expected LastSeen >= %v, got %v", relayHeartbeatStart, relays[0].LastSeen)
 	}
 
-	if err := backend.RemoveRelay(ctx, relay.ID); err != nil {
+	if err := backend.RemoveRelay(ctx, registry.DefaultNamespace, relay.ID); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	relays, err = backend.ListRelays(ctx)
+	relays, err = backend.ListRelays(ctx, registry.DefaultNamespace)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -68,16 +73,16 @@ func TestAgentLifecycle(t *testing.T) {
 
 	ctx := context.Background()
 	relay := registry.Relay{ID: "relay-1", Address: "127.0.0.1", GRPCPort: 9000}
-	if err := backend.RegisterRelay(ctx, relay); err != nil {
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, relay); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
 	agent := registry.Agent{ID: "agent-1"}
-	if err := backend.RegisterAgent(ctx, agent, relay.ID); err != nil {
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, agent, relay.ID); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	placement, err := backend.GetAgentPlacement(ctx, agent.ID)
+	placement, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, agent.ID)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -86,11 +91,11 @@ func TestAgentLifecycle(t *testing.T) {
 	}
 
 	agentHeartbeatStart := time.Now()
-	if err := backend.HeartbeatAgent(ctx, agent.ID); err != nil {
+	if err := backend.HeartbeatAgent(ctx, registry.DefaultNamespace, agent.ID); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	placement, err = backend.GetAgentPlacement(ctx, agent.ID)
+	placement, err = backend.GetAgentPlacement(ctx, registry.DefaultNamespace, agent.ID)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -98,11 +103,11 @@ func TestAgentLifecycle(t *testing.T) {
 		t.Fatalf("expected UpdatedAt >= %v, got %v", agentHeartbeatStart, placement.UpdatedAt)
 	}
 
-	if err := backend.RemoveRelay(ctx, relay.ID); err != nil {
+	if err := backend.RemoveRelay(ctx, registry.DefaultNamespace, relay.ID); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	placement, err = backend.GetAgentPlacement(ctx, agent.ID)
+	placement, err = backend.GetAgentPlacement(ctx, registry.DefaultNamespace, agent.ID)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -111,6 +116,99 @@ func TestAgentLifecycle(t *testing.T) {
 	}
 }
 
+func TestAgentMetadata(t *testing.T) {
+	cfg := newWALTestConfig(t)
+	ctx := context.Background()
+
+	backend, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1", Address: "127.0.0.1", GRPCPort: 9000}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	metadata := registry.AgentMetadata{
+		Kind:         registry.AgentKindDrone,
+		Version:      "px4-1.14.3",
+		Capabilities: []string{"mission_upload", "video"},
+		SessionStart: time.UnixMilli(1700000000000).UTC(),
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1", Metadata: metadata}, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.HeartbeatAgent(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	check := func(t *testing.T, backend *Backend) {
+		t.Helper()
+
+		placement, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1")
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if !reflect.DeepEqual(placement.Metadata, metadata) {
+			t.Fatalf("expected placement metadata %+v, got %+v", metadata, placement.Metadata)
+		}
+
+		agents, err := backend.ListAgents(ctx, registry.DefaultNamespace)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if len(agents) != 1 || !reflect.DeepEqual(agents[0].Metadata, metadata) {
+			t.Fatalf("expected agent metadata %+v, got %+v", metadata, agents)
+		}
+
+		relayAgents, err := backend.ListRelayAgents(ctx, registry.DefaultNamespace, "relay-1")
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if len(relayAgents) != 1 || !relayAgents[0].Metadata.HasCapability("video") {
+			t.Fatalf("expected relay agent metadata %+v, got %+v", metadata, relayAgents)
+		}
+	}
+
+	t.Run("kept across heartbeats", func(t *testing.T) {
+		check(t, backend)
+	})
+
+	t.Run("replayed from the wal", func(t *testing.T) {
+		simulateCrash(t, backend)
+
+		restored, err := New(cfg)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		check(t, restored)
+
+		if err := restored.Close(ctx); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	})
+
+	t.Run("restored from a snapshot", func(t *testing.T) {
+		restored, err := New(cfg)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		defer restored.Close(ctx)
+		check(t, restored)
+
+		// Registering again replaces the metadata of the previous session.
+		if err := restored.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		placement, err := restored.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1")
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if !reflect.DeepEqual(placement.Metadata, registry.AgentMetadata{}) {
+			t.Fatalf("expected metadata to be replaced, got %+v", placement.Metadata)
+		}
+	})
+}
+
 func TestListRelayAgents(t *testing.T) {
 	backend, err := New(&registry.MemoryConfig{})
 	if err != nil {
@@ -121,21 +219,21 @@ func TestListRelayAgents(t *testing.T) {
 	relay1 := registry.Relay{ID: "relay-1", Address: "127.0.0.1", GRPCPort: 9000}
 	relay2 := registry.Relay{ID: "relay-2", Address: "127.0.0.1", GRPCPort: 9001}
 
-	if err := backend.RegisterRelay(ctx, relay1); err != nil {
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, relay1); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
-	if err := backend.RegisterRelay(ctx, relay2); err != nil {
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, relay2); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	if err := backend.RegisterAgent(ctx, registry.Agent{ID: "agent-1"}, relay1.ID); err != nil {
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, relay1.ID); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
-	if err := backend.RegisterAgent(ctx, registry.Agent{ID: "agent-2"}, relay1.ID); err != nil {
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-2"}, relay1.ID); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	relay1Agents, err := backend.ListRelayAgents(ctx, relay1.ID)
+	relay1Agents, err := backend.ListRelayAgents(ctx, registry.DefaultNamespace, relay1.ID)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -151,11 +249,11 @@ func TestListRelayAgents(t *testing.T) {
 		t.Fatalf("unexpected relay-1 agents: %#v", relay1Agents)
 	}
 
-	if err := backend.RegisterAgent(ctx, registry.Agent{ID: "agent-1"}, relay2.ID); err != nil {
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, relay2.ID); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	relay1Agents, err = backend.ListRelayAgents(ctx, relay1.ID)
+	relay1Agents, err = backend.ListRelayAgents(ctx, registry.DefaultNamespace, relay1.ID)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -163,7 +261,7 @@ func TestListRelayAgents(t *testing.T) {
 		t.Fatalf("unexpected relay-1 agents after reassignment: %#v", relay1Agents)
 	}
 
-	relay2Agents, err := backend.ListRelayAgents(ctx, relay2.ID)
+	relay2Agents, err := backend.ListRelayAgents(ctx, registry.DefaultNamespace, relay2.ID)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -180,22 +278,22 @@ func TestRemoveAgents(t *testing.T) {
 
 	ctx := context.Background()
 	relay := registry.Relay{ID: "relay-1", Address: "127.0.0.1", GRPCPort: 9000}
-	if err := backend.RegisterRelay(ctx, relay); err != nil {
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, relay); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	if err := backend.RegisterAgent(ctx, registry.Agent{ID: "agent-1"}, relay.ID); err != nil {
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, relay.ID); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
-	if err := backend.RegisterAgent(ctx, registry.Agent{ID: "agent-2"}, relay.ID); err != nil {
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-2"}, relay.ID); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	if err := backend.RemoveAgents(ctx, []string{"agent-1"}); err != nil {
+	if err := backend.RemoveAgents(ctx, registry.DefaultNamespace, []string{"agent-1"}); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 
-	agents, err := backend.ListAgents(ctx)
+	agents, err := backend.ListAgents(ctx, registry.DefaultNamespace)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -203,11 +301,11 @@ func TestRemoveAgents(t *testing.T) {
 		t.Fatalf("unexpected agents after removal: %#v", agents)
 	}
 
-	if _, err := backend.GetAgentPlacement(ctx, "agent-1"); err == nil {
+	if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1"); err == nil {
 		t.Fatal("expected error for removed agent placement")
 	}
 
-	relayAgents, err := backend.ListRelayAgents(ctx, relay.ID)
+	relayAgents, err := backend.ListRelayAgents(ctx, registry.DefaultNamespace, relay.ID)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -215,3 +313,443 @@ func TestRemoveAgents(t *testing.T) {
 		t.Fatalf("unexpected relay agents after removal: %#v", relayAgents)
 	}
 }
+
+func TestCapacityLimits(t *testing.T) {
+	backend, err := New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	backend.SetCapacityLimits(registry.CapacityLimits{MaxRelays: 2, MaxAgents: 3, MaxAgentsPerRelay: 2}, nil)
+
+	ctx := context.Background()
+	for _, id := range []string{"relay-1", "relay-2"} {
+		if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: id}); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-3"}); !errors.Is(err, registry.ErrResourceExhausted) {
+		t.Fatalf("expected ErrResourceExhausted, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1", Address: "10.0.0.1"}); err != nil {
+		t.Fatalf("expected relay update to be admitted, got %v", err)
+	}
+
+	for _, id := range []string{"agent-1", "agent-2"} {
+		if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: id}, "relay-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-3"}, "relay-1"); !errors.Is(err, registry.ErrResourceExhausted) {
+		t.Fatalf("expected ErrResourceExhausted for full relay, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-2"}, "relay-1"); err != nil {
+		t.Fatalf("expected re-registration to be admitted, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-3"}, "relay-2"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-4"}, "relay-2"); !errors.Is(err, registry.ErrResourceExhausted) {
+		t.Fatalf("expected ErrResourceExhausted for agent limit, got %v", err)
+	}
+
+	placement, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-2")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if placement.RelayID != "relay-1" {
+		t.Fatalf("expected rejected registrations to leave placement unchanged, got %s", placement.RelayID)
+	}
+
+	backend.SetCapacityLimits(registry.CapacityLimits{}, nil)
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-4"}, "relay-2"); err != nil {
+		t.Fatalf("expected nil error after lifting limits, got %v", err)
+	}
+}
+
+func TestLeaseLifecycle(t *testing.T) {
+	backend, err := New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	ctx := context.Background()
+
+	lease, err := backend.AcquireLease(ctx, "sweeper", "replica-a", time.Minute)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if lease.HolderID != "replica-a" || lease.Token == 0 {
+		t.Fatalf("unexpected lease: %#v", lease)
+	}
+
+	if _, err := backend.AcquireLease(ctx, "sweeper", "replica-b", time.Minute); !errors.Is(err, registry.ErrConflict) {
+		t.Fatalf("expected ErrConflict for held lease, got %v", err)
+	}
+
+	renewed, err := backend.RenewLease(ctx, *lease, 2*time.Minute)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if renewed.Token != lease.Token || !renewed.ExpiresAt.After(lease.ExpiresAt) {
+		t.Fatalf("unexpected renewed lease: %#v", renewed)
+	}
+
+	if err := backend.ReleaseLease(ctx, *renewed); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := backend.RenewLease(ctx, *renewed, time.Minute); !errors.Is(err, registry.ErrConflict) {
+		t.Fatalf("expected ErrConflict renewing released lease, got %v", err)
+	}
+
+	next, err := backend.AcquireLease(ctx, "sweeper", "replica-b", time.Minute)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if next.Token <= lease.Token {
+		t.Fatalf("expected fencing token to increase, got %d after %d", next.Token, lease.Token)
+	}
+}
+
+func TestExpiredLeaseCanBeTakenOver(t *testing.T) {
+	backend, err := New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	ctx := context.Background()
+
+	stale, err := backend.AcquireLease(ctx, "sweeper", "replica-a", time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := backend.AcquireLease(ctx, "sweeper", "replica-b", time.Minute); err != nil {
+		t.Fatalf("expected expired lease to be acquirable, got %v", err)
+	}
+	if _, err := backend.RenewLease(ctx, *stale, time.Minute); !errors.Is(err, registry.ErrConflict) {
+		t.Fatalf("expected ErrConflict renewing superseded lease, got %v", err)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.snapshot")
+	ctx := context.Background()
+
+	backend, err := New(&registry.MemoryConfig{SnapshotPath: path})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	relay := registry.Relay{ID: "relay-1", Address: "127.0.0.1", GRPCPort: 9000}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, relay); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, relay.ID); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	relays, err := backend.ListRelays(ctx, registry.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	lastSeen := relays[0].LastSeen
+
+	if err := backend.Close(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	restored, err := New(&registry.MemoryConfig{SnapshotPath: path})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := restored.HeartbeatRelay(ctx, registry.DefaultNamespace, relay.ID); err != nil {
+		t.Fatalf("expected restored relay to accept heartbeats, got %v", err)
+	}
+
+	relays, err = restored.ListRelays(ctx, registry.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(relays) != 1 || relays[0].Address != relay.Address || relays[0].GRPCPort != relay.GRPCPort {
+		t.Fatalf("unexpected restored relays: %+v", relays)
+	}
+	if relays[0].LastSeen.Before(lastSeen) {
+		t.Fatalf("expected LastSeen >= %v, got %v", lastSeen, relays[0].LastSeen)
+	}
+
+	placement, err := restored.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if placement.RelayID != relay.ID {
+		t.Fatalf("expected placement on %q, got %q", relay.ID, placement.RelayID)
+	}
+
+	agents, err := restored.ListRelayAgents(ctx, registry.DefaultNamespace, relay.ID)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(agents) != 1 || agents[0].ID != "agent-1" {
+		t.Fatalf("unexpected restored relay agents: %+v", agents)
+	}
+}
+
+func TestSnapshotPreservesHeartbeatAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.snapshot")
+	ctx := context.Background()
+
+	backend, err := New(&registry.MemoryConfig{SnapshotPath: path})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	stale := time.Now().Add(-time.Hour)
+	backend.namespaces[registry.DefaultNamespace].relays["relay-1"].relay.LastSeen = stale
+
+	if err := backend.Close(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	restored, err := New(&registry.MemoryConfig{SnapshotPath: path})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	relays, err := restored.ListRelays(ctx, registry.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !relays[0].LastSeen.Equal(stale) {
+		t.Fatalf("expected LastSeen %v to survive restore, got %v", stale, relays[0].LastSeen)
+	}
+}
+
+func TestSnapshotMissingFileStartsEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.snapshot")
+
+	backend, err := New(&registry.MemoryConfig{SnapshotPath: path})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	relays, err := backend.ListRelays(context.Background(), registry.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(relays) != 0 {
+		t.Fatalf("expected 0 relays, got %d", len(relays))
+	}
+}
+
+func TestSnapshotCorruptFileRejected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.snapshot")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("write snapshot: %v", err)
+	}
+
+	_, err := New(&registry.MemoryConfig{SnapshotPath: path})
+	if !errors.Is(err, registry.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestPeriodicSnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.snapshot")
+	ctx := context.Background()
+
+	backend, err := New(&registry.MemoryConfig{
+		SnapshotPath:     path,
+		SnapshotInterval: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	defer backend.Close(ctx)
+
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected periodic snapshot at %s", path)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestImportPreservesTimestamps(t *testing.T) {
+	backend, err := New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	ctx := context.Background()
+	lastSeen := time.Now().Add(-time.Hour).Truncate(time.Second)
+	updatedAt := lastSeen.Add(time.Minute)
+	lastHeartbeat := lastSeen.Add(2 * time.Minute)
+
+	if err := backend.ImportRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1", Address: "10.0.0.1", GRPCPort: 9000, LastSeen: lastSeen}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	err = backend.ImportAgent(ctx, registry.DefaultNamespace,
+		registry.Agent{ID: "agent-1", LastHeartbeat: lastHeartbeat},
+		registry.AgentPlacement{AgentID: "agent-1", RelayID: "relay-1", UpdatedAt: updatedAt},
+	)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	relays, err := backend.ListRelays(ctx, registry.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(relays) != 1 || !relays[0].LastSeen.Equal(lastSeen) {
+		t.Fatalf("expected relay last seen %v, got %+v", lastSeen, relays)
+	}
+
+	agents, err := backend.ListAgents(ctx, registry.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(agents) != 1 || !agents[0].LastHeartbeat.Equal(lastHeartbeat) {
+		t.Fatalf("expected agent last heartbeat %v, got %+v", lastHeartbeat, agents)
+	}
+
+	if err := backend.ImportAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-2"}, registry.AgentPlacement{AgentID: "agent-2", RelayID: "missing"}); !errors.Is(err, registry.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown relay, got %v", err)
+	}
+}
+
+func TestNamespaceIsolation(t *testing.T) {
+	backend, err := New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	ctx := context.Background()
+	for _, namespace := range []string{"fleet-a", "fleet-b"} {
+		if err := backend.RegisterRelay(ctx, namespace, registry.Relay{ID: "relay-1", Address: namespace}); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if err := backend.RegisterAgent(ctx, namespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	relays, err := backend.ListRelays(ctx, "fleet-a")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(relays) != 1 || relays[0].Address != "fleet-a" {
+		t.Fatalf("expected only the fleet-a relay, got %+v", relays)
+	}
+
+	if err := backend.RemoveRelay(ctx, "fleet-a", "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RemoveAgents(ctx, "fleet-a", []string{"agent-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := backend.GetAgentPlacement(ctx, "fleet-a", "agent-1"); !errors.Is(err, registry.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound in fleet-a, got %v", err)
+	}
+
+	placement, err := backend.GetAgentPlacement(ctx, "fleet-b", "agent-1")
+	if err != nil {
+		t.Fatalf("expected fleet-b to keep its agent, got %v", err)
+	}
+	if placement.RelayID != "relay-1" {
+		t.Fatalf("expected placement on relay-1, got %q", placement.RelayID)
+	}
+
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-2"}, "relay-1"); !errors.Is(err, registry.ErrNotFound) {
+		t.Fatalf("expected relays of other namespaces to be unknown, got %v", err)
+	}
+
+	namespaces, err := backend.ListNamespaces(ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(namespaces) != 1 || namespaces[0] != "fleet-b" {
+		t.Fatalf("expected only fleet-b to hold entries, got %v", namespaces)
+	}
+}
+
+func TestTenantCapacityLimits(t *testing.T) {
+	backend, err := New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	backend.SetCapacityLimits(registry.CapacityLimits{MaxRelays: 3}, map[string]registry.CapacityLimits{
+		"fleet-a": {MaxRelays: 1, MaxAgents: 1},
+	})
+
+	ctx := context.Background()
+	if err := backend.RegisterRelay(ctx, "fleet-a", registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, "fleet-a", registry.Relay{ID: "relay-2"}); !errors.Is(err, registry.ErrResourceExhausted) {
+		t.Fatalf("expected ErrResourceExhausted for the tenant relay limit, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, "fleet-a", registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, "fleet-a", registry.Agent{ID: "agent-2"}, "relay-1"); !errors.Is(err, registry.ErrResourceExhausted) {
+		t.Fatalf("expected ErrResourceExhausted for the tenant agent limit, got %v", err)
+	}
+
+	for _, id := range []string{"relay-1", "relay-2"} {
+		if err := backend.RegisterRelay(ctx, "fleet-b", registry.Relay{ID: id}); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+	if err := backend.RegisterRelay(ctx, "fleet-b", registry.Relay{ID: "relay-3"}); !errors.Is(err, registry.ErrResourceExhausted) {
+		t.Fatalf("expected ErrResourceExhausted for the registry-wide relay limit, got %v", err)
+	}
+}
+
+func TestSnapshotRestoresNamespaces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.snapshot")
+	ctx := context.Background()
+
+	backend, err := New(&registry.MemoryConfig{SnapshotPath: path})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	for _, namespace := range []string{registry.DefaultNamespace, "fleet-a"} {
+		if err := backend.RegisterRelay(ctx, namespace, registry.Relay{ID: "relay-" + namespace}); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if err := backend.RegisterAgent(ctx, namespace, registry.Agent{ID: "agent-1"}, "relay-"+namespace); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+	if err := backend.Close(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	restored, err := New(&registry.MemoryConfig{SnapshotPath: path})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	for _, namespace := range []string{registry.DefaultNamespace, "fleet-a"} {
+		placement, err := restored.GetAgentPlacement(ctx, namespace, "agent-1")
+		if err != nil {
+			t.Fatalf("expected nil error in %s, got %v", namespace, err)
+		}
+		if placement.RelayID != "relay-"+namespace {
+			t.Fatalf("expected placement on relay-%s, got %q", namespace, placement.RelayID)
+		}
+	}
+}
@@ -9,4 +9,13 @@ import (
 var (
 	errRelayNotRegistered = fmt.Errorf("relay not registered: %w", registry.ErrNotFound)
 	errAgentNotRegistered = fmt.Errorf("agent not registered: %w", registry.ErrNotFound)
+	errLeaseHeld          = fmt.Errorf("lease held by another holder: %w", registry.ErrConflict)
+	errLeaseNotHeld       = fmt.Errorf("lease not held: %w", registry.ErrConflict)
+	errSnapshotCorrupt    = fmt.Errorf("memory snapshot corrupt: %w", registry.ErrInvalid)
+	errSnapshotVersion    = fmt.Errorf("unsupported memory snapshot version: %w", registry.ErrInvalid)
+	errMutationInvalid    = fmt.Errorf("invalid memory backend mutation: %w", registry.ErrInvalid)
+	errRelayCapacity      = fmt.Errorf("relay limit reached: %w", registry.ErrResourceExhausted)
+	errAgentCapacity      = fmt.Errorf("agent limit reached: %w", registry.ErrResourceExhausted)
+	errRelayAgentCapacity = fmt.Errorf("agents per relay limit reached: %w", registry.ErrResourceExhausted)
+	errStateLocked        = fmt.Errorf("memory backend files in use by another process: %w", registry.ErrConflict)
 )
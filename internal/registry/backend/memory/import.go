@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// ImportRelayMutation returns the mutation that registers relay in
+// namespace with relay.LastSeen as its last heartbeat.
+func ImportRelayMutation(namespace string, relay registry.Relay) Mutation {
+	return Mutation{
+		Op:        MutationRegisterRelay,
+		Namespace: namespace,
+		Relay: &MutationRelay{
+			ID:       relay.ID,
+			Address:  relay.Address,
+			GRPCPort: relay.GRPCPort,
+		},
+		At: relay.LastSeen,
+	}
+}
+
+// ImportAgentMutations returns the mutations that place agent as described
+// by placement and then advance its heartbeat to agent.LastHeartbeat when
+// that is more recent than the placement.
+func ImportAgentMutations(namespace string, agent registry.Agent, placement registry.AgentPlacement) []Mutation {
+	mutations := []Mutation{RegisterAgentMutation(namespace, agent, placement.RelayID, placement.UpdatedAt)}
+	if agent.LastHeartbeat.After(placement.UpdatedAt) {
+		mutations = append(mutations, Mutation{
+			Op:              MutationHeartbeats,
+			Namespace:       namespace,
+			AgentHeartbeats: map[string]time.Time{agent.ID: agent.LastHeartbeat},
+		})
+	}
+
+	return mutations
+}
+
+// ImportRelay registers relay in namespace with relay.LastSeen as its last
+// heartbeat.
+func (b *Backend) ImportRelay(ctx context.Context, namespace string, relay registry.Relay) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	return b.ApplyMutation(ImportRelayMutation(namespace, relay))
+}
+
+// ImportAgent registers agent in namespace with placement, keeping its
+// timestamps.
+func (b *Backend) ImportAgent(ctx context.Context, namespace string, agent registry.Agent, placement registry.AgentPlacement) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	for _, m := range ImportAgentMutations(namespace, agent, placement) {
+		if err := b.ApplyMutation(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package memory
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockFile creates path and returns it open. Platforms without flock get
+// no cross-process exclusion, so only one process may use the files.
+func lockFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+
+	return file, nil
+}
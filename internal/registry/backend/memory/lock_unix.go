@@ -0,0 +1,31 @@
+//go:build unix
+
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on path, creating it if needed, and
+// returns the open file that holds it. Closing the file releases the lock,
+// as does the process exiting. It fails with errStateLocked when another
+// process holds the lock.
+func lockFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%w: %s", errStateLocked, path)
+		}
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+
+	return file, nil
+}
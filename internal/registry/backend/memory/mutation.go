@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// MutationOp identifies the kind of state change a Mutation describes.
+type MutationOp string
+
+const (
+	MutationRegisterRelay MutationOp = "register_relay"
+	MutationRemoveRelay   MutationOp = "remove_relay"
+	MutationRegisterAgent MutationOp = "register_agent"
+	MutationRemoveAgents  MutationOp = "remove_agents"
+	MutationHeartbeats    MutationOp = "heartbeats"
+)
+
+// Mutation is a deterministic description of a backend state change.
+// Timestamps are carried in the mutation rather than read from the clock,
+// so applying the same sequence of mutations always yields the same state.
+// Mutations are what the write-ahead log records and what replicated
+// backends ship between nodes. Only the fields relevant to Op are set.
+// Every mutation applies to the entries of a single namespace; mutations
+// logged before namespaces existed have none and apply to the default
+// namespace.
+type Mutation struct {
+	Op              MutationOp             `json:"op"`
+	At              time.Time              `json:"at,omitzero"`
+	Namespace       string                 `json:"namespace,omitempty"`
+	Relay           *MutationRelay         `json:"relay,omitempty"`
+	RelayID         string                 `json:"relay_id,omitempty"`
+	AgentID         string                 `json:"agent_id,omitempty"`
+	AgentMetadata   *MutationAgentMetadata `json:"agent_metadata,omitempty"`
+	AgentIDs        []string               `json:"agent_ids,omitempty"`
+	RelayHeartbeats map[string]time.Time   `json:"relay_heartbeats,omitempty"`
+	AgentHeartbeats map[string]time.Time   `json:"agent_heartbeats,omitempty"`
+}
+
+// MutationRelay is the relay identity carried by a register_relay mutation.
+type MutationRelay struct {
+	ID       string `json:"id"`
+	Address  string `json:"address"`
+	GRPCPort int32  `json:"grpc_port"`
+}
+
+// MutationAgentMetadata is the agent metadata carried by a register_agent
+// mutation. Mutations logged before agents carried metadata have none.
+type MutationAgentMetadata struct {
+	Kind         registry.AgentKind `json:"kind,omitempty"`
+	Version      string             `json:"version,omitempty"`
+	Capabilities []string           `json:"capabilities,omitempty"`
+	SessionStart time.Time          `json:"session_start,omitzero"`
+}
+
+// RegisterAgentMutation returns the mutation that places agent, with its
+// metadata, on relayID of namespace at the given time.
+func RegisterAgentMutation(namespace string, agent registry.Agent, relayID string, at time.Time) Mutation {
+	m := Mutation{Op: MutationRegisterAgent, Namespace: namespace, AgentID: agent.ID, RelayID: relayID, At: at}
+
+	md := agent.Metadata
+	if md.Kind != "" || md.Version != "" || len(md.Capabilities) > 0 || !md.SessionStart.IsZero() {
+		m.AgentMetadata = &MutationAgentMetadata{
+			Kind:         md.Kind,
+			Version:      md.Version,
+			Capabilities: slices.Clone(md.Capabilities),
+			SessionStart: md.SessionStart,
+		}
+	}
+
+	return m
+}
+
+func (m Mutation) namespace() string {
+	if m.Namespace == "" {
+		return registry.DefaultNamespace
+	}
+
+	return m.Namespace
+}
+
+func (m Mutation) agentMetadata() registry.AgentMetadata {
+	if m.AgentMetadata == nil {
+		return registry.AgentMetadata{}
+	}
+
+	return registry.AgentMetadata{
+		Kind:         m.AgentMetadata.Kind,
+		Version:      m.AgentMetadata.Version,
+		Capabilities: m.AgentMetadata.Capabilities,
+		SessionStart: m.AgentMetadata.SessionStart,
+	}
+}
+
+// ApplyMutation applies m to the backend, recording it in the write-ahead
+// log when one is enabled. It returns the same errors as the equivalent
+// Backend method.
+func (b *Backend) ApplyMutation(m Mutation) error {
+	return b.logged(m, func() error {
+		return b.applyMutation(m)
+	})
+}
+
+func (b *Backend) applyMutation(m Mutation) error {
+	namespace := m.namespace()
+
+	switch m.Op {
+	case MutationRegisterRelay:
+		if m.Relay == nil {
+			return errMutationInvalid
+		}
+		return b.registerRelay(namespace, registry.Relay{
+			ID:       m.Relay.ID,
+			Address:  m.Relay.Address,
+			GRPCPort: m.Relay.GRPCPort,
+		}, m.At)
+	case MutationRemoveRelay:
+		return b.removeRelay(namespace, m.RelayID)
+	case MutationRegisterAgent:
+		return b.registerAgent(namespace, m.AgentID, m.agentMetadata(), m.RelayID, m.At)
+	case MutationRemoveAgents:
+		b.removeAgents(namespace, m.AgentIDs)
+		return nil
+	case MutationHeartbeats:
+		var errs []error
+		for relayID, at := range m.RelayHeartbeats {
+			errs = append(errs, b.advanceRelayHeartbeat(namespace, relayID, at))
+		}
+		for agentID, at := range m.AgentHeartbeats {
+			errs = append(errs, b.advanceAgentHeartbeat(namespace, agentID, at))
+		}
+		return errors.Join(errs...)
+	default:
+		return errMutationInvalid
+	}
+}
+
+// advanceRelayHeartbeat applies a heartbeat unless the relay has since been
+// seen more recently (e.g. re-registered before a heartbeat batch was
+// flushed).
+func (b *Backend) advanceRelayHeartbeat(namespace, relayID string, at time.Time) error {
+	b.relayMu.RLock()
+	entry, exists := b.namespaceLocked(namespace).relays[relayID]
+	b.relayMu.RUnlock()
+	if !exists {
+		return errRelayNotRegistered
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if at.After(entry.relay.LastSeen) {
+		entry.relay.LastSeen = at
+	}
+
+	return nil
+}
+
+func (b *Backend) advanceAgentHeartbeat(namespace, agentID string, at time.Time) error {
+	b.agentMu.RLock()
+	entry, exists := b.namespaceLocked(namespace).agents[agentID]
+	b.agentMu.RUnlock()
+	if !exists {
+		return errAgentNotRegistered
+	}
+
+	entry.mu.Lock()
+	seen := entry.agent.LastHeartbeat
+	entry.mu.Unlock()
+
+	if !at.After(seen) {
+		return nil
+	}
+
+	return b.heartbeatAgent(namespace, agentID, at)
+}
@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// undo holds the prior state of the relays and agents a mutation touches,
+// so the mutation can be reverted when its write-ahead log record cannot
+// be appended. A nil value records an entry that did not exist.
+type undo struct {
+	namespace   string
+	relays      map[string]*registry.Relay
+	relayAgents map[string]map[string]*agentEntry
+	agents      map[string]*registry.Agent
+	placements  map[string]*registry.AgentPlacement
+}
+
+// captureUndo records the current state of every entry m touches.
+func (b *Backend) captureUndo(m Mutation) *undo {
+	var relayIDs, agentIDs []string
+	switch m.Op {
+	case MutationRegisterRelay:
+		if m.Relay != nil {
+			relayIDs = []string{m.Relay.ID}
+		}
+	case MutationRemoveRelay:
+		relayIDs = []string{m.RelayID}
+	case MutationRegisterAgent:
+		agentIDs = []string{m.AgentID}
+	case MutationRemoveAgents:
+		agentIDs = m.AgentIDs
+	case MutationHeartbeats:
+		for relayID := range m.RelayHeartbeats {
+			relayIDs = append(relayIDs, relayID)
+		}
+		for agentID := range m.AgentHeartbeats {
+			agentIDs = append(agentIDs, agentID)
+		}
+	}
+
+	u := &undo{
+		namespace:   m.namespace(),
+		relays:      make(map[string]*registry.Relay, len(relayIDs)),
+		relayAgents: make(map[string]map[string]*agentEntry, len(relayIDs)),
+		agents:      make(map[string]*registry.Agent, len(agentIDs)),
+		placements:  make(map[string]*registry.AgentPlacement, len(agentIDs)),
+	}
+
+	b.relayMu.RLock()
+	defer b.relayMu.RUnlock()
+	b.agentMu.RLock()
+	defer b.agentMu.RUnlock()
+
+	ns := b.namespaceLocked(u.namespace)
+	for _, relayID := range relayIDs {
+		u.relays[relayID] = nil
+		if entry, ok := ns.relays[relayID]; ok {
+			entry.mu.Lock()
+			relay := *entry.relay
+			entry.mu.Unlock()
+			u.relays[relayID] = &relay
+		}
+		u.relayAgents[relayID] = ns.relayAgents[relayID]
+	}
+
+	for _, agentID := range agentIDs {
+		u.agents[agentID] = nil
+		if entry, ok := ns.agents[agentID]; ok {
+			entry.mu.Lock()
+			agent := *entry.agent
+			entry.mu.Unlock()
+			u.agents[agentID] = &agent
+		}
+
+		u.placements[agentID] = nil
+		if placement, ok := ns.placements[agentID]; ok {
+			placement := *placement
+			u.placements[agentID] = &placement
+		}
+	}
+
+	return u
+}
+
+// restore puts every captured entry back to its recorded state.
+func (b *Backend) restore(u *undo) {
+	b.relayMu.Lock()
+	defer b.relayMu.Unlock()
+	b.agentMu.Lock()
+	defer b.agentMu.Unlock()
+
+	ns, ok := b.namespaces[u.namespace]
+	if !ok {
+		return
+	}
+
+	for relayID, relay := range u.relays {
+		switch entry, exists := ns.relays[relayID]; {
+		case relay == nil:
+			delete(ns.relays, relayID)
+		case exists:
+			entry.mu.Lock()
+			*entry.relay = *relay
+			entry.mu.Unlock()
+		default:
+			ns.relays[relayID] = &relayEntry{relay: relay}
+		}
+
+		if agents := u.relayAgents[relayID]; agents != nil {
+			ns.relayAgents[relayID] = agents
+		}
+	}
+
+	for agentID, agent := range u.agents {
+		entry, exists := ns.agents[agentID]
+		if agent == nil {
+			if exists {
+				ns.removePlacement(agentID)
+				delete(ns.agents, agentID)
+			}
+			continue
+		}
+
+		if exists {
+			entry.mu.Lock()
+			*entry.agent = *agent
+			entry.mu.Unlock()
+		} else {
+			entry = &agentEntry{agent: agent}
+			ns.agents[agentID] = entry
+		}
+
+		if placement := u.placements[agentID]; placement != nil {
+			ns.setPlacement(agentID, placement.RelayID, entry, placement.UpdatedAt)
+		} else {
+			ns.removePlacement(agentID)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// snapshotVersion is bumped whenever the on-disk snapshot layout changes
+// incompatibly.
+const snapshotVersion = 1
+
+// Entries of snapshots written before namespaces existed have no namespace
+// and restore into the default namespace.
+
+// snapshot is the on-disk representation of the backend state. Leases are
+// intentionally not persisted: they are short-lived and re-acquired by the
+// sweeper after a restart. WALSeq is the last write-ahead log record the
+// snapshot includes; replay starts after it.
+type snapshot struct {
+	Version    int                 `json:"version"`
+	TakenAt    time.Time           `json:"taken_at"`
+	WALSeq     uint64              `json:"wal_seq,omitempty"`
+	Relays     []snapshotRelay     `json:"relays"`
+	Agents     []snapshotAgent     `json:"agents"`
+	Placements []snapshotPlacement `json:"placements"`
+}
+
+type snapshotRelay struct {
+	Namespace string    `json:"namespace,omitempty"`
+	ID        string    `json:"id"`
+	Address   string    `json:"address"`
+	GRPCPort  int32     `json:"grpc_port"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// snapshotAgent carries the agent metadata inline; snapshots written
+// before agents carried metadata restore with none.
+type snapshotAgent struct {
+	Namespace     string             `json:"namespace,omitempty"`
+	ID            string             `json:"id"`
+	LastHeartbeat time.Time          `json:"last_heartbeat"`
+	Kind          registry.AgentKind `json:"kind,omitempty"`
+	Version       string             `json:"version,omitempty"`
+	Capabilities  []string           `json:"capabilities,omitempty"`
+	SessionStart  time.Time          `json:"session_start,omitzero"`
+}
+
+type snapshotPlacement struct {
+	Namespace string    `json:"namespace,omitempty"`
+	AgentID   string    `json:"agent_id"`
+	RelayID   string    `json:"relay_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Snapshot writes the current relays, agents and placements to the
+// configured snapshot path. The file is replaced atomically so a crash
+// mid-write never leaves a truncated snapshot behind. When a write-ahead
+// log is enabled, mutations are paused only while the state is captured;
+// once the snapshot is on disk the log records it covers are dropped. It
+// is a no-op when snapshots are disabled.
+func (b *Backend) Snapshot(ctx context.Context) error {
+	if b.cfg == nil || b.cfg.SnapshotPath == "" {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	b.snapshotMu.Lock()
+	defer b.snapshotMu.Unlock()
+
+	var (
+		snap *snapshot
+		mark walMark
+	)
+	if b.wal != nil {
+		var err error
+		b.wal.mu.Lock()
+		snap = b.captureSnapshot(time.Now())
+		mark, err = b.wal.markLocked()
+		b.wal.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		snap.WALSeq = mark.seq
+	} else {
+		snap = b.captureSnapshot(time.Now())
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("encode memory snapshot: %w", err)
+	}
+
+	if err := writeFileAtomic(b.cfg.SnapshotPath, data); err != nil {
+		return err
+	}
+
+	if b.wal != nil {
+		return b.wal.compact(mark)
+	}
+
+	return nil
+}
+
+func (b *Backend) captureSnapshot(now time.Time) *snapshot {
+	b.relayMu.RLock()
+	defer b.relayMu.RUnlock()
+	b.agentMu.RLock()
+	defer b.agentMu.RUnlock()
+
+	snap := &snapshot{
+		Version:    snapshotVersion,
+		TakenAt:    now,
+		Relays:     []snapshotRelay{},
+		Agents:     []snapshotAgent{},
+		Placements: []snapshotPlacement{},
+	}
+
+	for namespace, ns := range b.namespaces {
+		ns.capture(snap, namespace)
+	}
+
+	return snap
+}
+
+func (ns *namespaceState) capture(snap *snapshot, namespace string) {
+	for _, entry := range ns.relays {
+		entry.mu.Lock()
+		snap.Relays = append(snap.Relays, snapshotRelay{
+			Namespace: namespace,
+			ID:        entry.relay.ID,
+			Address:   entry.relay.Address,
+			GRPCPort:  entry.relay.GRPCPort,
+			LastSeen:  entry.relay.LastSeen,
+		})
+		entry.mu.Unlock()
+	}
+
+	for _, entry := range ns.agents {
+		entry.mu.Lock()
+		snap.Agents = append(snap.Agents, snapshotAgent{
+			Namespace:     namespace,
+			ID:            entry.agent.ID,
+			LastHeartbeat: entry.agent.LastHeartbeat,
+			Kind:          entry.agent.Metadata.Kind,
+			Version:       entry.agent.Metadata.Version,
+			Capabilities:  entry.agent.Metadata.Capabilities,
+			SessionStart:  entry.agent.Metadata.SessionStart,
+		})
+		entry.mu.Unlock()
+	}
+
+	for _, placement := range ns.placements {
+		snap.Placements = append(snap.Placements, snapshotPlacement{
+			Namespace: namespace,
+			AgentID:   placement.AgentID,
+			RelayID:   placement.RelayID,
+			UpdatedAt: placement.UpdatedAt,
+		})
+	}
+}
+
+// restoreSnapshot loads state from the configured snapshot path into an
+// empty backend and returns the last WAL sequence it includes. Persisted
+// heartbeat timestamps are kept as-is, so entries age by the time the
+// registry was down and the first TTL sweep removes anything that expired
+// in the meantime. A missing file is not an error.
+func (b *Backend) restoreSnapshot() (uint64, error) {
+	data, err := os.ReadFile(b.cfg.SnapshotPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read memory snapshot: %w", err)
+	}
+
+	snap, err := decodeSnapshot(data)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", b.cfg.SnapshotPath, err)
+	}
+
+	b.loadSnapshot(snap)
+
+	slog.Info("restored memory backend snapshot",
+		"path", b.cfg.SnapshotPath,
+		"snapshot_age", time.Since(snap.TakenAt).Round(time.Millisecond),
+		"relays", len(snap.Relays),
+		"agents", len(snap.Agents),
+	)
+
+	return snap.WALSeq, nil
+}
+
+// WriteSnapshot encodes the current relays, agents and placements to w in
+// the same format as the on-disk snapshot file.
+func (b *Backend) WriteSnapshot(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(b.captureSnapshot(time.Now())); err != nil {
+		return fmt.Errorf("encode memory snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreSnapshot replaces the backend state with a snapshot previously
+// produced by WriteSnapshot. Leases are left untouched.
+func (b *Backend) RestoreSnapshot(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read memory snapshot: %w", err)
+	}
+
+	snap, err := decodeSnapshot(data)
+	if err != nil {
+		return err
+	}
+
+	b.loadSnapshot(snap)
+
+	return nil
+}
+
+func decodeSnapshot(data []byte) (*snapshot, error) {
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("%w: %v", errSnapshotCorrupt, err)
+	}
+
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("%w: got %d, want %d", errSnapshotVersion, snap.Version, snapshotVersion)
+	}
+
+	return &snap, nil
+}
+
+// loadSnapshot replaces the relay, agent and placement state with snap.
+func (b *Backend) loadSnapshot(snap *snapshot) {
+	namespaces := make(map[string]*namespaceState)
+	namespace := func(name string) *namespaceState {
+		if name == "" {
+			name = registry.DefaultNamespace
+		}
+		ns, ok := namespaces[name]
+		if !ok {
+			ns = newNamespaceState()
+			namespaces[name] = ns
+		}
+		return ns
+	}
+
+	for _, relay := range snap.Relays {
+		namespace(relay.Namespace).relays[relay.ID] = &relayEntry{
+			relay: &registry.Relay{
+				ID:       relay.ID,
+				Address:  relay.Address,
+				GRPCPort: relay.GRPCPort,
+				LastSeen: relay.LastSeen,
+			},
+		}
+	}
+
+	for _, agent := range snap.Agents {
+		namespace(agent.Namespace).agents[agent.ID] = &agentEntry{
+			agent: &registry.Agent{
+				ID:            agent.ID,
+				LastHeartbeat: agent.LastHeartbeat,
+				Metadata: registry.AgentMetadata{
+					Kind:         agent.Kind,
+					Version:      agent.Version,
+					Capabilities: agent.Capabilities,
+					SessionStart: agent.SessionStart,
+				},
+			},
+		}
+	}
+
+	for _, placement := range snap.Placements {
+		ns := namespace(placement.Namespace)
+		entry, ok := ns.agents[placement.AgentID]
+		if !ok {
+			continue
+		}
+
+		ns.setPlacement(placement.AgentID, placement.RelayID, entry, placement.UpdatedAt)
+	}
+
+	b.relayMu.Lock()
+	defer b.relayMu.Unlock()
+	b.agentMu.Lock()
+	defer b.agentMu.Unlock()
+
+	b.namespaces = namespaces
+}
+
+// runSnapshots writes a snapshot every interval until stop is closed.
+func (b *Backend) runSnapshots(interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := b.Snapshot(context.Background()); err != nil {
+				slog.Error("failed to write memory snapshot",
+					"path", b.cfg.SnapshotPath,
+					"error", err,
+				)
+			}
+		}
+	}
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create memory snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write memory snapshot: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync memory snapshot: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close memory snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace memory snapshot: %w", err)
+	}
+
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("sync memory snapshot directory: %w", err)
+	}
+
+	return nil
+}
+
+// syncDir flushes dir so a rename inside it survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
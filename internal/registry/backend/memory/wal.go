@@ -0,0 +1,459 @@
+package memory
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// defaultWALFlushInterval is how often batched heartbeats are appended to
+// the write-ahead log when MemoryConfig.WALFlushInterval is unset.
+const defaultWALFlushInterval = time.Second
+
+// walHeaderSize is the per-record frame header: a big-endian uint32 payload
+// length followed by a big-endian uint32 CRC-32C of the payload.
+const walHeaderSize = 8
+
+var walCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// walRecord frames a Mutation with the log sequence number assigned when it
+// was appended.
+type walRecord struct {
+	Seq uint64 `json:"seq"`
+	Mutation
+}
+
+// wal is an append-only log of backend mutations. Registrations and
+// removals are appended and synced before the call returns; heartbeats are
+// coalesced in memory and appended as one batch record per namespace on
+// each flush.
+//
+// mu is held across applying a mutation and appending its record so the
+// log order always matches the order mutations were applied in memory. A
+// mutation whose record cannot be appended is reverted before mu is
+// released, so memory never holds state the log would lose on restart.
+// Heartbeats are applied under mu as well, so a revert never overwrites
+// one that landed while the failed append was in progress.
+type wal struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	seq  uint64
+
+	// Pending heartbeats, keyed by namespace and then by entry ID.
+	relayHeartbeats map[string]map[string]time.Time
+	agentHeartbeats map[string]map[string]time.Time
+}
+
+// openWAL opens (or creates) the log at path and returns it along with the
+// records it holds. A torn or corrupt tail, as left by a crash mid-append,
+// is truncated away; every record before it is kept. The caller must hold
+// the backend's state lock.
+func openWAL(path string) (*wal, []walRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("read memory wal: %w", err)
+	}
+
+	records, valid := decodeWAL(data)
+	if valid < len(data) {
+		slog.Warn("truncating corrupt memory wal tail",
+			"path", path,
+			"valid_bytes", valid,
+			"discarded_bytes", len(data)-valid,
+		)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open memory wal: %w", err)
+	}
+
+	if err := file.Truncate(int64(valid)); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("truncate memory wal: %w", err)
+	}
+
+	if _, err := file.Seek(int64(valid), io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("seek memory wal: %w", err)
+	}
+
+	w := &wal{
+		path:            path,
+		file:            file,
+		relayHeartbeats: make(map[string]map[string]time.Time),
+		agentHeartbeats: make(map[string]map[string]time.Time),
+	}
+	if len(records) > 0 {
+		w.seq = records[len(records)-1].Seq
+	}
+
+	return w, records, nil
+}
+
+// decodeWAL parses framed records from data and returns them together with
+// the length of the valid prefix.
+func decodeWAL(data []byte) ([]walRecord, int) {
+	var records []walRecord
+
+	offset := 0
+	for len(data)-offset >= walHeaderSize {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		checksum := binary.BigEndian.Uint32(data[offset+4:])
+
+		start := offset + walHeaderSize
+		if length > len(data)-start {
+			break
+		}
+
+		payload := data[start : start+length]
+		if crc32.Checksum(payload, walCRCTable) != checksum {
+			break
+		}
+
+		var rec walRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			break
+		}
+
+		records = append(records, rec)
+		offset = start + length
+	}
+
+	return records, offset
+}
+
+// appendLocked assigns the next sequence number to m and durably appends
+// it. w.mu must be held.
+func (w *wal) appendLocked(m Mutation) error {
+	rec := walRecord{Seq: w.seq + 1, Mutation: m}
+
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode memory wal record: %w", err)
+	}
+
+	frame := make([]byte, walHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:], crc32.Checksum(payload, walCRCTable))
+	copy(frame[walHeaderSize:], payload)
+
+	offset, err := w.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("append memory wal: %w", err)
+	}
+
+	if _, err := w.file.Write(frame); err != nil {
+		w.discardFrom(offset)
+		return fmt.Errorf("append memory wal: %w", err)
+	}
+
+	if err := w.file.Sync(); err != nil {
+		w.discardFrom(offset)
+		return fmt.Errorf("sync memory wal: %w", err)
+	}
+
+	w.seq = rec.Seq
+
+	return nil
+}
+
+// discardFrom drops a partially written or unsynced record starting at
+// offset, so records appended after it are not hidden behind a torn frame
+// on replay. w.mu must be held.
+func (w *wal) discardFrom(offset int64) {
+	if err := w.file.Truncate(offset); err != nil {
+		slog.Error("failed to discard memory wal record",
+			"path", w.path,
+			"error", err,
+		)
+	}
+
+	if _, err := w.file.Seek(offset, io.SeekStart); err != nil {
+		slog.Error("failed to discard memory wal record",
+			"path", w.path,
+			"error", err,
+		)
+	}
+}
+
+// flushLocked appends pending heartbeats as one batch record per
+// namespace, in namespace order. w.mu must be held.
+func (w *wal) flushLocked() error {
+	if len(w.relayHeartbeats) == 0 && len(w.agentHeartbeats) == 0 {
+		return nil
+	}
+
+	relayHeartbeats, agentHeartbeats := w.relayHeartbeats, w.agentHeartbeats
+	w.relayHeartbeats = make(map[string]map[string]time.Time)
+	w.agentHeartbeats = make(map[string]map[string]time.Time)
+
+	namespaces := make([]string, 0, len(relayHeartbeats)+len(agentHeartbeats))
+	for namespace := range relayHeartbeats {
+		namespaces = append(namespaces, namespace)
+	}
+	for namespace := range agentHeartbeats {
+		if _, ok := relayHeartbeats[namespace]; !ok {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	slices.Sort(namespaces)
+
+	for i, namespace := range namespaces {
+		m := Mutation{
+			Op:              MutationHeartbeats,
+			Namespace:       namespace,
+			RelayHeartbeats: relayHeartbeats[namespace],
+			AgentHeartbeats: agentHeartbeats[namespace],
+		}
+		if err := w.appendLocked(m); err != nil {
+			// Memory already holds these heartbeats, so keep the batches
+			// not yet appended pending for the next flush.
+			for _, namespace := range namespaces[i:] {
+				for id, at := range relayHeartbeats[namespace] {
+					recordHeartbeat(w.relayHeartbeats, namespace, id, at)
+				}
+				for id, at := range agentHeartbeats[namespace] {
+					recordHeartbeat(w.agentHeartbeats, namespace, id, at)
+				}
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walMark is the end of the log at the moment a snapshot was captured:
+// the sequence number of the last record and the file offset just past it.
+type walMark struct {
+	seq    uint64
+	offset int64
+}
+
+// markLocked returns the current end of the log. w.mu must be held.
+func (w *wal) markLocked() (walMark, error) {
+	offset, err := w.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return walMark{}, fmt.Errorf("mark memory wal: %w", err)
+	}
+
+	return walMark{seq: w.seq, offset: offset}, nil
+}
+
+// compact drops the records up to mark after they have been captured in a
+// snapshot. Records appended since are copied to a new log that atomically
+// replaces the old one. Pending heartbeats are kept: flushing those that
+// are already in the snapshot only replays the same timestamps.
+func (w *wal) compact(mark walMark) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	end, err := w.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("compact memory wal: %w", err)
+	}
+
+	if end == mark.offset {
+		if err := w.file.Truncate(0); err != nil {
+			return fmt.Errorf("compact memory wal: %w", err)
+		}
+		if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("compact memory wal: %w", err)
+		}
+		return nil
+	}
+
+	tail := make([]byte, end-mark.offset)
+	if _, err := w.file.ReadAt(tail, mark.offset); err != nil {
+		return fmt.Errorf("compact memory wal: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), filepath.Base(w.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("compact memory wal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(tail); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact memory wal: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact memory wal: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), w.path); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact memory wal: %w", err)
+	}
+
+	// The renamed file is positioned at its end, ready for the next append.
+	w.file.Close()
+	w.file = tmp
+
+	if err := syncDir(filepath.Dir(w.path)); err != nil {
+		return fmt.Errorf("compact memory wal: %w", err)
+	}
+
+	return nil
+}
+
+func (w *wal) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.file.Close()
+}
+
+// logged applies a mutation and appends m to the write-ahead log when it
+// succeeds. Applying first keeps rejected mutations, such as registrations
+// over a capacity limit, out of the log. When the append fails the
+// mutation is reverted and the append error returned. Without a WAL it
+// simply applies the mutation.
+func (b *Backend) logged(m Mutation, apply func() error) error {
+	if b.wal == nil {
+		return apply()
+	}
+
+	b.wal.mu.Lock()
+	defer b.wal.mu.Unlock()
+
+	prior := b.captureUndo(m)
+	if err := apply(); err != nil {
+		return err
+	}
+
+	if err := b.wal.appendLocked(m); err != nil {
+		b.restore(prior)
+		return err
+	}
+
+	return nil
+}
+
+// loggedRelayHeartbeat applies a relay heartbeat and queues it for the
+// next flush. The heartbeat is applied under the WAL lock, so reverting a
+// mutation whose append failed cannot put back a LastSeen older than it.
+func (b *Backend) loggedRelayHeartbeat(namespace, relayID string, at time.Time) error {
+	if b.wal == nil {
+		return b.heartbeatRelay(namespace, relayID, at)
+	}
+
+	b.wal.mu.Lock()
+	defer b.wal.mu.Unlock()
+
+	if err := b.heartbeatRelay(namespace, relayID, at); err != nil {
+		return err
+	}
+	recordHeartbeat(b.wal.relayHeartbeats, namespace, relayID, at)
+
+	return nil
+}
+
+// loggedAgentHeartbeat is loggedRelayHeartbeat for agents.
+func (b *Backend) loggedAgentHeartbeat(namespace, agentID string, at time.Time) error {
+	if b.wal == nil {
+		return b.heartbeatAgent(namespace, agentID, at)
+	}
+
+	b.wal.mu.Lock()
+	defer b.wal.mu.Unlock()
+
+	if err := b.heartbeatAgent(namespace, agentID, at); err != nil {
+		return err
+	}
+	recordHeartbeat(b.wal.agentHeartbeats, namespace, agentID, at)
+
+	return nil
+}
+
+func recordHeartbeat(pending map[string]map[string]time.Time, namespace, id string, at time.Time) {
+	beats, ok := pending[namespace]
+	if !ok {
+		beats = make(map[string]time.Time)
+		pending[namespace] = beats
+	}
+	beats[id] = at
+}
+
+// flushHeartbeats appends pending heartbeats to the write-ahead log.
+func (b *Backend) flushHeartbeats() error {
+	if b.wal == nil {
+		return nil
+	}
+
+	b.wal.mu.Lock()
+	defer b.wal.mu.Unlock()
+
+	return b.wal.flushLocked()
+}
+
+// replayWAL re-applies logged mutations newer than the restored snapshot.
+func (b *Backend) replayWAL(records []walRecord, afterSeq uint64) {
+	replayed := b.applyRecords(records, afterSeq)
+
+	if b.wal.seq < afterSeq {
+		b.wal.seq = afterSeq
+	}
+
+	if replayed > 0 {
+		slog.Info("replayed memory wal",
+			"path", b.wal.path,
+			"records", replayed,
+		)
+	}
+}
+
+// applyRecords applies the records logged after afterSeq and returns how
+// many it applied.
+func (b *Backend) applyRecords(records []walRecord, afterSeq uint64) int {
+	applied := 0
+	for _, rec := range records {
+		if rec.Seq <= afterSeq {
+			continue
+		}
+
+		// Records that no longer apply, for example a heartbeat for a relay
+		// removed later in the log, are skipped.
+		_ = b.applyMutation(rec.Mutation)
+		applied++
+	}
+
+	return applied
+}
+
+// runWALFlush appends batched heartbeats every interval until stop is closed.
+func (b *Backend) runWALFlush(interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := b.flushHeartbeats(); err != nil {
+				slog.Error("failed to flush memory wal heartbeats",
+					"path", b.wal.path,
+					"error", err,
+				)
+			}
+		}
+	}
+}
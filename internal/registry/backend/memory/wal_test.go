@@ -0,0 +1,535 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+func TestWALReplaysMutationsAfterCrash(t *testing.T) {
+	cfg := newWALTestConfig(t)
+	ctx := context.Background()
+
+	backend, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1", Address: "10.0.0.1", GRPCPort: 9000}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-2", Address: "10.0.0.2", GRPCPort: 9000}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-2"}, "relay-2"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RemoveAgents(ctx, registry.DefaultNamespace, []string{"agent-2"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RemoveRelay(ctx, registry.DefaultNamespace, "relay-2"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	simulateCrash(t, backend)
+
+	restored, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	defer restored.Close(ctx)
+
+	relays, err := restored.ListRelays(ctx, registry.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(relays) != 1 || relays[0].ID != "relay-1" || relays[0].Address != "10.0.0.1" {
+		t.Fatalf("unexpected restored relays: %+v", relays)
+	}
+
+	placement, err := restored.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if placement.RelayID != "relay-1" {
+		t.Fatalf("expected placement on relay-1, got %q", placement.RelayID)
+	}
+
+	if _, err := restored.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-2"); err == nil {
+		t.Fatalf("expected removed agent to stay removed after replay")
+	}
+}
+
+func TestWALReplaysHeartbeatBatches(t *testing.T) {
+	cfg := newWALTestConfig(t)
+	ctx := context.Background()
+
+	backend, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := backend.HeartbeatRelay(ctx, registry.DefaultNamespace, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.HeartbeatAgent(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.flushHeartbeats(); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	relays, _ := backend.ListRelays(ctx, registry.DefaultNamespace)
+	agents, _ := backend.ListAgents(ctx, registry.DefaultNamespace)
+	wantRelaySeen := relays[0].LastSeen
+	wantAgentSeen := agents[0].LastHeartbeat
+
+	simulateCrash(t, backend)
+
+	restored, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	defer restored.Close(ctx)
+
+	relays, _ = restored.ListRelays(ctx, registry.DefaultNamespace)
+	if !relays[0].LastSeen.Equal(wantRelaySeen) {
+		t.Fatalf("expected relay LastSeen %v, got %v", wantRelaySeen, relays[0].LastSeen)
+	}
+
+	agents, _ = restored.ListAgents(ctx, registry.DefaultNamespace)
+	if !agents[0].LastHeartbeat.Equal(wantAgentSeen) {
+		t.Fatalf("expected agent LastHeartbeat %v, got %v", wantAgentSeen, agents[0].LastHeartbeat)
+	}
+}
+
+func TestWALTruncatesTornTail(t *testing.T) {
+	cfg := newWALTestConfig(t)
+	ctx := context.Background()
+
+	backend, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	simulateCrash(t, backend)
+
+	info, err := os.Stat(cfg.WALPath)
+	if err != nil {
+		t.Fatalf("stat wal: %v", err)
+	}
+	validSize := info.Size()
+
+	f, err := os.OpenFile(cfg.WALPath, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("open wal: %v", err)
+	}
+	if _, err := f.Write([]byte{0, 0, 0, 42, 1, 2, 3, 4, '{'}); err != nil {
+		t.Fatalf("write torn tail: %v", err)
+	}
+	f.Close()
+
+	restored, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected torn tail to be tolerated, got %v", err)
+	}
+
+	relays, _ := restored.ListRelays(ctx, registry.DefaultNamespace)
+	if len(relays) != 1 {
+		t.Fatalf("expected 1 relay, got %d", len(relays))
+	}
+
+	info, err = os.Stat(cfg.WALPath)
+	if err != nil {
+		t.Fatalf("stat wal: %v", err)
+	}
+	if info.Size() != validSize {
+		t.Fatalf("expected wal truncated to %d bytes, got %d", validSize, info.Size())
+	}
+
+	if err := restored.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-2"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	simulateCrash(t, restored)
+
+	again, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	defer again.Close(ctx)
+
+	relays, _ = again.ListRelays(ctx, registry.DefaultNamespace)
+	if len(relays) != 2 {
+		t.Fatalf("expected records appended after truncation to replay, got %d relays", len(relays))
+	}
+}
+
+func TestSnapshotCompactsWAL(t *testing.T) {
+	cfg := newWALTestConfig(t)
+	ctx := context.Background()
+
+	backend, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := backend.Snapshot(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	info, err := os.Stat(cfg.WALPath)
+	if err != nil {
+		t.Fatalf("stat wal: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Fatalf("expected wal compacted after snapshot, got %d bytes", info.Size())
+	}
+
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-2"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	simulateCrash(t, backend)
+
+	restored, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	defer restored.Close(ctx)
+
+	relays, _ := restored.ListRelays(ctx, registry.DefaultNamespace)
+	if len(relays) != 2 {
+		t.Fatalf("expected snapshot plus wal to restore 2 relays, got %d", len(relays))
+	}
+}
+
+func TestWALCompactionKeepsRecordsAfterMark(t *testing.T) {
+	cfg := newWALTestConfig(t)
+	ctx := context.Background()
+
+	backend, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	defer backend.Close(ctx)
+
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	backend.wal.mu.Lock()
+	mark, err := backend.wal.markLocked()
+	backend.wal.mu.Unlock()
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	// relay-2 is registered between capturing a snapshot and compacting
+	// the log, so it must survive compaction.
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-2"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.wal.compact(mark); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-3"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	data, err := os.ReadFile(cfg.WALPath)
+	if err != nil {
+		t.Fatalf("read wal: %v", err)
+	}
+	records, valid := decodeWAL(data)
+	if valid != len(data) || len(records) != 2 {
+		t.Fatalf("expected 2 intact records after compaction, got %d (%d of %d bytes valid)", len(records), valid, len(data))
+	}
+	if records[0].Relay.ID != "relay-2" || records[0].Seq != mark.seq+1 {
+		t.Fatalf("expected relay-2 at seq %d first, got %+v", mark.seq+1, records[0])
+	}
+	if records[1].Relay.ID != "relay-3" {
+		t.Fatalf("expected relay-3 appended to the compacted log, got %+v", records[1])
+	}
+}
+
+func newWALTestConfig(t *testing.T) *registry.MemoryConfig {
+	t.Helper()
+
+	dir := t.TempDir()
+	return &registry.MemoryConfig{
+		SnapshotPath:     filepath.Join(dir, "registry.snapshot"),
+		SnapshotInterval: time.Hour,
+		WALPath:          filepath.Join(dir, "registry.wal"),
+		WALFlushInterval: time.Hour,
+	}
+}
+
+// simulateCrash stops the backend's background goroutines and releases the
+// log file without writing the final snapshot Close would.
+func simulateCrash(t *testing.T, b *Backend) {
+	t.Helper()
+
+	b.closeOnce.Do(func() {
+		close(b.snapshotStop)
+		<-b.snapshotDone
+		close(b.walStop)
+		<-b.walDone
+
+		if err := b.wal.close(); err != nil {
+			t.Fatalf("close wal: %v", err)
+		}
+
+		// The lock dies with the process.
+		b.lock.Close()
+	})
+}
+
+func TestWALReplaysNamespaces(t *testing.T) {
+	cfg := newWALTestConfig(t)
+	ctx := context.Background()
+
+	backend, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	for _, namespace := range []string{"fleet-a", "fleet-b"} {
+		if err := backend.RegisterRelay(ctx, namespace, registry.Relay{ID: "relay-1"}); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+	time.Sleep(time.Millisecond)
+	if err := backend.HeartbeatRelay(ctx, "fleet-a", "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RemoveRelay(ctx, "fleet-b", "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.flushHeartbeats(); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	relays, _ := backend.ListRelays(ctx, "fleet-a")
+	wantSeen := relays[0].LastSeen
+
+	simulateCrash(t, backend)
+
+	restored, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	defer restored.Close(ctx)
+
+	relays, _ = restored.ListRelays(ctx, "fleet-a")
+	if len(relays) != 1 || !relays[0].LastSeen.Equal(wantSeen) {
+		t.Fatalf("expected the fleet-a relay seen at %v, got %+v", wantSeen, relays)
+	}
+	if relays, _ := restored.ListRelays(ctx, "fleet-b"); len(relays) != 0 {
+		t.Fatalf("expected the fleet-b relay to stay removed, got %+v", relays)
+	}
+}
+
+func TestWALAppendFailureRevertsMutation(t *testing.T) {
+	cfg := newWALTestConfig(t)
+	ctx := context.Background()
+
+	backend, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1", Address: "10.0.0.1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-2"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	// Closing the log file makes every later append fail.
+	simulateCrash(t, backend)
+
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1", Address: "10.0.0.9"}); err == nil {
+		t.Fatalf("expected the failed append to be reported")
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-3"}); err == nil {
+		t.Fatalf("expected the failed append to be reported")
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-2"); err == nil {
+		t.Fatalf("expected the failed append to be reported")
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-2"}, "relay-2"); err == nil {
+		t.Fatalf("expected the failed append to be reported")
+	}
+	if err := backend.RemoveAgents(ctx, registry.DefaultNamespace, []string{"agent-1"}); err == nil {
+		t.Fatalf("expected the failed append to be reported")
+	}
+	if err := backend.RemoveRelay(ctx, registry.DefaultNamespace, "relay-1"); err == nil {
+		t.Fatalf("expected the failed append to be reported")
+	}
+
+	relays, _ := backend.ListRelays(ctx, registry.DefaultNamespace)
+	if len(relays) != 2 {
+		t.Fatalf("expected the two logged relays, got %+v", relays)
+	}
+	for _, relay := range relays {
+		if relay.ID == "relay-1" && relay.Address != "10.0.0.1" {
+			t.Fatalf("expected the relay-1 update to be reverted, got %+v", relay)
+		}
+	}
+
+	agents, _ := backend.ListAgents(ctx, registry.DefaultNamespace)
+	if len(agents) != 1 || agents[0].ID != "agent-1" {
+		t.Fatalf("expected only agent-1, got %+v", agents)
+	}
+
+	placement, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if placement.RelayID != "relay-1" {
+		t.Fatalf("expected agent-1 to stay on relay-1, got %q", placement.RelayID)
+	}
+
+	relayAgents, _ := backend.ListRelayAgents(ctx, registry.DefaultNamespace, "relay-1")
+	if len(relayAgents) != 1 {
+		t.Fatalf("expected agent-1 to stay listed on relay-1, got %+v", relayAgents)
+	}
+	if relayAgents, _ := backend.ListRelayAgents(ctx, registry.DefaultNamespace, "relay-2"); len(relayAgents) != 0 {
+		t.Fatalf("expected no agents on relay-2, got %+v", relayAgents)
+	}
+}
+
+func TestWALFlushFailureKeepsHeartbeatsPending(t *testing.T) {
+	cfg := newWALTestConfig(t)
+	ctx := context.Background()
+
+	backend, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	for _, namespace := range []string{"fleet-a", "fleet-b"} {
+		if err := backend.RegisterRelay(ctx, namespace, registry.Relay{ID: "relay-1"}); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if err := backend.HeartbeatRelay(ctx, namespace, "relay-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	// Closing the log file makes every later append fail.
+	simulateCrash(t, backend)
+
+	if err := backend.flushHeartbeats(); err == nil {
+		t.Fatalf("expected the failed flush to be reported")
+	}
+
+	for _, namespace := range []string{"fleet-a", "fleet-b"} {
+		if _, ok := backend.wal.relayHeartbeats[namespace]["relay-1"]; !ok {
+			t.Fatalf("expected the %s heartbeat to stay pending, got %+v", namespace, backend.wal.relayHeartbeats)
+		}
+	}
+}
+
+func TestWALHasASingleWriter(t *testing.T) {
+	cfg := newWALTestConfig(t)
+	ctx := context.Background()
+
+	backend, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if _, err := New(cfg); !errors.Is(err, errStateLocked) {
+		t.Fatalf("expected errStateLocked while the wal is open, got %v", err)
+	}
+
+	if err := backend.Close(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	reopened, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected the wal to open once released, got %v", err)
+	}
+	defer reopened.Close(ctx)
+}
+
+func TestOpenReadOnlyLeavesFilesAlone(t *testing.T) {
+	cfg := newWALTestConfig(t)
+	ctx := context.Background()
+
+	backend, err := New(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if _, err := OpenReadOnly(cfg); !errors.Is(err, errStateLocked) {
+		t.Fatalf("expected errStateLocked while the backend is open, got %v", err)
+	}
+
+	simulateCrash(t, backend)
+
+	f, err := os.OpenFile(cfg.WALPath, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("open wal: %v", err)
+	}
+	if _, err := f.Write([]byte{0, 0, 0, 42, 1, 2, 3, 4, '{'}); err != nil {
+		t.Fatalf("write torn tail: %v", err)
+	}
+	f.Close()
+
+	wal, err := os.ReadFile(cfg.WALPath)
+	if err != nil {
+		t.Fatalf("read wal: %v", err)
+	}
+
+	readOnly, err := OpenReadOnly(cfg)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	relays, _ := readOnly.ListRelays(ctx, registry.DefaultNamespace)
+	if len(relays) != 1 {
+		t.Fatalf("expected 1 relay, got %d", len(relays))
+	}
+
+	if err := readOnly.Close(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	after, err := os.ReadFile(cfg.WALPath)
+	if err != nil {
+		t.Fatalf("read wal: %v", err)
+	}
+	if string(after) != string(wal) {
+		t.Fatalf("expected the wal untouched, was %d bytes and is %d", len(wal), len(after))
+	}
+	if _, err := os.Stat(cfg.SnapshotPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no snapshot written on close, got %v", err)
+	}
+}
@@ -0,0 +1,457 @@
+// Package raft provides an embedded, replicated registry backend. It runs
+// the in-memory backend's state machine on every node of a small Raft
+// cluster (typically three or five registry replicas), so registrations
+// survive the loss of a minority of nodes without an external store.
+//
+// Reads are served from the local replica. Writes are committed through
+// the Raft log; followers forward them to the current leader over the same
+// port used for replication. Heartbeats for entries the local replica knows
+// are coalesced and committed in batches.
+package raft
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+	"github.com/hashicorp/go-hclog"
+	hraft "github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// defaultApplyTimeout bounds a write when RaftConfig.ApplyTimeout is unset.
+	defaultApplyTimeout = 5 * time.Second
+
+	// leaderRetryInterval is the delay between attempts to reach a leader
+	// while an election is in progress.
+	leaderRetryInterval = 50 * time.Millisecond
+
+	// raftStoreLockTimeout bounds waiting for the log store's file lock,
+	// which another process holding the data dir keeps until it exits.
+	raftStoreLockTimeout = time.Second
+
+	raftTransportTimeout = 10 * time.Second
+	raftTransportPool    = 3
+	raftSnapshotsRetain  = 2
+)
+
+type Backend struct {
+	cfg          *registry.RaftConfig
+	applyTimeout time.Duration
+
+	state     *memory.Backend
+	raft      *hraft.Raft
+	store     *raftboltdb.BoltStore
+	mux       *muxTransport
+	transport *hraft.NetworkTransport
+	forwarder *forwarder
+
+	// heartbeats holds beats waiting for the next flush. flushStop and
+	// flushDone control the flush goroutine.
+	heartbeats *heartbeatBatch
+	flushStop  chan struct{}
+	flushDone  chan struct{}
+	closeOnce  sync.Once
+	closeErr   error
+}
+
+func New(cfg *registry.RaftConfig) (*Backend, error) {
+	if cfg == nil {
+		return nil, registry.ErrRaftConfigNil
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", cfg.BindAddress)
+	if err != nil {
+		return nil, fmt.Errorf("raft listen on %s: %w", cfg.BindAddress, err)
+	}
+
+	return newWithListener(cfg, listener, nil)
+}
+
+// newWithListener builds a node on an already bound listener. tune, when
+// set, adjusts the Raft configuration before the node starts.
+func newWithListener(cfg *registry.RaftConfig, listener net.Listener, tune func(*hraft.Config)) (*Backend, error) {
+	peers, err := cfg.ParsedPeers()
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	advertise := cfg.AdvertiseAddress
+	if advertise == "" {
+		advertise = cfg.BindAddress
+	}
+	advertiseAddr, err := net.ResolveTCPAddr("tcp", advertise)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("resolve raft advertise address %s: %w", advertise, err)
+	}
+
+	if err := os.MkdirAll(cfg.DataDir, 0o700); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("create raft data dir: %w", err)
+	}
+
+	logger := newLogger()
+
+	state, err := memory.New(nil)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	b := &Backend{
+		cfg:          cfg,
+		applyTimeout: cfg.ApplyTimeout,
+		state:        state,
+		heartbeats:   newHeartbeatBatch(),
+	}
+	if b.applyTimeout <= 0 {
+		b.applyTimeout = defaultApplyTimeout
+	}
+
+	b.mux = newMuxTransport(listener, advertiseAddr, b.serveForward)
+	b.forwarder = newForwarder(func(address string) (net.Conn, error) {
+		return b.mux.dial(address, connTypeForward, forwardDialTimeout)
+	})
+	b.transport = hraft.NewNetworkTransportWithConfig(&hraft.NetworkTransportConfig{
+		Stream:  &raftStream{mux: b.mux},
+		MaxPool: raftTransportPool,
+		Timeout: raftTransportTimeout,
+		Logger:  logger,
+	})
+
+	if err := b.start(peers, logger, tune); err != nil {
+		_ = b.transport.Close()
+		if b.store != nil {
+			_ = b.store.Close()
+		}
+		return nil, err
+	}
+
+	flushInterval := cfg.HeartbeatFlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultHeartbeatFlushInterval
+	}
+
+	b.flushStop = make(chan struct{})
+	b.flushDone = make(chan struct{})
+	go b.runHeartbeatFlush(flushInterval, b.flushStop, b.flushDone)
+
+	return b, nil
+}
+
+func (b *Backend) start(peers []registry.RaftPeer, logger hclog.Logger, tune func(*hraft.Config)) error {
+	store, err := raftboltdb.New(raftboltdb.Options{
+		Path:        filepath.Join(b.cfg.DataDir, "raft.db"),
+		BoltOptions: &bbolt.Options{Timeout: raftStoreLockTimeout},
+	})
+	if errors.Is(err, bbolt.ErrTimeout) {
+		return fmt.Errorf("%w: %s", errDataDirLocked, b.cfg.DataDir)
+	}
+	if err != nil {
+		return fmt.Errorf("open raft log store: %w", err)
+	}
+	b.store = store
+
+	snapshots, err := hraft.NewFileSnapshotStoreWithLogger(b.cfg.DataDir, raftSnapshotsRetain, logger)
+	if err != nil {
+		return fmt.Errorf("open raft snapshot store: %w", err)
+	}
+
+	conf := hraft.DefaultConfig()
+	conf.LocalID = hraft.ServerID(b.cfg.NodeID)
+	conf.Logger = logger
+	if tune != nil {
+		tune(conf)
+	}
+
+	existing, err := hraft.HasExistingState(store, store, snapshots)
+	if err != nil {
+		return fmt.Errorf("inspect raft state: %w", err)
+	}
+
+	r, err := hraft.NewRaft(conf, &fsm{state: b.state}, store, store, snapshots, b.transport)
+	if err != nil {
+		return fmt.Errorf("start raft: %w", err)
+	}
+	b.raft = r
+
+	if existing {
+		return nil
+	}
+
+	// Every node bootstraps with the same static membership; Raft treats
+	// identical bootstrap configurations as safe and elects one leader.
+	servers := make([]hraft.Server, 0, len(peers))
+	for _, peer := range peers {
+		servers = append(servers, hraft.Server{
+			Suffrage: hraft.Voter,
+			ID:       hraft.ServerID(peer.ID),
+			Address:  hraft.ServerAddress(peer.Address),
+		})
+	}
+
+	err = r.BootstrapCluster(hraft.Configuration{Servers: servers}).Error()
+	if err != nil && !errors.Is(err, hraft.ErrCantBootstrap) {
+		_ = r.Shutdown().Error()
+		return fmt.Errorf("bootstrap raft cluster: %w", err)
+	}
+
+	return nil
+}
+
+// IsLeader reports whether this node is the current Raft leader.
+func (b *Backend) IsLeader() bool {
+	return b.raft.State() == hraft.Leader
+}
+
+// apply commits m through the Raft log, forwarding it to the leader when
+// this node is a follower. It retries while leadership is changing until
+// the apply timeout elapses.
+func (b *Backend) apply(ctx context.Context, m memory.Mutation) error {
+	ctx, cancel := context.WithTimeout(ctx, b.applyTimeout)
+	defer cancel()
+
+	for {
+		err := b.applyOnce(ctx, m)
+		if !errors.Is(err, errNotLeader) && !errors.Is(err, errForwardFailed) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%w: %v", errNoLeader, err)
+		case <-time.After(leaderRetryInterval):
+		}
+	}
+}
+
+func (b *Backend) applyOnce(ctx context.Context, m memory.Mutation) error {
+	if b.IsLeader() {
+		timeout := b.applyTimeout
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+		return b.applyLocal(m, timeout)
+	}
+
+	leader, _ := b.raft.LeaderWithID()
+	if leader == "" {
+		return errNotLeader
+	}
+
+	return b.forwarder.send(ctx, string(leader), m)
+}
+
+// applyLocal commits m on this node, which must be the leader.
+func (b *Backend) applyLocal(m memory.Mutation, timeout time.Duration) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errMutationEncode, err)
+	}
+
+	future := b.raft.Apply(data, timeout)
+	if err := future.Error(); err != nil {
+		if errors.Is(err, hraft.ErrNotLeader) || errors.Is(err, hraft.ErrLeadershipLost) {
+			return errNotLeader
+		}
+		return fmt.Errorf("raft apply: %w", err)
+	}
+
+	if err, ok := future.Response().(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+func (b *Backend) RegisterRelay(ctx context.Context, namespace string, relay registry.Relay) error {
+	return b.apply(ctx, memory.Mutation{
+		Op:        memory.MutationRegisterRelay,
+		Namespace: namespace,
+		Relay: &memory.MutationRelay{
+			ID:       relay.ID,
+			Address:  relay.Address,
+			GRPCPort: relay.GRPCPort,
+		},
+		At: time.Now(),
+	})
+}
+
+// HeartbeatRelay batches the heartbeat for the next flush. A relay this
+// replica does not know, for example because its registration has not
+// been applied here yet, is committed directly so the leader decides
+// whether it exists.
+func (b *Backend) HeartbeatRelay(ctx context.Context, namespace, relayID string) error {
+	now := time.Now()
+	if b.state.HasRelay(namespace, relayID) {
+		b.heartbeats.recordRelay(namespace, relayID, now)
+		return nil
+	}
+
+	return b.apply(ctx, memory.Mutation{
+		Op:              memory.MutationHeartbeats,
+		Namespace:       namespace,
+		RelayHeartbeats: map[string]time.Time{relayID: now},
+	})
+}
+
+func (b *Backend) ListRelays(ctx context.Context, namespace string) ([]registry.Relay, error) {
+	return b.state.ListRelays(ctx, namespace)
+}
+
+func (b *Backend) RemoveRelay(ctx context.Context, namespace, relayID string) error {
+	return b.apply(ctx, memory.Mutation{
+		Op:        memory.MutationRemoveRelay,
+		Namespace: namespace,
+		RelayID:   relayID,
+	})
+}
+
+func (b *Backend) RegisterAgent(ctx context.Context, namespace string, agent registry.Agent, relayID string) error {
+	return b.apply(ctx, memory.RegisterAgentMutation(namespace, agent, relayID, time.Now()))
+}
+
+// ImportRelay commits relay with relay.LastSeen as its last heartbeat.
+func (b *Backend) ImportRelay(ctx context.Context, namespace string, relay registry.Relay) error {
+	return b.apply(ctx, memory.ImportRelayMutation(namespace, relay))
+}
+
+// ImportAgent commits agent with placement, keeping its timestamps.
+func (b *Backend) ImportAgent(ctx context.Context, namespace string, agent registry.Agent, placement registry.AgentPlacement) error {
+	for _, m := range memory.ImportAgentMutations(namespace, agent, placement) {
+		if err := b.apply(ctx, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HeartbeatAgent batches the heartbeat like HeartbeatRelay.
+func (b *Backend) HeartbeatAgent(ctx context.Context, namespace, agentID string) error {
+	now := time.Now()
+	if b.state.HasAgent(namespace, agentID) {
+		b.heartbeats.recordAgent(namespace, agentID, now)
+		return nil
+	}
+
+	return b.apply(ctx, memory.Mutation{
+		Op:              memory.MutationHeartbeats,
+		Namespace:       namespace,
+		AgentHeartbeats: map[string]time.Time{agentID: now},
+	})
+}
+
+func (b *Backend) GetAgentPlacement(ctx context.Context, namespace, agentID string) (*registry.AgentPlacement, error) {
+	return b.state.GetAgentPlacement(ctx, namespace, agentID)
+}
+
+func (b *Backend) ListAgents(ctx context.Context, namespace string) ([]registry.Agent, error) {
+	return b.state.ListAgents(ctx, namespace)
+}
+
+func (b *Backend) ListRelayAgents(ctx context.Context, namespace, relayID string) ([]*registry.Agent, error) {
+	return b.state.ListRelayAgents(ctx, namespace, relayID)
+}
+
+func (b *Backend) RemoveAgents(ctx context.Context, namespace string, agentIDs []string) error {
+	return b.apply(ctx, memory.Mutation{
+		Op:        memory.MutationRemoveAgents,
+		Namespace: namespace,
+		AgentIDs:  agentIDs,
+	})
+}
+
+func (b *Backend) ListNamespaces(ctx context.Context) ([]string, error) {
+	return b.state.ListNamespaces(ctx)
+}
+
+// AcquireLease grants the lease only on the Raft leader. The Raft term is
+// used as the fencing token, so the TTL sweeper always runs on the current
+// leader and a deposed leader's lease cannot be renewed.
+func (b *Backend) AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (*registry.Lease, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if !b.IsLeader() {
+		return nil, errLeaseNotLeader
+	}
+
+	return &registry.Lease{
+		Name:      name,
+		HolderID:  holderID,
+		Token:     b.raft.CurrentTerm(),
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+func (b *Backend) RenewLease(ctx context.Context, lease registry.Lease, ttl time.Duration) (*registry.Lease, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if !b.IsLeader() || b.raft.CurrentTerm() != lease.Token {
+		return nil, errLeaseNotHeld
+	}
+
+	lease.ExpiresAt = time.Now().Add(ttl)
+
+	return &lease, nil
+}
+
+// ReleaseLease is a no-op: the lease ends when Raft leadership moves.
+func (b *Backend) ReleaseLease(ctx context.Context, lease registry.Lease) error {
+	return nil
+}
+
+// Close commits pending heartbeats while a leader is known and shuts the
+// node down. Beats that cannot be committed are dropped; relays and agents
+// keep heartbeating through the remaining nodes.
+func (b *Backend) Close(ctx context.Context) error {
+	b.closeOnce.Do(func() {
+		close(b.flushStop)
+		<-b.flushDone
+
+		if leader, _ := b.raft.LeaderWithID(); leader != "" {
+			if err := b.flushHeartbeats(ctx); err != nil {
+				slog.LogAttrs(ctx, slog.LevelWarn, "dropping raft heartbeat batch on close",
+					slog.String("method", "Close"),
+					slog.String("node_id", b.cfg.NodeID),
+					slog.Any("error", err),
+				)
+			}
+		}
+
+		err := b.raft.Shutdown().Error()
+		b.forwarder.close()
+
+		b.closeErr = errors.Join(err, b.transport.Close(), b.store.Close())
+	})
+
+	return b.closeErr
+}
@@ -0,0 +1,344 @@
+package raft
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+	hraft "github.com/hashicorp/raft"
+)
+
+var _ registry.Backend = (*Backend)(nil)
+var _ registry.StateImporter = (*Backend)(nil)
+
+func TestClusterReplicatesWritesFromFollowers(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	ctx := context.Background()
+
+	leader := waitForLeader(t, nodes)
+	follower := anyFollower(nodes, leader)
+
+	relay := registry.Relay{ID: "relay-1", Address: "10.0.0.1", GRPCPort: 9000}
+	if err := follower.RegisterRelay(ctx, registry.DefaultNamespace, relay); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := follower.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, relay.ID); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	for _, node := range nodes {
+		waitFor(t, func() bool {
+			placement, err := node.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1")
+			return err == nil && placement.RelayID == relay.ID
+		})
+
+		relays, err := node.ListRelays(ctx, registry.DefaultNamespace)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if len(relays) != 1 || relays[0].Address != relay.Address {
+			t.Fatalf("node %s: unexpected relays %+v", node.cfg.NodeID, relays)
+		}
+	}
+}
+
+func TestForwardedWritesPreserveErrors(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	ctx := context.Background()
+
+	leader := waitForLeader(t, nodes)
+	follower := anyFollower(nodes, leader)
+
+	err := follower.HeartbeatRelay(ctx, registry.DefaultNamespace, "missing")
+	if !errors.Is(err, registry.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	err = follower.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "missing")
+	if !errors.Is(err, registry.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestForwardErrorsRoundTrip(t *testing.T) {
+	for _, sentinel := range []error{
+		registry.ErrNotFound,
+		registry.ErrInvalid,
+		registry.ErrConflict,
+		registry.ErrUnavailable,
+		registry.ErrResourceExhausted,
+		registry.ErrPermissionDenied,
+		errNotLeader,
+	} {
+		err := decodeForwardError(encodeForwardError(fmt.Errorf("apply: %w", sentinel)))
+		if !errors.Is(err, sentinel) {
+			t.Fatalf("expected %v to survive forwarding, got %v", sentinel, err)
+		}
+	}
+}
+
+func TestForwardWithoutReplyIsNotRetried(t *testing.T) {
+	// The leader reads the write and drops the connection before answering,
+	// so the write may have been applied.
+	f := newForwarder(func(string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			var req forwardRequest
+			_ = json.NewDecoder(server).Decode(&req)
+		}()
+		return client, nil
+	})
+
+	err := f.send(context.Background(), "leader", memory.Mutation{Op: memory.MutationRemoveRelay, RelayID: "relay-1"})
+	if !errors.Is(err, errForwardNoReply) || errors.Is(err, errForwardFailed) {
+		t.Fatalf("expected errForwardNoReply, got %v", err)
+	}
+	if !errors.Is(err, registry.ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+}
+
+func TestHeartbeatsAreBatched(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	ctx := context.Background()
+
+	leader := waitForLeader(t, nodes)
+	follower := anyFollower(nodes, leader)
+
+	if err := leader.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := leader.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	waitFor(t, func() bool {
+		return follower.state.HasAgent(registry.DefaultNamespace, "agent-1")
+	})
+
+	relays, _ := leader.ListRelays(ctx, registry.DefaultNamespace)
+	registeredAt := relays[0].LastSeen
+	before := leader.raft.LastIndex()
+
+	time.Sleep(time.Millisecond)
+	for range 20 {
+		if err := follower.HeartbeatRelay(ctx, registry.DefaultNamespace, "relay-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if err := follower.HeartbeatAgent(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+	if got := leader.raft.LastIndex(); got != before {
+		t.Fatalf("expected heartbeats to wait for the flush, log grew from %d to %d", before, got)
+	}
+
+	if err := follower.flushHeartbeats(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := leader.raft.LastIndex(); got != before+1 {
+		t.Fatalf("expected one batch entry, log grew from %d to %d", before, got)
+	}
+
+	for _, node := range nodes {
+		waitFor(t, func() bool {
+			relays, err := node.ListRelays(ctx, registry.DefaultNamespace)
+			return err == nil && len(relays) == 1 && relays[0].LastSeen.After(registeredAt)
+		})
+	}
+}
+
+func TestClusterSurvivesLeaderLoss(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	ctx := context.Background()
+
+	leader := waitForLeader(t, nodes)
+	if err := leader.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := leader.Close(ctx); err != nil {
+		t.Fatalf("expected nil error closing leader, got %v", err)
+	}
+	if err := leader.Close(ctx); err != nil {
+		t.Fatalf("expected closing twice to return the first result, got %v", err)
+	}
+
+	var survivors []*Backend
+	for _, node := range nodes {
+		if node != leader {
+			survivors = append(survivors, node)
+		}
+	}
+
+	newLeader := waitForLeader(t, survivors)
+	if err := survivors[0].HeartbeatRelay(ctx, registry.DefaultNamespace, "relay-1"); err != nil {
+		t.Fatalf("expected heartbeat after failover, got %v", err)
+	}
+	if err := survivors[1].RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-2"}); err != nil {
+		t.Fatalf("expected write after failover, got %v", err)
+	}
+
+	waitFor(t, func() bool {
+		relays, err := newLeader.ListRelays(ctx, registry.DefaultNamespace)
+		return err == nil && len(relays) == 2
+	})
+}
+
+func TestSweeperLeaseFollowsLeadership(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	ctx := context.Background()
+
+	leader := waitForLeader(t, nodes)
+	follower := anyFollower(nodes, leader)
+
+	if _, err := follower.AcquireLease(ctx, "registry/ttl-sweeper", "follower", time.Minute); !errors.Is(err, registry.ErrConflict) {
+		t.Fatalf("expected ErrConflict on follower, got %v", err)
+	}
+
+	lease, err := leader.AcquireLease(ctx, "registry/ttl-sweeper", "leader", time.Minute)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if _, err := leader.RenewLease(ctx, *lease, time.Minute); err != nil {
+		t.Fatalf("expected renew on leader, got %v", err)
+	}
+
+	stale := *lease
+	stale.Token--
+	if _, err := leader.RenewLease(ctx, stale, time.Minute); !errors.Is(err, registry.ErrConflict) {
+		t.Fatalf("expected ErrConflict renewing stale term, got %v", err)
+	}
+}
+
+func TestFSMSnapshotRestore(t *testing.T) {
+	nodes := newTestCluster(t, 1)
+	ctx := context.Background()
+
+	node := waitForLeader(t, nodes)
+	if err := node.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := node.raft.Snapshot().Error(); err != nil {
+		t.Fatalf("expected snapshot, got %v", err)
+	}
+	if err := node.Close(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	listener, err := net.Listen("tcp", node.cfg.BindAddress)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	restarted, err := newWithListener(node.cfg, listener, fastRaftConfig)
+	if err != nil {
+		t.Fatalf("expected restart, got %v", err)
+	}
+	t.Cleanup(func() { _ = restarted.Close(context.Background()) })
+
+	waitFor(t, func() bool {
+		relays, err := restarted.ListRelays(ctx, registry.DefaultNamespace)
+		return err == nil && len(relays) == 1
+	})
+}
+
+func TestDataDirHasASingleNode(t *testing.T) {
+	nodes := newTestCluster(t, 1)
+	node := waitForLeader(t, nodes)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if _, err := newWithListener(node.cfg, listener, fastRaftConfig); !errors.Is(err, registry.ErrConflict) {
+		t.Fatalf("expected ErrConflict while the data dir is in use, got %v", err)
+	}
+}
+
+func newTestCluster(t *testing.T, size int) []*Backend {
+	t.Helper()
+
+	listeners := make([]net.Listener, size)
+	peers := make([]string, size)
+	for i := range size {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		listeners[i] = listener
+		peers[i] = fmt.Sprintf("node-%d=%s", i, listener.Addr().String())
+	}
+
+	dir := t.TempDir()
+	nodes := make([]*Backend, size)
+	for i := range size {
+		cfg := &registry.RaftConfig{
+			NodeID:      fmt.Sprintf("node-%d", i),
+			BindAddress: listeners[i].Addr().String(),
+			DataDir:     filepath.Join(dir, fmt.Sprintf("node-%d", i)),
+			Peers:       peers,
+		}
+
+		node, err := newWithListener(cfg, listeners[i], fastRaftConfig)
+		if err != nil {
+			t.Fatalf("start node %d: %v", i, err)
+		}
+		nodes[i] = node
+		t.Cleanup(func() { _ = node.Close(context.Background()) })
+	}
+
+	return nodes
+}
+
+func fastRaftConfig(conf *hraft.Config) {
+	conf.HeartbeatTimeout = 100 * time.Millisecond
+	conf.ElectionTimeout = 100 * time.Millisecond
+	conf.LeaderLeaseTimeout = 50 * time.Millisecond
+	conf.CommitTimeout = 5 * time.Millisecond
+}
+
+func waitForLeader(t *testing.T, nodes []*Backend) *Backend {
+	t.Helper()
+
+	var leader *Backend
+	waitFor(t, func() bool {
+		for _, node := range nodes {
+			if node.IsLeader() {
+				leader = node
+				return true
+			}
+		}
+		return false
+	})
+
+	return leader
+}
+
+func anyFollower(nodes []*Backend, leader *Backend) *Backend {
+	for _, node := range nodes {
+		if node != leader {
+			return node
+		}
+	}
+	return nil
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met before deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,19 @@
+package raft
+
+import (
+	"fmt"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+var (
+	errNotLeader       = fmt.Errorf("raft node is not the leader: %w", registry.ErrUnavailable)
+	errNoLeader        = fmt.Errorf("raft leader unavailable: %w", registry.ErrUnavailable)
+	errForwardFailed   = fmt.Errorf("forward to raft leader failed: %w", registry.ErrUnavailable)
+	errForwardNoReply  = fmt.Errorf("raft leader did not answer forwarded write: %w", registry.ErrUnavailable)
+	errLeaseNotLeader  = fmt.Errorf("sweeper lease follows raft leadership: %w", registry.ErrConflict)
+	errLeaseNotHeld    = fmt.Errorf("sweeper lease not held: %w", registry.ErrConflict)
+	errMutationEncode  = fmt.Errorf("encode raft mutation: %w", registry.ErrInvalid)
+	errTransportClosed = fmt.Errorf("raft transport closed: %w", registry.ErrUnavailable)
+	errDataDirLocked   = fmt.Errorf("raft data dir in use by another process: %w", registry.ErrConflict)
+)
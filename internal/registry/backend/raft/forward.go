@@ -0,0 +1,216 @@
+package raft
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+)
+
+// forwardDialTimeout bounds establishing a forwarding connection.
+const forwardDialTimeout = 2 * time.Second
+
+// maxIdleForwardConns caps the idle forwarding connections kept per leader.
+const maxIdleForwardConns = 4
+
+// Error kinds carried across the forwarding protocol so followers can
+// return the same registry sentinel errors as the leader.
+const (
+	forwardErrNotFound    = "not_found"
+	forwardErrInvalid     = "invalid"
+	forwardErrConflict    = "conflict"
+	forwardErrUnavailable = "unavailable"
+	forwardErrExhausted   = "resource_exhausted"
+	forwardErrDenied      = "permission_denied"
+	forwardErrNotLeader   = "not_leader"
+)
+
+type forwardRequest struct {
+	Mutation memory.Mutation `json:"mutation"`
+}
+
+type forwardResponse struct {
+	Error string `json:"error,omitempty"`
+	Kind  string `json:"kind,omitempty"`
+}
+
+// serveForward applies mutations forwarded by followers. Each connection
+// carries a sequence of newline-delimited JSON request/response pairs.
+func (b *Backend) serveForward(conn net.Conn) {
+	defer conn.Close()
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+
+	for {
+		var req forwardRequest
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+
+		err := b.applyLocal(req.Mutation, b.applyTimeout)
+		if err := enc.Encode(encodeForwardError(err)); err != nil {
+			return
+		}
+	}
+}
+
+func encodeForwardError(err error) forwardResponse {
+	if err == nil {
+		return forwardResponse{}
+	}
+
+	resp := forwardResponse{Error: err.Error()}
+	switch {
+	case errors.Is(err, errNotLeader):
+		resp.Kind = forwardErrNotLeader
+	case errors.Is(err, registry.ErrNotFound):
+		resp.Kind = forwardErrNotFound
+	case errors.Is(err, registry.ErrInvalid):
+		resp.Kind = forwardErrInvalid
+	case errors.Is(err, registry.ErrConflict):
+		resp.Kind = forwardErrConflict
+	case errors.Is(err, registry.ErrUnavailable):
+		resp.Kind = forwardErrUnavailable
+	case errors.Is(err, registry.ErrResourceExhausted):
+		resp.Kind = forwardErrExhausted
+	case errors.Is(err, registry.ErrPermissionDenied):
+		resp.Kind = forwardErrDenied
+	}
+
+	return resp
+}
+
+func decodeForwardError(resp forwardResponse) error {
+	if resp.Error == "" {
+		return nil
+	}
+
+	var sentinel error
+	switch resp.Kind {
+	case forwardErrNotLeader:
+		sentinel = errNotLeader
+	case forwardErrNotFound:
+		sentinel = registry.ErrNotFound
+	case forwardErrInvalid:
+		sentinel = registry.ErrInvalid
+	case forwardErrConflict:
+		sentinel = registry.ErrConflict
+	case forwardErrUnavailable:
+		sentinel = registry.ErrUnavailable
+	case forwardErrExhausted:
+		sentinel = registry.ErrResourceExhausted
+	case forwardErrDenied:
+		sentinel = registry.ErrPermissionDenied
+	default:
+		return errors.New(resp.Error)
+	}
+
+	return fmt.Errorf("leader: %s: %w", resp.Error, sentinel)
+}
+
+type forwardConn struct {
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+}
+
+// forwarder sends mutations to the current leader over pooled connections.
+type forwarder struct {
+	dial func(address string) (net.Conn, error)
+
+	mu   sync.Mutex
+	idle map[string][]*forwardConn
+}
+
+func newForwarder(dial func(address string) (net.Conn, error)) *forwarder {
+	return &forwarder{
+		dial: dial,
+		idle: make(map[string][]*forwardConn),
+	}
+}
+
+// send forwards m to the node at address and returns the error the leader
+// reported applying it. Failures before m was written are reported as
+// errForwardFailed so the caller can retry against a new leader. Once m is
+// written the leader may have applied it, so a missing answer is reported
+// as errForwardNoReply and must not be retried: a repeated removal would
+// fail with ErrNotFound.
+func (f *forwarder) send(ctx context.Context, address string, m memory.Mutation) error {
+	fc, err := f.get(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errForwardFailed, err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = fc.conn.SetDeadline(deadline)
+	} else {
+		_ = fc.conn.SetDeadline(time.Time{})
+	}
+
+	var resp forwardResponse
+	if err := fc.enc.Encode(forwardRequest{Mutation: m}); err != nil {
+		fc.conn.Close()
+		return fmt.Errorf("%w: %v", errForwardFailed, err)
+	}
+	if err := fc.dec.Decode(&resp); err != nil {
+		fc.conn.Close()
+		return fmt.Errorf("%w: %v", errForwardNoReply, err)
+	}
+
+	f.put(address, fc)
+
+	return decodeForwardError(resp)
+}
+
+func (f *forwarder) get(address string) (*forwardConn, error) {
+	f.mu.Lock()
+	if conns := f.idle[address]; len(conns) > 0 {
+		fc := conns[len(conns)-1]
+		f.idle[address] = conns[:len(conns)-1]
+		f.mu.Unlock()
+		return fc, nil
+	}
+	f.mu.Unlock()
+
+	conn, err := f.dial(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &forwardConn{
+		conn: conn,
+		enc:  json.NewEncoder(conn),
+		dec:  json.NewDecoder(conn),
+	}, nil
+}
+
+func (f *forwarder) put(address string, fc *forwardConn) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.idle[address]) >= maxIdleForwardConns {
+		fc.conn.Close()
+		return
+	}
+
+	f.idle[address] = append(f.idle[address], fc)
+}
+
+func (f *forwarder) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for address, conns := range f.idle {
+		for _, fc := range conns {
+			fc.conn.Close()
+		}
+		delete(f.idle, address)
+	}
+}
@@ -0,0 +1,61 @@
+package raft
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+	hraft "github.com/hashicorp/raft"
+)
+
+// fsm applies committed mutations to a local memory backend. Every node
+// applies the same mutations in the same order, and mutations carry their
+// own timestamps, so all replicas converge on identical state.
+type fsm struct {
+	state *memory.Backend
+}
+
+var _ hraft.FSM = (*fsm)(nil)
+
+// Apply returns the error (or nil) produced by applying the mutation; it
+// is surfaced to the writer through ApplyFuture.Response.
+func (f *fsm) Apply(log *hraft.Log) any {
+	var m memory.Mutation
+	if err := json.Unmarshal(log.Data, &m); err != nil {
+		return fmt.Errorf("%w: %v", errMutationEncode, err)
+	}
+
+	return f.state.ApplyMutation(m)
+}
+
+func (f *fsm) Snapshot() (hraft.FSMSnapshot, error) {
+	var buf bytes.Buffer
+	if err := f.state.WriteSnapshot(&buf); err != nil {
+		return nil, err
+	}
+
+	return &fsmSnapshot{data: buf.Bytes()}, nil
+}
+
+func (f *fsm) Restore(snapshot io.ReadCloser) error {
+	defer snapshot.Close()
+
+	return f.state.RestoreSnapshot(snapshot)
+}
+
+type fsmSnapshot struct {
+	data []byte
+}
+
+func (s *fsmSnapshot) Persist(sink hraft.SnapshotSink) error {
+	if _, err := sink.Write(s.data); err != nil {
+		_ = sink.Cancel()
+		return err
+	}
+
+	return sink.Close()
+}
+
+func (s *fsmSnapshot) Release() {}
@@ -0,0 +1,152 @@
+package raft
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+)
+
+// defaultHeartbeatFlushInterval is how often coalesced heartbeats are
+// committed when RaftConfig.HeartbeatFlushInterval is unset.
+const defaultHeartbeatFlushInterval = time.Second
+
+// heartbeatBatch coalesces the heartbeats received by this node, keyed by
+// namespace and then by entry ID, so they are committed as one Raft entry
+// per namespace on each flush instead of one consensus round per beat.
+type heartbeatBatch struct {
+	mu     sync.Mutex
+	relays map[string]map[string]time.Time
+	agents map[string]map[string]time.Time
+}
+
+func newHeartbeatBatch() *heartbeatBatch {
+	return &heartbeatBatch{
+		relays: make(map[string]map[string]time.Time),
+		agents: make(map[string]map[string]time.Time),
+	}
+}
+
+func (h *heartbeatBatch) recordRelay(namespace, relayID string, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	record(h.relays, namespace, relayID, at)
+}
+
+func (h *heartbeatBatch) recordAgent(namespace, agentID string, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	record(h.agents, namespace, agentID, at)
+}
+
+// take returns one heartbeat mutation per namespace with pending beats,
+// in namespace order, and empties the batch.
+func (h *heartbeatBatch) take() []memory.Mutation {
+	h.mu.Lock()
+	relays, agents := h.relays, h.agents
+	h.relays = make(map[string]map[string]time.Time)
+	h.agents = make(map[string]map[string]time.Time)
+	h.mu.Unlock()
+
+	namespaces := make([]string, 0, len(relays)+len(agents))
+	for namespace := range relays {
+		namespaces = append(namespaces, namespace)
+	}
+	for namespace := range agents {
+		if _, ok := relays[namespace]; !ok {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	slices.Sort(namespaces)
+
+	mutations := make([]memory.Mutation, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		mutations = append(mutations, memory.Mutation{
+			Op:              memory.MutationHeartbeats,
+			Namespace:       namespace,
+			RelayHeartbeats: relays[namespace],
+			AgentHeartbeats: agents[namespace],
+		})
+	}
+
+	return mutations
+}
+
+// requeue puts the beats of a mutation that could not be committed back
+// into the batch, unless a newer beat for the same entry arrived since.
+func (h *heartbeatBatch) requeue(m memory.Mutation) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for relayID, at := range m.RelayHeartbeats {
+		if seen, ok := h.relays[m.Namespace][relayID]; !ok || at.After(seen) {
+			record(h.relays, m.Namespace, relayID, at)
+		}
+	}
+	for agentID, at := range m.AgentHeartbeats {
+		if seen, ok := h.agents[m.Namespace][agentID]; !ok || at.After(seen) {
+			record(h.agents, m.Namespace, agentID, at)
+		}
+	}
+}
+
+func record(pending map[string]map[string]time.Time, namespace, id string, at time.Time) {
+	beats, ok := pending[namespace]
+	if !ok {
+		beats = make(map[string]time.Time)
+		pending[namespace] = beats
+	}
+	beats[id] = at
+}
+
+// flushHeartbeats commits the pending heartbeats. Beats that cannot be
+// committed because the cluster has no leader are kept for the next flush;
+// beats for entries removed in the meantime are dropped.
+func (b *Backend) flushHeartbeats(ctx context.Context) error {
+	var errs []error
+	for _, m := range b.heartbeats.take() {
+		err := b.apply(ctx, m)
+		switch {
+		case err == nil:
+		case errors.Is(err, registry.ErrUnavailable):
+			b.heartbeats.requeue(m)
+			errs = append(errs, err)
+		case errors.Is(err, registry.ErrNotFound):
+		default:
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// runHeartbeatFlush commits batched heartbeats every interval until stop is
+// closed.
+func (b *Backend) runHeartbeatFlush(interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := b.flushHeartbeats(context.Background()); err != nil {
+				slog.LogAttrs(context.Background(), slog.LevelWarn, "failed to commit raft heartbeat batch",
+					slog.String("method", "runHeartbeatFlush"),
+					slog.String("node_id", b.cfg.NodeID),
+					slog.Any("error", err),
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package raft
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// newLogger returns an hclog logger for the Raft library that forwards
+// every entry to the default slog logger, so Raft output follows the
+// registry's log level and format.
+func newLogger() hclog.Logger {
+	logger := hclog.NewInterceptLogger(&hclog.LoggerOptions{
+		Name:   "raft",
+		Level:  hclog.Off,
+		Output: io.Discard,
+	})
+	logger.RegisterSink(slogSink{})
+
+	return logger
+}
+
+// slogSink adapts hclog entries, with their alternating key/value
+// arguments, to slog records.
+type slogSink struct{}
+
+func (slogSink) Accept(name string, level hclog.Level, msg string, args ...any) {
+	ctx := context.Background()
+	slogLevel := toSlogLevel(level)
+	if !slog.Default().Enabled(ctx, slogLevel) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, len(args)/2+1)
+	attrs = append(attrs, slog.String("component", name))
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs = append(attrs, slog.Any(fmt.Sprint(args[i]), args[i+1]))
+	}
+
+	slog.LogAttrs(ctx, slogLevel, msg, attrs...)
+}
+
+func toSlogLevel(level hclog.Level) slog.Level {
+	switch level {
+	case hclog.Trace:
+		return slog.LevelDebug - 4
+	case hclog.Debug:
+		return slog.LevelDebug
+	case hclog.Warn:
+		return slog.LevelWarn
+	case hclog.Error:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
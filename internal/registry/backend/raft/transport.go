@@ -0,0 +1,143 @@
+package raft
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	hraft "github.com/hashicorp/raft"
+)
+
+// Raft replication and forwarded writes share one listener. Every
+// connection opens with a single byte naming the protocol that follows.
+const (
+	connTypeRaft    byte = 0x01
+	connTypeForward byte = 0x02
+)
+
+// connTypeTimeout bounds how long an accepted connection may take to send
+// its protocol byte.
+const connTypeTimeout = 5 * time.Second
+
+// muxTransport demultiplexes inbound connections between the Raft network
+// transport and the write forwarding server.
+type muxTransport struct {
+	listener  net.Listener
+	advertise net.Addr
+	forward   func(net.Conn)
+
+	raftConns chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newMuxTransport(listener net.Listener, advertise net.Addr, forward func(net.Conn)) *muxTransport {
+	m := &muxTransport{
+		listener:  listener,
+		advertise: advertise,
+		forward:   forward,
+		raftConns: make(chan net.Conn),
+		closed:    make(chan struct{}),
+	}
+	go m.acceptLoop()
+
+	return m
+}
+
+func (m *muxTransport) acceptLoop() {
+	for {
+		conn, err := m.listener.Accept()
+		if err != nil {
+			select {
+			case <-m.closed:
+				return
+			default:
+			}
+
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			return
+		}
+
+		go m.dispatch(conn)
+	}
+}
+
+func (m *muxTransport) dispatch(conn net.Conn) {
+	var connType [1]byte
+
+	_ = conn.SetReadDeadline(time.Now().Add(connTypeTimeout))
+	if _, err := conn.Read(connType[:]); err != nil {
+		conn.Close()
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	switch connType[0] {
+	case connTypeRaft:
+		select {
+		case m.raftConns <- conn:
+		case <-m.closed:
+			conn.Close()
+		}
+	case connTypeForward:
+		m.forward(conn)
+	default:
+		conn.Close()
+	}
+}
+
+// dial opens a connection to address announcing connType.
+func (m *muxTransport) dial(address string, connType byte, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write([]byte{connType}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("announce connection type: %w", err)
+	}
+
+	return conn, nil
+}
+
+func (m *muxTransport) close() error {
+	var err error
+	m.closeOnce.Do(func() {
+		close(m.closed)
+		err = m.listener.Close()
+	})
+
+	return err
+}
+
+// raftStream adapts the mux to hraft.StreamLayer.
+type raftStream struct {
+	mux *muxTransport
+}
+
+var _ hraft.StreamLayer = (*raftStream)(nil)
+
+func (s *raftStream) Accept() (net.Conn, error) {
+	select {
+	case conn := <-s.mux.raftConns:
+		return conn, nil
+	case <-s.mux.closed:
+		return nil, errTransportClosed
+	}
+}
+
+func (s *raftStream) Close() error {
+	return s.mux.close()
+}
+
+func (s *raftStream) Addr() net.Addr {
+	return s.mux.advertise
+}
+
+func (s *raftStream) Dial(address hraft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	return s.mux.dial(string(address), connTypeRaft, timeout)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
 )
@@ -18,31 +19,35 @@ func TestBackendMethodsReturnNotImplemented(t *testing.T) {
 
 	ctx := context.Background()
 
-	if err := backend.RegisterRelay(ctx, registry.Relay{}); !errors.Is(err, registry.ErrNotImplemented) {
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{}); !errors.Is(err, registry.ErrNotImplemented) {
 		t.Fatalf("expected ErrNotImplemented, got %v", err)
 	}
 
-	if err := backend.HeartbeatRelay(ctx, "relay"); !errors.Is(err, registry.ErrNotImplemented) {
+	if err := backend.HeartbeatRelay(ctx, registry.DefaultNamespace, "relay"); !errors.Is(err, registry.ErrNotImplemented) {
 		t.Fatalf("expected ErrNotImplemented, got %v", err)
 	}
 
-	if _, err := backend.ListRelays(ctx); !errors.Is(err, registry.ErrNotImplemented) {
+	if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); !errors.Is(err, registry.ErrNotImplemented) {
 		t.Fatalf("expected ErrNotImplemented, got %v", err)
 	}
 
-	if err := backend.RemoveRelay(ctx, "relay"); !errors.Is(err, registry.ErrNotImplemented) {
+	if err := backend.RemoveRelay(ctx, registry.DefaultNamespace, "relay"); !errors.Is(err, registry.ErrNotImplemented) {
 		t.Fatalf("expected ErrNotImplemented, got %v", err)
 	}
 
-	if err := backend.RegisterAgent(ctx, registry.Agent{}, "relay"); !errors.Is(err, registry.ErrNotImplemented) {
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{}, "relay"); !errors.Is(err, registry.ErrNotImplemented) {
 		t.Fatalf("expected ErrNotImplemented, got %v", err)
 	}
 
-	if err := backend.HeartbeatAgent(ctx, "agent"); !errors.Is(err, registry.ErrNotImplemented) {
+	if err := backend.HeartbeatAgent(ctx, registry.DefaultNamespace, "agent"); !errors.Is(err, registry.ErrNotImplemented) {
 		t.Fatalf("expected ErrNotImplemented, got %v", err)
 	}
 
-	if _, err := backend.GetAgentPlacement(ctx, "agent"); !errors.Is(err, registry.ErrNotImplemented) {
+	if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent"); !errors.Is(err, registry.ErrNotImplemented) {
+		t.Fatalf("expected ErrNotImplemented, got %v", err)
+	}
+
+	if _, err := backend.AcquireLease(ctx, "lease", "holder", time.Second); !errors.Is(err, registry.ErrNotImplemented) {
 		t.Fatalf("expected ErrNotImplemented, got %v", err)
 	}
 
@@ -0,0 +1,334 @@
+// Package resilience provides a registry.Backend decorator that bounds,
+// retries and circuit-breaks backend calls so a slow or failing backend
+// surfaces as registry.ErrUnavailable instead of hanging requests or
+// internal errors.
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// maxRetryBackoff caps the exponential retry delay.
+const maxRetryBackoff = time.Second
+
+var errCircuitOpen = fmt.Errorf("backend circuit open: %w", registry.ErrUnavailable)
+
+// Backend wraps a registry.Backend with per-call timeouts, retries for
+// idempotent calls and a circuit breaker.
+type Backend struct {
+	next         registry.Backend
+	callTimeout  time.Duration
+	maxAttempts  int
+	retryBackoff time.Duration
+	breaker      *breaker
+	sleep        func(ctx context.Context, d time.Duration) error
+
+	listenersMu sync.Mutex
+	listeners   []func(available bool)
+}
+
+var _ registry.Backend = (*Backend)(nil)
+var _ registry.BackendWrapper = (*Backend)(nil)
+var _ registry.AvailabilityReporter = (*Backend)(nil)
+
+// New returns a resilience decorator for next. Unset cfg fields fall back
+// to the registry defaults.
+func New(next registry.Backend, cfg registry.ResilienceConfig) *Backend {
+	b := &Backend{
+		next:         next,
+		callTimeout:  cfg.CallTimeout,
+		maxAttempts:  cfg.MaxAttempts,
+		retryBackoff: cfg.RetryBackoff,
+		sleep:        sleepContext,
+	}
+	if b.callTimeout == 0 {
+		b.callTimeout = registry.DefaultBackendCallTimeout
+	}
+	if b.maxAttempts == 0 {
+		b.maxAttempts = registry.DefaultBackendMaxAttempts
+	}
+	if b.retryBackoff == 0 {
+		b.retryBackoff = registry.DefaultBackendRetryBackoff
+	}
+
+	b.breaker = &breaker{
+		threshold:   cfg.FailureThreshold,
+		openTimeout: cfg.OpenTimeout,
+		now:         time.Now,
+		onChange:    b.stateChanged,
+	}
+	if b.breaker.threshold == 0 {
+		b.breaker.threshold = registry.DefaultBackendFailureThreshold
+	}
+	if b.breaker.openTimeout == 0 {
+		b.breaker.openTimeout = registry.DefaultBackendOpenTimeout
+	}
+
+	return b
+}
+
+// Unwrap returns the decorated backend.
+func (b *Backend) Unwrap() registry.Backend {
+	return b.next
+}
+
+// State returns the current circuit breaker state.
+func (b *Backend) State() State {
+	return b.breaker.currentState()
+}
+
+// BackendAvailable reports whether the circuit is closed.
+func (b *Backend) BackendAvailable() bool {
+	return b.State() == StateClosed
+}
+
+// OnAvailabilityChange registers fn to be called whenever the circuit
+// opens or closes.
+func (b *Backend) OnAvailabilityChange(fn func(available bool)) {
+	b.listenersMu.Lock()
+	defer b.listenersMu.Unlock()
+
+	b.listeners = append(b.listeners, fn)
+}
+
+func (b *Backend) RegisterRelay(ctx context.Context, namespace string, relay registry.Relay) error {
+	return b.call(ctx, "RegisterRelay", false, func(ctx context.Context) error {
+		return b.next.RegisterRelay(ctx, namespace, relay)
+	})
+}
+
+func (b *Backend) HeartbeatRelay(ctx context.Context, namespace, relayID string) error {
+	return b.call(ctx, "HeartbeatRelay", true, func(ctx context.Context) error {
+		return b.next.HeartbeatRelay(ctx, namespace, relayID)
+	})
+}
+
+func (b *Backend) ListRelays(ctx context.Context, namespace string) (relays []registry.Relay, err error) {
+	err = b.call(ctx, "ListRelays", true, func(ctx context.Context) error {
+		relays, err = b.next.ListRelays(ctx, namespace)
+		return err
+	})
+	return relays, err
+}
+
+func (b *Backend) RemoveRelay(ctx context.Context, namespace, relayID string) error {
+	return b.call(ctx, "RemoveRelay", false, func(ctx context.Context) error {
+		return b.next.RemoveRelay(ctx, namespace, relayID)
+	})
+}
+
+func (b *Backend) RegisterAgent(ctx context.Context, namespace string, agent registry.Agent, relayID string) error {
+	return b.call(ctx, "RegisterAgent", false, func(ctx context.Context) error {
+		return b.next.RegisterAgent(ctx, namespace, agent, relayID)
+	})
+}
+
+func (b *Backend) HeartbeatAgent(ctx context.Context, namespace, agentID string) error {
+	return b.call(ctx, "HeartbeatAgent", true, func(ctx context.Context) error {
+		return b.next.HeartbeatAgent(ctx, namespace, agentID)
+	})
+}
+
+func (b *Backend) GetAgentPlacement(ctx context.Context, namespace, agentID string) (placement *registry.AgentPlacement, err error) {
+	err = b.call(ctx, "GetAgentPlacement", true, func(ctx context.Context) error {
+		placement, err = b.next.GetAgentPlacement(ctx, namespace, agentID)
+		return err
+	})
+	return placement, err
+}
+
+func (b *Backend) ListAgents(ctx context.Context, namespace string) (agents []registry.Agent, err error) {
+	err = b.call(ctx, "ListAgents", true, func(ctx context.Context) error {
+		agents, err = b.next.ListAgents(ctx, namespace)
+		return err
+	})
+	return agents, err
+}
+
+func (b *Backend) ListRelayAgents(ctx context.Context, namespace, relayID string) (agents []*registry.Agent, err error) {
+	err = b.call(ctx, "ListRelayAgents", true, func(ctx context.Context) error {
+		agents, err = b.next.ListRelayAgents(ctx, namespace, relayID)
+		return err
+	})
+	return agents, err
+}
+
+func (b *Backend) RemoveAgents(ctx context.Context, namespace string, agentIDs []string) error {
+	return b.call(ctx, "RemoveAgents", false, func(ctx context.Context) error {
+		return b.next.RemoveAgents(ctx, namespace, agentIDs)
+	})
+}
+
+func (b *Backend) ListNamespaces(ctx context.Context) (namespaces []string, err error) {
+	err = b.call(ctx, "ListNamespaces", true, func(ctx context.Context) error {
+		namespaces, err = b.next.ListNamespaces(ctx)
+		return err
+	})
+	return namespaces, err
+}
+
+func (b *Backend) AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (lease *registry.Lease, err error) {
+	err = b.call(ctx, "AcquireLease", false, func(ctx context.Context) error {
+		lease, err = b.next.AcquireLease(ctx, name, holderID, ttl)
+		return err
+	})
+	return lease, err
+}
+
+func (b *Backend) RenewLease(ctx context.Context, lease registry.Lease, ttl time.Duration) (renewed *registry.Lease, err error) {
+	err = b.call(ctx, "RenewLease", false, func(ctx context.Context) error {
+		renewed, err = b.next.RenewLease(ctx, lease, ttl)
+		return err
+	})
+	return renewed, err
+}
+
+func (b *Backend) ReleaseLease(ctx context.Context, lease registry.Lease) error {
+	return b.call(ctx, "ReleaseLease", false, func(ctx context.Context) error {
+		return b.next.ReleaseLease(ctx, lease)
+	})
+}
+
+// Close closes the wrapped backend without timeouts or circuit breaking;
+// shutdown is bounded by ctx.
+func (b *Backend) Close(ctx context.Context) error {
+	return b.next.Close(ctx)
+}
+
+// call runs fn through the circuit breaker, bounding each attempt by the
+// call timeout. Idempotent calls are retried on backend failures.
+func (b *Backend) call(ctx context.Context, method string, idempotent bool, fn func(ctx context.Context) error) error {
+	attempts := 1
+	if idempotent {
+		attempts = b.maxAttempts
+	}
+
+	var err error
+	for attempt := range attempts {
+		if attempt > 0 {
+			slog.LogAttrs(ctx, slog.LevelDebug, "retrying backend call",
+				slog.String("method", method),
+				slog.Int("attempt", attempt+1),
+				slog.String("error", err.Error()),
+			)
+			if sleepErr := b.sleep(ctx, b.backoff(attempt)); sleepErr != nil {
+				return err
+			}
+		}
+
+		if !b.breaker.allow() {
+			return errCircuitOpen
+		}
+
+		var result outcome
+		result, err = b.attempt(ctx, fn)
+		b.breaker.record(result)
+		if result != outcomeFailure {
+			return err
+		}
+	}
+
+	return err
+}
+
+// attempt runs fn once and classifies the result. Backend failures are
+// reported wrapped in registry.ErrUnavailable.
+func (b *Backend) attempt(ctx context.Context, fn func(ctx context.Context) error) (outcome, error) {
+	callCtx, cancel := context.WithTimeout(ctx, b.callTimeout)
+	defer cancel()
+
+	err := fn(callCtx)
+	switch {
+	case err == nil:
+		return outcomeSuccess, nil
+	case ctx.Err() != nil:
+		return outcomeIgnored, err
+	case callCtx.Err() != nil:
+		return outcomeFailure, fmt.Errorf("%w: backend call timed out after %s", registry.ErrUnavailable, b.callTimeout)
+	case !isBackendFailure(err):
+		return outcomeSuccess, err
+	case errors.Is(err, registry.ErrUnavailable):
+		return outcomeFailure, err
+	default:
+		return outcomeFailure, fmt.Errorf("%w: %w", registry.ErrUnavailable, err)
+	}
+}
+
+// backoff returns the delay before the given retry: the retry backoff
+// doubled per earlier retry, capped, with jitter over its upper half.
+func (b *Backend) backoff(retry int) time.Duration {
+	delay := b.retryBackoff << (retry - 1)
+	if delay <= 0 || delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+func (b *Backend) stateChanged(change transition) {
+	attrs := []slog.Attr{
+		slog.String("method", "resilience.breaker"),
+		slog.String("from", change.from.String()),
+		slog.String("to", change.to.String()),
+		slog.Int("consecutive_failures", change.failures),
+	}
+	switch change.to {
+	case StateOpen:
+		slog.LogAttrs(context.Background(), slog.LevelWarn, "backend circuit opened", attrs...)
+	case StateClosed:
+		slog.LogAttrs(context.Background(), slog.LevelInfo, "backend circuit closed", attrs...)
+	default:
+		slog.LogAttrs(context.Background(), slog.LevelInfo, "backend circuit probing", attrs...)
+	}
+
+	if (change.from == StateClosed) == (change.to == StateClosed) {
+		return
+	}
+
+	b.listenersMu.Lock()
+	listeners := append([]func(bool){}, b.listeners...)
+	b.listenersMu.Unlock()
+
+	// Report the state at delivery time so late notifications from racing
+	// transitions do not leave listeners with an outdated view.
+	available := b.BackendAvailable()
+	for _, fn := range listeners {
+		fn(available)
+	}
+}
+
+// isBackendFailure reports whether err means the backend failed, as
+// opposed to answering with a domain error.
+func isBackendFailure(err error) bool {
+	switch {
+	case errors.Is(err, registry.ErrNotFound),
+		errors.Is(err, registry.ErrConflict),
+		errors.Is(err, registry.ErrInvalid),
+		errors.Is(err, registry.ErrResourceExhausted),
+		errors.Is(err, registry.ErrNotImplemented):
+		return false
+	default:
+		return true
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
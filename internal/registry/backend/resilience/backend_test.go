@@ -0,0 +1,210 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+)
+
+var errBackendDown = errors.New("connection refused")
+
+// flakyBackend fails the next `failures` calls to ListRelays and
+// RegisterRelay before delegating to the wrapped backend.
+type flakyBackend struct {
+	registry.Backend
+	failures atomic.Int64
+	calls    atomic.Int64
+	block    bool
+}
+
+func (f *flakyBackend) ListRelays(ctx context.Context, namespace string) ([]registry.Relay, error) {
+	if err := f.fail(ctx); err != nil {
+		return nil, err
+	}
+	return f.Backend.ListRelays(ctx, namespace)
+}
+
+func (f *flakyBackend) RegisterRelay(ctx context.Context, namespace string, relay registry.Relay) error {
+	if err := f.fail(ctx); err != nil {
+		return err
+	}
+	return f.Backend.RegisterRelay(ctx, namespace, relay)
+}
+
+func (f *flakyBackend) GetAgentPlacement(ctx context.Context, namespace, agentID string) (*registry.AgentPlacement, error) {
+	f.calls.Add(1)
+	return f.Backend.GetAgentPlacement(ctx, namespace, agentID)
+}
+
+func (f *flakyBackend) fail(ctx context.Context) error {
+	f.calls.Add(1)
+	if f.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if f.failures.Add(-1) >= 0 {
+		return errBackendDown
+	}
+	return nil
+}
+
+func newTestBackend(t *testing.T, cfg registry.ResilienceConfig) (*Backend, *flakyBackend) {
+	t.Helper()
+
+	mem, err := memory.New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	next := &flakyBackend{Backend: mem}
+	backend := New(next, cfg)
+	backend.sleep = func(context.Context, time.Duration) error { return nil }
+
+	return backend, next
+}
+
+func TestRetriesIdempotentCalls(t *testing.T) {
+	backend, next := newTestBackend(t, registry.ResilienceConfig{MaxAttempts: 3})
+	next.failures.Store(2)
+
+	if _, err := backend.ListRelays(context.Background(), registry.DefaultNamespace); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := next.calls.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestDoesNotRetryWrites(t *testing.T) {
+	backend, next := newTestBackend(t, registry.ResilienceConfig{MaxAttempts: 3})
+	next.failures.Store(1)
+
+	err := backend.RegisterRelay(context.Background(), registry.DefaultNamespace, registry.Relay{ID: "relay-1"})
+	if !errors.Is(err, registry.ErrUnavailable) || !errors.Is(err, errBackendDown) {
+		t.Fatalf("expected unavailable error wrapping the backend error, got %v", err)
+	}
+	if got := next.calls.Load(); got != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got)
+	}
+}
+
+func TestDomainErrorsAreNotFailures(t *testing.T) {
+	backend, next := newTestBackend(t, registry.ResilienceConfig{MaxAttempts: 3, FailureThreshold: 1})
+
+	_, err := backend.GetAgentPlacement(context.Background(), registry.DefaultNamespace, "missing")
+	if !errors.Is(err, registry.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if got := next.calls.Load(); got != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got)
+	}
+	if backend.State() != StateClosed {
+		t.Fatalf("expected closed circuit, got %s", backend.State())
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	backend, next := newTestBackend(t, registry.ResilienceConfig{CallTimeout: 10 * time.Millisecond, MaxAttempts: 1})
+	next.block = true
+
+	_, err := backend.ListRelays(context.Background(), registry.DefaultNamespace)
+	if !errors.Is(err, registry.ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+}
+
+func TestCallerCancellationIsNotAFailure(t *testing.T) {
+	backend, next := newTestBackend(t, registry.ResilienceConfig{MaxAttempts: 3, FailureThreshold: 1})
+	next.block = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := backend.ListRelays(ctx, registry.DefaultNamespace)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected caller deadline error, got %v", err)
+	}
+	if got := next.calls.Load(); got != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got)
+	}
+	if backend.State() != StateClosed {
+		t.Fatalf("expected closed circuit, got %s", backend.State())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	backend, next := newTestBackend(t, registry.ResilienceConfig{
+		MaxAttempts:      1,
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+	})
+	now := time.Now()
+	backend.breaker.now = func() time.Time { return now }
+
+	var changes []bool
+	backend.OnAvailabilityChange(func(available bool) {
+		changes = append(changes, available)
+	})
+
+	ctx := context.Background()
+	next.failures.Store(2)
+	for range 2 {
+		if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); !errors.Is(err, registry.ErrUnavailable) {
+			t.Fatalf("expected ErrUnavailable, got %v", err)
+		}
+	}
+	if backend.State() != StateOpen || backend.BackendAvailable() {
+		t.Fatalf("expected open circuit, got %s", backend.State())
+	}
+
+	if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expected fast failure, got %v", err)
+	}
+	if got := next.calls.Load(); got != 2 {
+		t.Fatalf("expected open circuit to skip the backend, got %d calls", got)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); err != nil {
+		t.Fatalf("expected probe to succeed, got %v", err)
+	}
+	if backend.State() != StateClosed || !backend.BackendAvailable() {
+		t.Fatalf("expected closed circuit, got %s", backend.State())
+	}
+
+	if len(changes) != 2 || changes[0] || !changes[1] {
+		t.Fatalf("expected unavailable then available notifications, got %v", changes)
+	}
+}
+
+func TestHalfOpenProbeFailureReopens(t *testing.T) {
+	backend, next := newTestBackend(t, registry.ResilienceConfig{
+		MaxAttempts:      1,
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+	})
+	now := time.Now()
+	backend.breaker.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	next.failures.Store(2)
+	if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); err == nil {
+		t.Fatal("expected error")
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); errors.Is(err, errCircuitOpen) || err == nil {
+		t.Fatalf("expected failed probe, got %v", err)
+	}
+	if backend.State() != StateOpen {
+		t.Fatalf("expected circuit to reopen, got %s", backend.State())
+	}
+	if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expected fast failure after failed probe, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of the circuit breaker.
+type State int
+
+const (
+	// StateClosed passes every call to the backend.
+	StateClosed State = iota
+	// StateOpen fails every call fast.
+	StateOpen
+	// StateHalfOpen lets a single probe call through to test the backend.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// outcome classifies a finished backend call for the breaker.
+type outcome int
+
+const (
+	// outcomeSuccess means the backend answered, possibly with a domain
+	// error such as ErrNotFound.
+	outcomeSuccess outcome = iota
+	// outcomeFailure means the backend failed or timed out.
+	outcomeFailure
+	// outcomeIgnored means the caller gave up before the backend answered.
+	outcomeIgnored
+)
+
+// transition describes a change of breaker state.
+type transition struct {
+	from, to State
+	failures int
+}
+
+// breaker is a consecutive-failure circuit breaker. onChange is called
+// after every state change, outside the breaker's lock.
+type breaker struct {
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+	onChange    func(transition)
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a call may proceed. Once the open timeout has
+// passed, a single caller is let through as the half-open probe.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	allowed, change := b.allowLocked()
+	b.mu.Unlock()
+
+	b.notify(change)
+	return allowed
+}
+
+func (b *breaker) allowLocked() (bool, *transition) {
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return false, nil
+		}
+		b.probing = true
+		return true, b.transitionLocked(StateHalfOpen)
+	case StateHalfOpen:
+		if b.probing {
+			return false, nil
+		}
+		b.probing = true
+		return true, nil
+	default:
+		return true, nil
+	}
+}
+
+// record updates the breaker with the outcome of an allowed call.
+func (b *breaker) record(result outcome) {
+	b.mu.Lock()
+	change := b.recordLocked(result)
+	b.mu.Unlock()
+
+	b.notify(change)
+}
+
+func (b *breaker) recordLocked(result outcome) *transition {
+	switch result {
+	case outcomeSuccess:
+		b.failures = 0
+		b.probing = false
+		if b.state != StateClosed {
+			return b.transitionLocked(StateClosed)
+		}
+	case outcomeFailure:
+		b.failures++
+		b.probing = false
+		if b.state == StateHalfOpen || (b.state == StateClosed && b.failures >= b.threshold) {
+			b.openedAt = b.now()
+			return b.transitionLocked(StateOpen)
+		}
+	case outcomeIgnored:
+		// Let the next caller probe instead.
+		b.probing = false
+	}
+
+	return nil
+}
+
+func (b *breaker) currentState() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *breaker) transitionLocked(to State) *transition {
+	change := &transition{from: b.state, to: to, failures: b.failures}
+	b.state = to
+	return change
+}
+
+func (b *breaker) notify(change *transition) {
+	if change != nil && b.onChange != nil {
+		b.onChange(*change)
+	}
+}
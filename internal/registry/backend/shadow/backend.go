@@ -0,0 +1,435 @@
+// Package shadow provides a composite registry.Backend that runs a
+// candidate backend in shadow of the backend serving traffic, so a new
+// implementation can be compared against production state before it is
+// trusted.
+package shadow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// Backend serves every call from the primary backend. Successful writes
+// are mirrored to the shadow backend and reads are repeated against it in
+// the background, in the order they reached the primary; differences are
+// logged and counted. The shadow never affects the result of a call.
+//
+// Comparisons are made against a shadow that has applied every write
+// mirrored before the read, so a write racing with the read can show up as
+// a transient divergence.
+type Backend struct {
+	primary     registry.Backend
+	shadow      registry.Backend
+	callTimeout time.Duration
+
+	queue     chan job
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mirroredWrites       atomic.Uint64
+	mirrorErrors         atomic.Uint64
+	comparisons          atomic.Uint64
+	compareErrors        atomic.Uint64
+	dropped              atomic.Uint64
+	relayDivergences     atomic.Uint64
+	agentDivergences     atomic.Uint64
+	placementDivergences atomic.Uint64
+}
+
+// job is a mirrored write or a comparison waiting for the shadow.
+type job struct {
+	ctx    context.Context
+	method string
+	run    func(ctx context.Context)
+}
+
+var _ registry.Backend = (*Backend)(nil)
+var _ registry.BackendWrapper = (*Backend)(nil)
+var _ registry.ShadowStatsReporter = (*Backend)(nil)
+
+// New returns a backend serving from primary and shadowing it with shadow.
+// Unset cfg fields fall back to the registry defaults.
+func New(primary, shadow registry.Backend, cfg registry.ShadowConfig) *Backend {
+	queueSize := cfg.QueueSize
+	if queueSize == 0 {
+		queueSize = registry.DefaultShadowQueueSize
+	}
+
+	b := &Backend{
+		primary:     primary,
+		shadow:      shadow,
+		callTimeout: cfg.CallTimeout,
+		queue:       make(chan job, queueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if b.callTimeout == 0 {
+		b.callTimeout = registry.DefaultShadowCallTimeout
+	}
+
+	go b.run()
+
+	return b
+}
+
+// Unwrap returns the primary backend, so optional capabilities such as
+// capacity limits and leases are served by it alone.
+func (b *Backend) Unwrap() registry.Backend {
+	return b.primary
+}
+
+// ShadowStats returns a snapshot of the shadow counters.
+func (b *Backend) ShadowStats() registry.ShadowStats {
+	return registry.ShadowStats{
+		MirroredWrites:       b.mirroredWrites.Load(),
+		MirrorErrors:         b.mirrorErrors.Load(),
+		Comparisons:          b.comparisons.Load(),
+		CompareErrors:        b.compareErrors.Load(),
+		Dropped:              b.dropped.Load(),
+		RelayDivergences:     b.relayDivergences.Load(),
+		AgentDivergences:     b.agentDivergences.Load(),
+		PlacementDivergences: b.placementDivergences.Load(),
+	}
+}
+
+func (b *Backend) RegisterRelay(ctx context.Context, namespace string, relay registry.Relay) error {
+	if err := b.primary.RegisterRelay(ctx, namespace, relay); err != nil {
+		return err
+	}
+
+	b.mirror(ctx, "RegisterRelay", func(ctx context.Context) error {
+		return b.shadow.RegisterRelay(ctx, namespace, relay)
+	})
+	return nil
+}
+
+func (b *Backend) HeartbeatRelay(ctx context.Context, namespace, relayID string) error {
+	if err := b.primary.HeartbeatRelay(ctx, namespace, relayID); err != nil {
+		return err
+	}
+
+	b.mirror(ctx, "HeartbeatRelay", func(ctx context.Context) error {
+		return b.shadow.HeartbeatRelay(ctx, namespace, relayID)
+	})
+	return nil
+}
+
+func (b *Backend) ListRelays(ctx context.Context, namespace string) ([]registry.Relay, error) {
+	relays, err := b.primary.ListRelays(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	primary := slices.Clone(relays)
+	b.compare(ctx, "ListRelays", func(ctx context.Context) error {
+		shadowed, err := b.shadow.ListRelays(ctx, namespace)
+		if err != nil {
+			return err
+		}
+
+		b.compareRelays(ctx, namespace, primary, shadowed)
+		return nil
+	})
+	return relays, nil
+}
+
+func (b *Backend) RemoveRelay(ctx context.Context, namespace, relayID string) error {
+	if err := b.primary.RemoveRelay(ctx, namespace, relayID); err != nil {
+		return err
+	}
+
+	b.mirror(ctx, "RemoveRelay", func(ctx context.Context) error {
+		return b.shadow.RemoveRelay(ctx, namespace, relayID)
+	})
+	return nil
+}
+
+func (b *Backend) RegisterAgent(ctx context.Context, namespace string, agent registry.Agent, relayID string) error {
+	if err := b.primary.RegisterAgent(ctx, namespace, agent, relayID); err != nil {
+		return err
+	}
+
+	b.mirror(ctx, "RegisterAgent", func(ctx context.Context) error {
+		return b.shadow.RegisterAgent(ctx, namespace, agent, relayID)
+	})
+	return nil
+}
+
+func (b *Backend) HeartbeatAgent(ctx context.Context, namespace, agentID string) error {
+	if err := b.primary.HeartbeatAgent(ctx, namespace, agentID); err != nil {
+		return err
+	}
+
+	b.mirror(ctx, "HeartbeatAgent", func(ctx context.Context) error {
+		return b.shadow.HeartbeatAgent(ctx, namespace, agentID)
+	})
+	return nil
+}
+
+func (b *Backend) GetAgentPlacement(ctx context.Context, namespace, agentID string) (*registry.AgentPlacement, error) {
+	placement, err := b.primary.GetAgentPlacement(ctx, namespace, agentID)
+	if err != nil && !errors.Is(err, registry.ErrNotFound) {
+		return nil, err
+	}
+
+	wantRelay := ""
+	if placement != nil {
+		wantRelay = placement.RelayID
+	}
+	b.compare(ctx, "GetAgentPlacement", func(ctx context.Context) error {
+		shadowed, err := b.shadow.GetAgentPlacement(ctx, namespace, agentID)
+		gotRelay := ""
+		switch {
+		case err == nil:
+			gotRelay = shadowed.RelayID
+		case !errors.Is(err, registry.ErrNotFound):
+			return err
+		}
+
+		if gotRelay != wantRelay {
+			b.diverged(ctx, "GetAgentPlacement", &b.placementDivergences, "placement", namespace, agentID,
+				fmt.Sprintf("relay %s in primary, %s in shadow", describeRelay(wantRelay), describeRelay(gotRelay)))
+		}
+		return nil
+	})
+
+	return placement, err
+}
+
+func (b *Backend) ListAgents(ctx context.Context, namespace string) ([]registry.Agent, error) {
+	agents, err := b.primary.ListAgents(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	primary := sortedIDs(agents, func(agent registry.Agent) string { return agent.ID })
+	b.compare(ctx, "ListAgents", func(ctx context.Context) error {
+		shadowed, err := b.shadow.ListAgents(ctx, namespace)
+		if err != nil {
+			return err
+		}
+
+		b.compareIDs(ctx, "ListAgents", &b.agentDivergences, "agent", namespace, primary,
+			sortedIDs(shadowed, func(agent registry.Agent) string { return agent.ID }))
+		return nil
+	})
+	return agents, nil
+}
+
+func (b *Backend) ListRelayAgents(ctx context.Context, namespace, relayID string) ([]*registry.Agent, error) {
+	agents, err := b.primary.ListRelayAgents(ctx, namespace, relayID)
+	if err != nil {
+		return nil, err
+	}
+
+	primary := sortedIDs(agents, func(agent *registry.Agent) string { return agent.ID })
+	b.compare(ctx, "ListRelayAgents", func(ctx context.Context) error {
+		shadowed, err := b.shadow.ListRelayAgents(ctx, namespace, relayID)
+		if errors.Is(err, registry.ErrNotFound) {
+			b.diverged(ctx, "ListRelayAgents", &b.relayDivergences, "relay", namespace, relayID, "missing in shadow")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		b.compareIDs(ctx, "ListRelayAgents", &b.placementDivergences, "placement", namespace, primary,
+			sortedIDs(shadowed, func(agent *registry.Agent) string { return agent.ID }))
+		return nil
+	})
+	return agents, nil
+}
+
+func (b *Backend) RemoveAgents(ctx context.Context, namespace string, agentIDs []string) error {
+	if err := b.primary.RemoveAgents(ctx, namespace, agentIDs); err != nil {
+		return err
+	}
+
+	agentIDs = slices.Clone(agentIDs)
+	b.mirror(ctx, "RemoveAgents", func(ctx context.Context) error {
+		return b.shadow.RemoveAgents(ctx, namespace, agentIDs)
+	})
+	return nil
+}
+
+// ListNamespaces is served by the primary without a comparison: the
+// per-namespace reads already surface any namespace missing in the shadow.
+func (b *Backend) ListNamespaces(ctx context.Context) ([]string, error) {
+	return b.primary.ListNamespaces(ctx)
+}
+
+// Leases coordinate registry replicas and are held on the primary only.
+
+func (b *Backend) AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (*registry.Lease, error) {
+	return b.primary.AcquireLease(ctx, name, holderID, ttl)
+}
+
+func (b *Backend) RenewLease(ctx context.Context, lease registry.Lease, ttl time.Duration) (*registry.Lease, error) {
+	return b.primary.RenewLease(ctx, lease, ttl)
+}
+
+func (b *Backend) ReleaseLease(ctx context.Context, lease registry.Lease) error {
+	return b.primary.ReleaseLease(ctx, lease)
+}
+
+// Close finishes the queued shadow work, bounded by ctx, and closes both
+// backends.
+func (b *Backend) Close(ctx context.Context) error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.stop)
+		select {
+		case <-b.done:
+		case <-ctx.Done():
+			err = fmt.Errorf("drain shadow queue: %w", ctx.Err())
+		}
+
+		err = errors.Join(err, b.shadow.Close(ctx), b.primary.Close(ctx))
+	})
+
+	return err
+}
+
+func (b *Backend) run() {
+	defer close(b.done)
+
+	for {
+		select {
+		case j := <-b.queue:
+			b.runJob(j)
+		case <-b.stop:
+			for {
+				select {
+				case j := <-b.queue:
+					b.runJob(j)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (b *Backend) runJob(j job) {
+	ctx, cancel := context.WithTimeout(j.ctx, b.callTimeout)
+	defer cancel()
+
+	j.run(ctx)
+}
+
+// enqueue schedules fn on the shadow worker, detached from the caller's
+// cancellation. It drops fn when the queue is full.
+func (b *Backend) enqueue(ctx context.Context, method string, fn func(ctx context.Context)) {
+	select {
+	case b.queue <- job{ctx: context.WithoutCancel(ctx), method: method, run: fn}:
+	default:
+		b.dropped.Add(1)
+		slog.LogAttrs(ctx, slog.LevelDebug, "shadow queue full; dropping",
+			slog.String("method", method),
+		)
+	}
+}
+
+// mirror applies a write the primary accepted to the shadow.
+func (b *Backend) mirror(ctx context.Context, method string, write func(ctx context.Context) error) {
+	b.enqueue(ctx, method, func(ctx context.Context) {
+		if err := write(ctx); err != nil {
+			b.mirrorErrors.Add(1)
+			slog.LogAttrs(ctx, slog.LevelWarn, "shadow write failed",
+				slog.String("method", method),
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+		b.mirroredWrites.Add(1)
+	})
+}
+
+// compare repeats a read against the shadow. read reports an error when
+// the shadow could not be read.
+func (b *Backend) compare(ctx context.Context, method string, read func(ctx context.Context) error) {
+	b.enqueue(ctx, method, func(ctx context.Context) {
+		if err := read(ctx); err != nil {
+			b.compareErrors.Add(1)
+			slog.LogAttrs(ctx, slog.LevelWarn, "shadow read failed",
+				slog.String("method", method),
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+		b.comparisons.Add(1)
+	})
+}
+
+func (b *Backend) compareRelays(ctx context.Context, namespace string, primary, shadowed []registry.Relay) {
+	byID := make(map[string]registry.Relay, len(shadowed))
+	for _, relay := range shadowed {
+		byID[relay.ID] = relay
+	}
+
+	for _, want := range primary {
+		got, ok := byID[want.ID]
+		delete(byID, want.ID)
+		switch {
+		case !ok:
+			b.diverged(ctx, "ListRelays", &b.relayDivergences, "relay", namespace, want.ID, "missing in shadow")
+		case got.Address != want.Address || got.GRPCPort != want.GRPCPort:
+			b.diverged(ctx, "ListRelays", &b.relayDivergences, "relay", namespace, want.ID,
+				fmt.Sprintf("address %s:%d in primary, %s:%d in shadow", want.Address, want.GRPCPort, got.Address, got.GRPCPort))
+		}
+	}
+	for id := range byID {
+		b.diverged(ctx, "ListRelays", &b.relayDivergences, "relay", namespace, id, "missing in primary")
+	}
+}
+
+// compareIDs reports the IDs found in only one of the sorted ID lists.
+func (b *Backend) compareIDs(ctx context.Context, method string, counter *atomic.Uint64, kind, namespace string, primary, shadowed []string) {
+	for _, id := range primary {
+		if _, found := slices.BinarySearch(shadowed, id); !found {
+			b.diverged(ctx, method, counter, kind, namespace, id, "missing in shadow")
+		}
+	}
+	for _, id := range shadowed {
+		if _, found := slices.BinarySearch(primary, id); !found {
+			b.diverged(ctx, method, counter, kind, namespace, id, "missing in primary")
+		}
+	}
+}
+
+func (b *Backend) diverged(ctx context.Context, method string, counter *atomic.Uint64, kind, namespace, id, detail string) {
+	counter.Add(1)
+	slog.LogAttrs(ctx, slog.LevelWarn, "shadow backend diverged",
+		slog.String("method", method),
+		slog.String("kind", kind),
+		slog.String("namespace", namespace),
+		slog.String("id", id),
+		slog.String("detail", detail),
+	)
+}
+
+// sortedIDs returns the sorted IDs of agents.
+func sortedIDs[T any](agents []T, id func(T) string) []string {
+	ids := make([]string, 0, len(agents))
+	for _, agent := range agents {
+		ids = append(ids, id(agent))
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func describeRelay(relayID string) string {
+	if relayID == "" {
+		return "<none>"
+	}
+	return relayID
+}
@@ -0,0 +1,148 @@
+package shadow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+)
+
+// blockingBackend holds every shadow call until release is closed.
+type blockingBackend struct {
+	registry.Backend
+	release chan struct{}
+}
+
+func (b *blockingBackend) RegisterRelay(ctx context.Context, namespace string, relay registry.Relay) error {
+	<-b.release
+	return b.Backend.RegisterRelay(ctx, namespace, relay)
+}
+
+func newMemoryBackend(t *testing.T) *memory.Backend {
+	t.Helper()
+
+	backend, err := memory.New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	return backend
+}
+
+func TestMirrorsWritesAndServesPrimary(t *testing.T) {
+	ctx := context.Background()
+	primary, shadowed := newMemoryBackend(t), newMemoryBackend(t)
+	backend := New(primary, shadowed, registry.ShadowConfig{})
+
+	if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1", Address: "10.0.0.1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.HeartbeatAgent(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.HeartbeatRelay(ctx, registry.DefaultNamespace, "relay-missing"); !errors.Is(err, registry.ErrNotFound) {
+		t.Fatalf("expected primary error, got %v", err)
+	}
+
+	placement, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if placement.RelayID != "relay-1" {
+		t.Fatalf("expected placement on relay-1, got %q", placement.RelayID)
+	}
+	if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := backend.ListAgents(ctx, registry.DefaultNamespace); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := backend.Close(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	stats := backend.ShadowStats()
+	if stats.MirroredWrites != 3 || stats.MirrorErrors != 0 {
+		t.Fatalf("expected 3 mirrored writes, got %+v", stats)
+	}
+	if stats.Comparisons != 3 || stats.RelayDivergences+stats.AgentDivergences+stats.PlacementDivergences != 0 {
+		t.Fatalf("expected 3 matching comparisons, got %+v", stats)
+	}
+
+	if _, err := shadowed.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+		t.Fatalf("expected mirrored placement in shadow, got %v", err)
+	}
+}
+
+func TestCountsDivergences(t *testing.T) {
+	ctx := context.Background()
+	primary, shadowed := newMemoryBackend(t), newMemoryBackend(t)
+	backend := New(primary, shadowed, registry.ShadowConfig{})
+
+	// Writes that bypass the shadow backend.
+	if err := primary.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := primary.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := shadowed.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-2"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if _, err := backend.ListRelays(ctx, registry.DefaultNamespace); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := backend.ListAgents(ctx, registry.DefaultNamespace); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := backend.HeartbeatAgent(ctx, registry.DefaultNamespace, "agent-1"); err != nil {
+		t.Fatalf("expected primary to accept heartbeat, got %v", err)
+	}
+
+	if err := backend.Close(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	stats := backend.ShadowStats()
+	if stats.RelayDivergences != 2 {
+		t.Fatalf("expected relay-1 and relay-2 to diverge, got %+v", stats)
+	}
+	if stats.AgentDivergences != 1 || stats.PlacementDivergences != 1 {
+		t.Fatalf("expected agent-1 and its placement to diverge, got %+v", stats)
+	}
+	if stats.MirrorErrors != 1 {
+		t.Fatalf("expected heartbeat for unknown agent to fail in shadow, got %+v", stats)
+	}
+}
+
+func TestDropsWhenQueueFull(t *testing.T) {
+	ctx := context.Background()
+	shadowed := &blockingBackend{Backend: newMemoryBackend(t), release: make(chan struct{})}
+	backend := New(newMemoryBackend(t), shadowed, registry.ShadowConfig{QueueSize: 1})
+
+	// The first write occupies the worker, the second fills the queue.
+	for _, id := range []string{"relay-1", "relay-2", "relay-3", "relay-4"} {
+		if err := backend.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: id}); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	close(shadowed.release)
+	if err := backend.Close(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	stats := backend.ShadowStats()
+	if stats.Dropped == 0 || stats.MirroredWrites+stats.Dropped != 4 {
+		t.Fatalf("expected dropped writes to be counted, got %+v", stats)
+	}
+}
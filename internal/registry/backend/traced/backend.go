@@ -0,0 +1,175 @@
+// Package traced provides a registry.Backend decorator that records an
+// OpenTelemetry span for every backend call.
+package traced
+
+import (
+	"context"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of backend spans.
+const TracerName = "github.com/Aero-Arc/aero-arc-registry/internal/registry/backend"
+
+// Backend wraps a registry.Backend and creates a "Backend.<method>" span,
+// tagged with the backend type and, for relay and agent calls, the
+// namespace, around each call.
+type Backend struct {
+	next   registry.Backend
+	tracer trace.Tracer
+	system attribute.KeyValue
+}
+
+var _ registry.Backend = (*Backend)(nil)
+var _ registry.BackendWrapper = (*Backend)(nil)
+
+func New(next registry.Backend, tp trace.TracerProvider, backendType registry.RegistryBackend) *Backend {
+	return &Backend{
+		next:   next,
+		tracer: tp.Tracer(TracerName),
+		system: attribute.String("registry.backend", string(backendType)),
+	}
+}
+
+// Unwrap returns the decorated backend.
+func (b *Backend) Unwrap() registry.Backend {
+	return b.next
+}
+
+func namespaceAttr(namespace string) attribute.KeyValue {
+	return attribute.String("registry.namespace", namespace)
+}
+
+func (b *Backend) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return b.tracer.Start(ctx, "Backend."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, b.system)...),
+	)
+}
+
+func (b *Backend) RegisterRelay(ctx context.Context, namespace string, relay registry.Relay) (err error) {
+	ctx, span := b.start(ctx, "RegisterRelay", namespaceAttr(namespace), attribute.String("relay.id", relay.ID))
+	defer func() { registry.EndSpan(span, err) }()
+
+	return b.next.RegisterRelay(ctx, namespace, relay)
+}
+
+func (b *Backend) HeartbeatRelay(ctx context.Context, namespace, relayID string) (err error) {
+	ctx, span := b.start(ctx, "HeartbeatRelay", namespaceAttr(namespace), attribute.String("relay.id", relayID))
+	defer func() { registry.EndSpan(span, err) }()
+
+	return b.next.HeartbeatRelay(ctx, namespace, relayID)
+}
+
+func (b *Backend) ListRelays(ctx context.Context, namespace string) (relays []registry.Relay, err error) {
+	ctx, span := b.start(ctx, "ListRelays", namespaceAttr(namespace))
+	defer func() {
+		span.SetAttributes(attribute.Int("relay.count", len(relays)))
+		registry.EndSpan(span, err)
+	}()
+
+	return b.next.ListRelays(ctx, namespace)
+}
+
+func (b *Backend) RemoveRelay(ctx context.Context, namespace, relayID string) (err error) {
+	ctx, span := b.start(ctx, "RemoveRelay", namespaceAttr(namespace), attribute.String("relay.id", relayID))
+	defer func() { registry.EndSpan(span, err) }()
+
+	return b.next.RemoveRelay(ctx, namespace, relayID)
+}
+
+func (b *Backend) RegisterAgent(ctx context.Context, namespace string, agent registry.Agent, relayID string) (err error) {
+	ctx, span := b.start(ctx, "RegisterAgent",
+		namespaceAttr(namespace),
+		attribute.String("agent.id", agent.ID),
+		attribute.String("relay.id", relayID),
+	)
+	defer func() { registry.EndSpan(span, err) }()
+
+	return b.next.RegisterAgent(ctx, namespace, agent, relayID)
+}
+
+func (b *Backend) HeartbeatAgent(ctx context.Context, namespace, agentID string) (err error) {
+	ctx, span := b.start(ctx, "HeartbeatAgent", namespaceAttr(namespace), attribute.String("agent.id", agentID))
+	defer func() { registry.EndSpan(span, err) }()
+
+	return b.next.HeartbeatAgent(ctx, namespace, agentID)
+}
+
+func (b *Backend) GetAgentPlacement(ctx context.Context, namespace, agentID string) (placement *registry.AgentPlacement, err error) {
+	ctx, span := b.start(ctx, "GetAgentPlacement", namespaceAttr(namespace), attribute.String("agent.id", agentID))
+	defer func() { registry.EndSpan(span, err) }()
+
+	return b.next.GetAgentPlacement(ctx, namespace, agentID)
+}
+
+func (b *Backend) ListAgents(ctx context.Context, namespace string) (agents []registry.Agent, err error) {
+	ctx, span := b.start(ctx, "ListAgents", namespaceAttr(namespace))
+	defer func() {
+		span.SetAttributes(attribute.Int("agent.count", len(agents)))
+		registry.EndSpan(span, err)
+	}()
+
+	return b.next.ListAgents(ctx, namespace)
+}
+
+func (b *Backend) ListRelayAgents(ctx context.Context, namespace, relayID string) (agents []*registry.Agent, err error) {
+	ctx, span := b.start(ctx, "ListRelayAgents", namespaceAttr(namespace), attribute.String("relay.id", relayID))
+	defer func() {
+		span.SetAttributes(attribute.Int("agent.count", len(agents)))
+		registry.EndSpan(span, err)
+	}()
+
+	return b.next.ListRelayAgents(ctx, namespace, relayID)
+}
+
+func (b *Backend) RemoveAgents(ctx context.Context, namespace string, agentIDs []string) (err error) {
+	ctx, span := b.start(ctx, "RemoveAgents", namespaceAttr(namespace), attribute.Int("agent.count", len(agentIDs)))
+	defer func() { registry.EndSpan(span, err) }()
+
+	return b.next.RemoveAgents(ctx, namespace, agentIDs)
+}
+
+func (b *Backend) ListNamespaces(ctx context.Context) (namespaces []string, err error) {
+	ctx, span := b.start(ctx, "ListNamespaces")
+	defer func() {
+		span.SetAttributes(attribute.Int("namespace.count", len(namespaces)))
+		registry.EndSpan(span, err)
+	}()
+
+	return b.next.ListNamespaces(ctx)
+}
+
+func (b *Backend) AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (lease *registry.Lease, err error) {
+	ctx, span := b.start(ctx, "AcquireLease",
+		attribute.String("lease.name", name),
+		attribute.String("lease.holder_id", holderID),
+	)
+	defer func() { registry.EndSpan(span, err) }()
+
+	return b.next.AcquireLease(ctx, name, holderID, ttl)
+}
+
+func (b *Backend) RenewLease(ctx context.Context, lease registry.Lease, ttl time.Duration) (renewed *registry.Lease, err error) {
+	ctx, span := b.start(ctx, "RenewLease", attribute.String("lease.name", lease.Name))
+	defer func() { registry.EndSpan(span, err) }()
+
+	return b.next.RenewLease(ctx, lease, ttl)
+}
+
+func (b *Backend) ReleaseLease(ctx context.Context, lease registry.Lease) (err error) {
+	ctx, span := b.start(ctx, "ReleaseLease", attribute.String("lease.name", lease.Name))
+	defer func() { registry.EndSpan(span, err) }()
+
+	return b.next.ReleaseLease(ctx, lease)
+}
+
+func (b *Backend) Close(ctx context.Context) (err error) {
+	ctx, span := b.start(ctx, "Close")
+	defer func() { registry.EndSpan(span, err) }()
+
+	return b.next.Close(ctx)
+}
@@ -0,0 +1,39 @@
+package registry
+
+// CacheStats is a point-in-time snapshot of the backend read cache
+// counters.
+type CacheStats struct {
+	// RelayHits counts ListRelays calls served from the cache.
+	RelayHits uint64
+
+	// RelayMisses counts ListRelays calls read from the backend.
+	RelayMisses uint64
+
+	// PlacementHits counts GetAgentPlacement calls served from the cache.
+	PlacementHits uint64
+
+	// PlacementMisses counts GetAgentPlacement calls read from the backend.
+	PlacementMisses uint64
+
+	// Invalidations counts cache entries dropped because of a write.
+	Invalidations uint64
+
+	// Placements is the number of agent placements currently cached.
+	Placements int
+}
+
+// CacheStatsReporter is implemented by caching backend decorators.
+type CacheStatsReporter interface {
+	CacheStats() CacheStats
+}
+
+// CacheStats returns a snapshot of the backend read cache counters. It
+// reports false when the backend is not cached.
+func (r *Registry) CacheStats() (CacheStats, bool) {
+	reporter, ok := FindBackend[CacheStatsReporter](r.backend)
+	if !ok {
+		return CacheStats{}, false
+	}
+
+	return reporter.CacheStats(), true
+}
@@ -0,0 +1,192 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// CapacityLimiter is implemented by backends that enforce CapacityLimits
+// atomically as part of a registration. The registry pushes the current
+// registry-wide and per-namespace limits on startup and after every
+// configuration reload, and skips its own count-based admission checks for
+// such backends.
+type CapacityLimiter interface {
+	SetCapacityLimits(limits CapacityLimits, tenants map[string]CapacityLimits)
+}
+
+// capacityLimiter returns the backend, or the backend wrapped by a chain of
+// decorators, that enforces capacity limits natively.
+func (r *Registry) capacityLimiter() (CapacityLimiter, bool) {
+	return FindBackend[CapacityLimiter](r.backend)
+}
+
+// applyCapacityLimits hands the limits of cfg to the backend when it
+// enforces them itself.
+func (r *Registry) applyCapacityLimits(cfg *Config) {
+	if limiter, ok := r.capacityLimiter(); ok {
+		limiter.SetCapacityLimits(cfg.Limits, cfg.TenantLimits())
+	}
+}
+
+// admitRelay checks relayID against the registry-wide and namespace relay
+// limits for backends that do not enforce limits natively. The check counts
+// existing entries before the write, so concurrent registrations may
+// briefly overshoot the limit.
+func (r *Registry) admitRelay(ctx context.Context, namespace, relayID string) error {
+	cfg := r.config()
+	tenant, _ := cfg.Tenant(namespace)
+	if _, native := r.capacityLimiter(); native || (cfg.Limits.MaxRelays <= 0 && tenant.Limits.MaxRelays <= 0) {
+		return nil
+	}
+
+	relays, err := r.backend.ListRelays(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, relay := range relays {
+		if relay.ID == relayID {
+			return nil
+		}
+	}
+
+	if limit := tenant.Limits.MaxRelays; limit > 0 && len(relays) >= limit {
+		return fmt.Errorf("%w: namespace %s relay limit of %d reached", ErrResourceExhausted, namespace, limit)
+	}
+
+	if limit := cfg.Limits.MaxRelays; limit > 0 {
+		total, err := r.countEntries(ctx, func(ns string) (int, error) {
+			relays, err := r.backend.ListRelays(ctx, ns)
+			return len(relays), err
+		})
+		if err != nil {
+			return err
+		}
+		if total >= limit {
+			return fmt.Errorf("%w: relay limit of %d reached", ErrResourceExhausted, limit)
+		}
+	}
+
+	return nil
+}
+
+// admitAgent checks an agent registration against the registry-wide and
+// namespace agent and per-relay limits for backends that do not enforce
+// limits natively. Like admitRelay it is best effort under concurrent
+// registrations.
+func (r *Registry) admitAgent(ctx context.Context, namespace, agentID, relayID string) error {
+	cfg := r.config()
+	if _, native := r.capacityLimiter(); native {
+		return nil
+	}
+	tenant, _ := cfg.Tenant(namespace)
+
+	if cfg.Limits.MaxAgents > 0 || tenant.Limits.MaxAgents > 0 {
+		_, err := r.backend.GetAgentPlacement(ctx, namespace, agentID)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			if err := r.admitNewAgent(ctx, namespace, cfg.Limits.MaxAgents, tenant.Limits.MaxAgents); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		}
+	}
+
+	perRelay := cfg.Limits.MaxAgentsPerRelay
+	if limit := tenant.Limits.MaxAgentsPerRelay; limit > 0 && (perRelay <= 0 || limit < perRelay) {
+		perRelay = limit
+	}
+
+	if perRelay > 0 {
+		agents, err := r.backend.ListRelayAgents(ctx, namespace, relayID)
+		if err != nil {
+			// Let the backend report unknown relays on the write itself.
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		for _, agent := range agents {
+			if agent.ID == agentID {
+				return nil
+			}
+		}
+
+		if len(agents) >= perRelay {
+			return fmt.Errorf("%w: relay %s agent limit of %d reached", ErrResourceExhausted, relayID, perRelay)
+		}
+	}
+
+	return nil
+}
+
+// admitNewAgent checks the registry-wide and namespace agent limits for an
+// agent not yet registered in namespace.
+func (r *Registry) admitNewAgent(ctx context.Context, namespace string, limit, tenantLimit int) error {
+	if tenantLimit > 0 {
+		agents, err := r.backend.ListAgents(ctx, namespace)
+		if err != nil {
+			return err
+		}
+		if len(agents) >= tenantLimit {
+			return fmt.Errorf("%w: namespace %s agent limit of %d reached", ErrResourceExhausted, namespace, tenantLimit)
+		}
+	}
+
+	if limit > 0 {
+		total, err := r.countEntries(ctx, func(ns string) (int, error) {
+			agents, err := r.backend.ListAgents(ctx, ns)
+			return len(agents), err
+		})
+		if err != nil {
+			return err
+		}
+		if total >= limit {
+			return fmt.Errorf("%w: agent limit of %d reached", ErrResourceExhausted, limit)
+		}
+	}
+
+	return nil
+}
+
+// countEntries sums count over every namespace holding entries.
+func (r *Registry) countEntries(ctx context.Context, count func(namespace string) (int, error)) (int, error) {
+	namespaces, err := r.backend.ListNamespaces(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, namespace := range namespaces {
+		n, err := count(namespace)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+// recordCapacityRejection counts and logs a registration rejected by a
+// capacity limit.
+func (r *Registry) recordCapacityRejection(ctx context.Context, method string, err error, attrs ...slog.Attr) {
+	total := r.capacityRejections.Add(1)
+
+	attrs = append([]slog.Attr{
+		slog.String("method", method),
+		slog.String("error", err.Error()),
+		slog.Uint64("rejected_total", total),
+	}, attrs...)
+	slog.LogAttrs(ctx, slog.LevelWarn, "registration rejected by capacity limit", attrs...)
+}
+
+// CapacityRejections returns the number of registrations rejected by
+// capacity limits since the registry started.
+func (r *Registry) CapacityRejections() uint64 {
+	return r.capacityRejections.Load()
+}
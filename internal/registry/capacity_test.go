@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistryEnforcesRelayLimit(t *testing.T) {
+	backend := newTTLCleanupBackend()
+	backend.relays["relay-1"] = Relay{ID: "relay-1"}
+	backend.relays["relay-2"] = Relay{ID: "relay-2"}
+
+	reg := &Registry{
+		cfg:     &Config{Limits: CapacityLimits{MaxRelays: 2}},
+		backend: backend,
+	}
+
+	ctx := context.Background()
+	if err := reg.RegisterRelay(ctx, Relay{ID: "relay-3"}); !errors.Is(err, ErrResourceExhausted) {
+		t.Fatalf("expected ErrResourceExhausted, got %v", err)
+	}
+
+	if err := reg.RegisterRelay(ctx, Relay{ID: "relay-1"}); err != nil {
+		t.Fatalf("expected re-registration to be admitted, got %v", err)
+	}
+
+	if got := reg.CapacityRejections(); got != 1 {
+		t.Fatalf("expected 1 rejection, got %d", got)
+	}
+}
+
+func TestRegistryEnforcesAgentLimits(t *testing.T) {
+	backend := newTTLCleanupBackend()
+	backend.relays["relay-1"] = Relay{ID: "relay-1"}
+	backend.relays["relay-2"] = Relay{ID: "relay-2"}
+	backend.agents["agent-1"] = Agent{ID: "agent-1"}
+	backend.agents["agent-2"] = Agent{ID: "agent-2"}
+	backend.placements["agent-1"] = "relay-1"
+	backend.placements["agent-2"] = "relay-2"
+	backend.relayAgents["relay-1"] = map[string]struct{}{"agent-1": {}}
+	backend.relayAgents["relay-2"] = map[string]struct{}{"agent-2": {}}
+
+	reg := &Registry{
+		cfg:     &Config{Limits: CapacityLimits{MaxAgents: 2, MaxAgentsPerRelay: 1}},
+		backend: backend,
+	}
+
+	ctx := context.Background()
+	if err := reg.RegisterAgent(ctx, Agent{ID: "agent-3"}, "relay-1"); !errors.Is(err, ErrResourceExhausted) {
+		t.Fatalf("expected ErrResourceExhausted for agent limit, got %v", err)
+	}
+
+	if err := reg.RegisterAgent(ctx, Agent{ID: "agent-2"}, "relay-1"); !errors.Is(err, ErrResourceExhausted) {
+		t.Fatalf("expected ErrResourceExhausted for per relay limit, got %v", err)
+	}
+
+	if err := reg.RegisterAgent(ctx, Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("expected re-registration to be admitted, got %v", err)
+	}
+
+	if got := reg.CapacityRejections(); got != 2 {
+		t.Fatalf("expected 2 rejections, got %d", got)
+	}
+}
+
+func TestRegistryDelegatesLimitsToCapacityLimiter(t *testing.T) {
+	backend := &capacityLimiterBackend{ttlCleanupBackend: newTTLCleanupBackend()}
+	backend.relays["relay-1"] = Relay{ID: "relay-1"}
+
+	cfg := &Config{
+		Backend: BackendConfig{Type: MemoryRegistryBackend},
+		GRPC:    GRPCConfig{ListenPort: 50051},
+		TTL:     TTLConfig{Relay: 30 * time.Second, Agent: 30 * time.Second},
+		Limits:  CapacityLimits{MaxRelays: 1},
+	}
+	reg, err := New(cfg, backend)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if backend.limits != cfg.Limits {
+		t.Fatalf("expected limits %+v pushed to backend, got %+v", cfg.Limits, backend.limits)
+	}
+
+	// The backend enforces limits itself, so the registry must not reject
+	// based on its own count.
+	ctx := context.Background()
+	if err := reg.RegisterRelay(ctx, Relay{ID: "relay-2"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	next := *cfg
+	next.Limits = CapacityLimits{MaxRelays: 5, MaxAgents: 50}
+	if _, err := reg.ApplyConfig(ctx, &next); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if backend.limits != next.Limits {
+		t.Fatalf("expected reloaded limits %+v, got %+v", next.Limits, backend.limits)
+	}
+}
+
+type capacityLimiterBackend struct {
+	*ttlCleanupBackend
+	limits CapacityLimits
+}
+
+func (b *capacityLimiterBackend) SetCapacityLimits(limits CapacityLimits, tenants map[string]CapacityLimits) {
+	b.limits = limits
+}
@@ -2,6 +2,9 @@ package registry
 
 import (
 	"fmt"
+	"log/slog"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -11,39 +14,295 @@ import (
 type Config struct {
 	// Backend defines which registry backend implementation is used
 	// (e.g. memory, redis, etcd, consul) and its associated configuration.
-	Backend BackendConfig
+	Backend BackendConfig `yaml:"backend" toml:"backend"`
 
 	// GRPC defines the gRPC server configuration used to expose
 	// the registry control plane APIs.
-	GRPC GRPCConfig
+	GRPC GRPCConfig `yaml:"grpc" toml:"grpc"`
 
 	// TTL defines liveness and expiration semantics for relays and agents.
 	// These values are enforced at the registry layer, independent of backend.
-	TTL TTLConfig
+	TTL TTLConfig `yaml:"ttl" toml:"ttl"`
+
+	// Log defines logging behaviour for the registry process.
+	Log LogConfig `yaml:"log" toml:"log"`
+
+	// Admin defines the administrative gRPC service used by operators
+	// for actions such as configuration reloads.
+	Admin AdminConfig `yaml:"admin" toml:"admin"`
+
+	// Shutdown defines how the registry drains traffic when it stops.
+	Shutdown ShutdownConfig `yaml:"shutdown" toml:"shutdown"`
+
+	// Limits caps how many relays and agents the registry admits.
+	Limits CapacityLimits `yaml:"limits" toml:"limits"`
+
+	// RateLimit throttles gRPC requests per client.
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+
+	// Tracing defines OpenTelemetry trace export.
+	Tracing TracingConfig `yaml:"tracing" toml:"tracing"`
+
+	// Degraded defines how the registry keeps serving while the backend
+	// is unavailable.
+	Degraded DegradedConfig `yaml:"degraded" toml:"degraded"`
+
+	// HTTP defines the optional read-only HTTP/JSON gateway.
+	HTTP HTTPConfig `yaml:"http" toml:"http"`
+
+	// Audit defines the audit log of ownership and lifecycle changes.
+	Audit AuditConfig `yaml:"audit" toml:"audit"`
+
+	// PlacementHistory defines the per-agent placement history kept for
+	// incident analysis.
+	PlacementHistory PlacementHistoryConfig `yaml:"placement_history" toml:"placement_history"`
+
+	// Tenants scopes customer fleets to namespaces, binding caller
+	// identities to them and overriding limits and TTLs per namespace.
+	// Namespaces not listed here, other than DefaultNamespace, are
+	// rejected.
+	Tenants []TenantConfig `yaml:"tenants" toml:"tenants"`
+}
+
+// TenantConfig defines a tenant namespace.
+type TenantConfig struct {
+	// Namespace names the tenant. It must be a lowercase DNS label.
+	Namespace string `yaml:"namespace" toml:"namespace"`
+
+	// Identities lists the caller identities, such as "cert:fleet-a" for
+	// a client certificate common name, pinned to this namespace. When
+	// set, other callers may not use the namespace without the admin
+	// token.
+	Identities []string `yaml:"identities" toml:"identities"`
+
+	// Limits caps the relays and agents of this namespace, on top of the
+	// registry-wide limits.
+	Limits CapacityLimits `yaml:"limits" toml:"limits"`
+
+	// TTL overrides the registry TTLs for this namespace.
+	TTL TenantTTLConfig `yaml:"ttl" toml:"ttl"`
+}
+
+// TenantTTLConfig overrides the relay and agent TTLs of a namespace. Zero
+// uses the registry TTL.
+type TenantTTLConfig struct {
+	Relay time.Duration `yaml:"relay" toml:"relay"`
+	Agent time.Duration `yaml:"agent" toml:"agent"`
+}
+
+// Audit sink types.
+const (
+	AuditSinkSlog = "slog"
+	AuditSinkFile = "file"
+	AuditSinkNone = "none"
+)
+
+// AuditConfig defines the audit log. Every event is written to the sink
+// and kept in a bounded in-memory buffer that the admin service can query
+// and stream.
+type AuditConfig struct {
+	// Enabled determines whether audit events are recorded.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// Sink selects where events are written: "slog" (the default), "file"
+	// for JSON lines appended to FilePath, or "none" to only keep them in
+	// memory.
+	Sink string `yaml:"sink" toml:"sink"`
+
+	// FilePath is the JSON lines file used by the file sink.
+	FilePath string `yaml:"file_path" toml:"file_path"`
+
+	// BufferSize is the number of recent events kept in memory. Zero
+	// defaults to DefaultAuditBufferSize.
+	BufferSize int `yaml:"buffer_size" toml:"buffer_size"`
+}
+
+// PlacementHistoryConfig defines the recent placement history kept in
+// memory for each agent. It is bounded both per agent and in age, so it is
+// no substitute for long-term analytics.
+type PlacementHistoryConfig struct {
+	// Enabled determines whether placement changes are recorded.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// Entries is the number of placement changes kept per agent. Zero
+	// defaults to DefaultPlacementHistoryEntries.
+	Entries int `yaml:"entries" toml:"entries"`
+
+	// Retention is how long a placement change is kept. Zero defaults to
+	// DefaultPlacementHistoryRetention.
+	Retention time.Duration `yaml:"retention" toml:"retention"`
+}
+
+// HTTPConfig defines the read-only HTTP/JSON gateway for browser
+// dashboards. It serves the same registry as the gRPC API, over TLS with
+// the gRPC certificate when gRPC TLS is enabled, and requires the admin
+// token when one is set.
+type HTTPConfig struct {
+	// Enabled determines whether the HTTP listener is started.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// ListenAddress is the network address the HTTP server binds to.
+	ListenAddress string `yaml:"listen_address" toml:"listen_address"`
+
+	// ListenPort is the TCP port the HTTP server listens on.
+	ListenPort int `yaml:"listen_port" toml:"listen_port"`
+
+	// AllowedOrigins lists the browser origins allowed to call the
+	// gateway cross-origin. "*" allows any origin.
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"`
+
+	// EventsInterval is how often an open change stream checks the
+	// registry for changes. Zero defaults to DefaultHTTPEventsInterval.
+	EventsInterval time.Duration `yaml:"events_interval" toml:"events_interval"`
+}
+
+// DegradedConfig defines degraded-mode serving. While backend calls fail
+// with ErrUnavailable the registry answers relay lists and placement
+// lookups from the last state it read, flagged as stale, accepts
+// heartbeats into a buffer that is replayed once the backend returns, and
+// pauses the TTL sweeper until every live relay and agent has had a TTL to
+// heartbeat again.
+type DegradedConfig struct {
+	// Enabled determines whether degraded-mode serving is used.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// MaxStaleness bounds the age of last-known state that is served.
+	// Zero serves it for the whole outage.
+	MaxStaleness time.Duration `yaml:"max_staleness" toml:"max_staleness"`
+
+	// MaxBufferedHeartbeats caps the relays and agents whose heartbeats
+	// are held for replay; further heartbeats fail with ErrUnavailable.
+	// Zero defaults to DefaultMaxBufferedHeartbeats.
+	MaxBufferedHeartbeats int `yaml:"max_buffered_heartbeats" toml:"max_buffered_heartbeats"`
+}
+
+// TracingConfig defines OpenTelemetry tracing. When enabled, spans are
+// created for every RPC, registry operation, backend call and TTL sweep
+// and exported to an OTLP/gRPC collector.
+type TracingConfig struct {
+	// Enabled determines whether spans are exported.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// Endpoint is the host:port of the OTLP/gRPC collector.
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+
+	// Insecure disables TLS on the connection to the collector.
+	Insecure bool `yaml:"insecure" toml:"insecure"`
+
+	// ServiceName is reported as the service.name resource attribute.
+	// Empty defaults to DefaultTracingServiceName.
+	ServiceName string `yaml:"service_name" toml:"service_name"`
+
+	// SampleRatio is the fraction of new traces that are sampled, between
+	// 0 and 1. Zero defaults to sampling every trace. Traces started by a
+	// caller keep the caller's sampling decision.
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// RateLimitConfig defines per-client token bucket limits for the registry
+// gRPC API. Clients are identified by relay ID when the request carries
+// one, and otherwise by TLS client certificate or peer address.
+type RateLimitConfig struct {
+	// Heartbeat limits HeartbeatRelay and HeartbeatAgent.
+	Heartbeat RateLimit `yaml:"heartbeat" toml:"heartbeat"`
+
+	// Registration limits RegisterRelay and RegisterAgent.
+	Registration RateLimit `yaml:"registration" toml:"registration"`
+
+	// List limits ListRelays, ListAgents and GetAgentPlacement.
+	List RateLimit `yaml:"list" toml:"list"`
+}
+
+// RateLimit is a token bucket refilled at Rate tokens per second.
+type RateLimit struct {
+	// Rate is the sustained number of requests per second allowed for
+	// each client. Zero disables the limit.
+	Rate float64 `yaml:"rate" toml:"rate"`
+
+	// Burst is the number of requests a client may make at once. Zero
+	// defaults to Rate rounded up, and at least one.
+	Burst int `yaml:"burst" toml:"burst"`
+}
+
+// CapacityLimits bounds the registry's size so a misbehaving relay cannot
+// register an unbounded number of agents. Zero disables a limit.
+// Registrations that only update an existing entry are always admitted.
+type CapacityLimits struct {
+	// MaxRelays is the maximum number of registered relays.
+	MaxRelays int `yaml:"max_relays" toml:"max_relays"`
+
+	// MaxAgents is the maximum number of registered agents.
+	MaxAgents int `yaml:"max_agents" toml:"max_agents"`
+
+	// MaxAgentsPerRelay is the maximum number of agents placed on a
+	// single relay.
+	MaxAgentsPerRelay int `yaml:"max_agents_per_relay" toml:"max_agents_per_relay"`
+}
+
+// ShutdownConfig defines the graceful shutdown sequence.
+type ShutdownConfig struct {
+	// Timeout bounds draining in-flight gRPC requests and waiting for an
+	// in-progress TTL sweep. Remaining requests are cancelled once it
+	// elapses. Zero uses DefaultShutdownTimeout.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+
+	// PreStopDelay is how long the registry keeps serving after reporting
+	// NOT_SERVING on the health service, giving load balancers time to
+	// stop routing new requests to it.
+	PreStopDelay time.Duration `yaml:"pre_stop_delay" toml:"pre_stop_delay"`
+}
+
+// LogConfig defines logging settings for the registry process.
+type LogConfig struct {
+	// Level is the minimum log level: debug, info, warn or error.
+	// Empty defaults to info.
+	Level string `yaml:"level" toml:"level"`
+}
+
+// AdminConfig defines the administrative gRPC service configuration.
+type AdminConfig struct {
+	// Enabled determines whether the admin service is registered
+	// on the gRPC server.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// Token is the bearer token callers must present in the
+	// "authorization" metadata. Empty disables admin authentication.
+	Token string `yaml:"token" toml:"token"`
 }
 
 // GRPCConfig defines the gRPC server configuration for the registry service.
 type GRPCConfig struct {
 	// ListenAddress is the network address the gRPC server binds to.
-	ListenAddress string
+	ListenAddress string `yaml:"listen_address" toml:"listen_address"`
 
 	// ListenPort is the TCP port the gRPC server listens on.
-	ListenPort int
+	ListenPort int `yaml:"listen_port" toml:"listen_port"`
 
 	// TLS defines TLS configuration for securing the gRPC transport.
-	TLS TLSConfig
+	TLS TLSConfig `yaml:"tls" toml:"tls"`
 }
 
 // TLSConfig defines TLS settings for securing gRPC communication.
 type TLSConfig struct {
 	// Enabled determines whether TLS is enabled for the gRPC server.
-	Enabled bool
+	Enabled bool `yaml:"enabled" toml:"enabled"`
 
 	// CertPath is the filesystem path to the TLS certificate.
-	CertPath string
+	CertPath string `yaml:"cert_path" toml:"cert_path"`
 
 	// KeyPath is the filesystem path to the TLS private key.
-	KeyPath string
+	KeyPath string `yaml:"key_path" toml:"key_path"`
+
+	// ClientCAPath is the filesystem path to a PEM bundle of CAs that
+	// client certificates are verified against. When set, the gRPC server
+	// and the HTTP gateway require a valid client certificate from every
+	// caller.
+	ClientCAPath string `yaml:"client_ca_path" toml:"client_ca_path"`
+}
+
+// ClientAuth reports whether callers must present a client certificate.
+func (t TLSConfig) ClientAuth() bool {
+	return t.Enabled && t.ClientCAPath != ""
 }
 
 // TTLConfig defines time-to-live and liveness expectations
@@ -51,30 +310,185 @@ type TLSConfig struct {
 type TTLConfig struct {
 	// Relay defines the maximum allowed duration since the last
 	// heartbeat before a relay is considered unhealthy.
-	Relay time.Duration
+	Relay time.Duration `yaml:"relay" toml:"relay"`
 
 	// Agent defines the maximum allowed duration since the last
 	// heartbeat before an agent is considered unhealthy.
-	Agent time.Duration
+	Agent time.Duration `yaml:"agent" toml:"agent"`
+
+	// SweepInterval is the base delay between TTL cleanup passes. It must be
+	// shorter than Relay and Agent. When zero it defaults to a quarter of
+	// the shortest TTL.
+	SweepInterval time.Duration `yaml:"sweep_interval" toml:"sweep_interval"`
+
+	// AdaptiveSweep enables adaptive sweep scheduling: the interval shrinks
+	// while many entries are close to expiry and backs off while sweeps are
+	// slow or failing, staying within MinSweepInterval and MaxSweepInterval.
+	AdaptiveSweep bool `yaml:"adaptive_sweep" toml:"adaptive_sweep"`
+
+	// MinSweepInterval bounds how short the adaptive interval may become.
+	// When zero it defaults to a quarter of the base sweep interval.
+	MinSweepInterval time.Duration `yaml:"min_sweep_interval" toml:"min_sweep_interval"`
+
+	// MaxSweepInterval bounds how long the adaptive interval may become.
+	// When zero it defaults to four times the base sweep interval.
+	MaxSweepInterval time.Duration `yaml:"max_sweep_interval" toml:"max_sweep_interval"`
+
+	// Coordination defines how TTL sweeps are coordinated across
+	// registry replicas that share the same backend.
+	Coordination SweepCoordinationConfig `yaml:"coordination" toml:"coordination"`
 
 	// TODO(registry-ttl): add optional stale grace period to support soft TTL
 	// lifecycle (ACTIVE -> STALE -> DELETING) before hard removal.
-	// TODO(registry-ttl): add configurable TTL sweep interval independent of TTL
-	// values for adaptive/backpressure-aware scheduler evolution.
+}
+
+// SweepCoordinationConfig defines how replicas elect a single TTL sweeper.
+// When disabled every replica sweeps independently, which is only safe
+// when each replica owns its backend (e.g. the memory backend).
+type SweepCoordinationConfig struct {
+	// Enabled determines whether the TTL sweeper only runs on the replica
+	// currently holding the backend sweeper lease.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// ReplicaID identifies this registry replica as a lease holder.
+	ReplicaID string `yaml:"replica_id" toml:"replica_id"`
+
+	// LeaseTTL is how long an acquired sweeper lease stays valid without
+	// renewal. The holder renews it every third of LeaseTTL, independently
+	// of sweeps. Defaults to three times the shortest configured TTL.
+	LeaseTTL time.Duration `yaml:"lease_ttl" toml:"lease_ttl"`
 }
 
 // BackendConfig defines which registry backend implementation is used
 // and provides backend-specific configuration.
 type BackendConfig struct {
 	// Type specifies the registry backend implementation.
-	Type RegistryBackend
+	Type RegistryBackend `yaml:"type" toml:"type"`
 
 	// Redis contains Redis-specific configuration when the Redis backend is used.
 	// It must be non-nil when Type is set to the Redis backend.
-	Redis  *RedisConfig
-	Etcd   *EtcdConfig
-	Consul *ConsulConfig
-	Memory *MemoryConfig
+	Redis  *RedisConfig  `yaml:"redis" toml:"redis"`
+	Etcd   *EtcdConfig   `yaml:"etcd" toml:"etcd"`
+	Consul *ConsulConfig `yaml:"consul" toml:"consul"`
+	Memory *MemoryConfig `yaml:"memory" toml:"memory"`
+
+	// Raft contains configuration for the embedded replicated backend.
+	// It must be non-nil when Type is set to the Raft backend.
+	Raft *RaftConfig `yaml:"raft" toml:"raft"`
+
+	// Cache configures an optional read-through cache in front of the
+	// backend.
+	Cache CacheConfig `yaml:"cache" toml:"cache"`
+
+	// Resilience configures timeouts, retries and circuit breaking for
+	// backend calls.
+	Resilience ResilienceConfig `yaml:"resilience" toml:"resilience"`
+
+	// Primary and Shadow select the two backends of the shadow backend.
+	// Both must be non-nil when Type is set to the shadow backend.
+	Primary *BackendTarget `yaml:"primary" toml:"primary"`
+	Shadow  *BackendTarget `yaml:"shadow" toml:"shadow"`
+
+	// Shadowing tunes how the shadow backend mirrors writes and compares
+	// reads.
+	Shadowing ShadowConfig `yaml:"shadowing" toml:"shadowing"`
+}
+
+// BackendTarget selects one concrete backend of the shadow backend and
+// its settings.
+type BackendTarget struct {
+	// Type specifies the backend implementation. It cannot be the shadow
+	// backend.
+	Type RegistryBackend `yaml:"type" toml:"type"`
+
+	Redis  *RedisConfig  `yaml:"redis" toml:"redis"`
+	Etcd   *EtcdConfig   `yaml:"etcd" toml:"etcd"`
+	Consul *ConsulConfig `yaml:"consul" toml:"consul"`
+	Memory *MemoryConfig `yaml:"memory" toml:"memory"`
+	Raft   *RaftConfig   `yaml:"raft" toml:"raft"`
+}
+
+// BackendConfig returns the backend configuration t describes.
+func (t *BackendTarget) BackendConfig() BackendConfig {
+	return BackendConfig{
+		Type:   t.Type,
+		Redis:  t.Redis,
+		Etcd:   t.Etcd,
+		Consul: t.Consul,
+		Memory: t.Memory,
+		Raft:   t.Raft,
+	}
+}
+
+// ShadowConfig defines the shadow backend, used to trial a new backend
+// under production traffic. Every write is applied to the primary and then
+// mirrored to the shadow, reads are served by the primary and repeated
+// against the shadow, and differences between the two are logged and
+// counted. Mirroring and comparison run in the background so the shadow
+// never slows down or fails a request.
+type ShadowConfig struct {
+	// QueueSize bounds the mirrored writes and comparisons waiting for the
+	// shadow; further ones are dropped and counted. Zero defaults to
+	// DefaultShadowQueueSize.
+	QueueSize int `yaml:"queue_size" toml:"queue_size"`
+
+	// CallTimeout bounds each call to the shadow. Zero defaults to
+	// DefaultShadowCallTimeout.
+	CallTimeout time.Duration `yaml:"call_timeout" toml:"call_timeout"`
+}
+
+// ResilienceConfig defines how backend calls are protected against a slow
+// or failing backend. Every call is bounded by CallTimeout, idempotent
+// calls (heartbeats, lists and placement lookups) are retried with
+// exponential backoff, and after FailureThreshold consecutive failures the
+// circuit opens and calls fail fast with ErrUnavailable until a probe call
+// succeeds.
+type ResilienceConfig struct {
+	// Enabled determines whether backend calls are protected.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// CallTimeout bounds each backend call attempt. Zero defaults to
+	// DefaultBackendCallTimeout.
+	CallTimeout time.Duration `yaml:"call_timeout" toml:"call_timeout"`
+
+	// MaxAttempts is the total number of attempts for idempotent calls,
+	// including the first. One disables retries. Zero defaults to
+	// DefaultBackendMaxAttempts.
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts"`
+
+	// RetryBackoff is the delay before the first retry; it doubles with
+	// each further retry. Zero defaults to DefaultBackendRetryBackoff.
+	RetryBackoff time.Duration `yaml:"retry_backoff" toml:"retry_backoff"`
+
+	// FailureThreshold is the number of consecutive failed calls that
+	// opens the circuit. Zero defaults to DefaultBackendFailureThreshold.
+	FailureThreshold int `yaml:"failure_threshold" toml:"failure_threshold"`
+
+	// OpenTimeout is how long the circuit stays open before a probe call
+	// is let through. Zero defaults to DefaultBackendOpenTimeout.
+	OpenTimeout time.Duration `yaml:"open_timeout" toml:"open_timeout"`
+}
+
+// CacheConfig defines the read-through cache for relay lists and agent
+// placements. Cached entries are invalidated by writes made through this
+// replica and, when the backend supports change notifications, by writes
+// made through other replicas. Otherwise they may be stale by up to their
+// TTL.
+type CacheConfig struct {
+	// Enabled determines whether backend reads are cached.
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+
+	// RelayTTL bounds how long a cached relay list is served. Zero
+	// defaults to DefaultCacheRelayTTL.
+	RelayTTL time.Duration `yaml:"relay_ttl" toml:"relay_ttl"`
+
+	// PlacementTTL bounds how long a cached agent placement is served.
+	// Zero defaults to DefaultCachePlacementTTL.
+	PlacementTTL time.Duration `yaml:"placement_ttl" toml:"placement_ttl"`
+
+	// MaxPlacements caps the number of cached agent placements. Zero
+	// defaults to DefaultCacheMaxPlacements.
+	MaxPlacements int `yaml:"max_placements" toml:"max_placements"`
 }
 
 // RegistryBackend represents the supported registry backend implementations.
@@ -83,19 +497,19 @@ type RegistryBackend string
 // RedisConfig defines configuration for the Redis-backed registry implementation.
 type RedisConfig struct {
 	// Address is the Redis server hostname or IP.
-	Address string
+	Address string `yaml:"address" toml:"address"`
 
 	// Port is the Redis server port.
-	Port int
+	Port int `yaml:"port" toml:"port"`
 
 	// Username is the Redis username used for authentication.
-	Username string
+	Username string `yaml:"username" toml:"username"`
 
 	// Password is the Redis password used for authentication.
-	Password string
+	Password string `yaml:"password" toml:"password"`
 
 	// DB is the Redis logical database index to use.
-	DB int
+	DB int `yaml:"db" toml:"db"`
 }
 
 // EtcdConfig defines configuration for the Etcd-backed registry backend.
@@ -116,12 +530,71 @@ type EtcdConfig struct{}
 //   - Decide on session vs KV-based liveness tracking
 type ConsulConfig struct{}
 
+// RaftConfig defines configuration for the embedded Raft backend, which
+// replicates the in-memory backend state across a fixed set of registry
+// nodes without an external store.
+type RaftConfig struct {
+	// NodeID uniquely identifies this node within the cluster. It must
+	// match one of the IDs in Peers.
+	NodeID string `yaml:"node_id" toml:"node_id"`
+
+	// BindAddress is the host:port this node listens on for Raft
+	// replication and for writes forwarded by followers.
+	BindAddress string `yaml:"bind_address" toml:"bind_address"`
+
+	// AdvertiseAddress is the host:port other nodes use to reach this
+	// node. Empty defaults to BindAddress.
+	AdvertiseAddress string `yaml:"advertise_address" toml:"advertise_address"`
+
+	// DataDir holds the Raft log, stable store and snapshots.
+	DataDir string `yaml:"data_dir" toml:"data_dir"`
+
+	// Peers lists every cluster member, including this node, as
+	// "node-id=host:port". Typically three or five nodes.
+	Peers []string `yaml:"peers" toml:"peers"`
+
+	// ApplyTimeout bounds how long a write waits to be committed,
+	// including forwarding to the leader. Zero defaults to five seconds.
+	ApplyTimeout time.Duration `yaml:"apply_timeout" toml:"apply_timeout"`
+
+	// HeartbeatFlushInterval is how often heartbeats received by this node
+	// are committed to the Raft log as one batch. Registrations and
+	// removals are always committed immediately. Zero defaults to one
+	// second.
+	HeartbeatFlushInterval time.Duration `yaml:"heartbeat_flush_interval" toml:"heartbeat_flush_interval"`
+}
+
+// RaftPeer is a parsed RaftConfig peer entry.
+type RaftPeer struct {
+	ID      string
+	Address string
+}
+
 // MemoryConfig defines configuration for the in-memory registry backend.
 //
 // TODO:
-//   - Add optional capacity limits
 //   - Add debug logging / metrics toggles
-type MemoryConfig struct{}
+type MemoryConfig struct {
+	// SnapshotPath is the local file relays, agents and placements are
+	// persisted to and restored from at startup. Empty disables snapshots.
+	SnapshotPath string `yaml:"snapshot_path" toml:"snapshot_path"`
+
+	// SnapshotInterval is the delay between periodic snapshots. Zero
+	// disables periodic snapshots; a snapshot is still written on shutdown
+	// when SnapshotPath is set.
+	SnapshotInterval time.Duration `yaml:"snapshot_interval" toml:"snapshot_interval"`
+
+	// WALPath is the local append-only log every mutation is written to
+	// between snapshots. It is replayed on startup after the snapshot and
+	// compacted each time a snapshot is written. Empty disables the log;
+	// it requires SnapshotPath and SnapshotInterval.
+	WALPath string `yaml:"wal_path" toml:"wal_path"`
+
+	// WALFlushInterval is how often batched heartbeats are appended to the
+	// log. Registrations and removals are always written immediately.
+	// Zero defaults to one second.
+	WALFlushInterval time.Duration `yaml:"wal_flush_interval" toml:"wal_flush_interval"`
+}
 
 func ParseRegistryBackend(backend string) (RegistryBackend, error) {
 	if registryBackend, ok := registryMap[backend]; ok {
@@ -132,26 +605,143 @@ func ParseRegistryBackend(backend string) (RegistryBackend, error) {
 }
 
 func (c *Config) Validate() error {
-	switch c.Backend.Type {
+	if err := c.Backend.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.GRPC.Validate(); err != nil {
+		return fmt.Errorf("GRPC Config invalid: %w", err)
+	}
+
+	if err := c.TTL.Validate(); err != nil {
+		return fmt.Errorf("TTL Config invalid: %w", err)
+	}
+
+	if err := c.Log.Validate(); err != nil {
+		return fmt.Errorf("Log Config invalid: %w", err)
+	}
+
+	if err := c.Shutdown.Validate(); err != nil {
+		return fmt.Errorf("Shutdown Config invalid: %w", err)
+	}
+
+	if err := c.Limits.Validate(); err != nil {
+		return fmt.Errorf("Limits Config invalid: %w", err)
+	}
+
+	if err := c.RateLimit.Validate(); err != nil {
+		return fmt.Errorf("RateLimit Config invalid: %w", err)
+	}
+
+	if err := c.Tracing.Validate(); err != nil {
+		return fmt.Errorf("Tracing Config invalid: %w", err)
+	}
+
+	if err := c.Degraded.Validate(); err != nil {
+		return fmt.Errorf("Degraded Config invalid: %w", err)
+	}
+
+	if err := c.HTTP.Validate(); err != nil {
+		return fmt.Errorf("HTTP Config invalid: %w", err)
+	}
+
+	if err := c.Audit.Validate(); err != nil {
+		return fmt.Errorf("Audit Config invalid: %w", err)
+	}
+
+	if err := c.PlacementHistory.Validate(); err != nil {
+		return fmt.Errorf("PlacementHistory Config invalid: %w", err)
+	}
+
+	if err := validateTenants(c.Tenants); err != nil {
+		return fmt.Errorf("Tenants Config invalid: %w", err)
+	}
+
+	if err := c.validateTenantIdentities(); err != nil {
+		return fmt.Errorf("Tenants Config invalid: %w", err)
+	}
+
+	if shortest, _ := c.ttlBounds(); c.TTL.SweepInterval >= shortest {
+		return fmt.Errorf("TTL Config invalid: %w", ErrSweepIntervalTooLong)
+	}
+
+	return nil
+}
+
+// Validate checks the backend selection and the settings of the selected
+// backend.
+func (c *BackendConfig) Validate() error {
+	switch c.Type {
 	case RedisRegistryBackend:
-		if c.Backend.Redis == nil {
+		if c.Redis == nil {
 			return ErrRedisConfigNil
 		}
 
-		if err := c.Backend.Redis.Validate(); err != nil {
+		if err := c.Redis.Validate(); err != nil {
 			return fmt.Errorf("redis config invalid: %w", err)
 		}
-	case MemoryRegistryBackend, EtcdRegistryBackend, ConsulRegistryBackend:
+	case MemoryRegistryBackend:
+		if c.Memory != nil {
+			if err := c.Memory.Validate(); err != nil {
+				return fmt.Errorf("memory config invalid: %w", err)
+			}
+		}
+	case RaftRegistryBackend:
+		if c.Raft == nil {
+			return ErrRaftConfigNil
+		}
+
+		if err := c.Raft.Validate(); err != nil {
+			return fmt.Errorf("raft config invalid: %w", err)
+		}
+	case ShadowRegistryBackend:
+		if c.Primary == nil || c.Shadow == nil {
+			return ErrShadowBackendNil
+		}
+
+		if err := c.Primary.Validate(); err != nil {
+			return fmt.Errorf("primary backend invalid: %w", err)
+		}
+
+		if err := c.Shadow.Validate(); err != nil {
+			return fmt.Errorf("shadow backend invalid: %w", err)
+		}
+
+		if err := c.Shadowing.Validate(); err != nil {
+			return fmt.Errorf("shadowing config invalid: %w", err)
+		}
+	case EtcdRegistryBackend, ConsulRegistryBackend:
 	default:
-		return fmt.Errorf("unknown registry backend: %s", c.Backend.Type)
+		return fmt.Errorf("unknown registry backend: %s", c.Type)
 	}
 
-	if err := c.GRPC.Validate(); err != nil {
-		return fmt.Errorf("GRPC Config invalid: %w", err)
+	if err := c.Cache.Validate(); err != nil {
+		return fmt.Errorf("cache config invalid: %w", err)
 	}
 
-	if err := c.TTL.Validate(); err != nil {
-		return fmt.Errorf("TTL Config invalid: %w", err)
+	if err := c.Resilience.Validate(); err != nil {
+		return fmt.Errorf("resilience config invalid: %w", err)
+	}
+
+	return nil
+}
+
+func (t *BackendTarget) Validate() error {
+	if t.Type == "" || t.Type == ShadowRegistryBackend {
+		return ErrShadowTargetInvalid
+	}
+
+	cfg := t.BackendConfig()
+	return cfg.Validate()
+}
+
+func (c *ShadowConfig) Validate() error {
+	if c.QueueSize < 0 {
+		return ErrShadowQueueSizeInvalid
+	}
+
+	if c.CallTimeout < 0 {
+		return ErrShadowCallTimeoutInvalid
 	}
 
 	return nil
@@ -183,8 +773,333 @@ func (c *ConsulConfig) Validate() error {
 	return nil
 }
 
+func (c *RaftConfig) Validate() error {
+	if c.NodeID == "" {
+		return ErrRaftNodeIDEmpty
+	}
+
+	if c.BindAddress == "" {
+		return ErrRaftBindAddressEmpty
+	}
+
+	if c.DataDir == "" {
+		return ErrRaftDataDirEmpty
+	}
+
+	if c.ApplyTimeout < 0 {
+		return ErrRaftApplyTimeoutInvalid
+	}
+
+	if c.HeartbeatFlushInterval < 0 {
+		return ErrRaftHeartbeatFlushInvalid
+	}
+
+	peers, err := c.ParsedPeers()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]struct{}, len(peers))
+	self := false
+	for _, peer := range peers {
+		if _, dup := seen[peer.ID]; dup {
+			return fmt.Errorf("%w: duplicate node id %q", ErrRaftPeersInvalid, peer.ID)
+		}
+		seen[peer.ID] = struct{}{}
+		self = self || peer.ID == c.NodeID
+	}
+
+	if !self {
+		return fmt.Errorf("%w: node id %q not listed", ErrRaftPeersInvalid, c.NodeID)
+	}
+
+	return nil
+}
+
+// ParsedPeers parses Peers into node IDs and addresses.
+func (c *RaftConfig) ParsedPeers() ([]RaftPeer, error) {
+	if len(c.Peers) == 0 {
+		return nil, fmt.Errorf("%w: no peers configured", ErrRaftPeersInvalid)
+	}
+
+	peers := make([]RaftPeer, 0, len(c.Peers))
+	for _, raw := range c.Peers {
+		id, address, ok := strings.Cut(raw, "=")
+		if !ok || id == "" || address == "" {
+			return nil, fmt.Errorf("%w: %q must be node-id=host:port", ErrRaftPeersInvalid, raw)
+		}
+		peers = append(peers, RaftPeer{ID: id, Address: address})
+	}
+
+	return peers, nil
+}
+
+func (c *CapacityLimits) Validate() error {
+	if c.MaxRelays < 0 || c.MaxAgents < 0 || c.MaxAgentsPerRelay < 0 {
+		return ErrCapacityLimitInvalid
+	}
+
+	return nil
+}
+
+func validateTenants(tenants []TenantConfig) error {
+	namespaces := make(map[string]struct{}, len(tenants))
+	identities := make(map[string]string)
+	for i := range tenants {
+		tenant := &tenants[i]
+		if err := tenant.Validate(); err != nil {
+			return err
+		}
+
+		if _, ok := namespaces[tenant.Namespace]; ok {
+			return fmt.Errorf("%w: %s", ErrTenantDuplicate, tenant.Namespace)
+		}
+		namespaces[tenant.Namespace] = struct{}{}
+
+		for _, identity := range tenant.Identities {
+			if other, ok := identities[identity]; ok {
+				return fmt.Errorf("%w: %s is bound to %s and %s", ErrTenantIdentityDuplicate, identity, other, tenant.Namespace)
+			}
+			identities[identity] = tenant.Namespace
+		}
+	}
+
+	return nil
+}
+
+// validateTenantIdentities rejects tenant identity bindings unless callers
+// must present a verified client certificate, since otherwise nothing ties
+// a caller to the identity it is bound by.
+func (c *Config) validateTenantIdentities() error {
+	if c.GRPC.TLS.ClientAuth() {
+		return nil
+	}
+
+	for _, tenant := range c.Tenants {
+		if len(tenant.Identities) > 0 {
+			return fmt.Errorf("%w: %s", ErrTenantClientAuthRequired, tenant.Namespace)
+		}
+	}
+
+	return nil
+}
+
+func (t *TenantConfig) Validate() error {
+	if !ValidNamespace(t.Namespace) {
+		return fmt.Errorf("%w: %q", ErrNamespaceInvalid, t.Namespace)
+	}
+
+	if err := t.Limits.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", t.Namespace, err)
+	}
+
+	if t.TTL.Relay < 0 || t.TTL.Agent < 0 {
+		return fmt.Errorf("%s: %w", t.Namespace, ErrTenantTTLInvalid)
+	}
+
+	for _, identity := range t.Identities {
+		if name, ok := strings.CutPrefix(identity, "cert:"); !ok || name == "" {
+			return fmt.Errorf("%s: %w: %q", t.Namespace, ErrTenantIdentityInvalid, identity)
+		}
+	}
+
+	return nil
+}
+
+// Tenant returns the configuration of namespace, if it is listed in
+// Tenants.
+func (c *Config) Tenant(namespace string) (TenantConfig, bool) {
+	for _, tenant := range c.Tenants {
+		if tenant.Namespace == namespace {
+			return tenant, true
+		}
+	}
+
+	return TenantConfig{}, false
+}
+
+// TenantTTL returns the relay and agent TTLs of namespace.
+func (c *Config) TenantTTL(namespace string) TTLConfig {
+	ttl := c.TTL
+	if tenant, ok := c.Tenant(namespace); ok {
+		if tenant.TTL.Relay > 0 {
+			ttl.Relay = tenant.TTL.Relay
+		}
+		if tenant.TTL.Agent > 0 {
+			ttl.Agent = tenant.TTL.Agent
+		}
+	}
+
+	return ttl
+}
+
+// ttlBounds returns the shortest and longest relay or agent TTL, taking
+// the tenant overrides into account.
+func (c *Config) ttlBounds() (shortest, longest time.Duration) {
+	shortest, longest = min(c.TTL.Relay, c.TTL.Agent), max(c.TTL.Relay, c.TTL.Agent)
+	for _, tenant := range c.Tenants {
+		for _, ttl := range []time.Duration{tenant.TTL.Relay, tenant.TTL.Agent} {
+			if ttl > 0 {
+				shortest, longest = min(shortest, ttl), max(longest, ttl)
+			}
+		}
+	}
+
+	return shortest, longest
+}
+
+// TenantLimits returns the per-namespace capacity limits of the tenants
+// that set any.
+func (c *Config) TenantLimits() map[string]CapacityLimits {
+	limits := make(map[string]CapacityLimits)
+	for _, tenant := range c.Tenants {
+		if tenant.Limits != (CapacityLimits{}) {
+			limits[tenant.Namespace] = tenant.Limits
+		}
+	}
+
+	return limits
+}
+
+func (c *RateLimitConfig) Validate() error {
+	for _, limit := range []*RateLimit{&c.Heartbeat, &c.Registration, &c.List} {
+		if err := limit.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *RateLimit) Validate() error {
+	if l.Rate < 0 || math.IsNaN(l.Rate) || math.IsInf(l.Rate, 0) {
+		return ErrRateLimitInvalid
+	}
+
+	if l.Burst < 0 {
+		return ErrRateBurstInvalid
+	}
+
+	return nil
+}
+
+// EffectiveBurst returns Burst, or the default derived from Rate when it
+// is unset.
+func (l *RateLimit) EffectiveBurst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+
+	return max(1, int(math.Ceil(l.Rate)))
+}
+
+func (c *TracingConfig) Validate() error {
+	if c.SampleRatio < 0 || c.SampleRatio > 1 || math.IsNaN(c.SampleRatio) {
+		return ErrTracingSampleRatioInvalid
+	}
+
+	if c.Enabled && c.Endpoint == "" {
+		return ErrTracingEndpointEmpty
+	}
+
+	return nil
+}
+
+func (c *CacheConfig) Validate() error {
+	if c.RelayTTL < 0 || c.PlacementTTL < 0 {
+		return ErrCacheTTLInvalid
+	}
+
+	if c.MaxPlacements < 0 {
+		return ErrCacheSizeInvalid
+	}
+
+	return nil
+}
+
+func (c *ResilienceConfig) Validate() error {
+	if c.CallTimeout < 0 || c.RetryBackoff < 0 || c.OpenTimeout < 0 {
+		return ErrResilienceDurationInvalid
+	}
+
+	if c.MaxAttempts < 0 || c.FailureThreshold < 0 {
+		return ErrResilienceCountInvalid
+	}
+
+	return nil
+}
+
+func (c *DegradedConfig) Validate() error {
+	if c.MaxStaleness < 0 {
+		return ErrMaxStalenessInvalid
+	}
+
+	if c.MaxBufferedHeartbeats < 0 {
+		return ErrHeartbeatBufferInvalid
+	}
+
+	return nil
+}
+
+func (c *AuditConfig) Validate() error {
+	switch c.Sink {
+	case "", AuditSinkSlog, AuditSinkNone:
+	case AuditSinkFile:
+		if c.Enabled && c.FilePath == "" {
+			return ErrAuditFilePathEmpty
+		}
+	default:
+		return ErrAuditSinkInvalid
+	}
+
+	if c.BufferSize < 0 {
+		return ErrAuditBufferSizeInvalid
+	}
+
+	return nil
+}
+
+func (c *PlacementHistoryConfig) Validate() error {
+	if c.Entries < 0 {
+		return ErrHistoryEntriesInvalid
+	}
+
+	if c.Retention < 0 {
+		return ErrHistoryRetentionInvalid
+	}
+
+	return nil
+}
+
+func (c *HTTPConfig) Validate() error {
+	if c.Enabled && c.ListenPort <= 0 {
+		return ErrHTTPPortInvalid
+	}
+
+	if c.EventsInterval < 0 {
+		return ErrHTTPEventsIntervalInvalid
+	}
+
+	return nil
+}
+
 func (c *MemoryConfig) Validate() error {
-	// TODO: implement memory config validation
+	if c.SnapshotInterval < 0 {
+		return ErrSnapshotIntervalInvalid
+	}
+
+	if c.SnapshotInterval > 0 && c.SnapshotPath == "" {
+		return ErrSnapshotPathEmpty
+	}
+
+	if c.WALPath != "" && (c.SnapshotPath == "" || c.SnapshotInterval <= 0) {
+		return ErrWALRequiresSnapshots
+	}
+
+	if c.WALFlushInterval < 0 {
+		return ErrWALFlushIntervalInvalid
+	}
+
 	return nil
 }
 
@@ -215,5 +1130,81 @@ func (t *TTLConfig) Validate() error {
 		return ErrTTLRelayInvalid
 	}
 
+	if t.SweepInterval < 0 {
+		return ErrSweepIntervalInvalid
+	}
+
+	if t.SweepInterval >= min(t.Relay, t.Agent) {
+		return ErrSweepIntervalTooLong
+	}
+
+	if t.MinSweepInterval < 0 || t.MaxSweepInterval < 0 {
+		return ErrSweepBoundsInvalid
+	}
+
+	if t.MinSweepInterval > 0 && t.MaxSweepInterval > 0 && t.MinSweepInterval > t.MaxSweepInterval {
+		return ErrSweepBoundsInvalid
+	}
+
+	if err := t.Coordination.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
+
+func (c *SweepCoordinationConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.ReplicaID == "" {
+		return ErrReplicaIDEmpty
+	}
+
+	if c.LeaseTTL < 0 {
+		return ErrLeaseTTLInvalid
+	}
+
+	return nil
+}
+
+func (s *ShutdownConfig) Validate() error {
+	if s.Timeout < 0 {
+		return ErrShutdownTimeoutInvalid
+	}
+
+	if s.PreStopDelay < 0 {
+		return ErrPreStopDelayInvalid
+	}
+
+	return nil
+}
+
+// EffectiveTimeout returns Timeout, or DefaultShutdownTimeout when unset.
+func (s *ShutdownConfig) EffectiveTimeout() time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+
+	return DefaultShutdownTimeout
+}
+
+func (l *LogConfig) Validate() error {
+	_, err := l.SlogLevel()
+	return err
+}
+
+// SlogLevel parses Level into a slog.Level.
+func (l *LogConfig) SlogLevel() (slog.Level, error) {
+	if l.Level == "" {
+		return slog.LevelInfo, nil
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("%w: %s", ErrLogLevelInvalid, l.Level)
+	}
+
+	return level, nil
+}
@@ -0,0 +1,181 @@
+package registry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of environment variables that override
+// configuration values, e.g. AERO_REGISTRY_TTL_RELAY=45s or
+// AERO_REGISTRY_BACKEND_REDIS_ADDRESS=cache.internal.
+const EnvPrefix = "AERO_REGISTRY"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// LoadConfigFile decodes a YAML (.yaml, .yml) or TOML (.toml) configuration
+// file into cfg. Keys absent from the file leave the existing values in cfg
+// untouched, so callers can pre-populate defaults. Unknown keys are rejected
+// to surface typos early.
+func LoadConfigFile(path string, cfg *Config) error {
+	if cfg == nil {
+		return ErrNilConfig
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("decode yaml config %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("decode toml config %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("decode toml config %s: %w: unknown keys %v", path, ErrInvalid, undecoded)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrConfigFormatUnsupported, path)
+	}
+
+	return nil
+}
+
+// ApplyEnvOverrides overrides configuration values from environment
+// variables named after the configuration key path, upper-cased and
+// prefixed with EnvPrefix. Nested backend sections are allocated on demand
+// when one of their keys is set. lookup is typically os.LookupEnv.
+func ApplyEnvOverrides(cfg *Config, lookup func(string) (string, bool)) error {
+	if cfg == nil {
+		return ErrNilConfig
+	}
+
+	_, err := applyEnvOverrides(reflect.ValueOf(cfg).Elem(), EnvPrefix, lookup)
+	return err
+}
+
+func applyEnvOverrides(v reflect.Value, prefix string, lookup func(string) (string, bool)) (bool, error) {
+	applied := false
+	t := v.Type()
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key := field.Tag.Get("yaml")
+		if key == "" || key == "-" {
+			continue
+		}
+		name := prefix + "_" + strings.ToUpper(key)
+		fv := v.Field(i)
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			ok, err := applyEnvOverrides(fv, name, lookup)
+			if err != nil {
+				return false, err
+			}
+			applied = applied || ok
+		case field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct:
+			target := fv
+			if fv.IsNil() {
+				target = reflect.New(field.Type.Elem())
+			}
+			ok, err := applyEnvOverrides(target.Elem(), name, lookup)
+			if err != nil {
+				return false, err
+			}
+			if ok && fv.IsNil() {
+				fv.Set(target)
+			}
+			applied = applied || ok
+		default:
+			raw, ok := lookup(name)
+			if !ok {
+				continue
+			}
+			if err := setFromString(fv, raw); err != nil {
+				return false, fmt.Errorf("%w: %s: %v", ErrInvalid, name, err)
+			}
+			applied = true
+		}
+	}
+
+	return applied, nil
+}
+
+func setFromString(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", v.Type())
+		}
+		parts := strings.Split(raw, ",")
+		out := reflect.MakeSlice(v.Type(), 0, len(parts))
+		for _, part := range parts {
+			if part = strings.TrimSpace(part); part != "" {
+				out = reflect.Append(out, reflect.ValueOf(part).Convert(v.Type().Elem()))
+			}
+		}
+		v.Set(out)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
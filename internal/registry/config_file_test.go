@@ -0,0 +1,162 @@
+package registry
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("yaml file populates full config tree", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeConfigFile(t, "registry.yaml", `
+backend:
+  type: redis
+  redis:
+    address: cache.internal
+    port: 6380
+    db: 2
+grpc:
+  listen_address: 127.0.0.1
+  listen_port: 50055
+ttl:
+  relay: 45s
+  agent: 15s
+  sweep_interval: 5s
+  coordination:
+    enabled: true
+    replica_id: replica-a
+`)
+
+		cfg := &Config{}
+		if err := LoadConfigFile(path, cfg); err != nil {
+			t.Fatalf("LoadConfigFile() error = %v", err)
+		}
+		if cfg.Backend.Type != RedisRegistryBackend || cfg.Backend.Redis == nil {
+			t.Fatalf("unexpected backend config: %+v", cfg.Backend)
+		}
+		if cfg.Backend.Redis.Address != "cache.internal" || cfg.Backend.Redis.Port != 6380 || cfg.Backend.Redis.DB != 2 {
+			t.Fatalf("unexpected redis config: %+v", cfg.Backend.Redis)
+		}
+		if cfg.GRPC.ListenAddress != "127.0.0.1" || cfg.GRPC.ListenPort != 50055 {
+			t.Fatalf("unexpected grpc config: %+v", cfg.GRPC)
+		}
+		if cfg.TTL.Relay != 45*time.Second || cfg.TTL.Agent != 15*time.Second || cfg.TTL.SweepInterval != 5*time.Second {
+			t.Fatalf("unexpected ttl config: %+v", cfg.TTL)
+		}
+		if !cfg.TTL.Coordination.Enabled || cfg.TTL.Coordination.ReplicaID != "replica-a" {
+			t.Fatalf("unexpected coordination config: %+v", cfg.TTL.Coordination)
+		}
+	})
+
+	t.Run("toml file keeps unset defaults", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeConfigFile(t, "registry.toml", `
+[grpc]
+listen_port = 6000
+
+[ttl]
+agent = "20s"
+`)
+
+		cfg := &Config{
+			Backend: BackendConfig{Type: MemoryRegistryBackend},
+			TTL:     TTLConfig{Relay: 30 * time.Second, Agent: 30 * time.Second},
+		}
+		if err := LoadConfigFile(path, cfg); err != nil {
+			t.Fatalf("LoadConfigFile() error = %v", err)
+		}
+		if cfg.GRPC.ListenPort != 6000 || cfg.TTL.Agent != 20*time.Second {
+			t.Fatalf("expected file values to apply, got grpc=%+v ttl=%+v", cfg.GRPC, cfg.TTL)
+		}
+		if cfg.Backend.Type != MemoryRegistryBackend || cfg.TTL.Relay != 30*time.Second {
+			t.Fatalf("expected defaults to be preserved, got backend=%+v ttl=%+v", cfg.Backend, cfg.TTL)
+		}
+	})
+
+	t.Run("unknown yaml key is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeConfigFile(t, "registry.yaml", "ttl:\n  relya: 5s\n")
+		if err := LoadConfigFile(path, &Config{}); err == nil {
+			t.Fatal("expected error for unknown key")
+		}
+	})
+
+	t.Run("unknown toml key is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeConfigFile(t, "registry.toml", "[ttl]\nrelya = \"5s\"\n")
+		if err := LoadConfigFile(path, &Config{}); !errors.Is(err, ErrInvalid) {
+			t.Fatalf("expected ErrInvalid, got %v", err)
+		}
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeConfigFile(t, "registry.json", "{}")
+		if err := LoadConfigFile(path, &Config{}); !errors.Is(err, ErrConfigFormatUnsupported) {
+			t.Fatalf("expected ErrConfigFormatUnsupported, got %v", err)
+		}
+	})
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"AERO_REGISTRY_GRPC_LISTEN_PORT":            "7000",
+		"AERO_REGISTRY_GRPC_TLS_ENABLED":            "true",
+		"AERO_REGISTRY_TTL_RELAY":                   "1m",
+		"AERO_REGISTRY_BACKEND_REDIS_ADDRESS":       "redis.internal",
+		"AERO_REGISTRY_TTL_COORDINATION_REPLICA_ID": "replica-env",
+	}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	cfg := &Config{TTL: TTLConfig{Agent: 30 * time.Second}}
+	if err := ApplyEnvOverrides(cfg, lookup); err != nil {
+		t.Fatalf("ApplyEnvOverrides() error = %v", err)
+	}
+
+	if cfg.GRPC.ListenPort != 7000 || !cfg.GRPC.TLS.Enabled {
+		t.Fatalf("unexpected grpc config: %+v", cfg.GRPC)
+	}
+	if cfg.TTL.Relay != time.Minute || cfg.TTL.Agent != 30*time.Second {
+		t.Fatalf("unexpected ttl config: %+v", cfg.TTL)
+	}
+	if cfg.Backend.Redis == nil || cfg.Backend.Redis.Address != "redis.internal" {
+		t.Fatalf("expected redis section to be allocated, got %+v", cfg.Backend.Redis)
+	}
+	if cfg.Backend.Memory != nil {
+		t.Fatalf("expected untouched memory section to stay nil, got %+v", cfg.Backend.Memory)
+	}
+	if cfg.TTL.Coordination.ReplicaID != "replica-env" {
+		t.Fatalf("unexpected coordination config: %+v", cfg.TTL.Coordination)
+	}
+
+	env = map[string]string{"AERO_REGISTRY_TTL_AGENT": "soon"}
+	if err := ApplyEnvOverrides(cfg, lookup); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for malformed duration, got %v", err)
+	}
+}
+
+func writeConfigFile(t *testing.T, name, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+
+	return path
+}
@@ -2,6 +2,7 @@ package registry
 
 import (
 	"errors"
+	"math"
 	"testing"
 	"time"
 )
@@ -21,6 +22,12 @@ func TestParseRegistryBackend(t *testing.T) {
 			want:    RedisRegistryBackend,
 			wantErr: nil,
 		},
+		{
+			name:    "raft backend",
+			input:   "raft",
+			want:    RaftRegistryBackend,
+			wantErr: nil,
+		},
 		{
 			name:    "unsupported backend",
 			input:   "unknown",
@@ -189,6 +196,47 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: ErrTTLRelayInvalid,
 		},
+		{
+			name: "tenant identities with client certificates",
+			config: Config{
+				Backend: BackendConfig{
+					Type: MemoryRegistryBackend,
+				},
+				GRPC: GRPCConfig{
+					ListenAddress: "127.0.0.1",
+					ListenPort:    50051,
+					TLS: TLSConfig{
+						Enabled:      true,
+						CertPath:     "cert.pem",
+						KeyPath:      "key.pem",
+						ClientCAPath: "ca.pem",
+					},
+				},
+				TTL:     validTTL,
+				Tenants: []TenantConfig{{Namespace: "fleet-a", Identities: []string{"cert:fleet-a"}}},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "tenant identities without client certificates",
+			config: Config{
+				Backend: BackendConfig{
+					Type: MemoryRegistryBackend,
+				},
+				GRPC: GRPCConfig{
+					ListenAddress: "127.0.0.1",
+					ListenPort:    50051,
+					TLS: TLSConfig{
+						Enabled:  true,
+						CertPath: "cert.pem",
+						KeyPath:  "key.pem",
+					},
+				},
+				TTL:     validTTL,
+				Tenants: []TenantConfig{{Namespace: "fleet-a", Identities: []string{"cert:fleet-a"}}},
+			},
+			wantErr: ErrTenantClientAuthRequired,
+		},
 	}
 
 	for _, test := range tests {
@@ -360,6 +408,298 @@ func TestTTLConfigValidate(t *testing.T) {
 			},
 			wantErr: ErrTTLRelayInvalid,
 		},
+		{
+			name: "negative sweep interval",
+			config: TTLConfig{
+				Relay:         5 * time.Second,
+				Agent:         10 * time.Second,
+				SweepInterval: -time.Second,
+			},
+			wantErr: ErrSweepIntervalInvalid,
+		},
+		{
+			name: "sweep interval not shorter than ttl",
+			config: TTLConfig{
+				Relay:         5 * time.Second,
+				Agent:         10 * time.Second,
+				SweepInterval: 5 * time.Second,
+			},
+			wantErr: ErrSweepIntervalTooLong,
+		},
+		{
+			name: "inverted sweep interval bounds",
+			config: TTLConfig{
+				Relay:            5 * time.Second,
+				Agent:            10 * time.Second,
+				MinSweepInterval: 10 * time.Second,
+				MaxSweepInterval: time.Second,
+			},
+			wantErr: ErrSweepBoundsInvalid,
+		},
+		{
+			name: "coordination without replica id",
+			config: TTLConfig{
+				Relay:        5 * time.Second,
+				Agent:        10 * time.Second,
+				Coordination: SweepCoordinationConfig{Enabled: true},
+			},
+			wantErr: ErrReplicaIDEmpty,
+		},
+		{
+			name: "coordination with negative lease ttl",
+			config: TTLConfig{
+				Relay: 5 * time.Second,
+				Agent: 10 * time.Second,
+				Coordination: SweepCoordinationConfig{
+					Enabled:   true,
+					ReplicaID: "replica-a",
+					LeaseTTL:  -time.Second,
+				},
+			},
+			wantErr: ErrLeaseTTLInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestShutdownConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  ShutdownConfig
+		wantErr error
+	}{
+		{
+			name:    "zero values use defaults",
+			config:  ShutdownConfig{},
+			wantErr: nil,
+		},
+		{
+			name: "valid timeout and pre-stop delay",
+			config: ShutdownConfig{
+				Timeout:      10 * time.Second,
+				PreStopDelay: 2 * time.Second,
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "negative timeout",
+			config:  ShutdownConfig{Timeout: -time.Second},
+			wantErr: ErrShutdownTimeoutInvalid,
+		},
+		{
+			name:    "negative pre-stop delay",
+			config:  ShutdownConfig{PreStopDelay: -time.Second},
+			wantErr: ErrPreStopDelayInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCapacityLimitsValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  CapacityLimits
+		wantErr error
+	}{
+		{
+			name:    "limits disabled",
+			config:  CapacityLimits{},
+			wantErr: nil,
+		},
+		{
+			name:    "all limits set",
+			config:  CapacityLimits{MaxRelays: 10, MaxAgents: 1000, MaxAgentsPerRelay: 200},
+			wantErr: nil,
+		},
+		{
+			name:    "negative max relays",
+			config:  CapacityLimits{MaxRelays: -1},
+			wantErr: ErrCapacityLimitInvalid,
+		},
+		{
+			name:    "negative max agents per relay",
+			config:  CapacityLimits{MaxAgentsPerRelay: -1},
+			wantErr: ErrCapacityLimitInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRateLimitConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  RateLimitConfig
+		wantErr error
+	}{
+		{
+			name:    "limits disabled",
+			config:  RateLimitConfig{},
+			wantErr: nil,
+		},
+		{
+			name: "valid limits",
+			config: RateLimitConfig{
+				Heartbeat:    RateLimit{Rate: 2, Burst: 5},
+				Registration: RateLimit{Rate: 0.5},
+				List:         RateLimit{Rate: 10},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "negative rate",
+			config:  RateLimitConfig{Registration: RateLimit{Rate: -1}},
+			wantErr: ErrRateLimitInvalid,
+		},
+		{
+			name:    "infinite rate",
+			config:  RateLimitConfig{List: RateLimit{Rate: math.Inf(1)}},
+			wantErr: ErrRateLimitInvalid,
+		},
+		{
+			name:    "negative burst",
+			config:  RateLimitConfig{Heartbeat: RateLimit{Rate: 1, Burst: -1}},
+			wantErr: ErrRateBurstInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRateLimitEffectiveBurst(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		limit RateLimit
+		want  int
+	}{
+		{name: "explicit burst", limit: RateLimit{Rate: 1, Burst: 10}, want: 10},
+		{name: "derived from rate", limit: RateLimit{Rate: 2.5}, want: 3},
+		{name: "at least one", limit: RateLimit{Rate: 0.1}, want: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := test.limit.EffectiveBurst(); got != test.want {
+				t.Fatalf("expected burst %d, got %d", test.want, got)
+			}
+		})
+	}
+}
+
+func TestMemoryConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  MemoryConfig
+		wantErr error
+	}{
+		{
+			name:    "snapshots disabled",
+			config:  MemoryConfig{},
+			wantErr: nil,
+		},
+		{
+			name:    "snapshot on shutdown only",
+			config:  MemoryConfig{SnapshotPath: "/var/lib/registry/snapshot.json"},
+			wantErr: nil,
+		},
+		{
+			name: "periodic snapshots",
+			config: MemoryConfig{
+				SnapshotPath:     "/var/lib/registry/snapshot.json",
+				SnapshotInterval: time.Minute,
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "interval without path",
+			config:  MemoryConfig{SnapshotInterval: time.Minute},
+			wantErr: ErrSnapshotPathEmpty,
+		},
+		{
+			name: "wal with periodic snapshots",
+			config: MemoryConfig{
+				SnapshotPath:     "/var/lib/registry/snapshot.json",
+				SnapshotInterval: time.Minute,
+				WALPath:          "/var/lib/registry/registry.wal",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "wal without periodic snapshots",
+			config: MemoryConfig{
+				SnapshotPath: "/var/lib/registry/snapshot.json",
+				WALPath:      "/var/lib/registry/registry.wal",
+			},
+			wantErr: ErrWALRequiresSnapshots,
+		},
+		{
+			name: "negative wal flush interval",
+			config: MemoryConfig{
+				SnapshotPath:     "/var/lib/registry/snapshot.json",
+				SnapshotInterval: time.Minute,
+				WALPath:          "/var/lib/registry/registry.wal",
+				WALFlushInterval: -time.Second,
+			},
+			wantErr: ErrWALFlushIntervalInvalid,
+		},
+		{
+			name: "negative interval",
+			config: MemoryConfig{
+				SnapshotPath:     "/var/lib/registry/snapshot.json",
+				SnapshotInterval: -time.Minute,
+			},
+			wantErr: ErrSnapshotIntervalInvalid,
+		},
 	}
 
 	for _, test := range tests {
@@ -373,3 +713,528 @@ func TestTTLConfigValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestRaftConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := func() RaftConfig {
+		return RaftConfig{
+			NodeID:      "node-a",
+			BindAddress: "10.0.0.1:7000",
+			DataDir:     "/var/lib/registry/raft",
+			Peers:       []string{"node-a=10.0.0.1:7000", "node-b=10.0.0.2:7000", "node-c=10.0.0.3:7000"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(c *RaftConfig)
+		wantErr error
+	}{
+		{
+			name:    "valid three node cluster",
+			mutate:  func(c *RaftConfig) {},
+			wantErr: nil,
+		},
+		{
+			name:    "missing node id",
+			mutate:  func(c *RaftConfig) { c.NodeID = "" },
+			wantErr: ErrRaftNodeIDEmpty,
+		},
+		{
+			name:    "missing bind address",
+			mutate:  func(c *RaftConfig) { c.BindAddress = "" },
+			wantErr: ErrRaftBindAddressEmpty,
+		},
+		{
+			name:    "missing data dir",
+			mutate:  func(c *RaftConfig) { c.DataDir = "" },
+			wantErr: ErrRaftDataDirEmpty,
+		},
+		{
+			name:    "negative apply timeout",
+			mutate:  func(c *RaftConfig) { c.ApplyTimeout = -time.Second },
+			wantErr: ErrRaftApplyTimeoutInvalid,
+		},
+		{
+			name:    "negative heartbeat flush interval",
+			mutate:  func(c *RaftConfig) { c.HeartbeatFlushInterval = -time.Second },
+			wantErr: ErrRaftHeartbeatFlushInvalid,
+		},
+		{
+			name:    "no peers",
+			mutate:  func(c *RaftConfig) { c.Peers = nil },
+			wantErr: ErrRaftPeersInvalid,
+		},
+		{
+			name:    "malformed peer",
+			mutate:  func(c *RaftConfig) { c.Peers[1] = "10.0.0.2:7000" },
+			wantErr: ErrRaftPeersInvalid,
+		},
+		{
+			name:    "duplicate peer id",
+			mutate:  func(c *RaftConfig) { c.Peers[2] = "node-b=10.0.0.3:7000" },
+			wantErr: ErrRaftPeersInvalid,
+		},
+		{
+			name:    "node not in peers",
+			mutate:  func(c *RaftConfig) { c.NodeID = "node-z" },
+			wantErr: ErrRaftPeersInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := valid()
+			test.mutate(&config)
+
+			err := config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTracingConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  TracingConfig
+		wantErr error
+	}{
+		{
+			name:    "tracing disabled",
+			config:  TracingConfig{},
+			wantErr: nil,
+		},
+		{
+			name:    "tracing enabled",
+			config:  TracingConfig{Enabled: true, Endpoint: "localhost:4317", SampleRatio: 0.25},
+			wantErr: nil,
+		},
+		{
+			name:    "enabled without endpoint",
+			config:  TracingConfig{Enabled: true},
+			wantErr: ErrTracingEndpointEmpty,
+		},
+		{
+			name:    "sample ratio above one",
+			config:  TracingConfig{SampleRatio: 1.5},
+			wantErr: ErrTracingSampleRatioInvalid,
+		},
+		{
+			name:    "negative sample ratio",
+			config:  TracingConfig{SampleRatio: -0.1},
+			wantErr: ErrTracingSampleRatioInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCacheConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  CacheConfig
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			config:  CacheConfig{Enabled: true},
+			wantErr: nil,
+		},
+		{
+			name:    "negative relay ttl",
+			config:  CacheConfig{Enabled: true, RelayTTL: -time.Second},
+			wantErr: ErrCacheTTLInvalid,
+		},
+		{
+			name:    "negative placement ttl",
+			config:  CacheConfig{PlacementTTL: -time.Second},
+			wantErr: ErrCacheTTLInvalid,
+		},
+		{
+			name:    "negative max placements",
+			config:  CacheConfig{MaxPlacements: -1},
+			wantErr: ErrCacheSizeInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResilienceConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  ResilienceConfig
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			config:  ResilienceConfig{Enabled: true},
+			wantErr: nil,
+		},
+		{
+			name:    "retries disabled",
+			config:  ResilienceConfig{Enabled: true, MaxAttempts: 1},
+			wantErr: nil,
+		},
+		{
+			name:    "negative call timeout",
+			config:  ResilienceConfig{CallTimeout: -time.Second},
+			wantErr: ErrResilienceDurationInvalid,
+		},
+		{
+			name:    "negative open timeout",
+			config:  ResilienceConfig{OpenTimeout: -time.Second},
+			wantErr: ErrResilienceDurationInvalid,
+		},
+		{
+			name:    "negative failure threshold",
+			config:  ResilienceConfig{FailureThreshold: -1},
+			wantErr: ErrResilienceCountInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDegradedConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  DegradedConfig
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			config:  DegradedConfig{Enabled: true},
+			wantErr: nil,
+		},
+		{
+			name:    "bounded staleness",
+			config:  DegradedConfig{Enabled: true, MaxStaleness: time.Minute, MaxBufferedHeartbeats: 100},
+			wantErr: nil,
+		},
+		{
+			name:    "negative max staleness",
+			config:  DegradedConfig{MaxStaleness: -time.Second},
+			wantErr: ErrMaxStalenessInvalid,
+		},
+		{
+			name:    "negative heartbeat buffer",
+			config:  DegradedConfig{MaxBufferedHeartbeats: -1},
+			wantErr: ErrHeartbeatBufferInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHTTPConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  HTTPConfig
+		wantErr error
+	}{
+		{
+			name:    "disabled",
+			config:  HTTPConfig{},
+			wantErr: nil,
+		},
+		{
+			name:    "enabled",
+			config:  HTTPConfig{Enabled: true, ListenAddress: "0.0.0.0", ListenPort: 8080, EventsInterval: time.Second},
+			wantErr: nil,
+		},
+		{
+			name:    "enabled without port",
+			config:  HTTPConfig{Enabled: true},
+			wantErr: ErrHTTPPortInvalid,
+		},
+		{
+			name:    "negative events interval",
+			config:  HTTPConfig{EventsInterval: -time.Second},
+			wantErr: ErrHTTPEventsIntervalInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAuditConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  AuditConfig
+		wantErr error
+	}{
+		{
+			name:    "disabled",
+			config:  AuditConfig{},
+			wantErr: nil,
+		},
+		{
+			name:    "slog sink",
+			config:  AuditConfig{Enabled: true, Sink: AuditSinkSlog, BufferSize: 100},
+			wantErr: nil,
+		},
+		{
+			name:    "file sink",
+			config:  AuditConfig{Enabled: true, Sink: AuditSinkFile, FilePath: "/var/log/registry-audit.jsonl"},
+			wantErr: nil,
+		},
+		{
+			name:    "file sink without path",
+			config:  AuditConfig{Enabled: true, Sink: AuditSinkFile},
+			wantErr: ErrAuditFilePathEmpty,
+		},
+		{
+			name:    "unknown sink",
+			config:  AuditConfig{Enabled: true, Sink: "kafka"},
+			wantErr: ErrAuditSinkInvalid,
+		},
+		{
+			name:    "negative buffer size",
+			config:  AuditConfig{BufferSize: -1},
+			wantErr: ErrAuditBufferSizeInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPlacementHistoryConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  PlacementHistoryConfig
+		wantErr error
+	}{
+		{
+			name:    "defaults",
+			config:  PlacementHistoryConfig{Enabled: true},
+			wantErr: nil,
+		},
+		{
+			name:    "negative entries",
+			config:  PlacementHistoryConfig{Entries: -1},
+			wantErr: ErrHistoryEntriesInvalid,
+		},
+		{
+			name:    "negative retention",
+			config:  PlacementHistoryConfig{Retention: -time.Hour},
+			wantErr: ErrHistoryRetentionInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestShadowBackendConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	memoryTarget := &BackendTarget{Type: MemoryRegistryBackend}
+
+	tests := []struct {
+		name    string
+		config  BackendConfig
+		wantErr error
+	}{
+		{
+			name:    "memory shadowing memory",
+			config:  BackendConfig{Type: ShadowRegistryBackend, Primary: memoryTarget, Shadow: memoryTarget},
+			wantErr: nil,
+		},
+		{
+			name:    "missing shadow section",
+			config:  BackendConfig{Type: ShadowRegistryBackend, Primary: memoryTarget},
+			wantErr: ErrShadowBackendNil,
+		},
+		{
+			name:    "nested shadow backend",
+			config:  BackendConfig{Type: ShadowRegistryBackend, Primary: memoryTarget, Shadow: &BackendTarget{Type: ShadowRegistryBackend}},
+			wantErr: ErrShadowTargetInvalid,
+		},
+		{
+			name:    "invalid shadow section",
+			config:  BackendConfig{Type: ShadowRegistryBackend, Primary: memoryTarget, Shadow: &BackendTarget{Type: RedisRegistryBackend}},
+			wantErr: ErrRedisConfigNil,
+		},
+		{
+			name: "negative queue size",
+			config: BackendConfig{
+				Type:      ShadowRegistryBackend,
+				Primary:   memoryTarget,
+				Shadow:    memoryTarget,
+				Shadowing: ShadowConfig{QueueSize: -1},
+			},
+			wantErr: ErrShadowQueueSizeInvalid,
+		},
+		{
+			name: "negative call timeout",
+			config: BackendConfig{
+				Type:      ShadowRegistryBackend,
+				Primary:   memoryTarget,
+				Shadow:    memoryTarget,
+				Shadowing: ShadowConfig{CallTimeout: -time.Second},
+			},
+			wantErr: ErrShadowCallTimeoutInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTenantsValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		tenants []TenantConfig
+		wantErr error
+	}{
+		{
+			name: "valid tenants",
+			tenants: []TenantConfig{
+				{Namespace: "fleet-a", Identities: []string{"cert:fleet-a"}, Limits: CapacityLimits{MaxRelays: 5}},
+				{Namespace: "fleet-b", TTL: TenantTTLConfig{Agent: time.Minute}},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "invalid namespace",
+			tenants: []TenantConfig{{Namespace: "Fleet_A"}},
+			wantErr: ErrNamespaceInvalid,
+		},
+		{
+			name:    "duplicate namespace",
+			tenants: []TenantConfig{{Namespace: "fleet-a"}, {Namespace: "fleet-a"}},
+			wantErr: ErrTenantDuplicate,
+		},
+		{
+			name: "identity bound twice",
+			tenants: []TenantConfig{
+				{Namespace: "fleet-a", Identities: []string{"cert:fleet"}},
+				{Namespace: "fleet-b", Identities: []string{"cert:fleet"}},
+			},
+			wantErr: ErrTenantIdentityDuplicate,
+		},
+		{
+			name:    "negative limit",
+			tenants: []TenantConfig{{Namespace: "fleet-a", Limits: CapacityLimits{MaxAgents: -1}}},
+			wantErr: ErrCapacityLimitInvalid,
+		},
+		{
+			name:    "negative ttl",
+			tenants: []TenantConfig{{Namespace: "fleet-a", TTL: TenantTTLConfig{Relay: -time.Second}}},
+			wantErr: ErrTenantTTLInvalid,
+		},
+		{
+			name:    "peer address identity",
+			tenants: []TenantConfig{{Namespace: "fleet-a", Identities: []string{"peer:10.0.0.9"}}},
+			wantErr: ErrTenantIdentityInvalid,
+		},
+		{
+			name:    "empty common name",
+			tenants: []TenantConfig{{Namespace: "fleet-a", Identities: []string{"cert:"}}},
+			wantErr: ErrTenantIdentityInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateTenants(test.tenants)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
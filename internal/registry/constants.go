@@ -1,18 +1,74 @@
 package registry
 
+import "time"
+
 const (
 	DebugTLSCertPath = ".aeroarc/local-certs/localhost.crt"
 	DebugTLSKeyPath  = ".aeroarc/local-certs/localhost.key"
 )
 
+// DefaultShutdownTimeout bounds the graceful shutdown sequence when
+// ShutdownConfig.Timeout is unset.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// DefaultTracingServiceName is the service.name reported on spans when
+// TracingConfig.ServiceName is unset.
+const DefaultTracingServiceName = "aero-arc-registry"
+
+// DefaultMaxBufferedHeartbeats caps buffered heartbeats when
+// DegradedConfig.MaxBufferedHeartbeats is unset.
+const DefaultMaxBufferedHeartbeats = 10000
+
+// DefaultHTTPEventsInterval is how often change streams check for changes
+// when HTTPConfig.EventsInterval is unset.
+const DefaultHTTPEventsInterval = time.Second
+
+// DefaultAuditBufferSize is the number of recent audit events kept in
+// memory when AuditConfig.BufferSize is unset.
+const DefaultAuditBufferSize = 1000
+
+// DefaultPlacementHistoryEntries is the number of placement changes kept
+// per agent when PlacementHistoryConfig.Entries is unset.
+const DefaultPlacementHistoryEntries = 10
+
+// DefaultPlacementHistoryRetention is how long placement changes are kept
+// when PlacementHistoryConfig.Retention is unset.
+const DefaultPlacementHistoryRetention = 24 * time.Hour
+
+// Defaults for CacheConfig fields left unset.
+const (
+	DefaultCacheRelayTTL      = time.Second
+	DefaultCachePlacementTTL  = 2 * time.Second
+	DefaultCacheMaxPlacements = 10000
+)
+
+// Defaults for ResilienceConfig fields left unset.
+const (
+	DefaultBackendCallTimeout      = 2 * time.Second
+	DefaultBackendMaxAttempts      = 3
+	DefaultBackendRetryBackoff     = 50 * time.Millisecond
+	DefaultBackendFailureThreshold = 5
+	DefaultBackendOpenTimeout      = 10 * time.Second
+)
+
+// Defaults for ShadowConfig fields left unset.
+const (
+	DefaultShadowQueueSize   = 1024
+	DefaultShadowCallTimeout = 2 * time.Second
+)
+
 const (
 	RedisRegistryBackend  RegistryBackend = "redis"
 	EtcdRegistryBackend   RegistryBackend = "etcd"
 	ConsulRegistryBackend RegistryBackend = "consul"
 	MemoryRegistryBackend RegistryBackend = "memory"
+	RaftRegistryBackend   RegistryBackend = "raft"
+	ShadowRegistryBackend RegistryBackend = "shadow"
 )
 
 var registryMap = map[string]RegistryBackend{
 	"redis":  RedisRegistryBackend,
 	"memory": MemoryRegistryBackend,
+	"raft":   RaftRegistryBackend,
+	"shadow": ShadowRegistryBackend,
 }
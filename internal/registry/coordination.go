@@ -0,0 +1,190 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// sweeperLeaseName is the backend lease key replicas compete for
+// to become the single TTL sweeper.
+const sweeperLeaseName = "registry/ttl-sweeper"
+
+// sweeperLeaseRenewalDivisor sets how often a held sweeper lease is renewed
+// between sweeps, as a fraction of its TTL.
+const sweeperLeaseRenewalDivisor = 3
+
+// sweeperLeaseReleaseTimeout bounds the best-effort lease release
+// performed when the TTL loop stops.
+const sweeperLeaseReleaseTimeout = 5 * time.Second
+
+type sweeperState struct {
+	mu    sync.Mutex
+	lease *Lease
+}
+
+// acquireSweeperLease reports whether this replica is allowed to run the
+// next TTL sweep. It renews a held lease or attempts to acquire a free one.
+// When coordination is disabled every replica is its own sweeper.
+func (r *Registry) acquireSweeperLease(ctx context.Context) (bool, error) {
+	coordination := r.ttlConfig().Coordination
+	if !coordination.Enabled {
+		return true, nil
+	}
+
+	r.sweeper.mu.Lock()
+	defer r.sweeper.mu.Unlock()
+
+	leaseTTL := r.sweeperLeaseTTL()
+
+	if r.sweeper.lease != nil {
+		lease, err := r.backend.RenewLease(ctx, *r.sweeper.lease, leaseTTL)
+		if err == nil {
+			r.sweeper.lease = lease
+			return true, nil
+		}
+		if !errors.Is(err, ErrConflict) && !errors.Is(err, ErrNotFound) {
+			return false, err
+		}
+
+		r.dropSweeperLeaseLocked(ctx, "acquireSweeperLease", err)
+	}
+
+	lease, err := r.backend.AcquireLease(ctx, sweeperLeaseName, coordination.ReplicaID, leaseTTL)
+	if err != nil {
+		if errors.Is(err, ErrConflict) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "ttl sweeper lease acquired",
+		slog.String("method", "acquireSweeperLease"),
+		slog.String("replica_id", coordination.ReplicaID),
+		slog.Uint64("lease_token", lease.Token),
+		slog.Time("expires_at", lease.ExpiresAt),
+	)
+	r.sweeper.lease = lease
+	r.ttlStats.leaderChanges.Add(1)
+
+	return true, nil
+}
+
+// renewSweeperLeases renews a held sweeper lease every third of its TTL
+// until ctx is done or stop is closed. Sweeps renew the lease as well, but
+// an adaptive back-off or a slow sweep can leave them further apart than
+// the TTL, and the lease must not lapse while this replica sweeps.
+func (r *Registry) renewSweeperLeases(ctx context.Context, stop <-chan struct{}) {
+	timer := time.NewTimer(r.sweeperLeaseTTL() / sweeperLeaseRenewalDivisor)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-timer.C:
+			r.renewSweeperLease(ctx)
+			timer.Reset(r.sweeperLeaseTTL() / sweeperLeaseRenewalDivisor)
+		}
+	}
+}
+
+// renewSweeperLease renews the sweeper lease when this replica holds it.
+// A failed renewal keeps the lease until the backend reports it lost.
+func (r *Registry) renewSweeperLease(ctx context.Context) {
+	if !r.ttlConfig().Coordination.Enabled {
+		return
+	}
+
+	r.sweeper.mu.Lock()
+	defer r.sweeper.mu.Unlock()
+
+	if r.sweeper.lease == nil {
+		return
+	}
+
+	lease, err := r.backend.RenewLease(ctx, *r.sweeper.lease, r.sweeperLeaseTTL())
+	switch {
+	case err == nil:
+		r.sweeper.lease = lease
+	case errors.Is(err, ErrConflict), errors.Is(err, ErrNotFound):
+		r.dropSweeperLeaseLocked(ctx, "renewSweeperLease", err)
+	case !errorsIsContextCancellation(err):
+		slog.LogAttrs(ctx, slog.LevelWarn, "failed to renew ttl sweeper lease",
+			slog.String("method", "renewSweeperLease"),
+			slog.String("replica_id", r.sweeper.lease.HolderID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// dropSweeperLeaseLocked forgets a lease the backend reported as lost.
+// r.sweeper.mu must be held.
+func (r *Registry) dropSweeperLeaseLocked(ctx context.Context, method string, err error) {
+	slog.LogAttrs(ctx, slog.LevelWarn, "ttl sweeper lease lost",
+		slog.String("method", method),
+		slog.String("replica_id", r.sweeper.lease.HolderID),
+		slog.Uint64("lease_token", r.sweeper.lease.Token),
+		slog.String("error", err.Error()),
+	)
+	r.sweeper.lease = nil
+	r.ttlStats.leaderChanges.Add(1)
+}
+
+// releaseSweeperLease gives up a held sweeper lease so another replica can
+// take over without waiting for it to expire.
+func (r *Registry) releaseSweeperLease(ctx context.Context) {
+	r.sweeper.mu.Lock()
+	defer r.sweeper.mu.Unlock()
+
+	if r.sweeper.lease == nil {
+		return
+	}
+
+	lease := *r.sweeper.lease
+	r.sweeper.lease = nil
+
+	if err := r.backend.ReleaseLease(ctx, lease); err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "failed to release ttl sweeper lease",
+			slog.String("method", "releaseSweeperLease"),
+			slog.String("replica_id", lease.HolderID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "ttl sweeper lease released",
+		slog.String("method", "releaseSweeperLease"),
+		slog.String("replica_id", lease.HolderID),
+		slog.Uint64("lease_token", lease.Token),
+	)
+}
+
+func (r *Registry) isSweeperLeader() (bool, uint64) {
+	if !r.ttlConfig().Coordination.Enabled {
+		return true, 0
+	}
+
+	r.sweeper.mu.Lock()
+	defer r.sweeper.mu.Unlock()
+
+	if r.sweeper.lease == nil {
+		return false, 0
+	}
+
+	return true, r.sweeper.lease.Token
+}
+
+func (r *Registry) sweeperLeaseTTL() time.Duration {
+	cfg := r.config()
+	if cfg.TTL.Coordination.LeaseTTL > 0 {
+		return cfg.TTL.Coordination.LeaseTTL
+	}
+
+	shortest, _ := cfg.ttlBounds()
+	return 3 * shortest
+}
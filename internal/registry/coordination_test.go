@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCoordinatedTTLCleanupElectsSingleSweeper(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	backend := newTTLCleanupBackend()
+	backend.relays["relay-stale"] = Relay{ID: "relay-stale", LastSeen: now.Add(-45 * time.Second)}
+
+	newReplica := func(replicaID string) *Registry {
+		return &Registry{
+			cfg: &Config{
+				TTL: TTLConfig{
+					Relay: 30 * time.Second,
+					Agent: 30 * time.Second,
+					Coordination: SweepCoordinationConfig{
+						Enabled:   true,
+						ReplicaID: replicaID,
+					},
+				},
+			},
+			backend: backend,
+		}
+	}
+
+	leader := newReplica("replica-a")
+	follower := newReplica("replica-b")
+
+	if err := leader.runCoordinatedTTLCleanup(context.Background(), now); err != nil {
+		t.Fatalf("leader runCoordinatedTTLCleanup returned error: %v", err)
+	}
+	if err := follower.runCoordinatedTTLCleanup(context.Background(), now); err != nil {
+		t.Fatalf("follower runCoordinatedTTLCleanup returned error: %v", err)
+	}
+
+	leaderStats := leader.TTLStats()
+	if !leaderStats.Leader || leaderStats.Runs != 1 || leaderStats.RelaysRemoved != 1 {
+		t.Fatalf("unexpected leader stats: %+v", leaderStats)
+	}
+
+	followerStats := follower.TTLStats()
+	if followerStats.Leader || followerStats.Runs != 0 || followerStats.SkippedRuns != 1 {
+		t.Fatalf("unexpected follower stats: %+v", followerStats)
+	}
+
+	leader.releaseSweeperLease(context.Background())
+
+	if err := follower.runCoordinatedTTLCleanup(context.Background(), now); err != nil {
+		t.Fatalf("follower runCoordinatedTTLCleanup returned error: %v", err)
+	}
+	if stats := follower.TTLStats(); !stats.Leader || stats.Runs != 1 {
+		t.Fatalf("expected follower to take over after release, got %+v", stats)
+	}
+}
+
+func TestCoordinatedTTLCleanupReacquiresLostLease(t *testing.T) {
+	backend := newTTLCleanupBackend()
+	reg := &Registry{
+		cfg: &Config{
+			TTL: TTLConfig{
+				Relay: 30 * time.Second,
+				Agent: 30 * time.Second,
+				Coordination: SweepCoordinationConfig{
+					Enabled:   true,
+					ReplicaID: "replica-a",
+				},
+			},
+		},
+		backend: backend,
+	}
+
+	if err := reg.runCoordinatedTTLCleanup(context.Background(), time.Now()); err != nil {
+		t.Fatalf("runCoordinatedTTLCleanup returned error: %v", err)
+	}
+
+	// Simulate the lease expiring and being taken over by another replica.
+	backend.mu.Lock()
+	delete(backend.leases, sweeperLeaseName)
+	backend.mu.Unlock()
+	if _, err := backend.AcquireLease(context.Background(), sweeperLeaseName, "replica-b", time.Minute); err != nil {
+		t.Fatalf("AcquireLease returned error: %v", err)
+	}
+
+	if err := reg.runCoordinatedTTLCleanup(context.Background(), time.Now()); err != nil {
+		t.Fatalf("runCoordinatedTTLCleanup returned error: %v", err)
+	}
+
+	stats := reg.TTLStats()
+	if stats.Leader {
+		t.Fatalf("expected lease to be lost, got %+v", stats)
+	}
+	if stats.LeaderChanges != 2 || stats.SkippedRuns != 1 {
+		t.Fatalf("unexpected stats after lease loss: %+v", stats)
+	}
+}
+
+func TestSweeperLeaseRenewedBetweenSweeps(t *testing.T) {
+	backend := newTTLCleanupBackend()
+	reg := &Registry{
+		cfg: &Config{
+			TTL: TTLConfig{
+				Relay:         30 * time.Second,
+				Agent:         30 * time.Second,
+				SweepInterval: 20 * time.Second,
+				Coordination: SweepCoordinationConfig{
+					Enabled:   true,
+					ReplicaID: "replica-a",
+					LeaseTTL:  30 * time.Millisecond,
+				},
+			},
+		},
+		backend: backend,
+	}
+
+	if err := reg.runCoordinatedTTLCleanup(context.Background(), time.Now()); err != nil {
+		t.Fatalf("runCoordinatedTTLCleanup returned error: %v", err)
+	}
+
+	reg.RunTTL(context.Background())
+	time.Sleep(150 * time.Millisecond)
+
+	// The next sweep is 20s away, so only the renewal keeps the 30ms lease.
+	if _, err := backend.AcquireLease(context.Background(), sweeperLeaseName, "replica-b", time.Minute); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected the lease to stay held between sweeps, got %v", err)
+	}
+
+	if err := reg.StopTTL(context.Background()); err != nil {
+		t.Fatalf("StopTTL returned error: %v", err)
+	}
+	if stats := reg.TTLStats(); stats.LeaderChanges != 1 {
+		t.Fatalf("expected the lease to be held throughout, got %+v", stats)
+	}
+}
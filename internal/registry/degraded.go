@@ -0,0 +1,390 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// heartbeatReplayTimeout bounds replaying buffered heartbeats after an
+// outage.
+const heartbeatReplayTimeout = 30 * time.Second
+
+// degradedState holds the last-known relays and placements, heartbeats
+// buffered during a backend outage, and the outage timeline. Outages are
+// tracked even when degraded mode is disabled so that a reload enabling it
+// starts from an accurate state. Relays are kept per namespace.
+type degradedState struct {
+	mu           sync.Mutex
+	relays       map[string]relaysSnapshot
+	placements   map[entryKey]placementSnapshot
+	outageSince  time.Time
+	recoveredAt  time.Time
+	relayBeats   map[entryKey]time.Time
+	agentBeats   map[entryKey]time.Time
+	staleServed  atomic.Uint64
+	replayActive atomic.Bool
+}
+
+// entryKey identifies a relay or agent within its namespace.
+type entryKey struct {
+	namespace string
+	id        string
+}
+
+type relaysSnapshot struct {
+	relays []Relay
+	at     time.Time
+}
+
+type placementSnapshot struct {
+	placement AgentPlacement
+	at        time.Time
+}
+
+type staleReadKey struct{}
+
+// StaleRead records whether a read made with its context was answered from
+// last-known state during a backend outage.
+type StaleRead struct {
+	mu    sync.Mutex
+	stale bool
+	asOf  time.Time
+}
+
+// WithStaleRead returns a context that records stale reads made with it.
+func WithStaleRead(ctx context.Context) (context.Context, *StaleRead) {
+	read := &StaleRead{}
+	return context.WithValue(ctx, staleReadKey{}, read), read
+}
+
+// Stale reports whether a stale answer was served and the oldest time the
+// served state was read from the backend.
+func (s *StaleRead) Stale() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.asOf, s.stale
+}
+
+func markStale(ctx context.Context, asOf time.Time) {
+	read, ok := ctx.Value(staleReadKey{}).(*StaleRead)
+	if !ok {
+		return
+	}
+
+	read.mu.Lock()
+	defer read.mu.Unlock()
+
+	if !read.stale || asOf.Before(read.asOf) {
+		read.asOf = asOf
+	}
+	read.stale = true
+}
+
+// BackendOutage reports when the current backend outage began, if the
+// backend is unavailable.
+func (r *Registry) BackendOutage() (time.Time, bool) {
+	r.degraded.mu.Lock()
+	defer r.degraded.mu.Unlock()
+
+	return r.degraded.outageSince, !r.degraded.outageSince.IsZero()
+}
+
+// StaleReadsServed returns the number of reads answered from last-known
+// state since the registry started.
+func (r *Registry) StaleReadsServed() uint64 {
+	return r.degraded.staleServed.Load()
+}
+
+// observeBackend updates the outage state from the result of a backend
+// call. ErrUnavailable starts an outage; any other answer from the backend
+// ends it and replays buffered heartbeats.
+func (r *Registry) observeBackend(ctx context.Context, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	d := &r.degraded
+	now := time.Now()
+
+	d.mu.Lock()
+	if errors.Is(err, ErrUnavailable) {
+		started := d.outageSince.IsZero()
+		if started {
+			d.outageSince = now
+		}
+		d.mu.Unlock()
+
+		if started {
+			slog.LogAttrs(ctx, slog.LevelWarn, "backend unavailable; entering degraded mode",
+				slog.String("method", "observeBackend"),
+				slog.Bool("degraded_enabled", r.config().Degraded.Enabled),
+				slog.String("error", err.Error()),
+			)
+		}
+		return
+	}
+
+	if d.outageSince.IsZero() {
+		d.mu.Unlock()
+		return
+	}
+	outage := now.Sub(d.outageSince)
+	d.outageSince = time.Time{}
+	d.recoveredAt = now
+	d.mu.Unlock()
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "backend recovered; leaving degraded mode",
+		slog.String("method", "observeBackend"),
+		slog.Int64("outage_ms", outage.Milliseconds()),
+	)
+
+	if d.replayActive.CompareAndSwap(false, true) {
+		go func() {
+			defer d.replayActive.Store(false)
+
+			replayCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), heartbeatReplayTimeout)
+			defer cancel()
+			r.replayHeartbeats(replayCtx)
+		}()
+	}
+}
+
+// rememberRelays records relays as the last-known relay list of namespace.
+func (r *Registry) rememberRelays(namespace string, relays []Relay) {
+	if !r.config().Degraded.Enabled {
+		return
+	}
+
+	d := &r.degraded
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.relays == nil {
+		d.relays = make(map[string]relaysSnapshot)
+	}
+	d.relays[namespace] = relaysSnapshot{relays: slices.Clone(relays), at: time.Now()}
+}
+
+// forgetRelay drops a removed relay and its placements from the last-known
+// state.
+func (r *Registry) forgetRelay(namespace, relayID string) {
+	d := &r.degraded
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if snapshot, ok := d.relays[namespace]; ok {
+		snapshot.relays = slices.DeleteFunc(snapshot.relays, func(relay Relay) bool { return relay.ID == relayID })
+		d.relays[namespace] = snapshot
+	}
+	for key, snapshot := range d.placements {
+		if key.namespace == namespace && snapshot.placement.RelayID == relayID {
+			delete(d.placements, key)
+		}
+	}
+}
+
+// rememberPlacement records placement as last known.
+func (r *Registry) rememberPlacement(namespace string, placement AgentPlacement) {
+	if !r.config().Degraded.Enabled {
+		return
+	}
+
+	d := &r.degraded
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.placements == nil {
+		d.placements = make(map[entryKey]placementSnapshot)
+	}
+	d.placements[entryKey{namespace, placement.AgentID}] = placementSnapshot{placement: placement, at: time.Now()}
+}
+
+// forgetPlacements drops agents from the last-known placements.
+func (r *Registry) forgetPlacements(namespace string, agentIDs ...string) {
+	d := &r.degraded
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, agentID := range agentIDs {
+		delete(d.placements, entryKey{namespace, agentID})
+	}
+}
+
+// staleRelays returns the last-known relay list of namespace when err
+// reports an outage and degraded mode may serve it.
+func (r *Registry) staleRelays(ctx context.Context, namespace string, err error) ([]Relay, bool) {
+	cfg := r.config().Degraded
+	if !cfg.Enabled || !errors.Is(err, ErrUnavailable) {
+		return nil, false
+	}
+
+	d := &r.degraded
+	d.mu.Lock()
+	snapshot, ok := d.relays[namespace]
+	if !ok || !withinStaleness(cfg, snapshot.at) {
+		d.mu.Unlock()
+		return nil, false
+	}
+	relays, asOf := slices.Clone(snapshot.relays), snapshot.at
+	d.mu.Unlock()
+
+	d.staleServed.Add(1)
+	markStale(ctx, asOf)
+	return relays, true
+}
+
+// stalePlacement returns the last-known placement of agentID when err
+// reports an outage and degraded mode may serve it.
+func (r *Registry) stalePlacement(ctx context.Context, namespace, agentID string, err error) (*AgentPlacement, bool) {
+	cfg := r.config().Degraded
+	if !cfg.Enabled || !errors.Is(err, ErrUnavailable) {
+		return nil, false
+	}
+
+	d := &r.degraded
+	d.mu.Lock()
+	snapshot, ok := d.placements[entryKey{namespace, agentID}]
+	d.mu.Unlock()
+	if !ok || !withinStaleness(cfg, snapshot.at) {
+		return nil, false
+	}
+
+	d.staleServed.Add(1)
+	markStale(ctx, snapshot.at)
+	placement := snapshot.placement
+	return &placement, true
+}
+
+func withinStaleness(cfg DegradedConfig, at time.Time) bool {
+	return cfg.MaxStaleness == 0 || time.Since(at) <= cfg.MaxStaleness
+}
+
+// bufferHeartbeat holds a heartbeat that failed because of an outage for
+// replay. It reports false when degraded mode is disabled or the buffer is
+// full.
+func (r *Registry) bufferHeartbeat(beats *map[entryKey]time.Time, namespace, id string, err error) bool {
+	cfg := r.config().Degraded
+	if !cfg.Enabled || !errors.Is(err, ErrUnavailable) {
+		return false
+	}
+
+	limit := cfg.MaxBufferedHeartbeats
+	if limit == 0 {
+		limit = DefaultMaxBufferedHeartbeats
+	}
+
+	d := &r.degraded
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if *beats == nil {
+		*beats = make(map[entryKey]time.Time)
+	}
+	key := entryKey{namespace, id}
+	if _, ok := (*beats)[key]; !ok && len(d.relayBeats)+len(d.agentBeats) >= limit {
+		return false
+	}
+	(*beats)[key] = time.Now()
+
+	return true
+}
+
+// replayHeartbeats sends buffered heartbeats to the backend. Entries the
+// backend no longer knows are dropped; if the backend fails again the rest
+// stay buffered for the next recovery.
+func (r *Registry) replayHeartbeats(ctx context.Context) {
+	d := &r.degraded
+	d.mu.Lock()
+	relayBeats, agentBeats := d.relayBeats, d.agentBeats
+	d.relayBeats, d.agentBeats = nil, nil
+	d.mu.Unlock()
+
+	if len(relayBeats) == 0 && len(agentBeats) == 0 {
+		return
+	}
+
+	replayed, dropped := 0, 0
+	replay := func(beats map[entryKey]time.Time, heartbeat func(context.Context, string, string) error) error {
+		for key := range beats {
+			err := heartbeat(ctx, key.namespace, key.id)
+			switch {
+			case err == nil:
+				replayed++
+			case errors.Is(err, ErrUnavailable), ctx.Err() != nil:
+				return err
+			default:
+				dropped++
+			}
+			delete(beats, key)
+		}
+		return nil
+	}
+
+	err := replay(relayBeats, r.backend.HeartbeatRelay)
+	if err == nil {
+		err = replay(agentBeats, r.backend.HeartbeatAgent)
+	}
+
+	if err != nil {
+		d.mu.Lock()
+		d.relayBeats = mergeBeats(d.relayBeats, relayBeats)
+		d.agentBeats = mergeBeats(d.agentBeats, agentBeats)
+		d.mu.Unlock()
+		r.observeBackend(ctx, err)
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", "replayHeartbeats"),
+		slog.Int("replayed", replayed),
+		slog.Int("dropped", dropped),
+		slog.Int("remaining", len(relayBeats)+len(agentBeats)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	slog.LogAttrs(ctx, slog.LevelInfo, "buffered heartbeats replayed", attrs...)
+}
+
+// mergeBeats adds the entries of src missing from dst, keeping the newer
+// heartbeats already buffered in dst.
+func mergeBeats(dst, src map[entryKey]time.Time) map[entryKey]time.Time {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[entryKey]time.Time, len(src))
+	}
+	for key, at := range src {
+		if _, ok := dst[key]; !ok {
+			dst[key] = at
+		}
+	}
+	return dst
+}
+
+// sweepPaused reports whether TTL sweeps are held back: during an outage,
+// and after it until relays and agents have had a full TTL to heartbeat
+// against the recovered backend.
+func (r *Registry) sweepPaused(now time.Time) bool {
+	if !r.config().Degraded.Enabled {
+		return false
+	}
+
+	_, grace := r.config().ttlBounds()
+
+	d := &r.degraded
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.outageSince.IsZero() {
+		return true
+	}
+
+	return !d.recoveredAt.IsZero() && now.Sub(d.recoveredAt) < grace
+}
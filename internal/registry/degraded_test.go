@@ -0,0 +1,285 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// outageBackend fails every call with ErrUnavailable while down is set and
+// records the heartbeats that reach it.
+type outageBackend struct {
+	*ttlCleanupBackend
+	down atomic.Bool
+
+	beatsMu    sync.Mutex
+	relayBeats []string
+	agentBeats []string
+}
+
+func newOutageBackend() *outageBackend {
+	return &outageBackend{ttlCleanupBackend: newTTLCleanupBackend()}
+}
+
+func (b *outageBackend) err() error {
+	if b.down.Load() {
+		return ErrUnavailable
+	}
+	return nil
+}
+
+func (b *outageBackend) HeartbeatRelay(ctx context.Context, namespace, relayID string) error {
+	if err := b.err(); err != nil {
+		return err
+	}
+
+	b.beatsMu.Lock()
+	defer b.beatsMu.Unlock()
+	b.relayBeats = append(b.relayBeats, relayID)
+	return nil
+}
+
+func (b *outageBackend) HeartbeatAgent(ctx context.Context, namespace, agentID string) error {
+	if err := b.err(); err != nil {
+		return err
+	}
+
+	b.beatsMu.Lock()
+	defer b.beatsMu.Unlock()
+	b.agentBeats = append(b.agentBeats, agentID)
+	return nil
+}
+
+func (b *outageBackend) ListRelays(ctx context.Context, namespace string) ([]Relay, error) {
+	if err := b.err(); err != nil {
+		return nil, err
+	}
+	return b.ttlCleanupBackend.ListRelays(ctx, namespace)
+}
+
+func (b *outageBackend) GetAgentPlacement(ctx context.Context, namespace, agentID string) (*AgentPlacement, error) {
+	if err := b.err(); err != nil {
+		return nil, err
+	}
+	return b.ttlCleanupBackend.GetAgentPlacement(ctx, namespace, agentID)
+}
+
+func (b *outageBackend) replayed() (relays, agents int) {
+	b.beatsMu.Lock()
+	defer b.beatsMu.Unlock()
+
+	return len(b.relayBeats), len(b.agentBeats)
+}
+
+func newDegradedTestRegistry(cfg DegradedConfig) (*Registry, *outageBackend) {
+	backend := newOutageBackend()
+	backend.relays["relay-1"] = Relay{ID: "relay-1"}
+	backend.agents["agent-1"] = Agent{ID: "agent-1"}
+	backend.placements["agent-1"] = "relay-1"
+
+	reg := &Registry{
+		cfg: &Config{
+			TTL:      TTLConfig{Relay: 5 * time.Second, Agent: 5 * time.Second},
+			Degraded: cfg,
+		},
+		backend: backend,
+	}
+	return reg, backend
+}
+
+func TestDegradedServesLastKnownState(t *testing.T) {
+	reg, backend := newDegradedTestRegistry(DegradedConfig{Enabled: true})
+	ctx := context.Background()
+
+	if _, err := reg.ListRelays(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := reg.GetAgentPlacement(ctx, "agent-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	backend.down.Store(true)
+
+	staleCtx, read := WithStaleRead(ctx)
+	relays, err := reg.ListRelays(staleCtx)
+	if err != nil {
+		t.Fatalf("expected stale relays, got %v", err)
+	}
+	if len(relays) != 1 || relays[0].ID != "relay-1" {
+		t.Fatalf("expected last-known relay list, got %+v", relays)
+	}
+	if _, stale := read.Stale(); !stale {
+		t.Fatal("expected relay list to be marked stale")
+	}
+
+	staleCtx, read = WithStaleRead(ctx)
+	placement, err := reg.GetAgentPlacement(staleCtx, "agent-1")
+	if err != nil {
+		t.Fatalf("expected stale placement, got %v", err)
+	}
+	if placement.RelayID != "relay-1" {
+		t.Fatalf("expected placement on relay-1, got %q", placement.RelayID)
+	}
+	if _, stale := read.Stale(); !stale {
+		t.Fatal("expected placement to be marked stale")
+	}
+
+	if _, err := reg.GetAgentPlacement(ctx, "agent-unknown"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable for unknown agent, got %v", err)
+	}
+	if _, down := reg.BackendOutage(); !down {
+		t.Fatal("expected outage to be reported")
+	}
+	if got := reg.StaleReadsServed(); got != 2 {
+		t.Fatalf("expected 2 stale reads, got %d", got)
+	}
+}
+
+func TestDegradedMaxStaleness(t *testing.T) {
+	reg, backend := newDegradedTestRegistry(DegradedConfig{Enabled: true, MaxStaleness: time.Minute})
+	ctx := context.Background()
+
+	if _, err := reg.ListRelays(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	snapshot := reg.degraded.relays[DefaultNamespace]
+	snapshot.at = time.Now().Add(-2 * time.Minute)
+	reg.degraded.relays[DefaultNamespace] = snapshot
+
+	backend.down.Store(true)
+	if _, err := reg.ListRelays(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable beyond max staleness, got %v", err)
+	}
+}
+
+func TestDegradedDisabled(t *testing.T) {
+	reg, backend := newDegradedTestRegistry(DegradedConfig{})
+	ctx := context.Background()
+
+	if _, err := reg.ListRelays(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	backend.down.Store(true)
+	if _, err := reg.ListRelays(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+	if err := reg.HeartbeatRelay(ctx, "relay-1"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+	if reg.sweepPaused(time.Now()) {
+		t.Fatal("expected sweeps to run with degraded mode disabled")
+	}
+}
+
+func TestDegradedBuffersAndReplaysHeartbeats(t *testing.T) {
+	reg, backend := newDegradedTestRegistry(DegradedConfig{Enabled: true, MaxBufferedHeartbeats: 2})
+	ctx := context.Background()
+
+	backend.down.Store(true)
+	if err := reg.HeartbeatRelay(ctx, "relay-1"); err != nil {
+		t.Fatalf("expected buffered heartbeat, got %v", err)
+	}
+	if err := reg.HeartbeatAgent(ctx, "agent-1"); err != nil {
+		t.Fatalf("expected buffered heartbeat, got %v", err)
+	}
+	if err := reg.HeartbeatAgent(ctx, "agent-1"); err != nil {
+		t.Fatalf("expected repeated heartbeat to reuse its buffer entry, got %v", err)
+	}
+	if err := reg.HeartbeatAgent(ctx, "agent-2"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable with a full buffer, got %v", err)
+	}
+	if !reg.sweepPaused(time.Now()) {
+		t.Fatal("expected sweeps to be paused during the outage")
+	}
+
+	backend.down.Store(false)
+	if _, err := reg.ListRelays(ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		relays, agents := backend.replayed()
+		if relays == 1 && agents == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 1 relay and 1 agent heartbeat replayed, got %d and %d", relays, agents)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, down := reg.BackendOutage(); down {
+		t.Fatal("expected outage to end")
+	}
+	if !reg.sweepPaused(time.Now()) {
+		t.Fatal("expected sweeps to stay paused for a TTL after recovery")
+	}
+	if reg.sweepPaused(time.Now().Add(5 * time.Second)) {
+		t.Fatal("expected sweeps to resume a TTL after recovery")
+	}
+}
+
+func TestDegradedPausesCoordinatedCleanup(t *testing.T) {
+	reg, backend := newDegradedTestRegistry(DegradedConfig{Enabled: true})
+	ctx := context.Background()
+
+	backend.down.Store(true)
+	if _, err := reg.ListRelays(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable without last-known state, got %v", err)
+	}
+
+	if err := reg.runCoordinatedTTLCleanup(ctx, time.Now()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if stats := reg.TTLStats(); stats.Runs != 0 || stats.SkippedRuns != 1 {
+		t.Fatalf("expected paused sweep to be skipped, got %+v", stats)
+	}
+}
+
+func TestDegradedForgetsRemovedAgents(t *testing.T) {
+	t.Run("expired under a live relay", func(t *testing.T) {
+		reg, backend := newDegradedTestRegistry(DegradedConfig{Enabled: true})
+		ctx := context.Background()
+
+		now := time.Now()
+		backend.relays["relay-1"] = Relay{ID: "relay-1", LastSeen: now}
+		backend.agents["agent-1"] = Agent{ID: "agent-1", LastHeartbeat: now.Add(-time.Minute)}
+
+		if _, err := reg.GetAgentPlacement(ctx, "agent-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if err := reg.SweepNow(ctx); err != nil {
+			t.Fatalf("SweepNow returned error: %v", err)
+		}
+		if _, ok := backend.agents["agent-1"]; ok {
+			t.Fatal("expected agent-1 to be expired")
+		}
+
+		backend.down.Store(true)
+		if _, err := reg.GetAgentPlacement(ctx, "agent-1"); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("expected ErrUnavailable for the expired agent, got %v", err)
+		}
+	})
+
+	t.Run("drained with its relay", func(t *testing.T) {
+		reg, backend := newDegradedTestRegistry(DegradedConfig{Enabled: true})
+		ctx := context.Background()
+
+		if _, err := reg.GetAgentPlacement(ctx, "agent-1"); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if _, err := reg.DrainRelay(ctx, "relay-1"); err != nil {
+			t.Fatalf("DrainRelay returned error: %v", err)
+		}
+
+		backend.down.Store(true)
+		if _, err := reg.GetAgentPlacement(ctx, "agent-1"); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("expected ErrUnavailable for the drained agent, got %v", err)
+		}
+	})
+}
@@ -5,19 +5,70 @@ import (
 )
 
 var (
-	ErrUnsupportedBackend = errors.New("unsupported registry backend")
-	ErrRedisConfigNil     = errors.New("redis config is nil")
-	ErrRedisAddrEmpty     = errors.New("redis address is empty")
-	ErrRedisPortInvalid   = errors.New("redis port must be > 0")
-	ErrRedisDBInvalid     = errors.New("redis db must be > 0")
-	ErrGRPCPortInvalid    = errors.New("grpc port must be > 0")
-	ErrTLSCertPathMissing = errors.New("grpc tls cert path empty")
-	ErrTLSKeyPathMissing  = errors.New("grpc tls key path empty")
-	ErrTTLRelayInvalid    = errors.New("relay ttl must be > 0")
-	ErrTTLAgentInvalid    = errors.New("agent ttl must be > 0")
-	ErrNilConfig          = errors.New("registry config is nil")
-	ErrNotImplemented     = errors.New("not implemented")
-	ErrNotFound           = errors.New("not found")
-	ErrInvalid            = errors.New("invalid")
-	ErrConflict           = errors.New("conflict")
+	ErrUnsupportedBackend        = errors.New("unsupported registry backend")
+	ErrRedisConfigNil            = errors.New("redis config is nil")
+	ErrRedisAddrEmpty            = errors.New("redis address is empty")
+	ErrRedisPortInvalid          = errors.New("redis port must be > 0")
+	ErrRedisDBInvalid            = errors.New("redis db must be > 0")
+	ErrRaftConfigNil             = errors.New("raft config is nil")
+	ErrRaftNodeIDEmpty           = errors.New("raft node id empty")
+	ErrRaftBindAddressEmpty      = errors.New("raft bind address empty")
+	ErrRaftDataDirEmpty          = errors.New("raft data dir empty")
+	ErrRaftApplyTimeoutInvalid   = errors.New("raft apply timeout must be >= 0")
+	ErrRaftHeartbeatFlushInvalid = errors.New("raft heartbeat flush interval must be >= 0")
+	ErrRaftPeersInvalid          = errors.New("raft peers invalid")
+	ErrSnapshotPathEmpty         = errors.New("memory snapshot path empty")
+	ErrSnapshotIntervalInvalid   = errors.New("memory snapshot interval must be >= 0")
+	ErrWALRequiresSnapshots      = errors.New("memory wal requires a snapshot path and snapshot interval")
+	ErrWALFlushIntervalInvalid   = errors.New("memory wal flush interval must be >= 0")
+	ErrCacheTTLInvalid           = errors.New("cache ttl must be >= 0")
+	ErrCacheSizeInvalid          = errors.New("cache max placements must be >= 0")
+	ErrResilienceDurationInvalid = errors.New("resilience timeouts and backoff must be >= 0")
+	ErrResilienceCountInvalid    = errors.New("resilience attempts and failure threshold must be >= 0")
+	ErrShadowBackendNil          = errors.New("shadow backend requires primary and shadow sections")
+	ErrShadowTargetInvalid       = errors.New("shadow backend sections must name a concrete backend type")
+	ErrShadowQueueSizeInvalid    = errors.New("shadowing queue size must be >= 0")
+	ErrShadowCallTimeoutInvalid  = errors.New("shadowing call timeout must be >= 0")
+	ErrGRPCPortInvalid           = errors.New("grpc port must be > 0")
+	ErrTLSCertPathMissing        = errors.New("grpc tls cert path empty")
+	ErrTLSKeyPathMissing         = errors.New("grpc tls key path empty")
+	ErrTTLRelayInvalid           = errors.New("relay ttl must be > 0")
+	ErrTTLAgentInvalid           = errors.New("agent ttl must be > 0")
+	ErrSweepIntervalInvalid      = errors.New("ttl sweep interval must be >= 0")
+	ErrSweepIntervalTooLong      = errors.New("ttl sweep interval must be shorter than every relay and agent ttl")
+	ErrSweepBoundsInvalid        = errors.New("ttl sweep interval bounds must be >= 0 and min <= max")
+	ErrReplicaIDEmpty            = errors.New("sweep coordination replica id empty")
+	ErrLeaseTTLInvalid           = errors.New("sweep coordination lease ttl must be >= 0")
+	ErrLogLevelInvalid           = errors.New("log level must be one of debug, info, warn, error")
+	ErrShutdownTimeoutInvalid    = errors.New("shutdown timeout must be >= 0")
+	ErrPreStopDelayInvalid       = errors.New("shutdown pre-stop delay must be >= 0")
+	ErrCapacityLimitInvalid      = errors.New("capacity limits must be >= 0")
+	ErrRateLimitInvalid          = errors.New("rate limit must be a finite value >= 0")
+	ErrRateBurstInvalid          = errors.New("rate limit burst must be >= 0")
+	ErrTracingEndpointEmpty      = errors.New("tracing endpoint empty")
+	ErrTracingSampleRatioInvalid = errors.New("tracing sample ratio must be between 0 and 1")
+	ErrMaxStalenessInvalid       = errors.New("degraded max staleness must be >= 0")
+	ErrHeartbeatBufferInvalid    = errors.New("degraded max buffered heartbeats must be >= 0")
+	ErrHTTPPortInvalid           = errors.New("http port must be > 0")
+	ErrHTTPEventsIntervalInvalid = errors.New("http events interval must be >= 0")
+	ErrAuditSinkInvalid          = errors.New("audit sink must be one of slog, file, none")
+	ErrAuditFilePathEmpty        = errors.New("audit file sink requires a file path")
+	ErrAuditBufferSizeInvalid    = errors.New("audit buffer size must be >= 0")
+	ErrHistoryEntriesInvalid     = errors.New("placement history entries must be >= 0")
+	ErrHistoryRetentionInvalid   = errors.New("placement history retention must be >= 0")
+	ErrNamespaceInvalid          = errors.New("namespace must be a lowercase DNS label")
+	ErrTenantDuplicate           = errors.New("tenant namespace listed more than once")
+	ErrTenantIdentityDuplicate   = errors.New("tenant identity bound to more than one namespace")
+	ErrTenantTTLInvalid          = errors.New("tenant ttl overrides must be >= 0")
+	ErrTenantIdentityInvalid     = errors.New("tenant identity must be cert:<common name>")
+	ErrTenantClientAuthRequired  = errors.New("tenant identities require grpc tls with a client ca")
+	ErrConfigFormatUnsupported   = errors.New("unsupported config file format")
+	ErrNilConfig                 = errors.New("registry config is nil")
+	ErrNotImplemented            = errors.New("not implemented")
+	ErrNotFound                  = errors.New("not found")
+	ErrInvalid                   = errors.New("invalid")
+	ErrConflict                  = errors.New("conflict")
+	ErrUnavailable               = errors.New("unavailable")
+	ErrResourceExhausted         = errors.New("resource exhausted")
+	ErrPermissionDenied          = errors.New("permission denied")
 )
@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// historyPruneInterval is how often the placement history is scanned for
+// agents whose changes have all aged out.
+const historyPruneInterval = time.Minute
+
+// PlacementChangeReason says why an agent's placement changed.
+type PlacementChangeReason string
+
+// Placement change reasons.
+const (
+	// PlacementRegistered is an agent placed while it had no placement.
+	PlacementRegistered PlacementChangeReason = "registered"
+	// PlacementMoved is an agent placed on a different relay.
+	PlacementMoved PlacementChangeReason = "moved"
+	// PlacementExpired is an agent removed by a TTL sweep, on its own or
+	// with its expired relay.
+	PlacementExpired PlacementChangeReason = "expired"
+	// PlacementEvicted is an agent removed by its relay, an admin or a
+	// relay drain.
+	PlacementEvicted PlacementChangeReason = "evicted"
+)
+
+// PlacementChange is one entry of an agent's placement history. For an
+// expired or evicted agent, RelayID is the relay it was removed from when
+// known.
+type PlacementChange struct {
+	RelayID string
+	At      time.Time
+	Reason  PlacementChangeReason
+}
+
+// placementHistory holds the recent placement changes of each agent made
+// through this replica, keyed by namespace and agent ID. It reads its
+// limits from the registry config on every call, so they can be reloaded.
+type placementHistory struct {
+	mu        sync.Mutex
+	agents    map[entryKey][]PlacementChange
+	lastPrune time.Time
+}
+
+// PlacementHistory returns the recent placement changes of agentID in the
+// namespace of ctx, newest first. ok is false when the placement history
+// is disabled.
+func (r *Registry) PlacementHistory(ctx context.Context, agentID string) (changes []PlacementChange, ok bool) {
+	cfg := r.config().PlacementHistory
+	if !cfg.Enabled {
+		return nil, false
+	}
+	cutoff := time.Now().Add(-historyRetention(cfg))
+
+	r.history.mu.Lock()
+	defer r.history.mu.Unlock()
+
+	changes = []PlacementChange{}
+	for _, change := range slices.Backward(r.history.agents[entryKey{NamespaceFromContext(ctx), agentID}]) {
+		if change.At.Before(cutoff) {
+			break
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, true
+}
+
+// recordPlacement adds a change to the history of agentID in namespace
+// when the placement history is enabled.
+func (r *Registry) recordPlacement(namespace, agentID, relayID string, reason PlacementChangeReason) {
+	cfg := r.config().PlacementHistory
+	if !cfg.Enabled {
+		return
+	}
+	entries := cfg.Entries
+	if entries == 0 {
+		entries = DefaultPlacementHistoryEntries
+	}
+	now := time.Now()
+
+	r.history.mu.Lock()
+	defer r.history.mu.Unlock()
+
+	if r.history.agents == nil {
+		r.history.agents = make(map[entryKey][]PlacementChange)
+	}
+
+	key := entryKey{namespace, agentID}
+	changes := r.history.agents[key]
+	if relayID == "" && len(changes) > 0 {
+		relayID = changes[len(changes)-1].RelayID
+	}
+	changes = append(changes, PlacementChange{RelayID: relayID, At: now, Reason: reason})
+	if len(changes) > entries {
+		changes = slices.Clone(changes[len(changes)-entries:])
+	}
+	r.history.agents[key] = changes
+
+	if now.Sub(r.history.lastPrune) >= historyPruneInterval {
+		r.history.pruneLocked(now.Add(-historyRetention(cfg)))
+		r.history.lastPrune = now
+	}
+}
+
+// resetPlacementHistory drops all recorded changes, for when the history
+// is disabled on reload.
+func (r *Registry) resetPlacementHistory() {
+	r.history.mu.Lock()
+	defer r.history.mu.Unlock()
+
+	r.history.agents = nil
+}
+
+// pruneLocked drops the changes made before cutoff, and the agents left
+// without any.
+func (h *placementHistory) pruneLocked(cutoff time.Time) {
+	for key, changes := range h.agents {
+		kept := slices.DeleteFunc(changes, func(change PlacementChange) bool {
+			return change.At.Before(cutoff)
+		})
+		if len(kept) == 0 {
+			delete(h.agents, key)
+			continue
+		}
+		h.agents[key] = kept
+	}
+}
+
+func historyRetention(cfg PlacementHistoryConfig) time.Duration {
+	if cfg.Retention == 0 {
+		return DefaultPlacementHistoryRetention
+	}
+	return cfg.Retention
+}
@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newHistoryTestRegistry(t *testing.T, backend Backend, history PlacementHistoryConfig) *Registry {
+	t.Helper()
+
+	reg, err := New(&Config{
+		Backend:          BackendConfig{Type: MemoryRegistryBackend},
+		GRPC:             GRPCConfig{ListenAddress: "127.0.0.1", ListenPort: 50051},
+		TTL:              TTLConfig{Relay: 30 * time.Second, Agent: 30 * time.Second},
+		PlacementHistory: history,
+	}, backend)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return reg
+}
+
+func historyReasons(changes []PlacementChange) []string {
+	reasons := make([]string, len(changes))
+	for i, change := range changes {
+		reasons[i] = string(change.Reason) + "@" + change.RelayID
+	}
+	return reasons
+}
+
+func TestPlacementHistoryRecordsChanges(t *testing.T) {
+	backend := newTTLCleanupBackend()
+	reg := newHistoryTestRegistry(t, backend, PlacementHistoryConfig{Enabled: true})
+	ctx := context.Background()
+
+	place := func(relayID string) {
+		t.Helper()
+		if err := reg.RegisterAgent(ctx, Agent{ID: "agent-1"}, relayID); err != nil {
+			t.Fatalf("RegisterAgent() error = %v", err)
+		}
+		backend.placements["agent-1"] = relayID
+	}
+
+	place("relay-1")
+	place("relay-1")
+	place("relay-2")
+	if err := reg.RemoveAgents(ctx, []string{"agent-1"}); err != nil {
+		t.Fatalf("RemoveAgents() error = %v", err)
+	}
+	delete(backend.placements, "agent-1")
+	place("relay-3")
+
+	now := time.Now()
+	backend.agents["agent-1"] = Agent{ID: "agent-1", LastHeartbeat: now.Add(-time.Minute)}
+	backend.relayAgents["relay-3"] = map[string]struct{}{"agent-1": {}}
+	backend.relays["relay-3"] = Relay{ID: "relay-3", LastSeen: now}
+	if err := reg.runTTLCleanup(ctx, now); err != nil {
+		t.Fatalf("runTTLCleanup() error = %v", err)
+	}
+
+	changes, ok := reg.PlacementHistory(ctx, "agent-1")
+	if !ok {
+		t.Fatalf("expected the placement history to be enabled")
+	}
+	want := []string{"expired@relay-3", "registered@relay-3", "evicted@relay-2", "moved@relay-2", "registered@relay-1"}
+	if got := historyReasons(changes); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	if changes, _ := reg.PlacementHistory(ctx, "agent-unknown"); len(changes) != 0 {
+		t.Fatalf("expected no history for an unknown agent, got %+v", changes)
+	}
+}
+
+func TestPlacementHistoryBounds(t *testing.T) {
+	backend := newTTLCleanupBackend()
+	reg := newHistoryTestRegistry(t, backend, PlacementHistoryConfig{Enabled: true, Entries: 2, Retention: time.Hour})
+	ctx := context.Background()
+
+	for _, relayID := range []string{"relay-1", "relay-2", "relay-3"} {
+		if err := reg.RegisterAgent(ctx, Agent{ID: "agent-1"}, relayID); err != nil {
+			t.Fatalf("RegisterAgent() error = %v", err)
+		}
+		backend.placements["agent-1"] = relayID
+	}
+
+	if got, want := historyReasons(mustHistory(t, reg, "agent-1")), []string{"moved@relay-3", "moved@relay-2"}; !slices.Equal(got, want) {
+		t.Fatalf("expected the newest %v, got %v", want, got)
+	}
+
+	// Age agent-1's changes past the retention and record another agent
+	// after the prune interval, which drops agent-1 entirely.
+	reg.history.mu.Lock()
+	for i := range reg.history.agents[entryKey{DefaultNamespace, "agent-1"}] {
+		reg.history.agents[entryKey{DefaultNamespace, "agent-1"}][i].At = time.Now().Add(-2 * time.Hour)
+	}
+	reg.history.lastPrune = time.Now().Add(-historyPruneInterval)
+	reg.history.mu.Unlock()
+
+	if changes := mustHistory(t, reg, "agent-1"); len(changes) != 0 {
+		t.Fatalf("expected changes past retention to be hidden, got %+v", changes)
+	}
+	if err := reg.RegisterAgent(ctx, Agent{ID: "agent-2"}, "relay-1"); err != nil {
+		t.Fatalf("RegisterAgent() error = %v", err)
+	}
+
+	reg.history.mu.Lock()
+	_, kept := reg.history.agents[entryKey{DefaultNamespace, "agent-1"}]
+	reg.history.mu.Unlock()
+	if kept {
+		t.Fatalf("expected agent-1 to be pruned")
+	}
+}
+
+func TestPlacementHistoryDisabled(t *testing.T) {
+	reg := newHistoryTestRegistry(t, newTTLCleanupBackend(), PlacementHistoryConfig{Enabled: true})
+	ctx := context.Background()
+
+	if err := reg.RegisterAgent(ctx, Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("RegisterAgent() error = %v", err)
+	}
+
+	next := reg.Config()
+	next.PlacementHistory.Enabled = false
+	if _, err := reg.ApplyConfig(ctx, &next); err != nil {
+		t.Fatalf("ApplyConfig() error = %v", err)
+	}
+	if _, ok := reg.PlacementHistory(ctx, "agent-1"); ok {
+		t.Fatalf("expected the placement history to be disabled")
+	}
+
+	next.PlacementHistory.Enabled = true
+	if _, err := reg.ApplyConfig(ctx, &next); err != nil {
+		t.Fatalf("ApplyConfig() error = %v", err)
+	}
+	if changes := mustHistory(t, reg, "agent-1"); len(changes) != 0 {
+		t.Fatalf("expected the history to start empty after re-enabling, got %+v", changes)
+	}
+}
+
+func mustHistory(t *testing.T, reg *Registry, agentID string) []PlacementChange {
+	t.Helper()
+
+	changes, ok := reg.PlacementHistory(context.Background(), agentID)
+	if !ok {
+		t.Fatalf("expected the placement history to be enabled")
+	}
+	return changes
+}
@@ -0,0 +1,401 @@
+// Package migrate copies registry state between backends and reports
+// where two backends disagree. It is used to move a registry from one
+// backend to another without losing relays, agents or placements.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// timestampTolerance absorbs the precision backends lose when they store
+// timestamps.
+const timestampTolerance = time.Millisecond
+
+// Kind names the kind of entry a Mismatch is about.
+type Kind string
+
+const (
+	KindRelay     Kind = "relay"
+	KindAgent     Kind = "agent"
+	KindPlacement Kind = "placement"
+)
+
+// Reason explains how an entry differs between the two backends.
+type Reason string
+
+const (
+	// ReasonMissing means the entry exists only in the source.
+	ReasonMissing Reason = "missing"
+	// ReasonExtra means the entry exists only in the destination.
+	ReasonExtra Reason = "extra"
+	// ReasonDiffers means the entry exists in both with different values.
+	ReasonDiffers Reason = "differs"
+)
+
+// Mismatch is a single difference between the source and destination.
+type Mismatch struct {
+	Namespace string
+	Kind      Kind
+	ID        string
+	Reason    Reason
+	// Detail describes a ReasonDiffers mismatch.
+	Detail string
+}
+
+func (m Mismatch) String() string {
+	id := m.ID
+	if m.Namespace != registry.DefaultNamespace {
+		id = m.Namespace + "/" + m.ID
+	}
+	if m.Detail == "" {
+		return fmt.Sprintf("%s %s %s", m.Kind, id, m.Reason)
+	}
+	return fmt.Sprintf("%s %s %s: %s", m.Kind, id, m.Reason, m.Detail)
+}
+
+// Report is the result of comparing a source and a destination backend.
+type Report struct {
+	SourceRelays      int
+	SourceAgents      int
+	DestinationRelays int
+	DestinationAgents int
+	Mismatches        []Mismatch
+}
+
+// Options controls a copy.
+type Options struct {
+	// Prune removes entries that exist only in the destination, so that
+	// removals made on the source are carried over.
+	Prune bool
+}
+
+// Result counts the writes a copy made to the destination.
+type Result struct {
+	RelaysCopied  int
+	AgentsCopied  int
+	RelaysRemoved int
+	AgentsRemoved int
+
+	// Restamped is set when the destination cannot import timestamps, so
+	// copied entries carry the time of the copy instead of their own.
+	Restamped bool
+}
+
+// state is a point-in-time view of the relays, agents and placements of
+// one namespace of a backend, keyed by ID.
+type state struct {
+	relays     map[string]registry.Relay
+	agents     map[string]registry.Agent
+	placements map[string]registry.AgentPlacement
+}
+
+// namespaces returns the sorted union of the namespaces of src and dst.
+func namespaces(ctx context.Context, src, dst registry.Backend) ([]string, error) {
+	from, err := src.ListNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list source namespaces: %w", err)
+	}
+	to, err := dst.ListNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list destination namespaces: %w", err)
+	}
+
+	union := append(slices.Clone(from), to...)
+	slices.Sort(union)
+	return slices.Compact(union), nil
+}
+
+func readState(ctx context.Context, backend registry.Backend, namespace string) (*state, error) {
+	relays, err := backend.ListRelays(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("list relays in %s: %w", namespace, err)
+	}
+
+	agents, err := backend.ListAgents(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("list agents in %s: %w", namespace, err)
+	}
+
+	s := &state{
+		relays:     make(map[string]registry.Relay, len(relays)),
+		agents:     make(map[string]registry.Agent, len(agents)),
+		placements: make(map[string]registry.AgentPlacement, len(agents)),
+	}
+	for _, relay := range relays {
+		s.relays[relay.ID] = relay
+	}
+	for _, agent := range agents {
+		placement, err := backend.GetAgentPlacement(ctx, namespace, agent.ID)
+		if errors.Is(err, registry.ErrNotFound) {
+			// Removed between the list and the lookup.
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get placement of agent %s: %w", agent.ID, err)
+		}
+
+		s.agents[agent.ID] = agent
+		s.placements[agent.ID] = *placement
+	}
+
+	return s, nil
+}
+
+// Copy writes the relays, agents and placements of src that are missing
+// from or differ in dst, namespace by namespace. Timestamps are preserved
+// when dst implements registry.StateImporter. With opts.Prune, entries
+// found only in dst are removed.
+func Copy(ctx context.Context, src, dst registry.Backend, opts Options) (Result, error) {
+	var result Result
+
+	_, canImport := registry.FindBackend[registry.StateImporter](dst)
+	result.Restamped = !canImport
+
+	all, err := namespaces(ctx, src, dst)
+	if err != nil {
+		return result, err
+	}
+
+	for _, namespace := range all {
+		if err := copyNamespace(ctx, src, dst, namespace, opts, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func copyNamespace(ctx context.Context, src, dst registry.Backend, namespace string, opts Options, result *Result) error {
+	from, err := readState(ctx, src, namespace)
+	if err != nil {
+		return fmt.Errorf("read source: %w", err)
+	}
+	to, err := readState(ctx, dst, namespace)
+	if err != nil {
+		return fmt.Errorf("read destination: %w", err)
+	}
+
+	importer, canImport := registry.FindBackend[registry.StateImporter](dst)
+
+	for _, id := range sortedKeys(from.relays) {
+		relay := from.relays[id]
+		if existing, ok := to.relays[id]; ok && relayDiff(relay, existing) == "" {
+			continue
+		}
+
+		if canImport {
+			err = importer.ImportRelay(ctx, namespace, relay)
+		} else {
+			err = dst.RegisterRelay(ctx, namespace, relay)
+		}
+		if err != nil {
+			return fmt.Errorf("copy relay %s/%s: %w", namespace, id, err)
+		}
+		result.RelaysCopied++
+	}
+
+	for _, id := range sortedKeys(from.agents) {
+		agent, placement := from.agents[id], from.placements[id]
+		if existing, ok := to.agents[id]; ok &&
+			agentDiff(agent, existing) == "" &&
+			placementDiff(placement, to.placements[id]) == "" {
+			continue
+		}
+
+		if canImport {
+			err = importer.ImportAgent(ctx, namespace, agent, placement)
+		} else {
+			err = dst.RegisterAgent(ctx, namespace, agent, placement.RelayID)
+		}
+		if err != nil {
+			return fmt.Errorf("copy agent %s/%s: %w", namespace, id, err)
+		}
+		result.AgentsCopied++
+	}
+
+	if !opts.Prune {
+		return nil
+	}
+
+	var extraAgents []string
+	for _, id := range sortedKeys(to.agents) {
+		if _, ok := from.agents[id]; !ok {
+			extraAgents = append(extraAgents, id)
+		}
+	}
+	if len(extraAgents) > 0 {
+		if err := dst.RemoveAgents(ctx, namespace, extraAgents); err != nil {
+			return fmt.Errorf("remove agents in %s: %w", namespace, err)
+		}
+		result.AgentsRemoved += len(extraAgents)
+	}
+
+	for _, id := range sortedKeys(to.relays) {
+		if _, ok := from.relays[id]; ok {
+			continue
+		}
+		if err := dst.RemoveRelay(ctx, namespace, id); err != nil && !errors.Is(err, registry.ErrNotFound) {
+			return fmt.Errorf("remove relay %s/%s: %w", namespace, id, err)
+		}
+		result.RelaysRemoved++
+	}
+
+	return nil
+}
+
+// Sync copies src to dst with pruning every interval until ctx is done,
+// keeping dst in step with the writes src receives during a cutover
+// window. Failed passes are logged and retried on the next tick.
+func Sync(ctx context.Context, src, dst registry.Backend, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		result, err := Copy(ctx, src, dst, Options{Prune: true})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.LogAttrs(ctx, slog.LevelWarn, "migration sync pass failed",
+				slog.String("method", "migrate.Sync"),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		slog.LogAttrs(ctx, slog.LevelDebug, "migration sync pass completed",
+			slog.String("method", "migrate.Sync"),
+			slog.Int("relays_copied", result.RelaysCopied),
+			slog.Int("agents_copied", result.AgentsCopied),
+			slog.Int("relays_removed", result.RelaysRemoved),
+			slog.Int("agents_removed", result.AgentsRemoved),
+		)
+	}
+}
+
+// Diff compares the relays, agents and placements of src and dst in every
+// namespace either holds.
+func Diff(ctx context.Context, src, dst registry.Backend) (Report, error) {
+	all, err := namespaces(ctx, src, dst)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var report Report
+	for _, namespace := range all {
+		from, err := readState(ctx, src, namespace)
+		if err != nil {
+			return Report{}, fmt.Errorf("read source: %w", err)
+		}
+		to, err := readState(ctx, dst, namespace)
+		if err != nil {
+			return Report{}, fmt.Errorf("read destination: %w", err)
+		}
+
+		report.SourceRelays += len(from.relays)
+		report.SourceAgents += len(from.agents)
+		report.DestinationRelays += len(to.relays)
+		report.DestinationAgents += len(to.agents)
+		report.Mismatches = append(report.Mismatches, diffEntries(namespace, KindRelay, from.relays, to.relays, relayDiff)...)
+		report.Mismatches = append(report.Mismatches, diffEntries(namespace, KindAgent, from.agents, to.agents, agentDiff)...)
+		report.Mismatches = append(report.Mismatches, diffEntries(namespace, KindPlacement, from.placements, to.placements, placementDiff)...)
+	}
+
+	return report, nil
+}
+
+func diffEntries[T any](namespace string, kind Kind, from, to map[string]T, diff func(a, b T) string) []Mismatch {
+	var mismatches []Mismatch
+	for _, id := range sortedKeys(from) {
+		existing, ok := to[id]
+		switch {
+		case !ok:
+			mismatches = append(mismatches, Mismatch{Namespace: namespace, Kind: kind, ID: id, Reason: ReasonMissing})
+		case diff(from[id], existing) != "":
+			mismatches = append(mismatches, Mismatch{Namespace: namespace, Kind: kind, ID: id, Reason: ReasonDiffers, Detail: diff(from[id], existing)})
+		}
+	}
+	for _, id := range sortedKeys(to) {
+		if _, ok := from[id]; !ok {
+			mismatches = append(mismatches, Mismatch{Namespace: namespace, Kind: kind, ID: id, Reason: ReasonExtra})
+		}
+	}
+
+	return mismatches
+}
+
+func relayDiff(a, b registry.Relay) string {
+	switch {
+	case a.Address != b.Address:
+		return fmt.Sprintf("address %q != %q", a.Address, b.Address)
+	case a.GRPCPort != b.GRPCPort:
+		return fmt.Sprintf("grpc port %d != %d", a.GRPCPort, b.GRPCPort)
+	case !sameTime(a.LastSeen, b.LastSeen):
+		return fmt.Sprintf("last seen %s != %s", formatTime(a.LastSeen), formatTime(b.LastSeen))
+	default:
+		return ""
+	}
+}
+
+func agentDiff(a, b registry.Agent) string {
+	if !sameTime(a.LastHeartbeat, b.LastHeartbeat) {
+		return fmt.Sprintf("last heartbeat %s != %s", formatTime(a.LastHeartbeat), formatTime(b.LastHeartbeat))
+	}
+	return metadataDiff(a.Metadata, b.Metadata)
+}
+
+func placementDiff(a, b registry.AgentPlacement) string {
+	switch {
+	case a.RelayID != b.RelayID:
+		return fmt.Sprintf("relay %q != %q", a.RelayID, b.RelayID)
+	case !sameTime(a.UpdatedAt, b.UpdatedAt):
+		return fmt.Sprintf("updated at %s != %s", formatTime(a.UpdatedAt), formatTime(b.UpdatedAt))
+	default:
+		return metadataDiff(a.Metadata, b.Metadata)
+	}
+}
+
+func metadataDiff(a, b registry.AgentMetadata) string {
+	switch {
+	case a.Kind != b.Kind:
+		return fmt.Sprintf("kind %q != %q", a.Kind, b.Kind)
+	case a.Version != b.Version:
+		return fmt.Sprintf("version %q != %q", a.Version, b.Version)
+	case !slices.Equal(a.Capabilities, b.Capabilities):
+		return fmt.Sprintf("capabilities %q != %q", a.Capabilities, b.Capabilities)
+	case !sameTime(a.SessionStart, b.SessionStart):
+		return fmt.Sprintf("session start %s != %s", formatTime(a.SessionStart), formatTime(b.SessionStart))
+	default:
+		return ""
+	}
+}
+
+func sameTime(a, b time.Time) bool {
+	return a.Sub(b).Abs() < timestampTolerance
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,239 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+)
+
+// restampingBackend hides the memory backend's StateImporter.
+type restampingBackend struct {
+	registry.Backend
+}
+
+func newMemoryBackend(t *testing.T) *memory.Backend {
+	t.Helper()
+
+	backend, err := memory.New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	return backend
+}
+
+func seed(t *testing.T, backend *memory.Backend, at time.Time) {
+	t.Helper()
+
+	ctx := context.Background()
+	for _, id := range []string{"relay-1", "relay-2"} {
+		if err := backend.ImportRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: id, Address: "10.0.0.1", GRPCPort: 9000, LastSeen: at}); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+	for id, relayID := range map[string]string{"agent-1": "relay-1", "agent-2": "relay-2"} {
+		err := backend.ImportAgent(ctx, registry.DefaultNamespace,
+			registry.Agent{ID: id, LastHeartbeat: at},
+			registry.AgentPlacement{AgentID: id, RelayID: relayID, UpdatedAt: at},
+		)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+}
+
+func TestCopyPreservesState(t *testing.T) {
+	ctx := context.Background()
+	src, dst := newMemoryBackend(t), newMemoryBackend(t)
+	seed(t, src, time.Now().Add(-time.Minute))
+
+	result, err := Copy(ctx, src, dst, Options{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if result.RelaysCopied != 2 || result.AgentsCopied != 2 || result.Restamped {
+		t.Fatalf("expected 2 relays and 2 agents copied with timestamps, got %+v", result)
+	}
+
+	report, err := Diff(ctx, src, dst)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(report.Mismatches) != 0 {
+		t.Fatalf("expected no mismatches, got %v", report.Mismatches)
+	}
+
+	result, err = Copy(ctx, src, dst, Options{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if result.RelaysCopied != 0 || result.AgentsCopied != 0 {
+		t.Fatalf("expected second copy to be a no-op, got %+v", result)
+	}
+}
+
+func TestCopyWithoutImporterRestamps(t *testing.T) {
+	ctx := context.Background()
+	src, dst := newMemoryBackend(t), newMemoryBackend(t)
+	seed(t, src, time.Now().Add(-time.Hour))
+
+	result, err := Copy(ctx, src, restampingBackend{dst}, Options{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !result.Restamped {
+		t.Fatalf("expected copy to report restamped entries, got %+v", result)
+	}
+
+	report, err := Diff(ctx, src, dst)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	for _, mismatch := range report.Mismatches {
+		if mismatch.Reason != ReasonDiffers {
+			t.Fatalf("expected only timestamp differences, got %v", mismatch)
+		}
+	}
+	if len(report.Mismatches) != 6 {
+		t.Fatalf("expected 6 timestamp mismatches, got %v", report.Mismatches)
+	}
+}
+
+func TestCopyPrune(t *testing.T) {
+	ctx := context.Background()
+	src, dst := newMemoryBackend(t), newMemoryBackend(t)
+	at := time.Now().Add(-time.Minute)
+	seed(t, src, at)
+	seed(t, dst, at)
+
+	if err := src.RemoveAgents(ctx, registry.DefaultNamespace, []string{"agent-2"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := src.RemoveRelay(ctx, registry.DefaultNamespace, "relay-2"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	report, err := Diff(ctx, src, dst)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := []Mismatch{
+		{Namespace: registry.DefaultNamespace, Kind: KindRelay, ID: "relay-2", Reason: ReasonExtra},
+		{Namespace: registry.DefaultNamespace, Kind: KindAgent, ID: "agent-2", Reason: ReasonExtra},
+		{Namespace: registry.DefaultNamespace, Kind: KindPlacement, ID: "agent-2", Reason: ReasonExtra},
+	}
+	if len(report.Mismatches) != len(want) {
+		t.Fatalf("expected %v, got %v", want, report.Mismatches)
+	}
+	for i := range want {
+		if report.Mismatches[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, report.Mismatches)
+		}
+	}
+
+	result, err := Copy(ctx, src, dst, Options{Prune: true})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if result.RelaysRemoved != 1 || result.AgentsRemoved != 1 {
+		t.Fatalf("expected 1 relay and 1 agent removed, got %+v", result)
+	}
+
+	report, err = Diff(ctx, src, dst)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(report.Mismatches) != 0 {
+		t.Fatalf("expected no mismatches after prune, got %v", report.Mismatches)
+	}
+}
+
+func TestCopyComparesMetadata(t *testing.T) {
+	ctx := context.Background()
+	src, dst := newMemoryBackend(t), newMemoryBackend(t)
+	at := time.Now().Add(-time.Minute)
+	seed(t, src, at)
+	seed(t, dst, at)
+
+	agentMetadata := registry.AgentMetadata{Kind: registry.AgentKindDrone, Version: "px4-1.15", Capabilities: []string{"video"}}
+	err := src.ImportAgent(ctx, registry.DefaultNamespace,
+		registry.Agent{ID: "agent-1", LastHeartbeat: at, Metadata: agentMetadata},
+		registry.AgentPlacement{AgentID: "agent-1", RelayID: "relay-1", UpdatedAt: at, Metadata: agentMetadata},
+	)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	report, err := Diff(ctx, src, dst)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want := []Mismatch{
+		{Namespace: registry.DefaultNamespace, Kind: KindAgent, ID: "agent-1", Reason: ReasonDiffers, Detail: `kind "drone" != ""`},
+		{Namespace: registry.DefaultNamespace, Kind: KindPlacement, ID: "agent-1", Reason: ReasonDiffers, Detail: `kind "drone" != ""`},
+	}
+	if len(report.Mismatches) != len(want) {
+		t.Fatalf("expected %v, got %v", want, report.Mismatches)
+	}
+	for i := range want {
+		if report.Mismatches[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, report.Mismatches)
+		}
+	}
+
+	result, err := Copy(ctx, src, dst, Options{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if result.AgentsCopied != 1 {
+		t.Fatalf("expected the agent with new metadata to be copied, got %+v", result)
+	}
+
+	report, err = Diff(ctx, src, dst)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(report.Mismatches) != 0 {
+		t.Fatalf("expected no mismatches after copy, got %v", report.Mismatches)
+	}
+}
+
+func TestSyncFollowsSourceWrites(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src, dst := newMemoryBackend(t), newMemoryBackend(t)
+	seed(t, src, time.Now())
+	if _, err := Copy(ctx, src, dst, Options{}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Sync(ctx, src, dst, time.Millisecond)
+	}()
+
+	if err := src.RegisterRelay(ctx, registry.DefaultNamespace, registry.Relay{ID: "relay-3"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := src.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-3"}, "relay-3"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := dst.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-3"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected sync to copy the new agent")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// DefaultNamespace is the namespace of requests that do not name one. It
+// always exists, whether or not it is listed in the tenant config.
+const DefaultNamespace = "default"
+
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidNamespace reports whether namespace is a lowercase DNS label.
+func ValidNamespace(namespace string) bool {
+	return namespacePattern.MatchString(namespace)
+}
+
+type namespaceKey struct{}
+
+// WithNamespace returns a context whose registry calls are scoped to
+// namespace.
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// NamespaceFromContext returns the namespace set by WithNamespace, or
+// DefaultNamespace.
+func NamespaceFromContext(ctx context.Context) string {
+	if namespace, ok := ctx.Value(namespaceKey{}).(string); ok && namespace != "" {
+		return namespace
+	}
+
+	return DefaultNamespace
+}
+
+// ResolveNamespace returns the namespace a request from identity is scoped
+// to. requested is the namespace the caller asked for, or empty. A caller
+// bound to a tenant defaults to, and is pinned to, that tenant's namespace.
+// Other callers may only use DefaultNamespace. Privileged callers, such as
+// those holding the admin token, may use any known namespace.
+func (c *Config) ResolveNamespace(identity, requested string, privileged bool) (string, error) {
+	var bound string
+	for _, tenant := range c.Tenants {
+		if identity != "" && slices.Contains(tenant.Identities, identity) {
+			bound = tenant.Namespace
+			break
+		}
+	}
+
+	if requested == "" {
+		if bound != "" {
+			return bound, nil
+		}
+		requested = DefaultNamespace
+	}
+
+	if !ValidNamespace(requested) {
+		return "", fmt.Errorf("%w: namespace %q is not a lowercase DNS label", ErrInvalid, requested)
+	}
+
+	if _, configured := c.Tenant(requested); !configured && requested != DefaultNamespace {
+		return "", fmt.Errorf("%w: unknown namespace %s", ErrInvalid, requested)
+	}
+
+	switch {
+	case privileged, requested == bound:
+		return requested, nil
+	case bound != "":
+		return "", fmt.Errorf("%w: caller is bound to namespace %s", ErrPermissionDenied, bound)
+	case requested != DefaultNamespace:
+		return "", fmt.Errorf("%w: namespace %s is restricted to its tenant", ErrPermissionDenied, requested)
+	}
+
+	return requested, nil
+}
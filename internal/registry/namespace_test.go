@@ -0,0 +1,197 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConfigResolveNamespace(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		Tenants: []TenantConfig{
+			{Namespace: "fleet-a", Identities: []string{"cert:fleet-a"}},
+			{Namespace: "fleet-b", Identities: []string{"cert:fleet-b"}},
+			{Namespace: "shared"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		identity   string
+		requested  string
+		privileged bool
+		want       string
+		wantErr    error
+	}{
+		{
+			name: "unbound caller defaults to the default namespace",
+			want: DefaultNamespace,
+		},
+		{
+			name:     "bound caller defaults to its tenant",
+			identity: "cert:fleet-a",
+			want:     "fleet-a",
+		},
+		{
+			name:      "bound caller names its tenant",
+			identity:  "cert:fleet-a",
+			requested: "fleet-a",
+			want:      "fleet-a",
+		},
+		{
+			name:      "bound caller names another tenant",
+			identity:  "cert:fleet-a",
+			requested: "fleet-b",
+			wantErr:   ErrPermissionDenied,
+		},
+		{
+			name:      "bound caller names the default namespace",
+			identity:  "cert:fleet-a",
+			requested: DefaultNamespace,
+			wantErr:   ErrPermissionDenied,
+		},
+		{
+			name:      "unbound caller names the default namespace",
+			identity:  "cert:unbound",
+			requested: DefaultNamespace,
+			want:      DefaultNamespace,
+		},
+		{
+			name:      "unbound caller names a restricted tenant",
+			identity:  "cert:unbound",
+			requested: "fleet-b",
+			wantErr:   ErrPermissionDenied,
+		},
+		{
+			name:      "unbound caller names a tenant without identities",
+			identity:  "cert:unbound",
+			requested: "shared",
+			wantErr:   ErrPermissionDenied,
+		},
+		{
+			name:       "privileged unbound caller names a tenant without identities",
+			requested:  "shared",
+			privileged: true,
+			want:       "shared",
+		},
+		{
+			name:       "privileged caller names another tenant",
+			identity:   "cert:fleet-a",
+			requested:  "fleet-b",
+			privileged: true,
+			want:       "fleet-b",
+		},
+		{
+			name:       "unknown namespace",
+			requested:  "fleet-z",
+			privileged: true,
+			wantErr:    ErrInvalid,
+		},
+		{
+			name:      "malformed namespace",
+			requested: "Fleet_A",
+			wantErr:   ErrInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := cfg.ResolveNamespace(test.identity, test.requested, test.privileged)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+			if got != test.want {
+				t.Fatalf("expected namespace %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestNamespaceFromContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if got := NamespaceFromContext(ctx); got != DefaultNamespace {
+		t.Fatalf("expected %q without a namespace, got %q", DefaultNamespace, got)
+	}
+	if got := NamespaceFromContext(WithNamespace(ctx, "fleet-a")); got != "fleet-a" {
+		t.Fatalf("expected fleet-a, got %q", got)
+	}
+}
+
+func TestConfigTenantTTL(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		TTL: TTLConfig{Relay: 30 * time.Second, Agent: 30 * time.Second},
+		Tenants: []TenantConfig{
+			{Namespace: "fleet-a", TTL: TenantTTLConfig{Relay: 2 * time.Minute}},
+		},
+	}
+
+	if got := cfg.TenantTTL("fleet-a"); got.Relay != 2*time.Minute || got.Agent != 30*time.Second {
+		t.Fatalf("expected the relay TTL to be overridden, got %+v", got)
+	}
+	if got := cfg.TenantTTL(DefaultNamespace); got != cfg.TTL {
+		t.Fatalf("expected the registry TTLs, got %+v", got)
+	}
+}
+
+func TestRunTTLCleanupUsesTenantTTL(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	backend := newTTLCleanupBackend()
+	backend.namespaces = []string{"fleet-a"}
+	backend.relays["relay-1"] = Relay{ID: "relay-1", LastSeen: now.Add(-45 * time.Second)}
+
+	reg := &Registry{
+		cfg: &Config{
+			TTL: TTLConfig{Relay: 30 * time.Second, Agent: 30 * time.Second},
+			Tenants: []TenantConfig{
+				{Namespace: "fleet-a", TTL: TenantTTLConfig{Relay: time.Minute}},
+			},
+		},
+		backend: backend,
+	}
+
+	if err := reg.runTTLCleanup(context.Background(), now); err != nil {
+		t.Fatalf("runTTLCleanup returned error: %v", err)
+	}
+	if _, ok := backend.relays["relay-1"]; !ok {
+		t.Fatalf("expected relay within the tenant TTL to be kept")
+	}
+
+	if err := reg.runTTLCleanup(context.Background(), now.Add(30*time.Second)); err != nil {
+		t.Fatalf("runTTLCleanup returned error: %v", err)
+	}
+	if _, ok := backend.relays["relay-1"]; ok {
+		t.Fatalf("expected relay past the tenant TTL to be removed")
+	}
+}
+
+func TestRegistryEnforcesTenantLimits(t *testing.T) {
+	backend := newTTLCleanupBackend()
+	backend.relays["relay-1"] = Relay{ID: "relay-1"}
+
+	reg := &Registry{
+		cfg: &Config{
+			Tenants: []TenantConfig{
+				{Namespace: "fleet-a", Limits: CapacityLimits{MaxRelays: 1}},
+			},
+		},
+		backend: backend,
+	}
+
+	ctx := context.Background()
+	if err := reg.RegisterRelay(WithNamespace(ctx, "fleet-a"), Relay{ID: "relay-2"}); !errors.Is(err, ErrResourceExhausted) {
+		t.Fatalf("expected ErrResourceExhausted for the tenant relay limit, got %v", err)
+	}
+	if err := reg.RegisterRelay(ctx, Relay{ID: "relay-2"}); err != nil {
+		t.Fatalf("expected the default namespace to be unlimited, got %v", err)
+	}
+}
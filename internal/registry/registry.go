@@ -25,22 +25,44 @@ import (
 	"context"
 	"errors"
 	"log/slog"
-	"math/rand/v2"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/Aero-Arc/aero-arc-registry/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Registry struct {
+	// cfg is replaced wholesale by ApplyConfig; read it through config().
 	cfg     *Config
+	cfgMu   sync.RWMutex
 	backend Backend
 
-	ttlLoopRunning       atomic.Bool
+	ttlLoop              ttlLoop
 	ttlCleanupInProgress atomic.Bool
+	sweeper              sweeperState
+	ttlStats             ttlStats
+	schedule             sweepSchedule
+	capacityRejections   atomic.Uint64
+	degraded             degradedState
+	audit                auditLog
+	history              placementHistory
+
+	// tracerProvider creates registry spans; nil uses the global provider.
+	tracerProvider trace.TracerProvider
 }
 
-func New(cfg *Config, backend Backend) (*Registry, error) {
+// ttlLoop tracks the background TTL goroutine started by RunTTL.
+type ttlLoop struct {
+	mu     sync.Mutex
+	stop   chan struct{}
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+func New(cfg *Config, backend Backend, opts ...Option) (*Registry, error) {
 	if cfg == nil {
 		return nil, ErrNilConfig
 	}
@@ -53,79 +75,318 @@ func New(cfg *Config, backend Backend) (*Registry, error) {
 		cfg:     cfg,
 		backend: backend,
 	}
+	for _, opt := range opts {
+		opt(aeroRegistry)
+	}
+	aeroRegistry.applyCapacityLimits(cfg)
+	aeroRegistry.configureAudit(cfg.Audit)
 
 	return aeroRegistry, nil
 }
 
-func (r *Registry) RegisterRelay(ctx context.Context, relay Relay) error {
+func (r *Registry) RegisterRelay(ctx context.Context, relay Relay) (err error) {
+	ctx, span := r.startSpan(ctx, "RegisterRelay", attribute.String("relay.id", relay.ID))
+	defer func() { EndSpan(span, err) }()
+
 	// TODO(registry-ttl): make registry-owned timestamps authoritative by setting
 	// relay.LastSeen here before persisting, instead of trusting external clocks.
-	return r.backend.RegisterRelay(ctx, relay)
+	namespace := NamespaceFromContext(ctx)
+	err = r.admitRelay(ctx, namespace, relay.ID)
+	if err == nil {
+		err = r.backend.RegisterRelay(ctx, namespace, relay)
+		r.observeBackend(ctx, err)
+	}
+	if err == nil {
+		r.Audit(ctx, AuditEvent{Type: AuditRelayRegistered, RelayID: relay.ID})
+	}
+	if errors.Is(err, ErrResourceExhausted) {
+		r.recordCapacityRejection(ctx, "RegisterRelay", err, slog.String("relay_id", relay.ID))
+	}
+
+	return err
 }
 
-func (r *Registry) HeartbeatRelay(ctx context.Context, relayID string) error {
+func (r *Registry) HeartbeatRelay(ctx context.Context, relayID string) (err error) {
+	ctx, span := r.startSpan(ctx, "HeartbeatRelay", attribute.String("relay.id", relayID))
+	defer func() { EndSpan(span, err) }()
+
 	// TODO(registry-ttl): move heartbeat timestamp source of truth to registry
 	// write path (backend should persist registry-assigned time).
-	return r.backend.HeartbeatRelay(ctx, relayID)
+	namespace := NamespaceFromContext(ctx)
+	err = r.backend.HeartbeatRelay(ctx, namespace, relayID)
+	r.observeBackend(ctx, err)
+	if r.bufferHeartbeat(&r.degraded.relayBeats, namespace, relayID, err) {
+		return nil
+	}
+
+	return err
 }
 
-func (r *Registry) ListRelays(ctx context.Context) ([]Relay, error) {
-	return r.backend.ListRelays(ctx)
+func (r *Registry) ListRelays(ctx context.Context) (relays []Relay, err error) {
+	ctx, span := r.startSpan(ctx, "ListRelays")
+	defer func() { EndSpan(span, err) }()
+
+	namespace := NamespaceFromContext(ctx)
+	relays, err = r.backend.ListRelays(ctx, namespace)
+	r.observeBackend(ctx, err)
+	if err == nil {
+		r.rememberRelays(namespace, relays)
+		return relays, nil
+	}
+	if stale, ok := r.staleRelays(ctx, namespace, err); ok {
+		span.SetAttributes(attribute.Bool("registry.stale", true))
+		return stale, nil
+	}
+
+	return nil, err
 }
 
-func (r *Registry) RemoveRelay(ctx context.Context, relayID string) error {
-	return r.backend.RemoveRelay(ctx, relayID)
+func (r *Registry) RemoveRelay(ctx context.Context, relayID string) (err error) {
+	ctx, span := r.startSpan(ctx, "RemoveRelay", attribute.String("relay.id", relayID))
+	defer func() { EndSpan(span, err) }()
+
+	err = r.removeRelay(ctx, relayID)
+	if err == nil {
+		r.Audit(ctx, AuditEvent{Type: AuditRelayRemoved, RelayID: relayID})
+	}
+
+	return err
 }
 
-func (r *Registry) RegisterAgent(ctx context.Context, agent Agent, relayID string) error {
-	return r.backend.RegisterAgent(ctx, agent, relayID)
+func (r *Registry) removeRelay(ctx context.Context, relayID string) error {
+	namespace := NamespaceFromContext(ctx)
+	err := r.backend.RemoveRelay(ctx, namespace, relayID)
+	r.observeBackend(ctx, err)
+	if err == nil {
+		r.forgetRelay(namespace, relayID)
+	}
+
+	return err
+}
+
+// DrainRelay removes relayID together with the agents placed on it, the
+// way a TTL sweep removes an expired relay. The drained agents' heartbeats
+// then fail with ErrNotFound so they register again elsewhere.
+func (r *Registry) DrainRelay(ctx context.Context, relayID string) (agentsRemoved int, err error) {
+	ctx, span := r.startSpan(ctx, "DrainRelay", attribute.String("relay.id", relayID))
+	defer func() { EndSpan(span, err) }()
+
+	agentIDs, err := r.removeRelayAgents(ctx, relayID)
+	r.recordRemovals(ctx, AuditAgentRemoved, relayID, agentIDs)
+	if err != nil {
+		r.observeBackend(ctx, err)
+		return len(agentIDs), err
+	}
+
+	err = r.removeRelay(ctx, relayID)
+	if err == nil {
+		r.Audit(ctx, AuditEvent{Type: AuditRelayRemoved, RelayID: relayID})
+	}
+
+	return len(agentIDs), err
 }
 
-func (r *Registry) HeartbeatAgent(ctx context.Context, agentID string) error {
+func (r *Registry) RegisterAgent(ctx context.Context, agent Agent, relayID string) (err error) {
+	ctx, span := r.startSpan(ctx, "RegisterAgent",
+		attribute.String("agent.id", agent.ID),
+		attribute.String("relay.id", relayID),
+	)
+	defer func() { EndSpan(span, err) }()
+
+	namespace := NamespaceFromContext(ctx)
+	err = r.admitAgent(ctx, namespace, agent.ID, relayID)
+	var previousRelayID string
+	if err == nil && (r.audit.enabled || r.config().PlacementHistory.Enabled) {
+		previousRelayID = r.currentRelay(ctx, agent.ID)
+	}
+	if err == nil {
+		err = r.backend.RegisterAgent(ctx, namespace, agent, relayID)
+		r.observeBackend(ctx, err)
+	}
+	if err == nil {
+		r.rememberPlacement(namespace, AgentPlacement{AgentID: agent.ID, RelayID: relayID, UpdatedAt: time.Now(), Metadata: agent.Metadata})
+		if previousRelayID != relayID {
+			r.Audit(ctx, AuditEvent{Type: AuditAgentPlaced, AgentID: agent.ID, RelayID: relayID, PreviousRelayID: previousRelayID})
+
+			reason := PlacementMoved
+			if previousRelayID == "" {
+				reason = PlacementRegistered
+			}
+			r.recordPlacement(namespace, agent.ID, relayID, reason)
+		}
+	}
+	if errors.Is(err, ErrResourceExhausted) {
+		r.recordCapacityRejection(ctx, "RegisterAgent", err,
+			slog.String("agent_id", agent.ID),
+			slog.String("relay_id", relayID),
+		)
+	}
+
+	return err
+}
+
+func (r *Registry) HeartbeatAgent(ctx context.Context, agentID string) (err error) {
+	ctx, span := r.startSpan(ctx, "HeartbeatAgent", attribute.String("agent.id", agentID))
+	defer func() { EndSpan(span, err) }()
+
 	// TODO(registry-ttl): move heartbeat timestamp source of truth to registry
 	// write path (backend should persist registry-assigned time).
-	return r.backend.HeartbeatAgent(ctx, agentID)
+	namespace := NamespaceFromContext(ctx)
+	err = r.backend.HeartbeatAgent(ctx, namespace, agentID)
+	r.observeBackend(ctx, err)
+	if r.bufferHeartbeat(&r.degraded.agentBeats, namespace, agentID, err) {
+		return nil
+	}
+
+	return err
+}
+
+func (r *Registry) GetAgentPlacement(ctx context.Context, agentID string) (placement *AgentPlacement, err error) {
+	ctx, span := r.startSpan(ctx, "GetAgentPlacement", attribute.String("agent.id", agentID))
+	defer func() { EndSpan(span, err) }()
+
+	namespace := NamespaceFromContext(ctx)
+	placement, err = r.backend.GetAgentPlacement(ctx, namespace, agentID)
+	r.observeBackend(ctx, err)
+	switch {
+	case err == nil:
+		r.rememberPlacement(namespace, *placement)
+		return placement, nil
+	case errors.Is(err, ErrNotFound):
+		r.forgetPlacements(namespace, agentID)
+	}
+	if stale, ok := r.stalePlacement(ctx, namespace, agentID, err); ok {
+		span.SetAttributes(attribute.Bool("registry.stale", true))
+		return stale, nil
+	}
+
+	return nil, err
+}
+
+func (r *Registry) ListAgents(ctx context.Context) (agents []Agent, err error) {
+	ctx, span := r.startSpan(ctx, "ListAgents")
+	defer func() { EndSpan(span, err) }()
+
+	agents, err = r.backend.ListAgents(ctx, NamespaceFromContext(ctx))
+	r.observeBackend(ctx, err)
+
+	return agents, err
+}
+
+func (r *Registry) ListRelayAgents(ctx context.Context, relayID string) (agents []*Agent, err error) {
+	ctx, span := r.startSpan(ctx, "ListRelayAgents", attribute.String("relay.id", relayID))
+	defer func() { EndSpan(span, err) }()
+
+	agents, err = r.backend.ListRelayAgents(ctx, NamespaceFromContext(ctx), relayID)
+	r.observeBackend(ctx, err)
+
+	return agents, err
 }
 
-func (r *Registry) GetAgentPlacement(ctx context.Context, agentID string) (*AgentPlacement, error) {
-	return r.backend.GetAgentPlacement(ctx, agentID)
+func (r *Registry) RemoveAgents(ctx context.Context, agentIDs []string) (err error) {
+	ctx, span := r.startSpan(ctx, "RemoveAgents", attribute.Int("agent.count", len(agentIDs)))
+	defer func() { EndSpan(span, err) }()
+
+	// Callers only pass agent IDs, so look up the relays the agents are
+	// removed from when the change is recorded.
+	var relayIDs map[string]string
+	if r.audit.enabled || r.config().PlacementHistory.Enabled {
+		relayIDs = make(map[string]string, len(agentIDs))
+		for _, agentID := range agentIDs {
+			relayIDs[agentID] = r.currentRelay(ctx, agentID)
+		}
+	}
+
+	err = r.removeAgents(ctx, agentIDs)
+	r.observeBackend(ctx, err)
+	if err == nil {
+		for _, agentID := range agentIDs {
+			r.recordRemoval(ctx, AuditAgentRemoved, relayIDs[agentID], agentID)
+		}
+	}
+
+	return err
 }
 
-func (r *Registry) ListAgents(ctx context.Context) ([]Agent, error) {
-	return r.backend.ListAgents(ctx)
+// currentRelay returns the relay agentID is placed on in the namespace of
+// ctx, or an empty string when it is not placed or the lookup fails.
+func (r *Registry) currentRelay(ctx context.Context, agentID string) string {
+	placement, err := r.backend.GetAgentPlacement(ctx, NamespaceFromContext(ctx), agentID)
+	if err != nil {
+		return ""
+	}
+
+	return placement.RelayID
 }
 
-func (r *Registry) ListRelayAgents(ctx context.Context, relayID string) ([]*Agent, error) {
-	return r.backend.ListRelayAgents(ctx, relayID)
+func (r *Registry) recordRemovals(ctx context.Context, eventType AuditEventType, relayID string, agentIDs []string) {
+	for _, agentID := range agentIDs {
+		r.recordRemoval(ctx, eventType, relayID, agentID)
+	}
 }
 
-func (r *Registry) RemoveAgents(ctx context.Context, agentIDs []string) error {
-	return r.backend.RemoveAgents(ctx, agentIDs)
+// recordRemoval audits the removal of agentID from relayID and adds it to
+// the agent's placement history. eventType is AuditAgentRemoved or
+// AuditAgentExpired.
+func (r *Registry) recordRemoval(ctx context.Context, eventType AuditEventType, relayID, agentID string) {
+	reason := PlacementEvicted
+	if eventType == AuditAgentExpired {
+		reason = PlacementExpired
+	}
+
+	r.Audit(ctx, AuditEvent{Type: eventType, AgentID: agentID, RelayID: relayID})
+	r.recordPlacement(NamespaceFromContext(ctx), agentID, relayID, reason)
 }
 
 func (r *Registry) RunTTL(ctx context.Context) {
-	// TODO(registry-ttl): evaluate distributed cleanup coordination for large
-	// deployments (leader election, shard ownership, or backend advisory locks).
-	if !r.ttlLoopRunning.CompareAndSwap(false, true) {
+	r.ttlLoop.mu.Lock()
+	defer r.ttlLoop.mu.Unlock()
+
+	if r.ttlLoop.done != nil {
 		slog.LogAttrs(ctx, slog.LevelWarn, "ttl loop already running; ignoring duplicate call",
 			slog.String("method", "RunTTL"),
 		)
 		return
 	}
 
+	loopCtx, cancel := context.WithCancel(ctx)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	r.ttlLoop.stop, r.ttlLoop.done, r.ttlLoop.cancel = stop, done, cancel
+
 	go func() {
-		defer r.ttlLoopRunning.Store(false)
+		defer close(done)
+		defer func() {
+			r.ttlLoop.mu.Lock()
+			r.ttlLoop.stop, r.ttlLoop.done, r.ttlLoop.cancel = nil, nil, nil
+			r.ttlLoop.mu.Unlock()
+			cancel()
+		}()
+		defer func() {
+			releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(loopCtx), sweeperLeaseReleaseTimeout)
+			defer cancel()
+			r.releaseSweeperLease(releaseCtx)
+		}()
+
+		renewDone := make(chan struct{})
+		go func() {
+			defer close(renewDone)
+			r.renewSweeperLeases(loopCtx, stop)
+		}()
+		defer func() { <-renewDone }()
 
 		timer := time.NewTimer(r.nextTTLCleanupInterval())
 		defer timer.Stop()
 
 		for {
 			select {
-			case <-ctx.Done():
+			case <-loopCtx.Done():
+				return
+			case <-stop:
 				return
 			case <-timer.C:
-				if err := r.runTTLCleanup(ctx, time.Now()); err != nil && !errorsIsContextCancellation(err) {
-					slog.LogAttrs(ctx, slog.LevelError, "ttl cleanup pass failed",
+				if err := r.runCoordinatedTTLCleanup(loopCtx, time.Now()); err != nil && !errorsIsContextCancellation(err) {
+					slog.LogAttrs(loopCtx, slog.LevelError, "ttl cleanup pass failed",
 						slog.String("method", "runTTLCleanup"),
 						slog.String("error", err.Error()),
 					)
@@ -136,10 +397,69 @@ func (r *Registry) RunTTL(ctx context.Context) {
 	}()
 }
 
+// StopTTL stops the TTL loop started by RunTTL and waits for it to exit.
+// A cleanup pass that is already running is allowed to finish; if ctx
+// expires first the pass is cancelled and ctx.Err() is returned once the
+// loop has exited. StopTTL is a no-op when the loop is not running.
+func (r *Registry) StopTTL(ctx context.Context) error {
+	r.ttlLoop.mu.Lock()
+	stop, done, cancel := r.ttlLoop.stop, r.ttlLoop.done, r.ttlLoop.cancel
+	if stop != nil {
+		select {
+		case <-stop:
+		default:
+			close(stop)
+		}
+	}
+	r.ttlLoop.mu.Unlock()
+
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// runCoordinatedTTLCleanup runs a cleanup pass only when this replica
+// holds the sweeper lease (or coordination is disabled) and degraded mode
+// is not holding sweeps back after a backend outage.
+func (r *Registry) runCoordinatedTTLCleanup(ctx context.Context, now time.Time) error {
+	if r.sweepPaused(now) {
+		r.ttlStats.skippedRuns.Add(1)
+		slog.LogAttrs(ctx, slog.LevelDebug, "ttl cleanup skipped; paused by backend outage",
+			slog.String("method", "runTTLCleanup"),
+			slog.Bool("skipped_outage", true),
+		)
+		return nil
+	}
+
+	leader, err := r.acquireSweeperLease(ctx)
+	if err != nil {
+		r.ttlStats.skippedRuns.Add(1)
+		return err
+	}
+
+	if !leader {
+		r.ttlStats.skippedRuns.Add(1)
+		slog.LogAttrs(ctx, slog.LevelDebug, "ttl cleanup skipped; sweeper lease held by another replica",
+			slog.String("method", "runTTLCleanup"),
+			slog.String("replica_id", r.ttlConfig().Coordination.ReplicaID),
+			slog.Bool("skipped_not_leader", true),
+		)
+		return nil
+	}
+
+	return r.runTTLCleanup(ctx, now)
+}
+
 func (r *Registry) runTTLCleanup(ctx context.Context, now time.Time) error {
-	// TODO(registry-ttl): add metrics instrumentation:
-	// ttl_cleanup_duration_ms, ttl_relays_removed_total, ttl_agents_removed_total,
-	// ttl_skipped_runs_total, ttl_errors_total.
 	// TODO(registry-ttl): evolve immediate deletion to optional soft TTL lifecycle
 	// (ACTIVE -> STALE -> DELETING) with configurable grace period.
 	// TODO(registry-ttl): replace multi-pass scans with a single-pass cleanup model
@@ -149,15 +469,43 @@ func (r *Registry) runTTLCleanup(ctx context.Context, now time.Time) error {
 			slog.String("method", "runTTLCleanup"),
 			slog.Bool("skipped_in_progress", true),
 		)
+		r.ttlStats.skippedRuns.Add(1)
 		return nil
 	}
 	defer r.ttlCleanupInProgress.Store(false)
 
+	ttl := r.ttlConfig()
+	ctx, span := r.startSpan(ctx, "runTTLCleanup",
+		attribute.String("replica.id", ttl.Coordination.ReplicaID),
+	)
+
 	start := time.Now()
-	staleRelaysRemoved := 0
-	staleAgentsRemoved := 0
-	errs := &utils.ErrorRecorder{}
+	sweep := &ttlSweep{errs: &utils.ErrorRecorder{}}
+	errs := sweep.errs
 	defer func() {
+		span.SetAttributes(
+			attribute.Int("ttl.stale_relays_removed", sweep.relaysRemoved),
+			attribute.Int("ttl.stale_agents_removed", sweep.agentsRemoved),
+			attribute.Int("ttl.observed_entries", sweep.observedEntries),
+			attribute.Int("ttl.errors", errs.Len()),
+		)
+		EndSpan(span, errs.Err())
+
+		duration := time.Since(start)
+		r.ttlStats.recordRun(SweepResult{
+			StartedAt:     start,
+			Duration:      duration,
+			RelaysRemoved: sweep.relaysRemoved,
+			AgentsRemoved: sweep.agentsRemoved,
+			Errors:        errs.Len(),
+		})
+		r.adaptSweepInterval(ctx, sweepObservation{
+			entries:    sweep.observedEntries,
+			nearExpiry: sweep.nearExpiry,
+			duration:   duration,
+			failed:     errs.HasErrors(),
+		})
+
 		level := slog.LevelInfo
 		if errs.HasErrors() {
 			level = slog.LevelWarn
@@ -165,24 +513,55 @@ func (r *Registry) runTTLCleanup(ctx context.Context, now time.Time) error {
 
 		slog.LogAttrs(ctx, level, "ttl cleanup completed",
 			slog.String("method", "runTTLCleanup"),
-			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
-			slog.Int("stale_relays_removed", staleRelaysRemoved),
-			slog.Int("stale_agents_removed", staleAgentsRemoved),
+			slog.String("replica_id", ttl.Coordination.ReplicaID),
+			slog.Int64("duration_ms", duration.Milliseconds()),
+			slog.Int("stale_relays_removed", sweep.relaysRemoved),
+			slog.Int("stale_agents_removed", sweep.agentsRemoved),
 			slog.Int("errors_count", errs.Len()),
 			slog.Bool("skipped_in_progress", false),
 		)
 	}()
 
-	relays, err := r.backend.ListRelays(ctx)
+	namespaces, err := r.backend.ListNamespaces(ctx)
+	if err != nil {
+		errs.Record(err)
+		return errs.Err()
+	}
+
+	for _, namespace := range namespaces {
+		r.sweepNamespace(WithNamespace(ctx, namespace), now, sweep)
+	}
+
+	return errs.Err()
+}
+
+// ttlSweep accumulates the results of a cleanup pass across namespaces.
+type ttlSweep struct {
+	relaysRemoved   int
+	agentsRemoved   int
+	observedEntries int
+	nearExpiry      int
+	errs            *utils.ErrorRecorder
+}
+
+// sweepNamespace removes the expired relays and agents of the namespace of
+// ctx, using the namespace's TTLs.
+func (r *Registry) sweepNamespace(ctx context.Context, now time.Time, sweep *ttlSweep) {
+	namespace := NamespaceFromContext(ctx)
+	ttl := r.namespaceTTL(ctx)
+	errs := sweep.errs
+
+	relays, err := r.backend.ListRelays(ctx, namespace)
 	// TODO(registry-ttl): replace full ListRelays/ListRelayAgents/ListAgents scans
 	// with backend-indexed stale queries to avoid O(N) to O(N^2) chatter at scale.
 	if err != nil {
 		errs.Record(err)
-		return errs.Err()
+		return
 	}
 
+	sweep.observedEntries += len(relays)
 	for _, relay := range relays {
-		if now.Sub(relay.LastSeen) >= r.cfg.TTL.Relay {
+		if now.Sub(relay.LastSeen) >= ttl.Relay {
 			stillStale, err := r.isRelayStillStale(ctx, relay.ID, time.Now())
 			if err != nil {
 				errs.Record(err)
@@ -196,18 +575,24 @@ func (r *Registry) runTTLCleanup(ctx context.Context, now time.Time) error {
 			if err != nil {
 				errs.Record(err)
 			} else {
-				staleAgentsRemoved += removedAgents
+				sweep.agentsRemoved += len(removedAgents)
+				r.recordRemovals(ctx, AuditAgentExpired, relay.ID, removedAgents)
 			}
 
-			if err := r.RemoveRelay(ctx, relay.ID); err != nil {
+			if err := r.removeRelay(ctx, relay.ID); err != nil {
 				errs.Record(err)
 			} else {
-				staleRelaysRemoved++
+				sweep.relaysRemoved++
+				r.Audit(ctx, AuditEvent{Type: AuditRelayExpired, RelayID: relay.ID})
 			}
 			continue
 		}
 
-		relayAgents, err := r.backend.ListRelayAgents(ctx, relay.ID)
+		if isNearExpiry(now.Sub(relay.LastSeen), ttl.Relay) {
+			sweep.nearExpiry++
+		}
+
+		relayAgents, err := r.backend.ListRelayAgents(ctx, namespace, relay.ID)
 		if err != nil {
 			errs.Record(err)
 			continue
@@ -215,7 +600,7 @@ func (r *Registry) runTTLCleanup(ctx context.Context, now time.Time) error {
 
 		agentIDs := make([]string, 0, len(relayAgents))
 		for _, agent := range relayAgents {
-			if now.Sub(agent.LastHeartbeat) >= r.cfg.TTL.Agent {
+			if now.Sub(agent.LastHeartbeat) >= ttl.Agent {
 				agentIDs = append(agentIDs, agent.ID)
 			}
 		}
@@ -229,24 +614,31 @@ func (r *Registry) runTTLCleanup(ctx context.Context, now time.Time) error {
 		}
 
 		if len(agentIDs) > 0 {
-			if err := r.backend.RemoveAgents(ctx, agentIDs); err != nil {
+			if err := r.removeAgents(ctx, agentIDs); err != nil {
 				errs.Record(err)
 			} else {
-				staleAgentsRemoved += len(agentIDs)
+				sweep.agentsRemoved += len(agentIDs)
+				r.recordRemovals(ctx, AuditAgentExpired, relay.ID, agentIDs)
 			}
 		}
 	}
 
-	agents, err := r.backend.ListAgents(ctx)
+	agents, err := r.backend.ListAgents(ctx, namespace)
 	if err != nil {
 		errs.Record(err)
-		return errs.Err()
+		return
 	}
 
+	sweep.observedEntries += len(agents)
 	staleAgentIDs := make([]string, 0)
 	for _, agent := range agents {
-		if now.Sub(agent.LastHeartbeat) >= r.cfg.TTL.Agent {
+		age := now.Sub(agent.LastHeartbeat)
+		if age >= ttl.Agent {
 			staleAgentIDs = append(staleAgentIDs, agent.ID)
+			continue
+		}
+		if isNearExpiry(age, ttl.Agent) {
+			sweep.nearExpiry++
 		}
 	}
 
@@ -259,33 +651,22 @@ func (r *Registry) runTTLCleanup(ctx context.Context, now time.Time) error {
 	}
 
 	if len(staleAgentIDs) > 0 {
-		if err := r.backend.RemoveAgents(ctx, staleAgentIDs); err != nil {
+		if err := r.removeAgents(ctx, staleAgentIDs); err != nil {
 			errs.Record(err)
 		} else {
-			staleAgentsRemoved += len(staleAgentIDs)
+			sweep.agentsRemoved += len(staleAgentIDs)
+			r.recordRemovals(ctx, AuditAgentExpired, "", staleAgentIDs)
 		}
 	}
-
-	return errs.Err()
-}
-
-func (r *Registry) nextTTLCleanupInterval() time.Duration {
-	// TODO(registry-ttl): make sweep cadence configurable and support adaptive or
-	// backpressure-aware scheduling beyond static TTL + jitter timing.
-	ttl := min(r.cfg.TTL.Relay, r.cfg.TTL.Agent)
-	maxJitter := ttl / 10
-
-	if maxJitter <= 0 {
-		return ttl
-	}
-
-	return ttl + time.Duration(rand.Int64N(int64(maxJitter)+1))
 }
 
-func (r *Registry) removeRelayAgents(ctx context.Context, relayID string) (int, error) {
-	agents, err := r.backend.ListRelayAgents(ctx, relayID)
+// removeRelayAgents removes the agents still placed on relayID in the
+// namespace of ctx and returns their IDs.
+func (r *Registry) removeRelayAgents(ctx context.Context, relayID string) ([]string, error) {
+	namespace := NamespaceFromContext(ctx)
+	agents, err := r.backend.ListRelayAgents(ctx, namespace, relayID)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	agentIDs := []string{}
@@ -295,33 +676,47 @@ func (r *Registry) removeRelayAgents(ctx context.Context, relayID string) (int,
 	}
 
 	if len(agentIDs) == 0 {
-		return 0, nil
+		return nil, nil
 	}
 
 	agentIDs, err = r.filterAgentsStillPlacedOnRelay(ctx, relayID, agentIDs)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	if len(agentIDs) == 0 {
-		return 0, nil
+		return nil, nil
 	}
 
-	if err := r.backend.RemoveAgents(ctx, agentIDs); err != nil {
-		return 0, err
+	if err := r.removeAgents(ctx, agentIDs); err != nil {
+		return nil, err
+	}
+
+	return agentIDs, nil
+}
+
+// removeAgents removes agentIDs from the namespace of ctx and drops them
+// from the last-known placements, so degraded mode never serves an agent
+// the registry has already removed.
+func (r *Registry) removeAgents(ctx context.Context, agentIDs []string) error {
+	namespace := NamespaceFromContext(ctx)
+	if err := r.backend.RemoveAgents(ctx, namespace, agentIDs); err != nil {
+		return err
 	}
 
-	return len(agentIDs), nil
+	r.forgetPlacements(namespace, agentIDs...)
+
+	return nil
 }
 
 func (r *Registry) isRelayStillStale(ctx context.Context, relayID string, now time.Time) (bool, error) {
-	relays, err := r.backend.ListRelays(ctx)
+	relays, err := r.backend.ListRelays(ctx, NamespaceFromContext(ctx))
 	if err != nil {
 		return false, err
 	}
 
 	for _, relay := range relays {
 		if relay.ID == relayID {
-			return now.Sub(relay.LastSeen) >= r.cfg.TTL.Relay, nil
+			return now.Sub(relay.LastSeen) >= r.namespaceTTL(ctx).Relay, nil
 		}
 	}
 
@@ -333,7 +728,7 @@ func (r *Registry) filterStillStaleAgents(ctx context.Context, candidateIDs []st
 		return nil, nil
 	}
 
-	agents, err := r.backend.ListAgents(ctx)
+	agents, err := r.backend.ListAgents(ctx, NamespaceFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -348,7 +743,7 @@ func (r *Registry) filterStillStaleAgents(ctx context.Context, candidateIDs []st
 		if _, ok := candidates[agent.ID]; !ok {
 			continue
 		}
-		if now.Sub(agent.LastHeartbeat) >= r.cfg.TTL.Agent {
+		if now.Sub(agent.LastHeartbeat) >= r.namespaceTTL(ctx).Agent {
 			stale = append(stale, agent.ID)
 		}
 	}
@@ -367,7 +762,7 @@ func (r *Registry) filterAgentsStillPlacedOnRelay(ctx context.Context, relayID s
 	for _, agentID := range candidateIDs {
 		// TODO(registry-ttl): use batch placement lookups to avoid per-agent
 		// GetAgentPlacement roundtrips in large relay fan-outs.
-		placement, err := r.backend.GetAgentPlacement(ctx, agentID)
+		placement, err := r.backend.GetAgentPlacement(ctx, NamespaceFromContext(ctx), agentID)
 		if err != nil {
 			if errors.Is(err, ErrNotFound) {
 				continue
@@ -388,6 +783,27 @@ func errorsIsContextCancellation(err error) bool {
 	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
 }
 
+// config returns the currently active configuration snapshot.
+func (r *Registry) config() *Config {
+	r.cfgMu.RLock()
+	defer r.cfgMu.RUnlock()
+	return r.cfg
+}
+
+func (r *Registry) ttlConfig() TTLConfig {
+	return r.config().TTL
+}
+
+// namespaceTTL returns the TTLs of the namespace of ctx.
+func (r *Registry) namespaceTTL(ctx context.Context) TTLConfig {
+	return r.config().TenantTTL(NamespaceFromContext(ctx))
+}
+
+// Config returns a copy of the currently active configuration.
+func (r *Registry) Config() Config {
+	return *r.config()
+}
+
 func (r *Registry) Close(ctx context.Context) error {
 	return r.backend.Close(ctx)
 }
@@ -0,0 +1,196 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// restartRequiredKeys lists config key prefixes that cannot be changed on a
+// running registry. Changes under these keys are rejected on reload and the
+// current values are kept.
+var restartRequiredKeys = []string{
+	"backend",
+	"grpc.listen_address",
+	"grpc.listen_port",
+	"grpc.tls.enabled",
+	"grpc.tls.client_ca_path",
+	"ttl.coordination",
+	"admin.enabled",
+	"tracing",
+	"http.enabled",
+	"http.listen_address",
+	"http.listen_port",
+	"audit",
+}
+
+// ReloadResult describes the outcome of applying a new configuration
+// to a running registry.
+type ReloadResult struct {
+	// Applied lists the config keys whose new values took effect.
+	Applied []string
+
+	// Rejected lists the config keys that changed but require a restart.
+	// Their current values were kept.
+	Rejected []string
+}
+
+// ApplyConfig validates next and hot-applies the subset of changes that is
+// safe on a running registry (TTLs, sweep scheduling, log level, admin
+// token, TLS certificate paths, capacity limits, degraded mode, HTTP
+// gateway origins and events interval, placement history, tenants).
+// Changes that require a restart,
+// such as the backend or listen addresses, are logged and reported as
+// rejected.
+//
+// Components outside the registry (log handler, TLS credentials) read the
+// applied values back through Config.
+func (r *Registry) ApplyConfig(ctx context.Context, next *Config) (*ReloadResult, error) {
+	r.cfgMu.Lock()
+	current := r.cfg
+
+	merged, err := mergeReload(current, next)
+	if err != nil {
+		r.cfgMu.Unlock()
+		return nil, err
+	}
+
+	result := &ReloadResult{}
+	for _, key := range diffConfigKeys(current, next) {
+		if requiresRestart(key) {
+			result.Rejected = append(result.Rejected, key)
+			continue
+		}
+		result.Applied = append(result.Applied, key)
+	}
+
+	r.cfg = merged
+	r.cfgMu.Unlock()
+
+	if current.Limits != merged.Limits || !reflect.DeepEqual(current.TenantLimits(), merged.TenantLimits()) {
+		r.applyCapacityLimits(merged)
+	}
+
+	if current.PlacementHistory.Enabled && !merged.PlacementHistory.Enabled {
+		r.resetPlacementHistory()
+	}
+
+	if current.TTL != merged.TTL || !reflect.DeepEqual(current.Tenants, merged.Tenants) {
+		r.schedule.mu.Lock()
+		r.schedule.interval = 0
+		r.schedule.mu.Unlock()
+	}
+
+	for _, key := range result.Rejected {
+		slog.LogAttrs(ctx, slog.LevelWarn, "config change requires a restart; keeping current value",
+			slog.String("method", "ApplyConfig"),
+			slog.String("key", key),
+		)
+	}
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "configuration reloaded",
+		slog.String("method", "ApplyConfig"),
+		slog.String("applied", strings.Join(result.Applied, ",")),
+		slog.Int("rejected_count", len(result.Rejected)),
+	)
+
+	return result, nil
+}
+
+// ValidateReload reports whether ApplyConfig would accept next, without
+// applying anything. Callers that reload state outside the registry, such
+// as TLS certificates, check it first so a rejected reload changes nothing.
+func (r *Registry) ValidateReload(next *Config) error {
+	r.cfgMu.RLock()
+	defer r.cfgMu.RUnlock()
+
+	_, err := mergeReload(r.cfg, next)
+	return err
+}
+
+// mergeReload validates next and returns it with the restart-only values
+// of current kept.
+func mergeReload(current, next *Config) (*Config, error) {
+	if next == nil {
+		return nil, ErrNilConfig
+	}
+
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+
+	merged := *next
+	merged.Backend = current.Backend
+	merged.GRPC.ListenAddress = current.GRPC.ListenAddress
+	merged.GRPC.ListenPort = current.GRPC.ListenPort
+	merged.GRPC.TLS.Enabled = current.GRPC.TLS.Enabled
+	merged.GRPC.TLS.ClientCAPath = current.GRPC.TLS.ClientCAPath
+	merged.TTL.Coordination = current.TTL.Coordination
+	merged.Admin.Enabled = current.Admin.Enabled
+	merged.Tracing = current.Tracing
+	merged.HTTP.Enabled = current.HTTP.Enabled
+	merged.HTTP.ListenAddress = current.HTTP.ListenAddress
+	merged.HTTP.ListenPort = current.HTTP.ListenPort
+	merged.Audit = current.Audit
+
+	// Tenant identities are only safe to bind while client certificates are
+	// required, which a reload cannot turn on.
+	if err := merged.validateTenantIdentities(); err != nil {
+		return nil, fmt.Errorf("Tenants Config invalid: %w", err)
+	}
+
+	return &merged, nil
+}
+
+func requiresRestart(key string) bool {
+	for _, prefix := range restartRequiredKeys {
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// diffConfigKeys returns the dotted config keys (using yaml names) whose
+// values differ between a and b.
+func diffConfigKeys(a, b *Config) []string {
+	var keys []string
+	diffValues(reflect.ValueOf(*a), reflect.ValueOf(*b), "", &keys)
+	return keys
+}
+
+func diffValues(a, b reflect.Value, prefix string, keys *[]string) {
+	switch a.Kind() {
+	case reflect.Struct:
+		t := a.Type()
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name := field.Tag.Get("yaml")
+			if !field.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			diffValues(a.Field(i), b.Field(i), name, keys)
+		}
+	case reflect.Pointer:
+		if a.IsNil() && b.IsNil() {
+			return
+		}
+		if a.IsNil() || b.IsNil() || a.Elem().Kind() != reflect.Struct {
+			if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+				*keys = append(*keys, prefix)
+			}
+			return
+		}
+		diffValues(a.Elem(), b.Elem(), prefix, keys)
+	default:
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*keys = append(*keys, prefix)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestApplyConfig(t *testing.T) {
+	t.Parallel()
+
+	newConfig := func() *Config {
+		return &Config{
+			Backend: BackendConfig{Type: MemoryRegistryBackend},
+			GRPC:    GRPCConfig{ListenAddress: "127.0.0.1", ListenPort: 50051},
+			TTL:     TTLConfig{Relay: 30 * time.Second, Agent: 30 * time.Second},
+		}
+	}
+
+	t.Run("applies safe changes and rejects restart-only changes", func(t *testing.T) {
+		t.Parallel()
+
+		reg, err := New(newConfig(), newTTLCleanupBackend())
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		next := newConfig()
+		next.TTL.Relay = 45 * time.Second
+		next.Log.Level = "debug"
+		next.GRPC.ListenPort = 6000
+		next.Backend.Type = RedisRegistryBackend
+		next.Backend.Redis = &RedisConfig{Address: "localhost", Port: 6379}
+
+		result, err := reg.ApplyConfig(context.Background(), next)
+		if err != nil {
+			t.Fatalf("ApplyConfig() error = %v", err)
+		}
+
+		if !slices.Contains(result.Applied, "ttl.relay") || !slices.Contains(result.Applied, "log.level") {
+			t.Fatalf("expected ttl.relay and log.level to be applied, got %v", result.Applied)
+		}
+		if !slices.Contains(result.Rejected, "grpc.listen_port") || !slices.Contains(result.Rejected, "backend.type") {
+			t.Fatalf("expected listen port and backend type to be rejected, got %v", result.Rejected)
+		}
+
+		active := reg.Config()
+		if active.TTL.Relay != 45*time.Second || active.Log.Level != "debug" {
+			t.Fatalf("expected safe changes to be active, got ttl=%+v log=%+v", active.TTL, active.Log)
+		}
+		if active.GRPC.ListenPort != 50051 || active.Backend.Type != MemoryRegistryBackend || active.Backend.Redis != nil {
+			t.Fatalf("expected restart-only values to be kept, got grpc=%+v backend=%+v", active.GRPC, active.Backend)
+		}
+	})
+
+	t.Run("invalid config is rejected without changes", func(t *testing.T) {
+		t.Parallel()
+
+		reg, err := New(newConfig(), newTTLCleanupBackend())
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		next := newConfig()
+		next.TTL.Agent = 0
+
+		if _, err := reg.ApplyConfig(context.Background(), next); !errors.Is(err, ErrTTLAgentInvalid) {
+			t.Fatalf("expected ErrTTLAgentInvalid, got %v", err)
+		}
+		if got := reg.Config().TTL.Agent; got != 30*time.Second {
+			t.Fatalf("expected agent ttl to stay 30s, got %v", got)
+		}
+	})
+
+	t.Run("tenant identities need client certificates at startup", func(t *testing.T) {
+		t.Parallel()
+
+		reg, err := New(newConfig(), newTTLCleanupBackend())
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		next := newConfig()
+		next.GRPC.TLS = TLSConfig{Enabled: true, CertPath: "cert.pem", KeyPath: "key.pem", ClientCAPath: "ca.pem"}
+		next.Tenants = []TenantConfig{{Namespace: "fleet-a", Identities: []string{"cert:fleet-a"}}}
+
+		if err := reg.ValidateReload(next); !errors.Is(err, ErrTenantClientAuthRequired) {
+			t.Fatalf("expected ValidateReload to report ErrTenantClientAuthRequired, got %v", err)
+		}
+		if _, err := reg.ApplyConfig(context.Background(), next); !errors.Is(err, ErrTenantClientAuthRequired) {
+			t.Fatalf("expected ErrTenantClientAuthRequired, got %v", err)
+		}
+		if active := reg.Config(); len(active.Tenants) != 0 || active.GRPC.TLS.ClientCAPath != "" {
+			t.Fatalf("expected the running config to be kept, got tls=%+v tenants=%+v", active.GRPC.TLS, active.Tenants)
+		}
+	})
+
+	t.Run("http gateway keeps its listener", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := newConfig()
+		cfg.HTTP = HTTPConfig{Enabled: true, ListenAddress: "127.0.0.1", ListenPort: 8080}
+		reg, err := New(cfg, newTTLCleanupBackend())
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		next := newConfig()
+		next.HTTP = HTTPConfig{Enabled: true, ListenAddress: "127.0.0.1", ListenPort: 9090, AllowedOrigins: []string{"*"}}
+		result, err := reg.ApplyConfig(context.Background(), next)
+		if err != nil {
+			t.Fatalf("ApplyConfig() error = %v", err)
+		}
+
+		if !slices.Contains(result.Applied, "http.allowed_origins") || !slices.Contains(result.Rejected, "http.listen_port") {
+			t.Fatalf("expected origins applied and listen port rejected, got %+v", result)
+		}
+		if active := reg.Config().HTTP; active.ListenPort != 8080 || len(active.AllowedOrigins) != 1 {
+			t.Fatalf("expected new origins on the old port, got %+v", active)
+		}
+	})
+
+	t.Run("ttl change resets adaptive interval", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := newConfig()
+		cfg.TTL.AdaptiveSweep = true
+		reg, err := New(cfg, newTTLCleanupBackend())
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		reg.adaptSweepInterval(context.Background(), sweepObservation{failed: true})
+
+		next := newConfig()
+		next.TTL.AdaptiveSweep = true
+		next.TTL.SweepInterval = 10 * time.Second
+		if _, err := reg.ApplyConfig(context.Background(), next); err != nil {
+			t.Fatalf("ApplyConfig() error = %v", err)
+		}
+
+		if got := reg.SweepInterval(); got != 10*time.Second {
+			t.Fatalf("expected new base interval 10s, got %v", got)
+		}
+	})
+}
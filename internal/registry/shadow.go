@@ -0,0 +1,48 @@
+package registry
+
+// ShadowStats is a point-in-time snapshot of the shadow backend counters.
+type ShadowStats struct {
+	// MirroredWrites counts writes applied to the shadow.
+	MirroredWrites uint64
+
+	// MirrorErrors counts writes the primary accepted and the shadow
+	// rejected.
+	MirrorErrors uint64
+
+	// Comparisons counts reads repeated against the shadow.
+	Comparisons uint64
+
+	// CompareErrors counts comparisons abandoned because the shadow read
+	// failed.
+	CompareErrors uint64
+
+	// Dropped counts writes and comparisons skipped because the queue was
+	// full.
+	Dropped uint64
+
+	// RelayDivergences counts relays that differed between the backends.
+	RelayDivergences uint64
+
+	// AgentDivergences counts agents that differed between the backends.
+	AgentDivergences uint64
+
+	// PlacementDivergences counts placements that differed between the
+	// backends.
+	PlacementDivergences uint64
+}
+
+// ShadowStatsReporter is implemented by the shadow backend.
+type ShadowStatsReporter interface {
+	ShadowStats() ShadowStats
+}
+
+// ShadowStats returns a snapshot of the shadow backend counters. It
+// reports false when the backend is not shadowed.
+func (r *Registry) ShadowStats() (ShadowStats, bool) {
+	reporter, ok := FindBackend[ShadowStatsReporter](r.backend)
+	if !ok {
+		return ShadowStats{}, false
+	}
+
+	return reporter.ShadowStats(), true
+}
@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of spans created by the registry.
+const TracerName = "github.com/Aero-Arc/aero-arc-registry/internal/registry"
+
+// Option configures a Registry created by New.
+type Option func(*Registry)
+
+// WithTracerProvider sets the provider registry spans are created with.
+// The global OpenTelemetry provider is used when unset.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(r *Registry) {
+		r.tracerProvider = tp
+	}
+}
+
+func (r *Registry) tracer() trace.Tracer {
+	tp := r.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return tp.Tracer(TracerName)
+}
+
+// startSpan starts a span named "Registry.<name>" as a child of ctx,
+// tagged with the namespace ctx is scoped to, if any.
+func (r *Registry) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if namespace, ok := ctx.Value(namespaceKey{}).(string); ok {
+		attrs = append(attrs, attribute.String("registry.namespace", namespace))
+	}
+
+	return r.tracer().Start(ctx, "Registry."+name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err, if any, on span and ends it. It is shared with the
+// backend tracing decorator so registry and backend spans report errors
+// the same way.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
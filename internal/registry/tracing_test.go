@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRunTTLCleanupSpanRecordsRemovals(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	backend := newTTLCleanupBackend()
+	backend.relays["relay-stale"] = Relay{ID: "relay-stale", LastSeen: now.Add(-45 * time.Second)}
+	backend.agents["agent-stale"] = Agent{ID: "agent-stale", LastHeartbeat: now.Add(-40 * time.Second)}
+	backend.placements["agent-stale"] = "relay-gone"
+
+	reg := &Registry{
+		cfg: &Config{
+			TTL: TTLConfig{
+				Relay: 30 * time.Second,
+				Agent: 30 * time.Second,
+			},
+		},
+		backend:        backend,
+		tracerProvider: tp,
+	}
+
+	if err := reg.runTTLCleanup(context.Background(), now); err != nil {
+		t.Fatalf("runTTLCleanup returned error: %v", err)
+	}
+
+	var span *tracetest.SpanStub
+	spans := exporter.GetSpans()
+	for i := range spans {
+		if spans[i].Name == "Registry.runTTLCleanup" {
+			span = &spans[i]
+		}
+	}
+	if span == nil {
+		t.Fatalf("expected Registry.runTTLCleanup span, got %d spans", len(spans))
+	}
+	if span.Status.Code == codes.Error {
+		t.Fatalf("expected span without error status, got %v", span.Status)
+	}
+
+	want := map[attribute.Key]int64{
+		"ttl.stale_relays_removed": 1,
+		"ttl.stale_agents_removed": 1,
+		"ttl.errors":               0,
+	}
+	got := make(map[attribute.Key]int64)
+	for _, attr := range span.Attributes {
+		got[attr.Key] = attr.Value.AsInt64()
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Fatalf("expected %s=%d, got %d", key, value, got[key])
+		}
+	}
+}
+
+func TestEndSpanRecordsError(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reg := &Registry{tracerProvider: tp}
+
+	_, span := reg.startSpan(context.Background(), "RegisterRelay")
+	EndSpan(span, ErrNotFound)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Fatalf("expected error status, got %v", spans[0].Status)
+	}
+	if len(spans[0].Events) != 1 || spans[0].Events[0].Name != "exception" {
+		t.Fatalf("expected recorded error event, got %v", spans[0].Events)
+	}
+}
@@ -0,0 +1,158 @@
+package registry
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// adaptiveNearExpiryRatio is the fraction of observed entries that must be
+// near expiry before the adaptive scheduler shortens the interval.
+const adaptiveNearExpiryRatio = 0.1
+
+// nearExpiryWindowRatio is the final fraction of an entry's TTL in which it
+// counts as near expiry. It does not depend on the sweep interval, so the
+// adaptive scheduler's own adjustments do not feed back into it.
+const nearExpiryWindowRatio = 0.25
+
+// defaultSweepIntervalDivisor derives the base sweep interval from the
+// shortest TTL when none is configured. Sweeping several times per TTL keeps
+// expired entries from outliving their TTL by more than a fraction of it.
+const defaultSweepIntervalDivisor = 4
+
+// adaptiveSlowSweepRatio is the fraction of the current interval a sweep may
+// take before the adaptive scheduler considers it slow and backs off.
+const adaptiveSlowSweepRatio = 0.5
+
+type sweepSchedule struct {
+	mu       sync.Mutex
+	interval time.Duration
+}
+
+// sweepObservation summarises a completed cleanup pass for the
+// adaptive scheduler.
+type sweepObservation struct {
+	entries    int
+	nearExpiry int
+	duration   time.Duration
+	failed     bool
+}
+
+// SweepNow runs a TTL cleanup pass immediately on the calling goroutine,
+// independent of the background TTL loop schedule. It honours sweep
+// coordination, so only the replica holding the sweeper lease sweeps.
+func (r *Registry) SweepNow(ctx context.Context) error {
+	return r.runCoordinatedTTLCleanup(ctx, time.Now())
+}
+
+// SweepInterval returns the interval the scheduler will use for the next
+// TTL cleanup pass, before jitter is applied.
+func (r *Registry) SweepInterval() time.Duration {
+	base := r.baseSweepInterval()
+	if !r.ttlConfig().AdaptiveSweep {
+		return base
+	}
+
+	r.schedule.mu.Lock()
+	defer r.schedule.mu.Unlock()
+
+	if r.schedule.interval <= 0 {
+		return base
+	}
+
+	return r.schedule.interval
+}
+
+func (r *Registry) nextTTLCleanupInterval() time.Duration {
+	interval := r.SweepInterval()
+	maxJitter := interval / 10
+
+	if maxJitter <= 0 {
+		return interval
+	}
+
+	return interval + time.Duration(rand.Int64N(int64(maxJitter)+1))
+}
+
+func (r *Registry) baseSweepInterval() time.Duration {
+	cfg := r.config()
+	if cfg.TTL.SweepInterval > 0 {
+		return cfg.TTL.SweepInterval
+	}
+
+	shortest, _ := cfg.ttlBounds()
+	return max(shortest/defaultSweepIntervalDivisor, time.Millisecond)
+}
+
+func (r *Registry) sweepIntervalBounds(base time.Duration) (time.Duration, time.Duration) {
+	ttl := r.ttlConfig()
+	lower := ttl.MinSweepInterval
+	if lower <= 0 {
+		lower = base / 4
+	}
+
+	upper := ttl.MaxSweepInterval
+	if upper <= 0 {
+		upper = base * 4
+	}
+
+	return lower, max(lower, upper)
+}
+
+// adaptSweepInterval updates the adaptive interval after a cleanup pass.
+// Slow or failing passes back off to relieve the backend; passes that see
+// many entries about to expire tighten the interval so expired entries do
+// not linger; otherwise the interval drifts back towards the base value.
+func (r *Registry) adaptSweepInterval(ctx context.Context, obs sweepObservation) {
+	if !r.ttlConfig().AdaptiveSweep {
+		return
+	}
+
+	base := r.baseSweepInterval()
+	lower, upper := r.sweepIntervalBounds(base)
+
+	r.schedule.mu.Lock()
+	current := r.schedule.interval
+	if current <= 0 {
+		current = base
+	}
+
+	var next time.Duration
+	reason := "steady"
+	switch {
+	case obs.failed || obs.duration > time.Duration(float64(current)*adaptiveSlowSweepRatio):
+		next = min(current*2, upper)
+		reason = "backoff"
+	case obs.entries > 0 && float64(obs.nearExpiry) >= float64(obs.entries)*adaptiveNearExpiryRatio:
+		next = max(current/2, lower)
+		reason = "near_expiry"
+	case current < base:
+		next = min(current*2, base)
+	case current > base:
+		next = max(current/2, base)
+	default:
+		next = base
+	}
+
+	r.schedule.interval = next
+	r.schedule.mu.Unlock()
+
+	if next != current {
+		slog.LogAttrs(ctx, slog.LevelDebug, "ttl sweep interval adjusted",
+			slog.String("method", "adaptSweepInterval"),
+			slog.String("reason", reason),
+			slog.Duration("previous_interval", current),
+			slog.Duration("interval", next),
+			slog.Int("entries", obs.entries),
+			slog.Int("near_expiry", obs.nearExpiry),
+		)
+	}
+}
+
+// isNearExpiry reports whether an entry that is not yet stale has entered
+// the final nearExpiryWindowRatio of its TTL.
+func isNearExpiry(age, ttl time.Duration) bool {
+	return ttl-age <= time.Duration(float64(ttl)*nearExpiryWindowRatio)
+}
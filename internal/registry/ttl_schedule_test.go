@@ -0,0 +1,194 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNextTTLCleanupIntervalUsesConfiguredSweepInterval(t *testing.T) {
+	reg := &Registry{
+		cfg: &Config{
+			TTL: TTLConfig{
+				Relay:         30 * time.Second,
+				Agent:         30 * time.Second,
+				SweepInterval: 5 * time.Second,
+			},
+		},
+	}
+
+	for range 20 {
+		got := reg.nextTTLCleanupInterval()
+		if got < 5*time.Second || got > 5500*time.Millisecond {
+			t.Fatalf("interval %v outside [5s, 5.5s]", got)
+		}
+	}
+}
+
+func TestNextTTLCleanupIntervalDefaultsToQuarterOfShortestTTL(t *testing.T) {
+	reg := &Registry{
+		cfg: &Config{
+			TTL: TTLConfig{
+				Relay: 30 * time.Second,
+				Agent: 10 * time.Second,
+			},
+		},
+	}
+
+	if got := reg.SweepInterval(); got != 2500*time.Millisecond {
+		t.Fatalf("expected 2.5s base interval, got %v", got)
+	}
+}
+
+func TestIsNearExpiry(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want bool
+	}{
+		{age: 0, want: false},
+		{age: 20 * time.Second, want: false},
+		{age: 22500 * time.Millisecond, want: true},
+		{age: 29 * time.Second, want: true},
+	}
+
+	for _, test := range tests {
+		if got := isNearExpiry(test.age, 30*time.Second); got != test.want {
+			t.Fatalf("isNearExpiry(%v, 30s) = %v, want %v", test.age, got, test.want)
+		}
+	}
+}
+
+func TestAdaptSweepIntervalSteadyUnderFreshHeartbeats(t *testing.T) {
+	now := time.Now()
+
+	backend := newTTLCleanupBackend()
+	for _, id := range []string{"relay-1", "relay-2", "relay-3"} {
+		backend.relays[id] = Relay{ID: id, LastSeen: now.Add(-5 * time.Second)}
+	}
+
+	reg := &Registry{
+		cfg: &Config{
+			TTL: TTLConfig{
+				Relay:         30 * time.Second,
+				Agent:         30 * time.Second,
+				AdaptiveSweep: true,
+			},
+		},
+		backend: backend,
+	}
+
+	for range 3 {
+		if err := reg.SweepNow(context.Background()); err != nil {
+			t.Fatalf("SweepNow returned error: %v", err)
+		}
+		if got := reg.SweepInterval(); got != 7500*time.Millisecond {
+			t.Fatalf("expected the 7.5s base interval to hold, got %v", got)
+		}
+	}
+}
+
+func TestAdaptSweepInterval(t *testing.T) {
+	newAdaptiveRegistry := func() *Registry {
+		return &Registry{
+			cfg: &Config{
+				TTL: TTLConfig{
+					Relay:            30 * time.Second,
+					Agent:            30 * time.Second,
+					SweepInterval:    8 * time.Second,
+					AdaptiveSweep:    true,
+					MinSweepInterval: 2 * time.Second,
+					MaxSweepInterval: 20 * time.Second,
+				},
+			},
+		}
+	}
+
+	t.Run("near expiry shortens down to minimum", func(t *testing.T) {
+		reg := newAdaptiveRegistry()
+		obs := sweepObservation{entries: 10, nearExpiry: 5}
+
+		reg.adaptSweepInterval(context.Background(), obs)
+		if got := reg.SweepInterval(); got != 4*time.Second {
+			t.Fatalf("expected 4s after first shrink, got %v", got)
+		}
+
+		reg.adaptSweepInterval(context.Background(), obs)
+		reg.adaptSweepInterval(context.Background(), obs)
+		if got := reg.SweepInterval(); got != 2*time.Second {
+			t.Fatalf("expected interval clamped to 2s, got %v", got)
+		}
+	})
+
+	t.Run("failures back off up to maximum", func(t *testing.T) {
+		reg := newAdaptiveRegistry()
+		obs := sweepObservation{failed: true}
+
+		reg.adaptSweepInterval(context.Background(), obs)
+		if got := reg.SweepInterval(); got != 16*time.Second {
+			t.Fatalf("expected 16s after backoff, got %v", got)
+		}
+
+		reg.adaptSweepInterval(context.Background(), obs)
+		if got := reg.SweepInterval(); got != 20*time.Second {
+			t.Fatalf("expected interval clamped to 20s, got %v", got)
+		}
+	})
+
+	t.Run("slow sweeps back off", func(t *testing.T) {
+		reg := newAdaptiveRegistry()
+
+		reg.adaptSweepInterval(context.Background(), sweepObservation{duration: 6 * time.Second})
+		if got := reg.SweepInterval(); got != 16*time.Second {
+			t.Fatalf("expected 16s after slow sweep, got %v", got)
+		}
+	})
+
+	t.Run("healthy sweeps return to base", func(t *testing.T) {
+		reg := newAdaptiveRegistry()
+		reg.adaptSweepInterval(context.Background(), sweepObservation{failed: true})
+		reg.adaptSweepInterval(context.Background(), sweepObservation{entries: 10})
+		if got := reg.SweepInterval(); got != 8*time.Second {
+			t.Fatalf("expected return to 8s base, got %v", got)
+		}
+	})
+
+	t.Run("disabled adaptive sweep keeps base", func(t *testing.T) {
+		reg := newAdaptiveRegistry()
+		reg.cfg.TTL.AdaptiveSweep = false
+		reg.adaptSweepInterval(context.Background(), sweepObservation{failed: true})
+		if got := reg.SweepInterval(); got != 8*time.Second {
+			t.Fatalf("expected 8s base, got %v", got)
+		}
+	})
+}
+
+func TestRunTTLCleanupFeedsAdaptiveScheduler(t *testing.T) {
+	now := time.Now()
+
+	backend := newTTLCleanupBackend()
+	backend.relays["relay-1"] = Relay{ID: "relay-1", LastSeen: now}
+	backend.agents["agent-near"] = Agent{ID: "agent-near", LastHeartbeat: now.Add(-25 * time.Second)}
+
+	reg := &Registry{
+		cfg: &Config{
+			TTL: TTLConfig{
+				Relay:         30 * time.Second,
+				Agent:         30 * time.Second,
+				SweepInterval: 10 * time.Second,
+				AdaptiveSweep: true,
+			},
+		},
+		backend: backend,
+	}
+
+	if err := reg.SweepNow(context.Background()); err != nil {
+		t.Fatalf("SweepNow returned error: %v", err)
+	}
+
+	if got := reg.SweepInterval(); got != 5*time.Second {
+		t.Fatalf("expected interval to shrink to 5s, got %v", got)
+	}
+	if stats := reg.TTLStats(); stats.Runs != 1 {
+		t.Fatalf("expected one recorded run, got %+v", stats)
+	}
+}
@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// recentSweepsKept is how many completed cleanup passes RecentSweeps
+// returns.
+const recentSweepsKept = 20
+
+// TTLStats is a point-in-time snapshot of the TTL sweeper counters
+// for this registry replica.
+type TTLStats struct {
+	// ReplicaID identifies this replica when sweep coordination is enabled.
+	ReplicaID string
+
+	// Leader reports whether this replica currently holds the sweeper lease.
+	// It is always true when sweep coordination is disabled.
+	Leader bool
+
+	// LeaseToken is the fencing token of the held sweeper lease, if any.
+	LeaseToken uint64
+
+	// LeaderChanges counts sweeper lease acquisitions and losses.
+	LeaderChanges uint64
+
+	// Runs counts completed cleanup passes.
+	Runs uint64
+
+	// SkippedRuns counts passes skipped because another pass was still in
+	// progress or another replica holds the sweeper lease.
+	SkippedRuns uint64
+
+	// Errors counts cleanup passes that finished with at least one error.
+	Errors uint64
+
+	// RelaysRemoved counts relays removed for exceeding the relay TTL.
+	RelaysRemoved uint64
+
+	// AgentsRemoved counts agents removed for exceeding the agent TTL
+	// or belonging to an expired relay.
+	AgentsRemoved uint64
+
+	// LastRunAt is when the most recent completed cleanup pass started.
+	LastRunAt time.Time
+
+	// LastDuration is how long the most recent completed cleanup pass took.
+	LastDuration time.Duration
+}
+
+// SweepResult describes one completed TTL cleanup pass.
+type SweepResult struct {
+	// StartedAt is when the pass started.
+	StartedAt time.Time
+
+	// Duration is how long the pass took.
+	Duration time.Duration
+
+	// RelaysRemoved is the number of relays the pass removed.
+	RelaysRemoved int
+
+	// AgentsRemoved is the number of agents the pass removed.
+	AgentsRemoved int
+
+	// Errors is the number of errors the pass ran into.
+	Errors int
+}
+
+type ttlStats struct {
+	leaderChanges  atomic.Uint64
+	runs           atomic.Uint64
+	skippedRuns    atomic.Uint64
+	errors         atomic.Uint64
+	relaysRemoved  atomic.Uint64
+	agentsRemoved  atomic.Uint64
+	lastRunAtNanos atomic.Int64
+	lastDuration   atomic.Int64
+
+	recentMu sync.Mutex
+	recent   []SweepResult
+}
+
+func (s *ttlStats) recordRun(result SweepResult) {
+	s.runs.Add(1)
+	s.relaysRemoved.Add(uint64(result.RelaysRemoved))
+	s.agentsRemoved.Add(uint64(result.AgentsRemoved))
+	if result.Errors > 0 {
+		s.errors.Add(1)
+	}
+	s.lastRunAtNanos.Store(result.StartedAt.UnixNano())
+	s.lastDuration.Store(int64(result.Duration))
+
+	s.recentMu.Lock()
+	s.recent = append(s.recent, result)
+	if len(s.recent) > recentSweepsKept {
+		s.recent = s.recent[len(s.recent)-recentSweepsKept:]
+	}
+	s.recentMu.Unlock()
+}
+
+// TTLStats returns a snapshot of the TTL sweeper counters.
+func (r *Registry) TTLStats() TTLStats {
+	leader, token := r.isSweeperLeader()
+
+	stats := TTLStats{
+		ReplicaID:     r.ttlConfig().Coordination.ReplicaID,
+		Leader:        leader,
+		LeaseToken:    token,
+		LeaderChanges: r.ttlStats.leaderChanges.Load(),
+		Runs:          r.ttlStats.runs.Load(),
+		SkippedRuns:   r.ttlStats.skippedRuns.Load(),
+		Errors:        r.ttlStats.errors.Load(),
+		RelaysRemoved: r.ttlStats.relaysRemoved.Load(),
+		AgentsRemoved: r.ttlStats.agentsRemoved.Load(),
+		LastDuration:  time.Duration(r.ttlStats.lastDuration.Load()),
+	}
+
+	if nanos := r.ttlStats.lastRunAtNanos.Load(); nanos != 0 {
+		stats.LastRunAt = time.Unix(0, nanos)
+	}
+
+	return stats
+}
+
+// RecentSweeps returns the most recent completed cleanup passes on this
+// replica, newest first.
+func (r *Registry) RecentSweeps() []SweepResult {
+	r.ttlStats.recentMu.Lock()
+	defer r.ttlStats.recentMu.Unlock()
+
+	sweeps := make([]SweepResult, len(r.ttlStats.recent))
+	for i, result := range r.ttlStats.recent {
+		sweeps[len(sweeps)-1-i] = result
+	}
+
+	return sweeps
+}
@@ -67,6 +67,43 @@ func TestRunTTLCleanupRelayFirstThenLeftoverAgents(t *testing.T) {
 	}
 }
 
+func TestRecentSweeps(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	backend := newTTLCleanupBackend()
+	backend.relays["relay-stale"] = Relay{ID: "relay-stale", LastSeen: now.Add(-45 * time.Second)}
+
+	reg := &Registry{
+		cfg: &Config{
+			TTL: TTLConfig{
+				Relay: 30 * time.Second,
+				Agent: 30 * time.Second,
+			},
+		},
+		backend: backend,
+	}
+
+	for range recentSweepsKept + 1 {
+		if err := reg.runTTLCleanup(context.Background(), now); err != nil {
+			t.Fatalf("runTTLCleanup returned error: %v", err)
+		}
+	}
+
+	sweeps := reg.RecentSweeps()
+	if len(sweeps) != recentSweepsKept {
+		t.Fatalf("expected %d recent sweeps, got %d", recentSweepsKept, len(sweeps))
+	}
+	if oldest := sweeps[len(sweeps)-1]; oldest.RelaysRemoved != 0 {
+		t.Fatalf("expected the first sweep to be dropped, got %+v", oldest)
+	}
+	if sweeps[0].StartedAt.Before(sweeps[len(sweeps)-1].StartedAt) {
+		t.Fatalf("expected newest sweep first, got %+v", sweeps)
+	}
+	if stats := reg.TTLStats(); stats.Runs != recentSweepsKept+1 || stats.RelaysRemoved != 1 {
+		t.Fatalf("expected counters to cover every sweep, got %+v", stats)
+	}
+}
+
 func TestRunTTLCleanupSkipsWhenCleanupAlreadyInProgress(t *testing.T) {
 	backend := newTTLCleanupBackend()
 	reg := &Registry{
@@ -163,14 +200,97 @@ func TestRunTTLCleanupDoesNotRemoveAgentMovedToAnotherRelay(t *testing.T) {
 	}
 }
 
+func TestStopTTLWaitsForInProgressCleanup(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	backend := newTTLCleanupBackend()
+	backend.onListRelays = func(b *ttlCleanupBackend, callNum int) {
+		if callNum == 1 {
+			close(started)
+			<-release
+		}
+	}
+
+	reg := &Registry{
+		cfg: &Config{
+			TTL: TTLConfig{
+				Relay:         30 * time.Second,
+				Agent:         30 * time.Second,
+				SweepInterval: time.Millisecond,
+			},
+		},
+		backend: backend,
+	}
+
+	reg.RunTTL(context.Background())
+	<-started
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- reg.StopTTL(context.Background())
+	}()
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("expected StopTTL to wait for the running cleanup, returned %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("StopTTL returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected StopTTL to return after cleanup finished")
+	}
+
+	if got := reg.TTLStats().Runs; got != 1 {
+		t.Fatalf("expected in-progress cleanup to complete, got %d runs", got)
+	}
+}
+
+func TestStopTTLWithoutRunningLoop(t *testing.T) {
+	reg := &Registry{
+		cfg: &Config{
+			TTL: TTLConfig{
+				Relay: 30 * time.Second,
+				Agent: 30 * time.Second,
+			},
+		},
+		backend: newTTLCleanupBackend(),
+	}
+
+	if err := reg.StopTTL(context.Background()); err != nil {
+		t.Fatalf("expected StopTTL to be a no-op, got %v", err)
+	}
+
+	reg.RunTTL(context.Background())
+	if err := reg.StopTTL(context.Background()); err != nil {
+		t.Fatalf("StopTTL returned error: %v", err)
+	}
+
+	reg.RunTTL(context.Background())
+	if err := reg.StopTTL(context.Background()); err != nil {
+		t.Fatalf("expected TTL loop to restart after stop, got %v", err)
+	}
+}
+
 type ttlCleanupBackend struct {
 	mu          sync.Mutex
 	relays      map[string]Relay
 	agents      map[string]Agent
 	placements  map[string]string
 	relayAgents map[string]map[string]struct{}
+	leases      map[string]Lease
+	leaseToken  uint64
 	callLog     []string
 
+	namespaces []string
+
 	listRelaysCalls int
 	listAgentsCalls int
 	onListRelays    func(b *ttlCleanupBackend, callNum int)
@@ -183,18 +303,19 @@ func newTTLCleanupBackend() *ttlCleanupBackend {
 		agents:      make(map[string]Agent),
 		placements:  make(map[string]string),
 		relayAgents: make(map[string]map[string]struct{}),
+		leases:      make(map[string]Lease),
 	}
 }
 
-func (b *ttlCleanupBackend) RegisterRelay(ctx context.Context, relay Relay) error {
+func (b *ttlCleanupBackend) RegisterRelay(ctx context.Context, namespace string, relay Relay) error {
 	return nil
 }
 
-func (b *ttlCleanupBackend) HeartbeatRelay(ctx context.Context, relayID string) error {
+func (b *ttlCleanupBackend) HeartbeatRelay(ctx context.Context, namespace, relayID string) error {
 	return nil
 }
 
-func (b *ttlCleanupBackend) ListRelays(ctx context.Context) ([]Relay, error) {
+func (b *ttlCleanupBackend) ListRelays(ctx context.Context, namespace string) ([]Relay, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -218,15 +339,15 @@ func (b *ttlCleanupBackend) ListRelays(ctx context.Context) ([]Relay, error) {
 	return out, nil
 }
 
-func (b *ttlCleanupBackend) RegisterAgent(ctx context.Context, agent Agent, relayID string) error {
+func (b *ttlCleanupBackend) RegisterAgent(ctx context.Context, namespace string, agent Agent, relayID string) error {
 	return nil
 }
 
-func (b *ttlCleanupBackend) HeartbeatAgent(ctx context.Context, agentID string) error {
+func (b *ttlCleanupBackend) HeartbeatAgent(ctx context.Context, namespace, agentID string) error {
 	return nil
 }
 
-func (b *ttlCleanupBackend) GetAgentPlacement(ctx context.Context, agentID string) (*AgentPlacement, error) {
+func (b *ttlCleanupBackend) GetAgentPlacement(ctx context.Context, namespace, agentID string) (*AgentPlacement, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -242,7 +363,7 @@ func (b *ttlCleanupBackend) GetAgentPlacement(ctx context.Context, agentID strin
 	}, nil
 }
 
-func (b *ttlCleanupBackend) ListAgents(ctx context.Context) ([]Agent, error) {
+func (b *ttlCleanupBackend) ListAgents(ctx context.Context, namespace string) ([]Agent, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -266,7 +387,7 @@ func (b *ttlCleanupBackend) ListAgents(ctx context.Context) ([]Agent, error) {
 	return out, nil
 }
 
-func (b *ttlCleanupBackend) ListRelayAgents(ctx context.Context, relayID string) ([]*Agent, error) {
+func (b *ttlCleanupBackend) ListRelayAgents(ctx context.Context, namespace, relayID string) ([]*Agent, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.callLog = append(b.callLog, "ListRelayAgents:"+relayID)
@@ -287,7 +408,7 @@ func (b *ttlCleanupBackend) ListRelayAgents(ctx context.Context, relayID string)
 	return out, nil
 }
 
-func (b *ttlCleanupBackend) RemoveAgents(ctx context.Context, agentIDs []string) error {
+func (b *ttlCleanupBackend) RemoveAgents(ctx context.Context, namespace string, agentIDs []string) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -309,7 +430,7 @@ func (b *ttlCleanupBackend) RemoveAgents(ctx context.Context, agentIDs []string)
 	return nil
 }
 
-func (b *ttlCleanupBackend) RemoveRelay(ctx context.Context, relayID string) error {
+func (b *ttlCleanupBackend) RemoveRelay(ctx context.Context, namespace, relayID string) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.callLog = append(b.callLog, "RemoveRelay:"+relayID)
@@ -318,6 +439,54 @@ func (b *ttlCleanupBackend) RemoveRelay(ctx context.Context, relayID string) err
 	return nil
 }
 
+// ListNamespaces reports the stubbed namespaces. The stub serves the same
+// relays and agents in each of them.
+func (b *ttlCleanupBackend) ListNamespaces(ctx context.Context) ([]string, error) {
+	if b.namespaces != nil {
+		return b.namespaces, nil
+	}
+	return []string{DefaultNamespace}, nil
+}
+
+func (b *ttlCleanupBackend) AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (*Lease, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if current, ok := b.leases[name]; ok && now.Before(current.ExpiresAt) && current.HolderID != holderID {
+		return nil, ErrConflict
+	}
+
+	b.leaseToken++
+	lease := Lease{Name: name, HolderID: holderID, Token: b.leaseToken, ExpiresAt: now.Add(ttl)}
+	b.leases[name] = lease
+	return &lease, nil
+}
+
+func (b *ttlCleanupBackend) RenewLease(ctx context.Context, lease Lease, ttl time.Duration) (*Lease, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current, ok := b.leases[lease.Name]
+	if !ok || current.Token != lease.Token {
+		return nil, ErrConflict
+	}
+
+	current.ExpiresAt = time.Now().Add(ttl)
+	b.leases[lease.Name] = current
+	return &current, nil
+}
+
+func (b *ttlCleanupBackend) ReleaseLease(ctx context.Context, lease Lease) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if current, ok := b.leases[lease.Name]; ok && current.Token == lease.Token {
+		delete(b.leases, lease.Name)
+	}
+	return nil
+}
+
 func (b *ttlCleanupBackend) Close(ctx context.Context) error {
 	return nil
 }
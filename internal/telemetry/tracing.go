@@ -0,0 +1,73 @@
+// Package telemetry configures OpenTelemetry for the registry process.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// SetupTracing installs a global tracer provider exporting to the OTLP/gRPC
+// collector in cfg, along with W3C trace context propagation. It returns a
+// function that flushes and stops the exporter. When tracing is disabled
+// the global no-op provider is left in place.
+func SetupTracing(ctx context.Context, cfg registry.TracingConfig) (func(context.Context) error, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create otlp trace exporter: %w", err)
+	}
+
+	tp := newTracerProvider(cfg, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return tp.Shutdown, nil
+}
+
+// NewInMemoryTracerProvider returns a provider that samples every trace and
+// records finished spans synchronously in the returned exporter. It is
+// meant for tests.
+func NewInMemoryTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := newTracerProvider(registry.TracingConfig{}, sdktrace.WithSyncer(exporter))
+
+	return tp, exporter
+}
+
+func newTracerProvider(cfg registry.TracingConfig, exporter sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = registry.DefaultTracingServiceName
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+
+	return sdktrace.NewTracerProvider(
+		exporter,
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+}
@@ -0,0 +1,205 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// keepAliveInterval is how often an idle change stream sends a comment so
+// proxies do not close it.
+const keepAliveInterval = 15 * time.Second
+
+// Change stream event types.
+const (
+	EventRelayRegistered = "relay_registered"
+	EventRelayRemoved    = "relay_removed"
+	EventAgentPlaced     = "agent_placed"
+	EventAgentRemoved    = "agent_removed"
+)
+
+type event struct {
+	kind string
+	data any
+}
+
+type relayRemovedJSON struct {
+	RelayID string `json:"relay_id"`
+}
+
+type agentPlacedJSON struct {
+	AgentID string `json:"agent_id"`
+	RelayID string `json:"relay_id"`
+}
+
+type agentRemovedJSON struct {
+	AgentID string `json:"agent_id"`
+}
+
+// fleet is a point-in-time view of relays and agent placements.
+type fleet struct {
+	relays     map[string]registry.Relay
+	placements map[string]string
+}
+
+// events streams registry changes as Server-Sent Events. No backend pushes
+// changes, so each stream polls the registry every events interval and
+// sends the difference; the first poll is sent in full so a dashboard can
+// build its view from the stream alone. Heartbeats that change nothing but
+// a timestamp are not reported.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+
+	current, err := s.readFleet(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeEvents(w, rc, diffFleet(&fleet{}, current)); err != nil {
+		return
+	}
+
+	interval := s.registry.Config().HTTP.EventsInterval
+	if interval <= 0 {
+		interval = registry.DefaultHTTPEventsInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastWrite := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		next, err := s.readFleet(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			slog.LogAttrs(ctx, slog.LevelWarn, "change stream poll failed",
+				slog.String("method", "Events"),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		events := diffFleet(current, next)
+		current = next
+
+		switch {
+		case len(events) > 0:
+			err = writeEvents(w, rc, events)
+		case time.Since(lastWrite) >= keepAliveInterval:
+			_, err = fmt.Fprint(w, ": keepalive\n\n")
+			if err == nil {
+				err = rc.Flush()
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return
+		}
+		lastWrite = time.Now()
+	}
+}
+
+func (s *Server) readFleet(ctx context.Context) (*fleet, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	relays, err := s.registry.ListRelays(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &fleet{
+		relays:     make(map[string]registry.Relay, len(relays)),
+		placements: make(map[string]string),
+	}
+	for _, relay := range relays {
+		f.relays[relay.ID] = relay
+
+		agents, err := s.registry.ListRelayAgents(ctx, relay.ID)
+		if errors.Is(err, registry.ErrNotFound) {
+			// Removed since it was listed; the next poll reports it.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, agent := range agents {
+			f.placements[agent.ID] = relay.ID
+		}
+	}
+
+	return f, nil
+}
+
+// diffFleet returns the events that turn prev into next: relays first so
+// agents are placed on known relays, then removals in the reverse order.
+// Events of one kind are sorted by ID.
+func diffFleet(prev, next *fleet) []event {
+	var events []event
+
+	for _, id := range slices.Sorted(maps.Keys(next.relays)) {
+		relay := next.relays[id]
+		old, ok := prev.relays[id]
+		if !ok || old.Address != relay.Address || old.GRPCPort != relay.GRPCPort {
+			events = append(events, event{kind: EventRelayRegistered, data: toRelayJSON(relay)})
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(next.placements)) {
+		relayID := next.placements[id]
+		if old, ok := prev.placements[id]; !ok || old != relayID {
+			events = append(events, event{kind: EventAgentPlaced, data: agentPlacedJSON{AgentID: id, RelayID: relayID}})
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(prev.placements)) {
+		if _, ok := next.placements[id]; !ok {
+			events = append(events, event{kind: EventAgentRemoved, data: agentRemovedJSON{AgentID: id}})
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(prev.relays)) {
+		if _, ok := next.relays[id]; !ok {
+			events = append(events, event{kind: EventRelayRemoved, data: relayRemovedJSON{RelayID: id}})
+		}
+	}
+
+	return events
+}
+
+func writeEvents(w http.ResponseWriter, rc *http.ResponseController, events []event) error {
+	for _, e := range events {
+		data, err := json.Marshal(e.data)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.kind, data); err != nil {
+			return err
+		}
+	}
+
+	return rc.Flush()
+}
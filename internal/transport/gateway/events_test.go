@@ -0,0 +1,119 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// readEvent returns the next event type and data line from an event
+// stream, skipping comments.
+func readEvent(t *testing.T, scanner *bufio.Scanner) (string, string) {
+	t.Helper()
+
+	var kind, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			kind = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && kind != "":
+			return kind, data
+		}
+	}
+
+	t.Fatalf("expected an event, stream ended: %v", scanner.Err())
+	return "", ""
+}
+
+func TestEventsStream(t *testing.T) {
+	t.Parallel()
+	s, reg := newTestGateway(t, registry.HTTPConfig{EventsInterval: 10 * time.Millisecond})
+
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1/events", nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %q", got)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+
+	want := []struct{ kind, id string }{
+		{EventRelayRegistered, `"relay_id":"relay-1"`},
+		{EventRelayRegistered, `"relay_id":"relay-2"`},
+		{EventAgentPlaced, `"agent_id":"agent-1"`},
+		{EventAgentPlaced, `"agent_id":"agent-2"`},
+		{EventAgentPlaced, `"agent_id":"agent-3"`},
+	}
+	for _, w := range want {
+		if kind, data := readEvent(t, scanner); kind != w.kind || !strings.Contains(data, w.id) {
+			t.Fatalf("expected initial %s event for %s, got %s %s", w.kind, w.id, kind, data)
+		}
+	}
+
+	if err := reg.RegisterAgent(ctx, registry.Agent{ID: "agent-4"}, "relay-2"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if kind, data := readEvent(t, scanner); kind != EventAgentPlaced || data != `{"agent_id":"agent-4","relay_id":"relay-2"}` {
+		t.Fatalf("expected agent-4 to be placed, got %s %s", kind, data)
+	}
+
+	if _, err := reg.DrainRelay(ctx, "relay-1"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	want = []struct{ kind, id string }{
+		{EventAgentRemoved, `"agent_id":"agent-1"`},
+		{EventAgentRemoved, `"agent_id":"agent-2"`},
+		{EventRelayRemoved, `"relay_id":"relay-1"`},
+	}
+	for _, w := range want {
+		if kind, data := readEvent(t, scanner); kind != w.kind || !strings.Contains(data, w.id) {
+			t.Fatalf("expected %s event for %s, got %s %s", w.kind, w.id, kind, data)
+		}
+	}
+}
+
+func TestDiffFleetIgnoresHeartbeats(t *testing.T) {
+	t.Parallel()
+
+	prev := &fleet{
+		relays:     map[string]registry.Relay{"relay-1": {ID: "relay-1", Address: "10.0.0.1", LastSeen: time.Unix(1, 0)}},
+		placements: map[string]string{"agent-1": "relay-1"},
+	}
+	next := &fleet{
+		relays:     map[string]registry.Relay{"relay-1": {ID: "relay-1", Address: "10.0.0.1", LastSeen: time.Unix(2, 0)}},
+		placements: map[string]string{"agent-1": "relay-1"},
+	}
+
+	if events := diffFleet(prev, next); len(events) != 0 {
+		t.Fatalf("expected no events for a heartbeat, got %+v", events)
+	}
+
+	next.placements["agent-1"] = "relay-2"
+	events := diffFleet(prev, next)
+	if len(events) != 1 || events[0].kind != EventAgentPlaced {
+		t.Fatalf("expected a moved agent to be placed again, got %+v", events)
+	}
+}
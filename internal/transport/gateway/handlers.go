@@ -0,0 +1,213 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// requestTimeout bounds the registry calls made for one JSON request,
+// matching the gRPC transport's default deadline.
+const requestTimeout = 30 * time.Second
+
+// JSON field names follow the gRPC messages in snake case so dashboards
+// can share types between the two APIs.
+
+type relayJSON struct {
+	RelayID             string `json:"relay_id"`
+	Address             string `json:"address"`
+	GRPCPort            int32  `json:"grpc_port"`
+	LastHeartbeatUnixMs int64  `json:"last_heartbeat_unix_ms"`
+}
+
+type agentJSON struct {
+	AgentID             string `json:"agent_id"`
+	LastHeartbeatUnixMs int64  `json:"last_heartbeat_unix_ms"`
+	agentMetadataJSON
+}
+
+type placementJSON struct {
+	AgentID           string `json:"agent_id"`
+	RelayID           string `json:"relay_id"`
+	LastUpdatedUnixMs int64  `json:"last_updated_unix_ms"`
+	agentMetadataJSON
+}
+
+// agentMetadataJSON is the metadata an agent reported when it registered.
+// The gRPC messages have no fields for it, so its names follow the gRPC
+// metadata keys instead. Unreported fields are omitted.
+type agentMetadataJSON struct {
+	Kind               string   `json:"kind,omitempty"`
+	Version            string   `json:"version,omitempty"`
+	Capabilities       []string `json:"capabilities,omitempty"`
+	SessionStartUnixMs int64    `json:"session_start_unix_ms,omitempty"`
+}
+
+type errorJSON struct {
+	Error string `json:"error"`
+	Code  int    `json:"code"`
+}
+
+func (s *Server) listRelays(w http.ResponseWriter, r *http.Request) {
+	ctx, read, cancel := requestContext(r)
+	defer cancel()
+
+	relays, err := s.registry.ListRelays(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	out := make([]relayJSON, len(relays))
+	for i, relay := range relays {
+		out[i] = toRelayJSON(relay)
+	}
+
+	writeJSON(w, read, map[string]any{"relays": out})
+}
+
+func (s *Server) listAgents(w http.ResponseWriter, r *http.Request) {
+	ctx, read, cancel := requestContext(r)
+	defer cancel()
+
+	agents, err := s.registry.ListAgents(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	out := make([]agentJSON, len(agents))
+	for i, agent := range agents {
+		out[i] = toAgentJSON(agent)
+	}
+
+	writeJSON(w, read, map[string]any{"agents": out})
+}
+
+func (s *Server) listRelayAgents(w http.ResponseWriter, r *http.Request) {
+	ctx, read, cancel := requestContext(r)
+	defer cancel()
+
+	relayID := r.PathValue("relay_id")
+	agents, err := s.registry.ListRelayAgents(ctx, relayID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	out := make([]agentJSON, len(agents))
+	for i, agent := range agents {
+		out[i] = toAgentJSON(*agent)
+	}
+
+	writeJSON(w, read, map[string]any{"relay_id": relayID, "agents": out})
+}
+
+func (s *Server) getAgentPlacement(w http.ResponseWriter, r *http.Request) {
+	ctx, read, cancel := requestContext(r)
+	defer cancel()
+
+	placement, err := s.registry.GetAgentPlacement(ctx, r.PathValue("agent_id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, read, map[string]any{"placement": placementJSON{
+		AgentID:           placement.AgentID,
+		RelayID:           placement.RelayID,
+		LastUpdatedUnixMs: placement.UpdatedAt.UnixMilli(),
+		agentMetadataJSON: toAgentMetadataJSON(placement.Metadata),
+	}})
+}
+
+// requestContext bounds a JSON request and records whether it was answered
+// from last-known state.
+func requestContext(r *http.Request) (context.Context, *registry.StaleRead, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+	ctx, read := registry.WithStaleRead(ctx)
+	return ctx, read, cancel
+}
+
+func toRelayJSON(relay registry.Relay) relayJSON {
+	return relayJSON{
+		RelayID:             relay.ID,
+		Address:             relay.Address,
+		GRPCPort:            relay.GRPCPort,
+		LastHeartbeatUnixMs: relay.LastSeen.UnixMilli(),
+	}
+}
+
+func toAgentJSON(agent registry.Agent) agentJSON {
+	return agentJSON{
+		AgentID:             agent.ID,
+		LastHeartbeatUnixMs: agent.LastHeartbeat.UnixMilli(),
+		agentMetadataJSON:   toAgentMetadataJSON(agent.Metadata),
+	}
+}
+
+func toAgentMetadataJSON(metadata registry.AgentMetadata) agentMetadataJSON {
+	out := agentMetadataJSON{
+		Kind:         string(metadata.Kind),
+		Version:      metadata.Version,
+		Capabilities: metadata.Capabilities,
+	}
+	if !metadata.SessionStart.IsZero() {
+		out.SessionStartUnixMs = metadata.SessionStart.UnixMilli()
+	}
+	return out
+}
+
+// writeJSON writes body with a 200 status, flagging stale answers with the
+// stale response headers.
+func writeJSON(w http.ResponseWriter, read *registry.StaleRead, body any) {
+	if asOf, stale := read.Stale(); stale {
+		w.Header().Set(StaleHeader, "true")
+		w.Header().Set(StaleAsOfHeader, strconv.FormatInt(asOf.UnixMilli(), 10))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Warn("writing gateway response failed", "error", err)
+	}
+}
+
+// writeError maps a registry error to an HTTP status the same way the gRPC
+// transport maps it to a status code.
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeErrorMessage(w, http.StatusGatewayTimeout, err.Error())
+	case errors.Is(err, context.Canceled):
+		writeErrorMessage(w, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, registry.ErrNotFound):
+		writeErrorMessage(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, registry.ErrInvalid):
+		writeErrorMessage(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, registry.ErrConflict):
+		writeErrorMessage(w, http.StatusConflict, err.Error())
+	case errors.Is(err, registry.ErrUnavailable):
+		writeErrorMessage(w, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, registry.ErrResourceExhausted):
+		writeErrorMessage(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, registry.ErrPermissionDenied):
+		writeErrorMessage(w, http.StatusForbidden, err.Error())
+	default:
+		slog.Error("unclassified error", "err", err)
+		writeErrorMessage(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func writeErrorMessage(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(errorJSON{Error: message, Code: code}); err != nil {
+		slog.Warn("writing gateway response failed", "error", err)
+	}
+}
@@ -0,0 +1,310 @@
+package gateway
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+)
+
+const testToken = "secret"
+
+// newTestGateway returns a gateway over a memory-backed registry holding
+// relay-1 with agent-1 and agent-2, and relay-2 with agent-3.
+func newTestGateway(t *testing.T, httpCfg registry.HTTPConfig) (*Server, *registry.Registry) {
+	t.Helper()
+
+	backend, err := memory.New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	cfg := &registry.Config{
+		Backend: registry.BackendConfig{Type: registry.MemoryRegistryBackend},
+		GRPC:    registry.GRPCConfig{ListenAddress: "127.0.0.1", ListenPort: 50051},
+		TTL:     registry.TTLConfig{Relay: 30 * time.Second, Agent: 30 * time.Second},
+		Admin:   registry.AdminConfig{Token: testToken},
+		HTTP:    httpCfg,
+	}
+	reg, err := registry.New(cfg, backend)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	ctx := context.Background()
+	for _, relayID := range []string{"relay-1", "relay-2"} {
+		if err := reg.RegisterRelay(ctx, registry.Relay{ID: relayID, Address: "10.0.0.1", GRPCPort: 7000}); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+	for agentID, relayID := range map[string]string{"agent-1": "relay-1", "agent-2": "relay-1", "agent-3": "relay-2"} {
+		if err := reg.RegisterAgent(ctx, registry.Agent{ID: agentID}, relayID); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	}
+
+	return New(reg, nil), reg
+}
+
+func get(t *testing.T, s *Server, path string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	return rec
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var body T
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("expected json body, got %v", err)
+	}
+
+	return body
+}
+
+func TestListRelays(t *testing.T) {
+	t.Parallel()
+	s, _ := newTestGateway(t, registry.HTTPConfig{})
+
+	rec := get(t, s, "/v1/relays")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("expected json content type, got %q", got)
+	}
+
+	body := decode[struct {
+		Relays []relayJSON `json:"relays"`
+	}](t, rec)
+	if len(body.Relays) != 2 {
+		t.Fatalf("expected 2 relays, got %+v", body.Relays)
+	}
+	for _, relay := range body.Relays {
+		if relay.Address != "10.0.0.1" || relay.GRPCPort != 7000 || relay.LastHeartbeatUnixMs == 0 {
+			t.Fatalf("unexpected relay %+v", relay)
+		}
+	}
+}
+
+func TestListAgents(t *testing.T) {
+	t.Parallel()
+	s, _ := newTestGateway(t, registry.HTTPConfig{})
+
+	rec := get(t, s, "/v1/agents")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	body := decode[struct {
+		Agents []agentJSON `json:"agents"`
+	}](t, rec)
+	if len(body.Agents) != 3 {
+		t.Fatalf("expected 3 agents, got %+v", body.Agents)
+	}
+}
+
+func TestListRelayAgents(t *testing.T) {
+	t.Parallel()
+	s, _ := newTestGateway(t, registry.HTTPConfig{})
+
+	rec := get(t, s, "/v1/relays/relay-1/agents")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	body := decode[struct {
+		RelayID string      `json:"relay_id"`
+		Agents  []agentJSON `json:"agents"`
+	}](t, rec)
+	if body.RelayID != "relay-1" || len(body.Agents) != 2 {
+		t.Fatalf("expected the 2 agents of relay-1, got %+v", body)
+	}
+}
+
+func TestGetAgentPlacement(t *testing.T) {
+	t.Parallel()
+	s, reg := newTestGateway(t, registry.HTTPConfig{})
+
+	sessionStart := time.UnixMilli(1700000000000)
+	agent := registry.Agent{ID: "agent-3", Metadata: registry.AgentMetadata{
+		Kind:         registry.AgentKindDrone,
+		Version:      "px4-1.14.3",
+		Capabilities: []string{"mission_upload"},
+		SessionStart: sessionStart,
+	}}
+	if err := reg.RegisterAgent(context.Background(), agent, "relay-2"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	rec := get(t, s, "/v1/agents/agent-3/placement")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	body := decode[struct {
+		Placement placementJSON `json:"placement"`
+	}](t, rec)
+	if body.Placement.AgentID != "agent-3" || body.Placement.RelayID != "relay-2" || body.Placement.LastUpdatedUnixMs == 0 {
+		t.Fatalf("expected agent-3 placed on relay-2, got %+v", body.Placement)
+	}
+	if got := body.Placement.agentMetadataJSON; got.Kind != "drone" || got.Version != "px4-1.14.3" ||
+		len(got.Capabilities) != 1 || got.SessionStartUnixMs != sessionStart.UnixMilli() {
+		t.Fatalf("expected agent-3's metadata, got %+v", got)
+	}
+
+	rec = get(t, s, "/v1/relays/relay-2/agents")
+	agents := decode[struct {
+		Agents []agentJSON `json:"agents"`
+	}](t, rec).Agents
+	if len(agents) != 1 || agents[0].Kind != "drone" || agents[0].Capabilities[0] != "mission_upload" {
+		t.Fatalf("expected agent-3 listed with its metadata, got %+v", agents)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	t.Parallel()
+	s, _ := newTestGateway(t, registry.HTTPConfig{})
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		wantCode int
+	}{
+		{name: "unknown agent", method: http.MethodGet, path: "/v1/agents/missing/placement", wantCode: http.StatusNotFound},
+		{name: "unknown relay", method: http.MethodGet, path: "/v1/relays/missing/agents", wantCode: http.StatusNotFound},
+		{name: "unknown path", method: http.MethodGet, path: "/v1/unknown", wantCode: http.StatusNotFound},
+		{name: "write method", method: http.MethodPost, path: "/v1/relays", wantCode: http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(test.method, test.path, nil)
+			req.Header.Set("Authorization", "Bearer "+testToken)
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != test.wantCode {
+				t.Fatalf("expected %d, got %d: %s", test.wantCode, rec.Code, rec.Body)
+			}
+		})
+	}
+
+	rec := get(t, s, "/v1/agents/missing/placement")
+	body := decode[errorJSON](t, rec)
+	if body.Code != http.StatusNotFound || body.Error == "" {
+		t.Fatalf("expected a json error body, got %+v", body)
+	}
+}
+
+func TestNamespaceParam(t *testing.T) {
+	t.Parallel()
+	s, reg := newTestGateway(t, registry.HTTPConfig{})
+
+	ctx := context.Background()
+	next := reg.Config()
+	next.Tenants = []registry.TenantConfig{{Namespace: "fleet-a"}}
+	if _, err := reg.ApplyConfig(ctx, &next); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := reg.RegisterRelay(registry.WithNamespace(ctx, "fleet-a"), registry.Relay{ID: "relay-a", Address: "10.0.1.1", GRPCPort: 7000}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	rec := get(t, s, "/v1/relays?namespace=fleet-a")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	body := decode[struct {
+		Relays []relayJSON `json:"relays"`
+	}](t, rec)
+	if len(body.Relays) != 1 || body.Relays[0].RelayID != "relay-a" {
+		t.Fatalf("expected only the fleet-a relay, got %+v", body.Relays)
+	}
+
+	if rec := get(t, s, "/v1/relays?namespace=fleet-z"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown namespace, got %d: %s", rec.Code, rec.Body)
+	}
+
+	next.Admin.Token = ""
+	if _, err := reg.ApplyConfig(ctx, &next); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if rec := get(t, s, "/v1/relays?namespace=fleet-a"); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a restricted namespace without the admin token, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestNamespaceClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	backend, err := memory.New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	cfg := &registry.Config{
+		Backend: registry.BackendConfig{Type: registry.MemoryRegistryBackend},
+		GRPC: registry.GRPCConfig{
+			ListenAddress: "127.0.0.1",
+			ListenPort:    50051,
+			TLS:           registry.TLSConfig{Enabled: true, CertPath: "cert.pem", KeyPath: "key.pem", ClientCAPath: "ca.pem"},
+		},
+		TTL:     registry.TTLConfig{Relay: 30 * time.Second, Agent: 30 * time.Second},
+		Tenants: []registry.TenantConfig{{Namespace: "fleet-a", Identities: []string{"cert:fleet-a"}}},
+	}
+	reg, err := registry.New(cfg, backend)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := reg.RegisterRelay(registry.WithNamespace(context.Background(), "fleet-a"), registry.Relay{ID: "relay-a", Address: "10.0.1.1", GRPCPort: 7000}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	s := New(reg, nil)
+
+	// The TLS state stands in for a verified client certificate.
+	getAs := func(commonName, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: commonName}}},
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := getAs("fleet-a", "/v1/relays")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	body := decode[struct {
+		Relays []relayJSON `json:"relays"`
+	}](t, rec)
+	if len(body.Relays) != 1 || body.Relays[0].RelayID != "relay-a" {
+		t.Fatalf("expected the bound caller to default to fleet-a, got %+v", body.Relays)
+	}
+
+	if rec := getAs("fleet-a", "/v1/relays?namespace=default"); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a bound caller naming another namespace, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := getAs("fleet-b", "/v1/relays?namespace=fleet-a"); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for an unbound caller naming a tenant, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,256 @@
+// Package gateway implements a read-only HTTP/JSON view of the Aero Arc
+// Registry for browser dashboards, plus an HTML status page for on-call
+// engineers. It serves the same registry.Registry as the gRPC transport,
+// guarded by the admin token, and maps domain errors into HTTP status
+// codes.
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// Response headers flagging answers served from last-known state. They
+// match the gRPC transport's stale metadata keys.
+const (
+	StaleHeader     = "X-Registry-Stale"
+	StaleAsOfHeader = "X-Registry-Stale-As-Of-Unix-Ms"
+)
+
+// NamespaceParam is the query parameter naming the tenant namespace a
+// request reads.
+const NamespaceParam = "namespace"
+
+// readHeaderTimeout bounds how long a client may take to send request
+// headers.
+const readHeaderTimeout = 10 * time.Second
+
+type Server struct {
+	registry   *registry.Registry
+	httpServer *http.Server
+	handler    http.Handler
+}
+
+// New creates a gateway for reg. A non-nil tlsConfig makes Serve speak
+// HTTPS.
+func New(reg *registry.Registry, tlsConfig *tls.Config) *Server {
+	s := &Server{registry: reg}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/relays", s.listRelays)
+	mux.HandleFunc("GET /v1/relays/{relay_id}/agents", s.listRelayAgents)
+	mux.HandleFunc("GET /v1/agents", s.listAgents)
+	mux.HandleFunc("GET /v1/agents/{agent_id}/placement", s.getAgentPlacement)
+	mux.HandleFunc("GET /v1/events", s.events)
+	mux.HandleFunc("GET "+statusPagePath+"{$}", s.statusPage)
+
+	s.handler = s.logRequests(s.cors(s.authorize(s.scopeNamespace(mux))))
+
+	// Change streams never go idle, so Shutdown cancels the context every
+	// request derives from to end them instead of waiting out its deadline.
+	baseCtx, cancel := context.WithCancel(context.Background())
+	s.httpServer = &http.Server{
+		Handler:           s.handler,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: readHeaderTimeout,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
+	s.httpServer.RegisterOnShutdown(cancel)
+
+	return s
+}
+
+// Handler returns the gateway's HTTP handler, including auth, CORS and
+// request logging.
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Serve accepts connections on lis until Shutdown is called, in which
+// case it returns nil.
+func (s *Server) Serve(lis net.Listener) error {
+	var err error
+	if s.httpServer.TLSConfig != nil {
+		err = s.httpServer.ServeTLS(lis, "", "")
+	} else {
+		err = s.httpServer.Serve(lis)
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Shutdown closes open change streams and waits for in-flight requests to
+// finish or ctx to expire.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// authorize requires the admin token, when one is configured, as a bearer
+// token or, so browsers can open the status page, as the password of HTTP
+// basic auth. CORS preflight requests carry no credentials and are let
+// through; the mux rejects them like any other non-GET request.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := s.registry.Config().Admin.Token
+		if token == "" || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		presented, basic := presentedToken(r)
+		switch {
+		case presented == "":
+			challenge(w, r)
+			writeErrorMessage(w, http.StatusUnauthorized, "admin token required")
+		case subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1:
+			if basic {
+				// Let the browser prompt again.
+				challenge(w, r)
+				writeErrorMessage(w, http.StatusUnauthorized, "invalid admin token")
+				return
+			}
+			writeErrorMessage(w, http.StatusForbidden, "invalid admin token")
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// scopeNamespace scopes a request to the namespace named by its
+// NamespaceParam query parameter, or DefaultNamespace. Callers presenting a
+// client certificate are bound to tenants the same way as gRPC callers;
+// when an admin token is configured they have presented it, so they may
+// read any configured namespace.
+func (s *Server) scopeNamespace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := s.registry.Config()
+		namespace, err := cfg.ResolveNamespace(clientIdentity(r), r.URL.Query().Get(NamespaceParam), cfg.Admin.Token != "")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(registry.WithNamespace(r.Context(), namespace)))
+	})
+}
+
+// clientIdentity returns the tenant identity of the verified client
+// certificate r was sent with, or empty without one.
+func clientIdentity(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName == "" {
+		return ""
+	}
+
+	return "cert:" + r.TLS.PeerCertificates[0].Subject.CommonName
+}
+
+// presentedToken returns the token sent with r and whether it came from
+// basic auth.
+func presentedToken(r *http.Request) (string, bool) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token, false
+	}
+
+	if _, password, ok := r.BasicAuth(); ok {
+		return password, true
+	}
+
+	return "", false
+}
+
+// challenge asks for credentials: browsers opening the status page get a
+// basic auth prompt, API clients a bearer challenge.
+func challenge(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == statusPagePath {
+		w.Header().Set("WWW-Authenticate", `Basic realm="aero-arc-registry", charset="UTF-8"`)
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", "Bearer")
+}
+
+// cors allows the configured browser origins to call the gateway. The
+// origins are read per request so a config reload applies immediately.
+func (s *Server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if !originAllowed(s.registry.Config().HTTP.AllowedOrigins, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", http.MethodGet)
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Access-Control-Expose-Headers", StaleHeader+", "+StaleAsOfHeader)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func originAllowed(allowed []string, origin string) bool {
+	return slices.Contains(allowed, "*") || slices.Contains(allowed, origin)
+}
+
+// logRequests logs one line per request, mirroring the gRPC transport's
+// request log.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		slog.LogAttrs(r.Context(), level, "request completed",
+			slog.String("method", r.Method+" "+r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
+		)
+	})
+}
+
+// statusRecorder captures the response status for logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, which
+// the change stream needs to flush.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,143 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+func basicAuth(password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte("operator:"+password))
+}
+
+func TestAuthorize(t *testing.T) {
+	t.Parallel()
+	s, _ := newTestGateway(t, registry.HTTPConfig{})
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      int
+	}{
+		{name: "missing token", wantCode: http.StatusUnauthorized},
+		{name: "wrong token", authorization: "Bearer wrong", wantCode: http.StatusForbidden},
+		{name: "not a bearer token", authorization: testToken, wantCode: http.StatusUnauthorized},
+		{name: "valid token", authorization: "Bearer " + testToken, wantCode: http.StatusOK},
+		{name: "wrong basic auth password", authorization: basicAuth("wrong"), wantCode: http.StatusUnauthorized},
+		{name: "valid basic auth password", authorization: basicAuth(testToken), wantCode: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/relays", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != test.wantCode {
+				t.Fatalf("expected %d, got %d: %s", test.wantCode, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+	s, _ := newTestGateway(t, registry.HTTPConfig{AllowedOrigins: []string{"https://dash.example.com"}})
+
+	t.Run("preflight from allowed origin", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodOptions, "/v1/relays", nil)
+		req.Header.Set("Origin", "https://dash.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("expected 204, got %d", rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://dash.example.com" {
+			t.Fatalf("expected allowed origin header, got %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "Authorization" {
+			t.Fatalf("expected Authorization to be allowed, got %q", got)
+		}
+	})
+
+	t.Run("request from allowed origin", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/relays", nil)
+		req.Header.Set("Origin", "https://dash.example.com")
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://dash.example.com" {
+			t.Fatalf("expected allowed origin header, got %q", got)
+		}
+	})
+
+	t.Run("other origin", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodOptions, "/v1/relays", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Fatalf("expected no allowed origin header, got %q", got)
+		}
+		if rec.Code == http.StatusNoContent {
+			t.Fatalf("expected preflight to be rejected, got %d", rec.Code)
+		}
+	})
+}
+
+func TestShutdownEndsEventStreams(t *testing.T) {
+	t.Parallel()
+	s, _ := newTestGateway(t, registry.HTTPConfig{})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- s.Serve(lis) }()
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+lis.Addr().String()+"/v1/events", nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("expected open streams to end on shutdown, got %v", err)
+	}
+	if err := <-serveErr; err != nil {
+		t.Fatalf("expected nil serve error after shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package gateway
+
+import (
+	"bytes"
+	"cmp"
+	_ "embed"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+// statusPagePath is where the operator status page is served.
+const statusPagePath = "/"
+
+// statusPageRefresh is how often the status page reloads itself.
+const statusPageRefresh = 10 * time.Second
+
+// expiringFraction is the share of its TTL an entry may go without a
+// heartbeat before the status page lists it as approaching expiry.
+const expiringFraction = 0.5
+
+//go:embed status_page.html
+var statusPageHTML string
+
+var statusPageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"duration": formatDuration,
+	"time": func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339)
+	},
+}).Parse(statusPageHTML))
+
+type statusPageData struct {
+	GeneratedAt    time.Time
+	RefreshSeconds int
+
+	Namespace          string
+	Backend            string
+	BackendAvailable   bool
+	OutageSince        time.Time
+	DegradedEnabled    bool
+	StaleReadsServed   uint64
+	CapacityRejections uint64
+	CacheEnabled       bool
+	ShadowEnabled      bool
+
+	RelayTTL time.Duration
+	AgentTTL time.Duration
+
+	// ListError is set when the fleet could not be read; the backend
+	// health and sweep sections are still shown.
+	ListError string
+	Relays    []relayRow
+	Agents    int
+	Expiring  []expiringRow
+
+	TTL    registry.TTLStats
+	Sweeps []registry.SweepResult
+}
+
+type relayRow struct {
+	ID           string
+	Address      string
+	HeartbeatAge time.Duration
+	ExpiresIn    time.Duration
+	Agents       int
+	Expiring     bool
+}
+
+type expiringRow struct {
+	Kind         string
+	ID           string
+	RelayID      string
+	HeartbeatAge time.Duration
+	ExpiresIn    time.Duration
+}
+
+// statusPage renders a read-only HTML overview of the fleet, backend
+// health and recent TTL sweeps for on-call engineers.
+func (s *Server) statusPage(w http.ResponseWriter, r *http.Request) {
+	ctx, _, cancel := requestContext(r)
+	defer cancel()
+
+	cfg := s.registry.Config()
+	namespace := registry.NamespaceFromContext(ctx)
+	ttl := cfg.TenantTTL(namespace)
+	now := time.Now()
+	_, cacheEnabled := s.registry.CacheStats()
+	_, shadowEnabled := s.registry.ShadowStats()
+
+	data := statusPageData{
+		GeneratedAt:        now,
+		RefreshSeconds:     int(statusPageRefresh / time.Second),
+		Namespace:          namespace,
+		Backend:            string(cfg.Backend.Type),
+		BackendAvailable:   s.registry.BackendAvailable(),
+		DegradedEnabled:    cfg.Degraded.Enabled,
+		StaleReadsServed:   s.registry.StaleReadsServed(),
+		CapacityRejections: s.registry.CapacityRejections(),
+		CacheEnabled:       cacheEnabled,
+		ShadowEnabled:      shadowEnabled,
+		RelayTTL:           ttl.Relay,
+		AgentTTL:           ttl.Agent,
+		TTL:                s.registry.TTLStats(),
+		Sweeps:             s.registry.RecentSweeps(),
+	}
+	if since, down := s.registry.BackendOutage(); down {
+		data.OutageSince = since
+	}
+
+	relays, err := s.registry.ListRelays(ctx)
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "status page could not list relays",
+			slog.String("method", "StatusPage"),
+			slog.String("error", err.Error()),
+		)
+		data.ListError = err.Error()
+	}
+
+	slices.SortFunc(relays, func(a, b registry.Relay) int { return cmp.Compare(a.ID, b.ID) })
+	for _, relay := range relays {
+		row := relayRow{
+			ID:           relay.ID,
+			Address:      relay.Address + ":" + strconv.Itoa(int(relay.GRPCPort)),
+			HeartbeatAge: now.Sub(relay.LastSeen),
+			ExpiresIn:    relay.LastSeen.Add(ttl.Relay).Sub(now),
+			Expiring:     expiring(now, relay.LastSeen, ttl.Relay),
+		}
+		if row.Expiring {
+			data.Expiring = append(data.Expiring, expiringRow{
+				Kind:         "relay",
+				ID:           relay.ID,
+				HeartbeatAge: row.HeartbeatAge,
+				ExpiresIn:    row.ExpiresIn,
+			})
+		}
+
+		agents, err := s.registry.ListRelayAgents(ctx, relay.ID)
+		if err != nil && !errors.Is(err, registry.ErrNotFound) {
+			data.ListError = err.Error()
+		}
+		row.Agents = len(agents)
+		data.Agents += len(agents)
+
+		for _, agent := range agents {
+			if !expiring(now, agent.LastHeartbeat, ttl.Agent) {
+				continue
+			}
+			data.Expiring = append(data.Expiring, expiringRow{
+				Kind:         "agent",
+				ID:           agent.ID,
+				RelayID:      relay.ID,
+				HeartbeatAge: now.Sub(agent.LastHeartbeat),
+				ExpiresIn:    agent.LastHeartbeat.Add(ttl.Agent).Sub(now),
+			})
+		}
+
+		data.Relays = append(data.Relays, row)
+	}
+	slices.SortFunc(data.Expiring, func(a, b expiringRow) int { return cmp.Compare(a.ExpiresIn, b.ExpiresIn) })
+
+	var buf bytes.Buffer
+	if err := statusPageTemplate.Execute(&buf, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	if _, err := buf.WriteTo(w); err != nil {
+		slog.Warn("writing gateway response failed", "error", err)
+	}
+}
+
+// expiring reports whether an entry last seen at lastSeen has used up
+// expiringFraction of its TTL.
+func expiring(now, lastSeen time.Time, ttl time.Duration) bool {
+	return now.Sub(lastSeen) >= time.Duration(float64(ttl)*expiringFraction)
+}
+
+// formatDuration rounds d for display; durations below zero are entries
+// past their TTL that the next sweep will remove.
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		return "overdue"
+	}
+
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+
+	return d.Round(100 * time.Millisecond).String()
+}
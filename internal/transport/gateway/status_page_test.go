@@ -0,0 +1,83 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+)
+
+func TestStatusPage(t *testing.T) {
+	t.Parallel()
+	s, reg := newTestGateway(t, registry.HTTPConfig{})
+
+	next := reg.Config()
+	next.TTL.Agent = 20 * time.Millisecond
+	if _, err := reg.ApplyConfig(context.Background(), &next); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	time.Sleep(15 * time.Millisecond)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", basicAuth(testToken))
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Fatalf("expected html content type, got %q", got)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"Relays (2, 3 agents)",
+		"<td>relay-1</td><td>10.0.0.1:7000</td>",
+		`<span class="ok">available</span>`,
+		"<td>agent</td><td>agent-3</td><td>relay-2</td>",
+		"No sweeps have run on this replica yet.",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected status page to contain %q, got %s", want, body)
+		}
+	}
+}
+
+func TestStatusPageRequiresToken(t *testing.T) {
+	t.Parallel()
+	s, _ := newTestGateway(t, registry.HTTPConfig{})
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("WWW-Authenticate"); !strings.HasPrefix(got, "Basic") {
+		t.Fatalf("expected a basic auth challenge, got %q", got)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: -time.Second, want: "overdue"},
+		{in: 1500 * time.Microsecond, want: "2ms"},
+		{in: 12340 * time.Millisecond, want: "12.3s"},
+	}
+
+	for _, test := range tests {
+		if got := formatDuration(test.in); got != test.want {
+			t.Fatalf("expected %v to format as %q, got %q", test.in, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"strings"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Reloader re-reads runtime configuration and hot-applies it.
+type Reloader interface {
+	Reload(ctx context.Context) (*registry.ReloadResult, error)
+}
+
+// auditWatchBuffer is how many audit events a WatchAudit client may lag
+// behind before it is disconnected.
+const auditWatchBuffer = 256
+
+type adminService struct {
+	registry *registry.Registry
+	reloader Reloader
+}
+
+// EnableAdmin registers the admin service on the server. It must be called
+// before Serve. reloader may be nil, in which case ReloadConfig returns
+// Unimplemented.
+func (s *Server) EnableAdmin(reloader Reloader) {
+	s.grpcServer.RegisterService(adminServiceDesc(), &adminService{
+		registry: s.registry,
+		reloader: reloader,
+	})
+}
+
+func (a *adminService) ReloadConfig(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if a.reloader == nil {
+		return nil, status.Error(codes.Unimplemented, "config reload is not available")
+	}
+
+	result, err := a.reloader.Reload(ctx)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	a.auditAction(ctx, AdminMethodReloadConfig, registry.AuditEvent{})
+
+	return structpb.NewStruct(map[string]any{
+		"applied":  stringsToAny(result.Applied),
+		"rejected": stringsToAny(result.Rejected),
+	})
+}
+
+func (a *adminService) SweepTTL(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := a.registry.SweepNow(ctx); err != nil {
+		return nil, toStatusError(err)
+	}
+	a.auditAction(ctx, AdminMethodSweepTTL, registry.AuditEvent{})
+
+	return ttlStatsToStruct(a.registry.TTLStats())
+}
+
+func (a *adminService) CacheStats(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	stats, ok := a.registry.CacheStats()
+	if !ok {
+		return structpb.NewStruct(map[string]any{"enabled": false})
+	}
+
+	return structpb.NewStruct(map[string]any{
+		"enabled":          true,
+		"relay_hits":       float64(stats.RelayHits),
+		"relay_misses":     float64(stats.RelayMisses),
+		"placement_hits":   float64(stats.PlacementHits),
+		"placement_misses": float64(stats.PlacementMisses),
+		"invalidations":    float64(stats.Invalidations),
+		"placements":       float64(stats.Placements),
+	})
+}
+
+func (a *adminService) ShadowStats(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	stats, ok := a.registry.ShadowStats()
+	if !ok {
+		return structpb.NewStruct(map[string]any{"enabled": false})
+	}
+
+	return structpb.NewStruct(map[string]any{
+		"enabled":               true,
+		"mirrored_writes":       float64(stats.MirroredWrites),
+		"mirror_errors":         float64(stats.MirrorErrors),
+		"comparisons":           float64(stats.Comparisons),
+		"compare_errors":        float64(stats.CompareErrors),
+		"dropped":               float64(stats.Dropped),
+		"relay_divergences":     float64(stats.RelayDivergences),
+		"agent_divergences":     float64(stats.AgentDivergences),
+		"placement_divergences": float64(stats.PlacementDivergences),
+	})
+}
+
+func (a *adminService) ListRelayAgents(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	relayID, err := requiredField(req, "relay_id")
+	if err != nil {
+		return nil, err
+	}
+
+	agents, err := a.registry.ListRelayAgents(ctx, relayID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	out := make([]any, len(agents))
+	for i, agent := range agents {
+		entry := map[string]any{
+			"agent_id":               agent.ID,
+			"last_heartbeat_unix_ms": float64(agent.LastHeartbeat.UnixMilli()),
+		}
+		addAgentMetadata(entry, agent.Metadata)
+		out[i] = entry
+	}
+
+	return structpb.NewStruct(map[string]any{
+		"relay_id": relayID,
+		"agents":   out,
+	})
+}
+
+func (a *adminService) DrainRelay(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	relayID, err := requiredField(req, "relay_id")
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := a.registry.DrainRelay(ctx, relayID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	a.auditAction(ctx, AdminMethodDrainRelay, registry.AuditEvent{RelayID: relayID})
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "relay drained",
+		slog.String("method", "DrainRelay"),
+		slog.String("relay_id", relayID),
+		slog.Int("agents_removed", removed),
+	)
+
+	return structpb.NewStruct(map[string]any{
+		"relay_id":       relayID,
+		"agents_removed": float64(removed),
+	})
+}
+
+func (a *adminService) EvictAgent(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	agentID, err := requiredField(req, "agent_id")
+	if err != nil {
+		return nil, err
+	}
+
+	// Removing an unknown agent succeeds in every backend, so look it up
+	// first to report NotFound.
+	placement, err := a.registry.GetAgentPlacement(ctx, agentID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if err := a.registry.RemoveAgents(ctx, []string{agentID}); err != nil {
+		return nil, toStatusError(err)
+	}
+	a.auditAction(ctx, AdminMethodEvictAgent, registry.AuditEvent{RelayID: placement.RelayID, AgentID: agentID})
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "agent evicted",
+		slog.String("method", "EvictAgent"),
+		slog.String("agent_id", agentID),
+		slog.String("relay_id", placement.RelayID),
+	)
+
+	return structpb.NewStruct(map[string]any{
+		"agent_id": agentID,
+		"relay_id": placement.RelayID,
+	})
+}
+
+func (a *adminService) Status(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	relays, err := a.registry.ListRelays(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	agents, err := a.registry.ListAgents(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	_, cacheEnabled := a.registry.CacheStats()
+	_, shadowEnabled := a.registry.ShadowStats()
+
+	out := map[string]any{
+		"backend":             string(a.registry.Config().Backend.Type),
+		"backend_available":   a.registry.BackendAvailable(),
+		"relays":              float64(len(relays)),
+		"agents":              float64(len(agents)),
+		"cache_enabled":       cacheEnabled,
+		"shadow_enabled":      shadowEnabled,
+		"stale_reads_served":  float64(a.registry.StaleReadsServed()),
+		"capacity_rejections": float64(a.registry.CapacityRejections()),
+		"ttl":                 ttlStatsToMap(a.registry.TTLStats()),
+	}
+	if since, down := a.registry.BackendOutage(); down {
+		out["backend_outage_since_unix_ms"] = float64(since.UnixMilli())
+	}
+
+	return structpb.NewStruct(out)
+}
+
+func (a *adminService) AuditLog(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if !a.registry.AuditEnabled() {
+		return nil, status.Error(codes.FailedPrecondition, "the audit log is disabled")
+	}
+
+	fields := req.GetFields()
+	events := a.registry.AuditLog(registry.AuditFilter{
+		RelayID:   fields["relay_id"].GetStringValue(),
+		AgentID:   fields["agent_id"].GetStringValue(),
+		Namespace: fields["namespace"].GetStringValue(),
+		Type:      registry.AuditEventType(fields["type"].GetStringValue()),
+		Limit:     int(fields["limit"].GetNumberValue()),
+	})
+
+	out := make([]any, len(events))
+	for i, event := range events {
+		out[i] = auditEventToMap(event)
+	}
+
+	return structpb.NewStruct(map[string]any{"events": out})
+}
+
+// WatchAudit streams audit events as they are recorded until the client
+// goes away. A client that cannot keep up is disconnected with
+// ResourceExhausted rather than silently missing events.
+func (a *adminService) WatchAudit(req *structpb.Struct, stream gogrpc.ServerStream) error {
+	ctx := stream.Context()
+	if err := a.authorize(ctx); err != nil {
+		return err
+	}
+	if !a.registry.AuditEnabled() {
+		return status.Error(codes.FailedPrecondition, "the audit log is disabled")
+	}
+
+	sub := a.registry.SubscribeAudit(auditWatchBuffer)
+	defer sub.Cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "audit watcher fell behind")
+			}
+
+			msg, err := structpb.NewStruct(auditEventToMap(event))
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			if err := stream.SendMsg(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (a *adminService) PlacementHistory(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	agentID, err := requiredField(req, "agent_id")
+	if err != nil {
+		return nil, err
+	}
+
+	changes, ok := a.registry.PlacementHistory(ctx, agentID)
+	if !ok {
+		return nil, status.Error(codes.FailedPrecondition, "the placement history is disabled")
+	}
+
+	out := make([]any, len(changes))
+	for i, change := range changes {
+		out[i] = map[string]any{
+			"relay_id":   change.RelayID,
+			"reason":     string(change.Reason),
+			"at_unix_ms": float64(change.At.UnixMilli()),
+		}
+	}
+
+	return structpb.NewStruct(map[string]any{
+		"agent_id": agentID,
+		"changes":  out,
+	})
+}
+
+// auditAction records a successful admin action in the audit log.
+func (a *adminService) auditAction(ctx context.Context, method string, event registry.AuditEvent) {
+	event.Type = registry.AuditAdminAction
+	event.Action = method
+	a.registry.Audit(ctx, event)
+}
+
+// requiredField returns the non-empty string field name of req.
+func requiredField(req *structpb.Struct, name string) (string, error) {
+	value := req.GetFields()[name].GetStringValue()
+	if value == "" {
+		return "", status.Errorf(codes.InvalidArgument, "%s is required", name)
+	}
+	return value, nil
+}
+
+// authorize checks the bearer token in the "authorization" metadata against
+// the currently configured admin token. An empty token disables the check.
+func (a *adminService) authorize(ctx context.Context) error {
+	token := a.registry.Config().Admin.Token
+	if token == "" {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "admin token required")
+	}
+
+	if !validBearer(md, token) {
+		return status.Error(codes.PermissionDenied, "invalid admin token")
+	}
+
+	return nil
+}
+
+// validBearer reports whether the "authorization" metadata in md carries
+// token as a bearer token.
+func validBearer(md metadata.MD, token string) bool {
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return false
+	}
+
+	presented, ok := strings.CutPrefix(values[0], "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
+
+func ttlStatsToStruct(stats registry.TTLStats) (*structpb.Struct, error) {
+	return structpb.NewStruct(ttlStatsToMap(stats))
+}
+
+func ttlStatsToMap(stats registry.TTLStats) map[string]any {
+	out := map[string]any{
+		"replica_id":       stats.ReplicaID,
+		"leader":           stats.Leader,
+		"runs":             float64(stats.Runs),
+		"skipped_runs":     float64(stats.SkippedRuns),
+		"errors":           float64(stats.Errors),
+		"relays_removed":   float64(stats.RelaysRemoved),
+		"agents_removed":   float64(stats.AgentsRemoved),
+		"last_duration_ms": float64(stats.LastDuration.Milliseconds()),
+	}
+	if !stats.LastRunAt.IsZero() {
+		out["last_run_unix_ms"] = float64(stats.LastRunAt.UnixMilli())
+	}
+
+	return out
+}
+
+func auditEventToMap(event registry.AuditEvent) map[string]any {
+	out := map[string]any{
+		"seq":          float64(event.Seq),
+		"time_unix_ms": float64(event.Time.UnixMilli()),
+		"type":         string(event.Type),
+	}
+	for key, value := range map[string]string{
+		"namespace":         event.Namespace,
+		"relay_id":          event.RelayID,
+		"agent_id":          event.AgentID,
+		"previous_relay_id": event.PreviousRelayID,
+		"action":            event.Action,
+		"caller":            event.Caller,
+		"request_id":        event.RequestID,
+	} {
+		if value != "" {
+			out[key] = value
+		}
+	}
+
+	return out
+}
+
+// addAgentMetadata adds the metadata fields the agent reported to out.
+func addAgentMetadata(out map[string]any, metadata registry.AgentMetadata) {
+	if metadata.Kind != "" {
+		out["kind"] = string(metadata.Kind)
+	}
+	if metadata.Version != "" {
+		out["version"] = metadata.Version
+	}
+	if len(metadata.Capabilities) > 0 {
+		out["capabilities"] = stringsToAny(metadata.Capabilities)
+	}
+	if !metadata.SessionStart.IsZero() {
+		out["session_start_unix_ms"] = float64(metadata.SessionStart.UnixMilli())
+	}
+}
+
+func stringsToAny(values []string) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,166 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The admin service is not part of the public aero-arc-protos API. It is
+// described here with well-known protobuf types so operators can reach it
+// with grpcurl (via reflection) or the registry CLI without generated code.
+const (
+	AdminServiceName = "aeroarc.registry.admin.v1.RegistryAdmin"
+	adminProtoFile   = "aeroarc/registry/admin/v1/admin.proto"
+)
+
+// Admin method names. Requests and responses are google.protobuf.Struct.
+const (
+	AdminMethodReloadConfig = "ReloadConfig"
+	AdminMethodSweepTTL     = "SweepTTL"
+	AdminMethodCacheStats   = "CacheStats"
+	AdminMethodShadowStats  = "ShadowStats"
+
+	AdminMethodListRelayAgents = "ListRelayAgents"
+	AdminMethodDrainRelay      = "DrainRelay"
+	AdminMethodEvictAgent      = "EvictAgent"
+	AdminMethodStatus          = "Status"
+
+	AdminMethodAuditLog   = "AuditLog"
+	AdminMethodWatchAudit = "WatchAudit"
+
+	AdminMethodPlacementHistory = "PlacementHistory"
+)
+
+type adminMethod struct {
+	name    string
+	handler func(s *adminService, ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+}
+
+var adminMethods = []adminMethod{
+	{AdminMethodReloadConfig, (*adminService).ReloadConfig},
+	{AdminMethodSweepTTL, (*adminService).SweepTTL},
+	{AdminMethodCacheStats, (*adminService).CacheStats},
+	{AdminMethodShadowStats, (*adminService).ShadowStats},
+	{AdminMethodListRelayAgents, (*adminService).ListRelayAgents},
+	{AdminMethodDrainRelay, (*adminService).DrainRelay},
+	{AdminMethodEvictAgent, (*adminService).EvictAgent},
+	{AdminMethodStatus, (*adminService).Status},
+	{AdminMethodAuditLog, (*adminService).AuditLog},
+	{AdminMethodPlacementHistory, (*adminService).PlacementHistory},
+}
+
+// adminStreamMethod is a server-streaming admin method: one Struct request,
+// a stream of Struct responses.
+type adminStreamMethod struct {
+	name    string
+	handler func(s *adminService, req *structpb.Struct, stream gogrpc.ServerStream) error
+}
+
+var adminStreamMethods = []adminStreamMethod{
+	{AdminMethodWatchAudit, (*adminService).WatchAudit},
+}
+
+// AdminMethodPath returns the full gRPC method path for an admin method.
+func AdminMethodPath(method string) string {
+	return "/" + AdminServiceName + "/" + method
+}
+
+func init() {
+	if err := registerAdminFileDescriptor(); err != nil {
+		panic(fmt.Sprintf("register admin service descriptor: %v", err))
+	}
+}
+
+func registerAdminFileDescriptor() error {
+	methods := make([]*descriptorpb.MethodDescriptorProto, 0, len(adminMethods)+len(adminStreamMethods))
+	for _, method := range adminMethods {
+		methods = append(methods, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(method.name),
+			InputType:  proto.String(".google.protobuf.Struct"),
+			OutputType: proto.String(".google.protobuf.Struct"),
+		})
+	}
+	for _, method := range adminStreamMethods {
+		methods = append(methods, &descriptorpb.MethodDescriptorProto{
+			Name:            proto.String(method.name),
+			InputType:       proto.String(".google.protobuf.Struct"),
+			OutputType:      proto.String(".google.protobuf.Struct"),
+			ServerStreaming: proto.Bool(true),
+		})
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String(adminProtoFile),
+		Package:    proto.String("aeroarc.registry.admin.v1"),
+		Dependency: []string{"google/protobuf/struct.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name:   proto.String("RegistryAdmin"),
+			Method: methods,
+		}},
+		Syntax: proto.String("proto3"),
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		return err
+	}
+
+	return protoregistry.GlobalFiles.RegisterFile(file)
+}
+
+func adminServiceDesc() *gogrpc.ServiceDesc {
+	desc := &gogrpc.ServiceDesc{
+		ServiceName: AdminServiceName,
+		HandlerType: (*any)(nil),
+		Metadata:    adminProtoFile,
+	}
+
+	for _, method := range adminMethods {
+		handler := method.handler
+		fullMethod := AdminMethodPath(method.name)
+
+		desc.Methods = append(desc.Methods, gogrpc.MethodDesc{
+			MethodName: method.name,
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor gogrpc.UnaryServerInterceptor) (any, error) {
+				req := &structpb.Struct{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+
+				admin := srv.(*adminService)
+				if interceptor == nil {
+					return handler(admin, ctx, req)
+				}
+
+				info := &gogrpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}
+				return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+					return handler(admin, ctx, req.(*structpb.Struct))
+				})
+			},
+		})
+	}
+
+	for _, method := range adminStreamMethods {
+		handler := method.handler
+
+		desc.Streams = append(desc.Streams, gogrpc.StreamDesc{
+			StreamName:    method.name,
+			ServerStreams: true,
+			Handler: func(srv any, stream gogrpc.ServerStream) error {
+				req := &structpb.Struct{}
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+
+				return handler(srv.(*adminService), req, stream)
+			},
+		})
+	}
+
+	return desc
+}
@@ -0,0 +1,341 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type reloaderStub struct {
+	result *registry.ReloadResult
+	err    error
+	calls  int
+}
+
+func (r *reloaderStub) Reload(ctx context.Context) (*registry.ReloadResult, error) {
+	r.calls++
+	return r.result, r.err
+}
+
+func TestAdminReloadConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns applied and rejected keys", func(t *testing.T) {
+		t.Parallel()
+		reloader := &reloaderStub{result: &registry.ReloadResult{
+			Applied:  []string{"ttl.relay"},
+			Rejected: []string{"grpc.listen_port"},
+		}}
+		conn := newAdminTestConn(t, "", reloader)
+
+		resp := &structpb.Struct{}
+		if err := conn.Invoke(context.Background(), AdminMethodPath(AdminMethodReloadConfig), &structpb.Struct{}, resp); err != nil {
+			t.Fatalf("ReloadConfig error = %v", err)
+		}
+
+		applied := resp.Fields["applied"].GetListValue().GetValues()
+		rejected := resp.Fields["rejected"].GetListValue().GetValues()
+		if len(applied) != 1 || applied[0].GetStringValue() != "ttl.relay" {
+			t.Fatalf("unexpected applied keys: %v", applied)
+		}
+		if len(rejected) != 1 || rejected[0].GetStringValue() != "grpc.listen_port" {
+			t.Fatalf("unexpected rejected keys: %v", rejected)
+		}
+	})
+
+	t.Run("maps reload failure", func(t *testing.T) {
+		t.Parallel()
+		conn := newAdminTestConn(t, "", &reloaderStub{err: errors.New("bad config")})
+
+		err := conn.Invoke(context.Background(), AdminMethodPath(AdminMethodReloadConfig), &structpb.Struct{}, &structpb.Struct{})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("expected FailedPrecondition, got %v", err)
+		}
+	})
+
+	t.Run("nil reloader is unimplemented", func(t *testing.T) {
+		t.Parallel()
+		conn := newAdminTestConn(t, "", nil)
+
+		err := conn.Invoke(context.Background(), AdminMethodPath(AdminMethodReloadConfig), &structpb.Struct{}, &structpb.Struct{})
+		if status.Code(err) != codes.Unimplemented {
+			t.Fatalf("expected Unimplemented, got %v", err)
+		}
+	})
+}
+
+func TestAdminAuthorization(t *testing.T) {
+	t.Parallel()
+
+	reloader := &reloaderStub{result: &registry.ReloadResult{}}
+	conn := newAdminTestConn(t, "s3cret", reloader)
+	method := AdminMethodPath(AdminMethodReloadConfig)
+
+	err := conn.Invoke(context.Background(), method, &structpb.Struct{}, &structpb.Struct{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without token, got %v", err)
+	}
+
+	wrongCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope")
+	err = conn.Invoke(wrongCtx, method, &structpb.Struct{}, &structpb.Struct{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied with wrong token, got %v", err)
+	}
+
+	okCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer s3cret")
+	if err := conn.Invoke(okCtx, method, &structpb.Struct{}, &structpb.Struct{}); err != nil {
+		t.Fatalf("expected success with valid token, got %v", err)
+	}
+	if reloader.calls != 1 {
+		t.Fatalf("expected reloader to run once, got %d", reloader.calls)
+	}
+}
+
+func TestAdminSweepTTL(t *testing.T) {
+	t.Parallel()
+
+	conn := newAdminTestConn(t, "", nil)
+
+	resp := &structpb.Struct{}
+	if err := conn.Invoke(context.Background(), AdminMethodPath(AdminMethodSweepTTL), &structpb.Struct{}, resp); err != nil {
+		t.Fatalf("SweepTTL error = %v", err)
+	}
+	if got := resp.Fields["runs"].GetNumberValue(); got != 1 {
+		t.Fatalf("expected one sweep run, got %v", got)
+	}
+}
+
+func TestAdminCacheStatsWithoutCache(t *testing.T) {
+	t.Parallel()
+
+	conn := newAdminTestConn(t, "", nil)
+
+	resp := &structpb.Struct{}
+	if err := conn.Invoke(context.Background(), AdminMethodPath(AdminMethodCacheStats), &structpb.Struct{}, resp); err != nil {
+		t.Fatalf("CacheStats error = %v", err)
+	}
+	if resp.Fields["enabled"].GetBoolValue() {
+		t.Fatalf("expected cache to be reported disabled, got %v", resp)
+	}
+}
+
+func TestAdminShadowStatsWithoutShadow(t *testing.T) {
+	t.Parallel()
+
+	conn := newAdminTestConn(t, "", nil)
+
+	resp := &structpb.Struct{}
+	if err := conn.Invoke(context.Background(), AdminMethodPath(AdminMethodShadowStats), &structpb.Struct{}, resp); err != nil {
+		t.Fatalf("ShadowStats error = %v", err)
+	}
+	if resp.Fields["enabled"].GetBoolValue() {
+		t.Fatalf("expected shadowing to be reported disabled, got %v", resp)
+	}
+}
+
+func TestAdminRequiresIDs(t *testing.T) {
+	t.Parallel()
+
+	conn := newAdminTestConn(t, "", nil)
+
+	for _, method := range []string{AdminMethodListRelayAgents, AdminMethodDrainRelay, AdminMethodEvictAgent, AdminMethodPlacementHistory} {
+		err := conn.Invoke(context.Background(), AdminMethodPath(method), &structpb.Struct{}, &structpb.Struct{})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("%s: expected InvalidArgument, got %v", method, err)
+		}
+	}
+}
+
+func TestAdminAuditLog(t *testing.T) {
+	t.Parallel()
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+		conn := newAdminTestConn(t, "", nil)
+
+		err := conn.Invoke(context.Background(), AdminMethodPath(AdminMethodAuditLog), &structpb.Struct{}, &structpb.Struct{})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("expected FailedPrecondition, got %v", err)
+		}
+	})
+
+	t.Run("records admin actions with the caller", func(t *testing.T) {
+		t.Parallel()
+		cfg := adminTestConfig("")
+		cfg.Audit = registry.AuditConfig{Enabled: true, Sink: registry.AuditSinkNone}
+		conn := serveAdminTestConn(t, cfg, nil)
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDMetadataKey, "req-42")
+		if err := conn.Invoke(ctx, AdminMethodPath(AdminMethodSweepTTL), &structpb.Struct{}, &structpb.Struct{}); err != nil {
+			t.Fatalf("SweepTTL error = %v", err)
+		}
+
+		req, err := structpb.NewStruct(map[string]any{"type": string(registry.AuditAdminAction), "limit": 10})
+		if err != nil {
+			t.Fatalf("NewStruct() error = %v", err)
+		}
+		resp := &structpb.Struct{}
+		if err := conn.Invoke(context.Background(), AdminMethodPath(AdminMethodAuditLog), req, resp); err != nil {
+			t.Fatalf("AuditLog error = %v", err)
+		}
+
+		events := resp.Fields["events"].GetListValue().GetValues()
+		if len(events) != 1 {
+			t.Fatalf("expected one admin action, got %v", events)
+		}
+		event := events[0].GetStructValue().GetFields()
+		if got := event["action"].GetStringValue(); got != AdminMethodSweepTTL {
+			t.Fatalf("expected action %q, got %q", AdminMethodSweepTTL, got)
+		}
+		if got := event["request_id"].GetStringValue(); got != "req-42" {
+			t.Fatalf("expected the caller's request ID, got %q", got)
+		}
+		if event["caller"].GetStringValue() == "" {
+			t.Fatalf("expected a caller identity, got %v", event)
+		}
+	})
+}
+
+func TestAdminWatchAudit(t *testing.T) {
+	t.Parallel()
+
+	cfg := adminTestConfig("")
+	cfg.Audit = registry.AuditConfig{Enabled: true, Sink: registry.AuditSinkNone}
+	conn := serveAdminTestConn(t, cfg, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := conn.NewStream(ctx, &gogrpc.StreamDesc{ServerStreams: true}, AdminMethodPath(AdminMethodWatchAudit))
+	if err != nil {
+		t.Fatalf("NewStream error = %v", err)
+	}
+	if err := stream.SendMsg(&structpb.Struct{}); err != nil {
+		t.Fatalf("SendMsg error = %v", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend error = %v", err)
+	}
+	// The server subscribes some time after the stream opens, so keep
+	// sweeping until an event arrives.
+	received := make(chan *structpb.Struct, 1)
+	go func() {
+		msg := &structpb.Struct{}
+		if err := stream.RecvMsg(msg); err == nil {
+			received <- msg
+		}
+	}()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if err := conn.Invoke(ctx, AdminMethodPath(AdminMethodSweepTTL), &structpb.Struct{}, &structpb.Struct{}); err != nil {
+			t.Fatalf("SweepTTL error = %v", err)
+		}
+
+		select {
+		case msg := <-received:
+			if got := msg.Fields["action"].GetStringValue(); got != AdminMethodSweepTTL {
+				t.Fatalf("expected a SweepTTL admin action, got %v", msg)
+			}
+			return
+		case <-ticker.C:
+		case <-ctx.Done():
+			t.Fatalf("expected an audit event before the deadline")
+		}
+	}
+}
+
+func TestAdminPlacementHistory(t *testing.T) {
+	t.Parallel()
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+		conn := newAdminTestConn(t, "", nil)
+
+		req, _ := structpb.NewStruct(map[string]any{"agent_id": "agent-1"})
+		err := conn.Invoke(context.Background(), AdminMethodPath(AdminMethodPlacementHistory), req, &structpb.Struct{})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("expected FailedPrecondition, got %v", err)
+		}
+	})
+
+	t.Run("returns recorded changes", func(t *testing.T) {
+		t.Parallel()
+		cfg := adminTestConfig("")
+		cfg.PlacementHistory = registry.PlacementHistoryConfig{Enabled: true}
+		conn := serveAdminTestConn(t, cfg, nil)
+
+		_, err := registryv1.NewAeroRegistryClient(conn).RegisterAgent(context.Background(), &registryv1.RegisterAgentRequest{
+			Agent:   &registryv1.Agent{AgentId: "agent-1"},
+			RelayId: "relay-1",
+		})
+		if err != nil {
+			t.Fatalf("RegisterAgent error = %v", err)
+		}
+
+		req, _ := structpb.NewStruct(map[string]any{"agent_id": "agent-1"})
+		resp := &structpb.Struct{}
+		if err := conn.Invoke(context.Background(), AdminMethodPath(AdminMethodPlacementHistory), req, resp); err != nil {
+			t.Fatalf("PlacementHistory error = %v", err)
+		}
+
+		changes := resp.Fields["changes"].GetListValue().GetValues()
+		if len(changes) != 1 {
+			t.Fatalf("expected one change, got %v", changes)
+		}
+		change := changes[0].GetStructValue().GetFields()
+		if change["relay_id"].GetStringValue() != "relay-1" || change["reason"].GetStringValue() != string(registry.PlacementRegistered) {
+			t.Fatalf("unexpected change %v", change)
+		}
+	})
+}
+
+func newAdminTestConn(t *testing.T, token string, reloader Reloader) *gogrpc.ClientConn {
+	t.Helper()
+
+	return serveAdminTestConn(t, adminTestConfig(token), reloader)
+}
+
+func adminTestConfig(token string) *registry.Config {
+	return &registry.Config{
+		Backend: registry.BackendConfig{Type: registry.MemoryRegistryBackend},
+		GRPC: registry.GRPCConfig{
+			ListenAddress: "127.0.0.1",
+			ListenPort:    50051,
+		},
+		TTL: registry.TTLConfig{
+			Relay: 5 * time.Second,
+			Agent: 5 * time.Second,
+		},
+		Admin: registry.AdminConfig{Enabled: true, Token: token},
+	}
+}
+
+func serveAdminTestConn(t *testing.T, cfg *registry.Config, reloader Reloader) *gogrpc.ClientConn {
+	t.Helper()
+
+	reg, err := registry.New(cfg, &transportBackendStub{})
+	if err != nil {
+		t.Fatalf("registry.New() error = %v", err)
+	}
+
+	s, err := New(reg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	s.EnableAdmin(reloader)
+
+	t.Cleanup(s.GracefulStop)
+
+	return serveBufconn(t, s)
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys carrying an agent's metadata. The v1 Agent message has no
+// fields for it, so RegisterAgent reads it from the request metadata and
+// GetAgentPlacement returns it in the response headers. Capabilities are
+// comma-separated and the session start is a Unix time in milliseconds.
+const (
+	AgentKindMetadataKey         = "x-agent-kind"
+	AgentVersionMetadataKey      = "x-agent-version"
+	AgentCapabilitiesMetadataKey = "x-agent-capabilities"
+	AgentSessionStartMetadataKey = "x-agent-session-start-unix-ms"
+)
+
+// incomingAgentMetadata reads the agent metadata sent with a RegisterAgent
+// request.
+func incomingAgentMetadata(ctx context.Context) (registry.AgentMetadata, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	value := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+
+	agentMetadata := registry.AgentMetadata{
+		Kind:    registry.AgentKind(value(AgentKindMetadataKey)),
+		Version: value(AgentVersionMetadataKey),
+	}
+	if !agentMetadata.Kind.Valid() {
+		return registry.AgentMetadata{}, status.Errorf(codes.InvalidArgument, "unknown agent kind %q", agentMetadata.Kind)
+	}
+
+	for _, values := range md.Get(AgentCapabilitiesMetadataKey) {
+		for capability := range strings.SplitSeq(values, ",") {
+			if capability = strings.TrimSpace(capability); capability != "" {
+				agentMetadata.Capabilities = append(agentMetadata.Capabilities, capability)
+			}
+		}
+	}
+
+	if sessionStart := value(AgentSessionStartMetadataKey); sessionStart != "" {
+		ms, err := strconv.ParseInt(sessionStart, 10, 64)
+		if err != nil || ms <= 0 {
+			return registry.AgentMetadata{}, status.Errorf(codes.InvalidArgument, "invalid agent session start %q", sessionStart)
+		}
+		agentMetadata.SessionStart = time.UnixMilli(ms)
+	}
+
+	return agentMetadata, nil
+}
+
+// agentMetadataHeader returns the response headers carrying agentMetadata,
+// omitting the fields the agent did not report.
+func agentMetadataHeader(agentMetadata registry.AgentMetadata) metadata.MD {
+	md := metadata.MD{}
+	if agentMetadata.Kind != "" {
+		md.Set(AgentKindMetadataKey, string(agentMetadata.Kind))
+	}
+	if agentMetadata.Version != "" {
+		md.Set(AgentVersionMetadataKey, agentMetadata.Version)
+	}
+	if len(agentMetadata.Capabilities) > 0 {
+		md.Set(AgentCapabilitiesMetadataKey, strings.Join(agentMetadata.Capabilities, ","))
+	}
+	if !agentMetadata.SessionStart.IsZero() {
+		md.Set(AgentSessionStartMetadataKey, strconv.FormatInt(agentMetadata.SessionStart.UnixMilli(), 10))
+	}
+	return md
+}
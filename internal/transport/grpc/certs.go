@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// CertReloader serves a TLS certificate, and optionally the CAs client
+// certificates are verified against, that can be replaced at runtime
+// without restarting the gRPC server. New handshakes pick up the latest
+// files; established connections are unaffected.
+type CertReloader struct {
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// NewCertReloader loads the initial certificate and key pair. When
+// clientCAPath is set, every caller must present a client certificate
+// signed by one of the CAs it holds.
+func NewCertReloader(certPath, keyPath, clientCAPath string) (*CertReloader, error) {
+	c := &CertReloader{}
+	if err := c.Reload(certPath, keyPath, clientCAPath); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Reload reads the certificate and key pair, and the client CA bundle when
+// clientCAPath is set, from disk and swaps them in. The previous files stay
+// active if loading fails.
+func (c *CertReloader) Reload(certPath, keyPath, clientCAPath string) error {
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return err
+	}
+
+	var clientCAs *x509.CertPool
+	if clientCAPath != "" {
+		pem, err := os.ReadFile(clientCAPath)
+		if err != nil {
+			return err
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("tls: no client ca certificates found in " + clientCAPath)
+		}
+	}
+
+	c.mu.Lock()
+	c.cert = &cert
+	c.clientCAs = clientCAs
+	c.mu.Unlock()
+
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// GetConfigForClient implements tls.Config.GetConfigForClient. It returns
+// the config for a new handshake, requiring and verifying a client
+// certificate when client CAs are loaded. The returned config replaces the
+// listener's, so it offers the protocols both gRPC and the HTTP gateway
+// negotiate.
+func (c *CertReloader) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{*c.cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if c.clientCAs != nil {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = c.clientCAs
+	}
+
+	return cfg, nil
+}
+
+// TLSConfig returns a server TLS config backed by the reloader, for
+// listeners other than the gRPC server that share its certificate and
+// client certificate requirement.
+func (c *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: c.GetConfigForClient,
+		MinVersion:         tls.VersionTLS12,
+	}
+}
+
+// TransportCredentials returns server credentials backed by the reloader.
+func (c *CertReloader) TransportCredentials() credentials.TransportCredentials {
+	return credentials.NewTLS(c.TLSConfig())
+}
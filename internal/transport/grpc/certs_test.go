@@ -0,0 +1,217 @@
+package grpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPKI is a throwaway CA with a server certificate for "bufnet" written
+// to a temporary directory.
+type testPKI struct {
+	certPath string
+	keyPath  string
+	caPath   string
+	ca       *x509.Certificate
+	caKey    *ecdsa.PrivateKey
+	roots    *x509.CertPool
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+
+	dir := t.TempDir()
+	p := &testPKI{
+		certPath: filepath.Join(dir, "server.crt"),
+		keyPath:  filepath.Join(dir, "server.key"),
+		caPath:   filepath.Join(dir, "ca.crt"),
+	}
+
+	p.caKey = newTestKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &p.caKey.PublicKey, p.caKey)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() error = %v", err)
+	}
+	if p.ca, err = x509.ParseCertificate(der); err != nil {
+		t.Fatalf("x509.ParseCertificate() error = %v", err)
+	}
+	p.roots = x509.NewCertPool()
+	p.roots.AddCert(p.ca)
+	writePEM(t, p.caPath, "CERTIFICATE", der)
+
+	server := p.issue(t, "bufnet", x509.ExtKeyUsageServerAuth)
+	writePEM(t, p.certPath, "CERTIFICATE", server.Certificate[0])
+	keyDER, err := x509.MarshalECPrivateKey(server.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatalf("x509.MarshalECPrivateKey() error = %v", err)
+	}
+	writePEM(t, p.keyPath, "EC PRIVATE KEY", keyDER)
+
+	return p
+}
+
+// issue returns a certificate for commonName signed by the CA.
+func (p *testPKI) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+
+	key := newTestKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, p.ca, &key.PublicKey, p.caKey)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() error = %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// clientTLS returns a client config trusting the CA that presents a client
+// certificate for commonName, or none when commonName is empty.
+func (p *testPKI) clientTLS(t *testing.T, commonName string) *tls.Config {
+	t.Helper()
+
+	cfg := &tls.Config{RootCAs: p.roots, ServerName: "bufnet", MinVersion: tls.VersionTLS12}
+	if commonName != "" {
+		cfg.Certificates = []tls.Certificate{p.issue(t, commonName, x509.ExtKeyUsageClientAuth)}
+	}
+
+	return cfg
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+
+	return key
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+}
+
+// handshake runs a TLS handshake between server and client over loopback
+// and returns the client's view of it. A client certificate rejected by the
+// server only surfaces server-side under TLS 1.3, so both errors count.
+func handshake(t *testing.T, server, client *tls.Config) (tls.ConnectionState, error) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer lis.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- tls.Server(conn, server).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", lis.Addr().String(), client)
+	if err != nil {
+		<-serverErr
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+
+	if err := <-serverErr; err != nil {
+		return tls.ConnectionState{}, err
+	}
+
+	return conn.ConnectionState(), nil
+}
+
+func TestCertReloaderClientAuth(t *testing.T) {
+	t.Parallel()
+
+	pki := newTestPKI(t)
+
+	t.Run("without a client ca", func(t *testing.T) {
+		t.Parallel()
+
+		certs, err := NewCertReloader(pki.certPath, pki.keyPath, "")
+		if err != nil {
+			t.Fatalf("NewCertReloader() error = %v", err)
+		}
+
+		if _, err := handshake(t, certs.TLSConfig(), pki.clientTLS(t, "")); err != nil {
+			t.Fatalf("expected a handshake without a client certificate, got %v", err)
+		}
+	})
+
+	t.Run("with a client ca", func(t *testing.T) {
+		t.Parallel()
+
+		certs, err := NewCertReloader(pki.certPath, pki.keyPath, pki.caPath)
+		if err != nil {
+			t.Fatalf("NewCertReloader() error = %v", err)
+		}
+
+		if _, err := handshake(t, certs.TLSConfig(), pki.clientTLS(t, "")); err == nil {
+			t.Fatalf("expected the handshake to fail without a client certificate")
+		}
+
+		client := pki.clientTLS(t, "fleet-a")
+		client.NextProtos = []string{"h2"}
+		state, err := handshake(t, certs.TLSConfig(), client)
+		if err != nil {
+			t.Fatalf("expected a handshake with a client certificate, got %v", err)
+		}
+		if state.NegotiatedProtocol != "h2" {
+			t.Fatalf("expected h2 to be negotiated, got %q", state.NegotiatedProtocol)
+		}
+
+		foreign := newTestPKI(t).clientTLS(t, "fleet-a")
+		foreign.RootCAs = pki.roots
+		if _, err := handshake(t, certs.TLSConfig(), foreign); err == nil {
+			t.Fatalf("expected the handshake to fail with a certificate from another ca")
+		}
+	})
+
+	t.Run("missing client ca", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewCertReloader(pki.certPath, pki.keyPath, filepath.Join(t.TempDir(), "missing.crt")); err == nil {
+			t.Fatalf("expected an error for a missing client ca")
+		}
+	})
+}
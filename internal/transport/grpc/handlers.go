@@ -4,23 +4,19 @@ import (
 	"context"
 	"errors"
 	"log/slog"
-	"time"
 
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
 	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	gogrpc "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-func (s *Server) RegisterRelay(ctx context.Context, req *registryv1.RegisterRelayRequest) (*registryv1.RegisterRelayResponse, error) {
-	start := time.Now()
-	defer func() {
-		slog.LogAttrs(ctx, slog.LevelInfo, "request completed",
-			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
-			slog.String("method", "RegisterRelay"),
-		)
-	}()
+// Request logging, request IDs, panic recovery, deadlines and rate limits
+// are applied by the interceptor chain installed in New; handlers only
+// validate requests and call into the registry.
 
+func (s *Server) RegisterRelay(ctx context.Context, req *registryv1.RegisterRelayRequest) (*registryv1.RegisterRelayResponse, error) {
 	if req.Relay == nil {
 		return nil, status.Error(codes.InvalidArgument, "Relay is required")
 	}
@@ -33,13 +29,6 @@ func (s *Server) RegisterRelay(ctx context.Context, req *registryv1.RegisterRela
 		return nil, status.Error(codes.InvalidArgument, "Address is required")
 	}
 
-	slog.LogAttrs(ctx, slog.LevelInfo, "received request",
-		slog.String("method", "RegisterRelay"),
-		slog.String("relay_id", req.Relay.RelayId),
-		slog.String("address", req.Relay.Address),
-		slog.Int64("grpc_port", int64(req.Relay.GrpcPort)),
-	)
-
 	relay := registry.Relay{
 		ID:       req.Relay.RelayId,
 		Address:  req.Relay.Address,
@@ -47,9 +36,6 @@ func (s *Server) RegisterRelay(ctx context.Context, req *registryv1.RegisterRela
 	}
 
 	if err := s.registry.RegisterRelay(ctx, relay); err != nil {
-		slog.LogAttrs(ctx, slog.LevelError, "relay registration failed",
-			slog.String("error", err.Error()))
-
 		return nil, toStatusError(err)
 	}
 
@@ -57,62 +43,26 @@ func (s *Server) RegisterRelay(ctx context.Context, req *registryv1.RegisterRela
 }
 
 func (s *Server) HeartbeatRelay(ctx context.Context, req *registryv1.HeartbeatRelayRequest) (*registryv1.HeartbeatRelayResponse, error) {
-	start := time.Now()
-	defer func() {
-		slog.LogAttrs(ctx, slog.LevelDebug, "request completed",
-			slog.String("method", "HeartbeatRelay"),
-			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
-		)
-	}()
-
 	if req.RelayId == "" {
 		return nil, status.Errorf(codes.InvalidArgument, "RelayId is required")
 	}
 
-	slog.LogAttrs(ctx, slog.LevelDebug, "received request",
-		slog.String("method", "HeartbeatRelay"),
-		slog.String("relay_id", req.RelayId),
-		slog.Time("ts", start),
-	)
-
-	resp := &registryv1.HeartbeatRelayResponse{}
-
 	if err := s.registry.HeartbeatRelay(ctx, req.RelayId); err != nil {
-		slog.LogAttrs(ctx, slog.LevelError, "failed to track relay heartbeat",
-			slog.String("error", err.Error()),
-			slog.String("relay_id", req.RelayId),
-		)
-
 		return nil, toStatusError(err)
 	}
 
-	return resp, nil
+	return &registryv1.HeartbeatRelayResponse{}, nil
 }
 
 func (s *Server) ListRelays(ctx context.Context, req *registryv1.ListRelaysRequest) (*registryv1.ListRelaysResponse, error) {
-	start := time.Now()
-	defer func() {
-		slog.LogAttrs(ctx, slog.LevelInfo, "request completed",
-			slog.String("method", "ListRelays"),
-			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
-		)
-	}()
-
-	slog.LogAttrs(ctx, slog.LevelInfo, "received request",
-		slog.String("method", "ListRelays"),
-	)
-
-	resp := &registryv1.ListRelaysResponse{}
-
 	relays, err := s.registry.ListRelays(ctx)
 	if err != nil {
-		slog.LogAttrs(ctx, slog.LevelError, "failed to list relays",
-			slog.String("error", err.Error()),
-		)
 		return nil, toStatusError(err)
 	}
 
-	resp.Relays = make([]*registryv1.Relay, len(relays))
+	resp := &registryv1.ListRelaysResponse{
+		Relays: make([]*registryv1.Relay, len(relays)),
+	}
 	for i, relay := range relays {
 		resp.Relays[i] = &registryv1.Relay{
 			Address:             relay.Address,
@@ -126,14 +76,6 @@ func (s *Server) ListRelays(ctx context.Context, req *registryv1.ListRelaysReque
 }
 
 func (s *Server) RegisterAgent(ctx context.Context, req *registryv1.RegisterAgentRequest) (*registryv1.RegisterAgentResponse, error) {
-	start := time.Now()
-	defer func() {
-		slog.LogAttrs(ctx, slog.LevelInfo, "request completed",
-			slog.String("method", "RegisterAgent"),
-			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
-		)
-	}()
-
 	if req.RelayId == "" {
 		return nil, status.Errorf(codes.InvalidArgument, "RelayId is required")
 	}
@@ -146,22 +88,17 @@ func (s *Server) RegisterAgent(ctx context.Context, req *registryv1.RegisterAgen
 		return nil, status.Errorf(codes.InvalidArgument, "AgentId is required")
 	}
 
-	slog.LogAttrs(ctx, slog.LevelInfo, "received request",
-		slog.String("method", "RegisterAgent"),
-		slog.String("agent_id", req.Agent.AgentId),
-		slog.String("relay_id", req.RelayId),
-	)
+	agentMetadata, err := incomingAgentMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	agent := registry.Agent{
-		ID: req.Agent.AgentId,
+		ID:       req.Agent.AgentId,
+		Metadata: agentMetadata,
 	}
 
 	if err := s.registry.RegisterAgent(ctx, agent, req.RelayId); err != nil {
-		slog.LogAttrs(ctx, slog.LevelError, "agent registration failed",
-			slog.String("error", err.Error()),
-			slog.String("agent_id", req.Agent.AgentId),
-			slog.String("relay_id", req.RelayId),
-		)
 		return nil, toStatusError(err)
 	}
 
@@ -169,28 +106,11 @@ func (s *Server) RegisterAgent(ctx context.Context, req *registryv1.RegisterAgen
 }
 
 func (s *Server) HeartbeatAgent(ctx context.Context, req *registryv1.HeartbeatAgentRequest) (*registryv1.HeartbeatAgentResponse, error) {
-	start := time.Now()
-	defer func() {
-		slog.LogAttrs(ctx, slog.LevelDebug, "request completed",
-			slog.String("method", "HeartbeatAgent"),
-			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
-		)
-	}()
-
 	if req.AgentId == "" {
 		return nil, status.Errorf(codes.InvalidArgument, "AgentId is required")
 	}
 
-	slog.LogAttrs(ctx, slog.LevelDebug, "received request",
-		slog.String("method", "HeartbeatAgent"),
-		slog.String("agent_id", req.AgentId),
-	)
-
 	if err := s.registry.HeartbeatAgent(ctx, req.AgentId); err != nil {
-		slog.LogAttrs(ctx, slog.LevelError, "failed to track agent heartbeat",
-			slog.String("error", err.Error()),
-			slog.String("agent_id", req.AgentId),
-		)
 		return nil, toStatusError(err)
 	}
 
@@ -198,66 +118,40 @@ func (s *Server) HeartbeatAgent(ctx context.Context, req *registryv1.HeartbeatAg
 }
 
 func (s *Server) GetAgentPlacement(ctx context.Context, req *registryv1.GetAgentPlacementRequest) (*registryv1.GetAgentPlacementResponse, error) {
-	start := time.Now()
-	defer func() {
-		slog.LogAttrs(ctx, slog.LevelInfo, "request completed",
-			slog.String("method", "GetAgentPlacement"),
-			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
-		)
-	}()
-
 	if req.AgentId == "" {
 		return nil, status.Errorf(codes.InvalidArgument, "AgentId is required")
 	}
 
-	slog.LogAttrs(ctx, slog.LevelInfo, "received request",
-		slog.String("method", "GetAgentPlacement"),
-		slog.String("agent_id", req.AgentId),
-	)
-
-	resp := &registryv1.GetAgentPlacementResponse{}
-
 	placement, err := s.registry.GetAgentPlacement(ctx, req.AgentId)
 	if err != nil {
-		slog.LogAttrs(ctx, slog.LevelError, "failed to fetch agent placement",
-			slog.String("error", err.Error()),
-			slog.String("agent_id", req.AgentId),
-		)
 		return nil, toStatusError(err)
 	}
-
-	resp.Placement = &registryv1.AgentPlacement{
-		AgentId:           placement.AgentID,
-		RelayId:           placement.RelayID,
-		LastUpdatedUnixMs: placement.UpdatedAt.UnixMilli(),
+	if md := agentMetadataHeader(placement.Metadata); md.Len() > 0 {
+		_ = gogrpc.SetHeader(ctx, md)
 	}
-	return resp, nil
+
+	return &registryv1.GetAgentPlacementResponse{
+		Placement: &registryv1.AgentPlacement{
+			AgentId:           placement.AgentID,
+			RelayId:           placement.RelayID,
+			LastUpdatedUnixMs: placement.UpdatedAt.UnixMilli(),
+		},
+	}, nil
 }
 
+// ListAgents does not return agent metadata: the v1 Agent message has no
+// fields for it, and metadata for a whole fleet would exceed the size
+// limits many clients and proxies put on response metadata. It is served
+// by the admin ListRelayAgents RPC and the HTTP gateway instead.
 func (s *Server) ListAgents(ctx context.Context, req *registryv1.ListAgentsRequest) (*registryv1.ListAgentsResponse, error) {
-	start := time.Now()
-	defer func() {
-		slog.LogAttrs(ctx, slog.LevelInfo, "request completed",
-			slog.String("method", "ListAgents"),
-			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
-		)
-	}()
-
-	slog.LogAttrs(ctx, slog.LevelInfo, "received request",
-		slog.String("method", "ListAgents"),
-	)
-
-	resp := &registryv1.ListAgentsResponse{}
 	agents, err := s.registry.ListAgents(ctx)
 	if err != nil {
-		slog.LogAttrs(ctx, slog.LevelError, "failed to list agents",
-			slog.String("error", err.Error()),
-		)
 		return nil, toStatusError(err)
 	}
 
-	resp.Agents = make([]*registryv1.Agent, len(agents))
-
+	resp := &registryv1.ListAgentsResponse{
+		Agents: make([]*registryv1.Agent, len(agents)),
+	}
 	for i, agent := range agents {
 		resp.Agents[i] = &registryv1.Agent{
 			AgentId:             agent.ID,
@@ -282,6 +176,12 @@ func toStatusError(err error) error {
 		return status.Error(codes.InvalidArgument, err.Error())
 	case errors.Is(err, registry.ErrConflict):
 		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, registry.ErrUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, registry.ErrResourceExhausted):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, registry.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
 	default:
 		slog.Error("unclassified error", "err", err)
 		return status.Error(codes.Internal, "internal error")
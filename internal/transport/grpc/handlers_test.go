@@ -3,12 +3,15 @@ package grpc
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
 	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	gogrpc "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -31,7 +34,7 @@ type transportBackendStub struct {
 	lastAgentHeartbeat  string
 }
 
-func (b *transportBackendStub) RegisterRelay(ctx context.Context, relay registry.Relay) error {
+func (b *transportBackendStub) RegisterRelay(ctx context.Context, namespace string, relay registry.Relay) error {
 	b.lastRegisteredRelay = relay
 	if b.registerRelayFn != nil {
 		return b.registerRelayFn(ctx, relay)
@@ -39,7 +42,7 @@ func (b *transportBackendStub) RegisterRelay(ctx context.Context, relay registry
 	return nil
 }
 
-func (b *transportBackendStub) HeartbeatRelay(ctx context.Context, relayID string) error {
+func (b *transportBackendStub) HeartbeatRelay(ctx context.Context, namespace, relayID string) error {
 	b.lastRelayHeartbeat = relayID
 	if b.heartbeatRelayFn != nil {
 		return b.heartbeatRelayFn(ctx, relayID)
@@ -47,14 +50,14 @@ func (b *transportBackendStub) HeartbeatRelay(ctx context.Context, relayID strin
 	return nil
 }
 
-func (b *transportBackendStub) ListRelays(ctx context.Context) ([]registry.Relay, error) {
+func (b *transportBackendStub) ListRelays(ctx context.Context, namespace string) ([]registry.Relay, error) {
 	if b.listRelaysFn != nil {
 		return b.listRelaysFn(ctx)
 	}
 	return nil, nil
 }
 
-func (b *transportBackendStub) RegisterAgent(ctx context.Context, agent registry.Agent, relayID string) error {
+func (b *transportBackendStub) RegisterAgent(ctx context.Context, namespace string, agent registry.Agent, relayID string) error {
 	b.lastRegisteredAgent = agent
 	b.lastAgentRelayID = relayID
 	if b.registerAgentFn != nil {
@@ -63,7 +66,7 @@ func (b *transportBackendStub) RegisterAgent(ctx context.Context, agent registry
 	return nil
 }
 
-func (b *transportBackendStub) HeartbeatAgent(ctx context.Context, agentID string) error {
+func (b *transportBackendStub) HeartbeatAgent(ctx context.Context, namespace, agentID string) error {
 	b.lastAgentHeartbeat = agentID
 	if b.heartbeatAgentFn != nil {
 		return b.heartbeatAgentFn(ctx, agentID)
@@ -71,41 +74,57 @@ func (b *transportBackendStub) HeartbeatAgent(ctx context.Context, agentID strin
 	return nil
 }
 
-func (b *transportBackendStub) GetAgentPlacement(ctx context.Context, agentID string) (*registry.AgentPlacement, error) {
+func (b *transportBackendStub) GetAgentPlacement(ctx context.Context, namespace, agentID string) (*registry.AgentPlacement, error) {
 	if b.getPlacementFn != nil {
 		return b.getPlacementFn(ctx, agentID)
 	}
 	return nil, registry.ErrNotFound
 }
 
-func (b *transportBackendStub) ListAgents(ctx context.Context) ([]registry.Agent, error) {
+func (b *transportBackendStub) ListAgents(ctx context.Context, namespace string) ([]registry.Agent, error) {
 	if b.listAgentsFn != nil {
 		return b.listAgentsFn(ctx)
 	}
 	return nil, nil
 }
 
-func (b *transportBackendStub) ListRelayAgents(ctx context.Context, relayID string) ([]*registry.Agent, error) {
+func (b *transportBackendStub) ListRelayAgents(ctx context.Context, namespace, relayID string) ([]*registry.Agent, error) {
 	if b.listRelayAgentsFn != nil {
 		return b.listRelayAgentsFn(ctx, relayID)
 	}
 	return nil, nil
 }
 
-func (b *transportBackendStub) RemoveAgents(ctx context.Context, agentIDs []string) error {
+func (b *transportBackendStub) RemoveAgents(ctx context.Context, namespace string, agentIDs []string) error {
 	if b.removeAgentsFn != nil {
 		return b.removeAgentsFn(ctx, agentIDs)
 	}
 	return nil
 }
 
-func (b *transportBackendStub) RemoveRelay(ctx context.Context, relayID string) error {
+func (b *transportBackendStub) RemoveRelay(ctx context.Context, namespace, relayID string) error {
 	if b.removeRelayFn != nil {
 		return b.removeRelayFn(ctx, relayID)
 	}
 	return nil
 }
 
+func (b *transportBackendStub) ListNamespaces(ctx context.Context) ([]string, error) {
+	return []string{registry.DefaultNamespace}, nil
+}
+
+func (b *transportBackendStub) AcquireLease(ctx context.Context, name, holderID string, ttl time.Duration) (*registry.Lease, error) {
+	return nil, registry.ErrNotImplemented
+}
+
+func (b *transportBackendStub) RenewLease(ctx context.Context, lease registry.Lease, ttl time.Duration) (*registry.Lease, error) {
+	return nil, registry.ErrNotImplemented
+}
+
+func (b *transportBackendStub) ReleaseLease(ctx context.Context, lease registry.Lease) error {
+	return registry.ErrNotImplemented
+}
+
 func (b *transportBackendStub) Close(ctx context.Context) error {
 	if b.closeFn != nil {
 		return b.closeFn(ctx)
@@ -256,6 +275,53 @@ func TestRegisterAgent(t *testing.T) {
 			t.Fatalf("unexpected agent payload: agent=%+v relay=%s", b.lastRegisteredAgent, b.lastAgentRelayID)
 		}
 	})
+
+	t.Run("forwards metadata", func(t *testing.T) {
+		t.Parallel()
+		b := &transportBackendStub{}
+		s := newTransportTestServer(t, b)
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			AgentKindMetadataKey, "drone",
+			AgentVersionMetadataKey, "px4-1.14.3",
+			AgentCapabilitiesMetadataKey, "mission_upload, video",
+			AgentCapabilitiesMetadataKey, "geofence",
+			AgentSessionStartMetadataKey, "1700000000000",
+		))
+		_, err := s.RegisterAgent(ctx, &registryv1.RegisterAgentRequest{
+			RelayId: "relay-1",
+			Agent:   &registryv1.Agent{AgentId: "agent-1"},
+		})
+		if err != nil {
+			t.Fatalf("RegisterAgent() error = %v", err)
+		}
+
+		want := registry.AgentMetadata{
+			Kind:         registry.AgentKindDrone,
+			Version:      "px4-1.14.3",
+			Capabilities: []string{"mission_upload", "video", "geofence"},
+			SessionStart: time.UnixMilli(1700000000000),
+		}
+		if got := b.lastRegisteredAgent.Metadata; !reflect.DeepEqual(got, want) {
+			t.Fatalf("unexpected agent metadata: got %+v want %+v", got, want)
+		}
+	})
+
+	t.Run("rejects invalid metadata", func(t *testing.T) {
+		t.Parallel()
+		s := newTransportTestServer(t, &transportBackendStub{})
+		for _, md := range []metadata.MD{
+			metadata.Pairs(AgentKindMetadataKey, "submarine"),
+			metadata.Pairs(AgentSessionStartMetadataKey, "yesterday"),
+		} {
+			_, err := s.RegisterAgent(metadata.NewIncomingContext(context.Background(), md), &registryv1.RegisterAgentRequest{
+				RelayId: "relay-1",
+				Agent:   &registryv1.Agent{AgentId: "agent-1"},
+			})
+			if status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("expected InvalidArgument for %v, got %v", md, err)
+			}
+		}
+	})
 }
 
 func TestHeartbeatAgent(t *testing.T) {
@@ -302,6 +368,43 @@ func TestGetAgentPlacement(t *testing.T) {
 		}
 	})
 
+	t.Run("returns metadata headers", func(t *testing.T) {
+		t.Parallel()
+		b := &transportBackendStub{
+			getPlacementFn: func(ctx context.Context, agentID string) (*registry.AgentPlacement, error) {
+				return &registry.AgentPlacement{
+					AgentID:   "agent-1",
+					RelayID:   "relay-1",
+					UpdatedAt: time.Now(),
+					Metadata: registry.AgentMetadata{
+						Kind:         registry.AgentKindGroundStation,
+						Capabilities: []string{"mission_upload", "video"},
+					},
+				}, nil
+			},
+		}
+		s, err := New(newTransportTestServer(t, b).registry)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		t.Cleanup(s.GracefulStop)
+		client := registryv1.NewAeroRegistryClient(serveBufconn(t, s))
+
+		var header metadata.MD
+		if _, err := client.GetAgentPlacement(context.Background(), &registryv1.GetAgentPlacementRequest{AgentId: "agent-1"}, gogrpc.Header(&header)); err != nil {
+			t.Fatalf("GetAgentPlacement() error = %v", err)
+		}
+		if got := header.Get(AgentKindMetadataKey); len(got) != 1 || got[0] != "ground_station" {
+			t.Fatalf("expected the agent kind header, got %v", got)
+		}
+		if got := header.Get(AgentCapabilitiesMetadataKey); len(got) != 1 || got[0] != "mission_upload,video" {
+			t.Fatalf("expected the capabilities header, got %v", got)
+		}
+		if got := header.Get(AgentVersionMetadataKey); len(got) != 0 {
+			t.Fatalf("expected no version header for an unreported version, got %v", got)
+		}
+	})
+
 	t.Run("maps not found", func(t *testing.T) {
 		t.Parallel()
 		s := newTransportTestServer(t, &transportBackendStub{
@@ -360,6 +463,9 @@ func TestToStatusError(t *testing.T) {
 		{name: "not found", err: registry.ErrNotFound, code: codes.NotFound},
 		{name: "invalid", err: registry.ErrInvalid, code: codes.InvalidArgument},
 		{name: "conflict", err: registry.ErrConflict, code: codes.AlreadyExists},
+		{name: "unavailable", err: registry.ErrUnavailable, code: codes.Unavailable},
+		{name: "resource exhausted", err: registry.ErrResourceExhausted, code: codes.ResourceExhausted},
+		{name: "permission denied", err: registry.ErrPermissionDenied, code: codes.PermissionDenied},
 		{name: "internal fallback", err: errors.New("boom"), code: codes.Internal},
 	}
 
@@ -0,0 +1,283 @@
+package grpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDMetadataKey is the metadata key carrying the request ID. An ID
+// supplied by the caller is reused; otherwise one is generated. The ID is
+// echoed back in the response headers.
+const RequestIDMetadataKey = "x-request-id"
+
+// Response header keys set when a read was answered from last-known state
+// during a backend outage. The second carries the Unix time in
+// milliseconds at which that state was read from the backend.
+const (
+	StaleMetadataKey     = "x-registry-stale"
+	StaleAsOfMetadataKey = "x-registry-stale-as-of-unix-ms"
+)
+
+// maxRequestIDLength caps caller-supplied request IDs.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the ID assigned to the current request, or
+// an empty string outside a request.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// unaryInterceptors returns the built-in unary chain followed by the
+// interceptors supplied through options. Order matters: request IDs are
+// assigned first so every later stage can log them, recovery sits inside
+// logging so a recovered panic is logged as Internal, and the namespace is
+// resolved before any stage that reaches the registry.
+func (s *Server) unaryInterceptors(o options) []gogrpc.UnaryServerInterceptor {
+	return append([]gogrpc.UnaryServerInterceptor{
+		requestIDUnaryInterceptor,
+		loggingUnaryInterceptor(o.observers),
+		recoveryUnaryInterceptor,
+		s.namespaceUnaryInterceptor,
+		deadlineUnaryInterceptor(o.requestTimeout),
+		s.limiter.unaryInterceptor,
+		staleReadUnaryInterceptor,
+	}, o.unary...)
+}
+
+func (s *Server) streamInterceptors(o options) []gogrpc.StreamServerInterceptor {
+	return append([]gogrpc.StreamServerInterceptor{
+		requestIDStreamInterceptor,
+		loggingStreamInterceptor(o.observers),
+		recoveryStreamInterceptor,
+		s.namespaceStreamInterceptor,
+	}, o.stream...)
+}
+
+// The request ID interceptors also attribute the registry changes a request
+// makes to its caller, for the audit log.
+
+func requestIDUnaryInterceptor(ctx context.Context, req any, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (any, error) {
+	id := incomingRequestID(ctx)
+	ctx = withRequestID(ctx, id)
+	_ = gogrpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadataKey, id))
+
+	return handler(ctx, req)
+}
+
+func requestIDStreamInterceptor(srv any, ss gogrpc.ServerStream, info *gogrpc.StreamServerInfo, handler gogrpc.StreamHandler) error {
+	id := incomingRequestID(ss.Context())
+	_ = ss.SetHeader(metadata.Pairs(RequestIDMetadataKey, id))
+
+	return handler(srv, &contextStream{
+		ServerStream: ss,
+		ctx:          withRequestID(ss.Context(), id),
+	})
+}
+
+func withRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return registry.WithCaller(ctx, registry.Caller{Identity: peerIdentity(ctx), RequestID: id})
+}
+
+func incomingRequestID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(RequestIDMetadataKey); len(values) > 0 && values[0] != "" && len(values[0]) <= maxRequestIDLength {
+		return values[0]
+	}
+
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+
+	return hex.EncodeToString(b[:])
+}
+
+// staleReadUnaryInterceptor flags responses served from last-known state
+// with the stale response headers.
+func staleReadUnaryInterceptor(ctx context.Context, req any, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (any, error) {
+	ctx, read := registry.WithStaleRead(ctx)
+	resp, err := handler(ctx, req)
+	if asOf, stale := read.Stale(); stale && err == nil {
+		_ = gogrpc.SetHeader(ctx, metadata.Pairs(
+			StaleMetadataKey, "true",
+			StaleAsOfMetadataKey, strconv.FormatInt(asOf.UnixMilli(), 10),
+		))
+	}
+
+	return resp, err
+}
+
+func loggingUnaryInterceptor(observers []RequestObserver) gogrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		completeRequest(ctx, info.FullMethod, requestAttrs(req), start, err, observers)
+
+		return resp, err
+	}
+}
+
+func loggingStreamInterceptor(observers []RequestObserver) gogrpc.StreamServerInterceptor {
+	return func(srv any, ss gogrpc.ServerStream, info *gogrpc.StreamServerInfo, handler gogrpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		completeRequest(ss.Context(), info.FullMethod, nil, start, err, observers)
+
+		return err
+	}
+}
+
+// completeRequest notifies observers and logs the outcome of an RPC.
+// Heartbeats are logged at debug level unless they fail with a server-side
+// error.
+func completeRequest(ctx context.Context, fullMethod string, attrs []slog.Attr, start time.Time, err error, observers []RequestObserver) {
+	latency := time.Since(start)
+	code := status.Code(err)
+
+	for _, observer := range observers {
+		observer.ObserveRequest(ctx, fullMethod, code, latency)
+	}
+
+	level := slog.LevelInfo
+	switch {
+	case isServerFault(code):
+		level = slog.LevelError
+	case rateClasses[fullMethod] == rateClassHeartbeat:
+		level = slog.LevelDebug
+	}
+
+	attrs = append([]slog.Attr{
+		slog.String("method", methodName(fullMethod)),
+		slog.String("request_id", RequestIDFromContext(ctx)),
+		slog.String("code", code.String()),
+		slog.Int64("latency_ms", latency.Milliseconds()),
+	}, attrs...)
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+
+	slog.LogAttrs(ctx, level, "request completed", attrs...)
+}
+
+func isServerFault(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// methodName trims the service prefix from a full gRPC method path.
+func methodName(fullMethod string) string {
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[i+1:]
+	}
+
+	return fullMethod
+}
+
+// requestAttrs extracts the relay and agent IDs a request refers to.
+func requestAttrs(req any) []slog.Attr {
+	var attrs []slog.Attr
+
+	switch r := req.(type) {
+	case *registryv1.RegisterRelayRequest:
+		attrs = append(attrs, slog.String("relay_id", r.GetRelay().GetRelayId()))
+	case *registryv1.RegisterAgentRequest:
+		attrs = append(attrs, slog.String("agent_id", r.GetAgent().GetAgentId()))
+	}
+
+	if r, ok := req.(interface{ GetRelayId() string }); ok && r.GetRelayId() != "" {
+		attrs = append(attrs, slog.String("relay_id", r.GetRelayId()))
+	}
+
+	if r, ok := req.(interface{ GetAgentId() string }); ok && r.GetAgentId() != "" {
+		attrs = append(attrs, slog.String("agent_id", r.GetAgentId()))
+	}
+
+	return attrs
+}
+
+func recoveryUnaryInterceptor(ctx context.Context, req any, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = recoveredPanic(ctx, info.FullMethod, p)
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+func recoveryStreamInterceptor(srv any, ss gogrpc.ServerStream, info *gogrpc.StreamServerInfo, handler gogrpc.StreamHandler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = recoveredPanic(ss.Context(), info.FullMethod, p)
+		}
+	}()
+
+	return handler(srv, ss)
+}
+
+func recoveredPanic(ctx context.Context, fullMethod string, p any) error {
+	slog.LogAttrs(ctx, slog.LevelError, "recovered panic in grpc handler",
+		slog.String("method", methodName(fullMethod)),
+		slog.String("request_id", RequestIDFromContext(ctx)),
+		slog.String("panic", fmt.Sprint(p)),
+		slog.String("stack", string(debug.Stack())),
+	)
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+// deadlineUnaryInterceptor applies timeout to requests without a deadline,
+// rejects requests whose deadline has already passed, and reports context
+// errors returned by handlers with the matching status code.
+func deadlineUnaryInterceptor(timeout time.Duration) gogrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (any, error) {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+
+		resp, err := handler(ctx, req)
+		if _, isStatus := status.FromError(err); !isStatus &&
+			(errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+			return nil, status.FromContextError(err).Err()
+		}
+
+		return resp, err
+	}
+}
+
+// contextStream overrides the context of a server stream.
+type contextStream struct {
+	gogrpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
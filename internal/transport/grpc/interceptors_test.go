@@ -0,0 +1,224 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRecoveryInterceptorReturnsInternal(t *testing.T) {
+	t.Parallel()
+
+	info := &gogrpc.UnaryServerInfo{FullMethod: registryv1.AeroRegistry_ListRelays_FullMethodName}
+	_, err := recoveryUnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		panic("boom")
+	})
+
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected Internal, got %v", err)
+	}
+}
+
+func TestDeadlineInterceptor(t *testing.T) {
+	t.Parallel()
+
+	info := &gogrpc.UnaryServerInfo{FullMethod: registryv1.AeroRegistry_ListRelays_FullMethodName}
+
+	t.Run("applies default timeout", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := deadlineUnaryInterceptor(time.Minute)
+		_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Fatal("expected handler context to carry a deadline")
+			}
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	})
+
+	t.Run("rejects expired requests", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		called := false
+		_, err := deadlineUnaryInterceptor(time.Minute)(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			called = true
+			return nil, nil
+		})
+		if called {
+			t.Fatal("expected handler not to be called")
+		}
+		if status.Code(err) != codes.DeadlineExceeded {
+			t.Fatalf("expected DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("maps handler context errors", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := deadlineUnaryInterceptor(0)(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, context.Canceled
+		})
+		if status.Code(err) != codes.Canceled {
+			t.Fatalf("expected Canceled, got %v", err)
+		}
+	})
+}
+
+func TestInterceptorChain(t *testing.T) {
+	t.Parallel()
+
+	observer := &observerStub{}
+	panicking := func(ctx context.Context, req any, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (any, error) {
+		if info.FullMethod == registryv1.AeroRegistry_ListAgents_FullMethodName {
+			panic("boom")
+		}
+		return handler(ctx, req)
+	}
+
+	s, err := New(newTransportTestRegistryForServer(t),
+		WithRequestObserver(observer),
+		WithUnaryInterceptors(panicking),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(s.GracefulStop)
+
+	client := registryv1.NewAeroRegistryClient(serveBufconn(t, s))
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDMetadataKey, "req-123")
+	if _, err := client.ListRelays(ctx, &registryv1.ListRelaysRequest{}, gogrpc.Header(&header)); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := header.Get(RequestIDMetadataKey); len(got) != 1 || got[0] != "req-123" {
+		t.Fatalf("expected caller request id echoed, got %v", got)
+	}
+
+	header = nil
+	if _, err := client.ListRelays(context.Background(), &registryv1.ListRelaysRequest{}, gogrpc.Header(&header)); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := header.Get(RequestIDMetadataKey); len(got) != 1 || len(got[0]) != 16 {
+		t.Fatalf("expected generated request id, got %v", got)
+	}
+
+	_, err = client.ListAgents(context.Background(), &registryv1.ListAgentsRequest{})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected Internal after panic, got %v", err)
+	}
+
+	// The server keeps serving after a recovered panic.
+	if _, err := client.HeartbeatRelay(context.Background(), &registryv1.HeartbeatRelayRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+
+	want := map[string]codes.Code{
+		registryv1.AeroRegistry_ListAgents_FullMethodName:     codes.Internal,
+		registryv1.AeroRegistry_HeartbeatRelay_FullMethodName: codes.InvalidArgument,
+	}
+	for method, code := range want {
+		if got, ok := observer.code(method); !ok || got != code {
+			t.Fatalf("expected observer to record %s for %s, got %v (seen %v)", code, method, got, ok)
+		}
+	}
+}
+
+type observerStub struct {
+	mu    sync.Mutex
+	codes map[string]codes.Code
+}
+
+func (o *observerStub) ObserveRequest(ctx context.Context, method string, code codes.Code, latency time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.codes == nil {
+		o.codes = make(map[string]codes.Code)
+	}
+	o.codes[method] = code
+}
+
+func (o *observerStub) code(method string) (codes.Code, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	code, ok := o.codes[method]
+	return code, ok
+}
+
+func TestStaleReadHeaders(t *testing.T) {
+	t.Parallel()
+
+	cfg := &registry.Config{
+		Backend: registry.BackendConfig{Type: registry.MemoryRegistryBackend},
+		GRPC: registry.GRPCConfig{
+			ListenAddress: "127.0.0.1",
+			ListenPort:    50051,
+		},
+		TTL: registry.TTLConfig{
+			Relay: 5 * time.Second,
+			Agent: 5 * time.Second,
+		},
+		Degraded: registry.DegradedConfig{Enabled: true},
+	}
+
+	var down atomic.Bool
+	reg, err := registry.New(cfg, &transportBackendStub{
+		listRelaysFn: func(ctx context.Context) ([]registry.Relay, error) {
+			if down.Load() {
+				return nil, registry.ErrUnavailable
+			}
+			return []registry.Relay{{ID: "r1"}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("registry.New() error = %v", err)
+	}
+	s, err := New(reg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(s.GracefulStop)
+
+	client := registryv1.NewAeroRegistryClient(serveBufconn(t, s))
+
+	var header metadata.MD
+	if _, err := client.ListRelays(context.Background(), &registryv1.ListRelaysRequest{}, gogrpc.Header(&header)); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := header.Get(StaleMetadataKey); len(got) != 0 {
+		t.Fatalf("expected fresh response without stale header, got %v", got)
+	}
+
+	down.Store(true)
+	header = nil
+	resp, err := client.ListRelays(context.Background(), &registryv1.ListRelaysRequest{}, gogrpc.Header(&header))
+	if err != nil {
+		t.Fatalf("expected stale response, got %v", err)
+	}
+	if len(resp.GetRelays()) != 1 {
+		t.Fatalf("expected 1 relay, got %d", len(resp.GetRelays()))
+	}
+	if got := header.Get(StaleMetadataKey); len(got) != 1 || got[0] != "true" {
+		t.Fatalf("expected stale header, got %v", got)
+	}
+	if got := header.Get(StaleAsOfMetadataKey); len(got) != 1 || got[0] == "" {
+		t.Fatalf("expected stale as-of header, got %v", got)
+	}
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// NamespaceMetadataKey is the metadata key naming the tenant namespace a
+// request is scoped to. Callers bound to a tenant default to its namespace
+// and may only name another one when they also present the admin token.
+const NamespaceMetadataKey = "x-registry-namespace"
+
+func (s *Server) namespaceUnaryInterceptor(ctx context.Context, req any, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (any, error) {
+	ctx, err := s.withNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *Server) namespaceStreamInterceptor(srv any, ss gogrpc.ServerStream, info *gogrpc.StreamServerInfo, handler gogrpc.StreamHandler) error {
+	ctx, err := s.withNamespace(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// withNamespace scopes ctx to the namespace the caller may use.
+func (s *Server) withNamespace(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var requested string
+	if values := md.Get(NamespaceMetadataKey); len(values) > 0 {
+		requested = strings.TrimSpace(values[0])
+	}
+
+	cfg := s.registry.Config()
+	privileged := cfg.Admin.Token != "" && validBearer(md, cfg.Admin.Token)
+	namespace, err := cfg.ResolveNamespace(peerIdentity(ctx), requested, privileged)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return registry.WithNamespace(ctx, namespace), nil
+}
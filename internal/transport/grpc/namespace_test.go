@@ -0,0 +1,141 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestNamespaceInterceptor(t *testing.T) {
+	t.Parallel()
+
+	pki := newTestPKI(t)
+	cfg := &registry.Config{
+		Backend: registry.BackendConfig{Type: registry.MemoryRegistryBackend},
+		GRPC: registry.GRPCConfig{
+			ListenAddress: "127.0.0.1",
+			ListenPort:    50051,
+			TLS: registry.TLSConfig{
+				Enabled:      true,
+				CertPath:     pki.certPath,
+				KeyPath:      pki.keyPath,
+				ClientCAPath: pki.caPath,
+			},
+		},
+		TTL: registry.TTLConfig{
+			Relay: 5 * time.Second,
+			Agent: 5 * time.Second,
+		},
+		Admin: registry.AdminConfig{Token: "s3cret"},
+		Tenants: []registry.TenantConfig{
+			{Namespace: "fleet-a", Identities: []string{"cert:fleet-a"}},
+			{Namespace: "fleet-b", Identities: []string{"cert:fleet-b"}},
+			{Namespace: "fleet-c"},
+		},
+	}
+
+	var (
+		mu   sync.Mutex
+		seen string
+	)
+	reg, err := registry.New(cfg, &transportBackendStub{
+		listRelaysFn: func(ctx context.Context) ([]registry.Relay, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen = registry.NamespaceFromContext(ctx)
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("registry.New() error = %v", err)
+	}
+	certs, err := NewCertReloader(pki.certPath, pki.keyPath, pki.caPath)
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+	s, err := New(reg, WithServerOptions(gogrpc.Creds(certs.TransportCredentials())))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(s.GracefulStop)
+
+	bound := registryv1.NewAeroRegistryClient(serveBufconnWithCredentials(t, s, credentials.NewTLS(pki.clientTLS(t, "fleet-a"))))
+	unbound := registryv1.NewAeroRegistryClient(serveBufconnWithCredentials(t, s, credentials.NewTLS(pki.clientTLS(t, "fleet-z"))))
+
+	tests := []struct {
+		name     string
+		client   registryv1.AeroRegistryClient
+		md       []string
+		wantCode codes.Code
+		want     string
+	}{
+		{
+			name:   "bound caller defaults to its tenant",
+			client: bound,
+			want:   "fleet-a",
+		},
+		{
+			name:   "bound caller names its tenant",
+			client: bound,
+			md:     []string{NamespaceMetadataKey, "fleet-a"},
+			want:   "fleet-a",
+		},
+		{
+			name:     "bound caller names another tenant",
+			client:   bound,
+			md:       []string{NamespaceMetadataKey, "fleet-b"},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:   "admin token unpins the caller",
+			client: bound,
+			md:     []string{NamespaceMetadataKey, "fleet-b", "authorization", "Bearer s3cret"},
+			want:   "fleet-b",
+		},
+		{
+			name:     "unknown namespace",
+			client:   bound,
+			md:       []string{NamespaceMetadataKey, "fleet-z", "authorization", "Bearer s3cret"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:   "unbound caller defaults to the default namespace",
+			client: unbound,
+			want:   registry.DefaultNamespace,
+		},
+		{
+			name:     "unbound caller names a tenant without identities",
+			client:   unbound,
+			md:       []string{NamespaceMetadataKey, "fleet-c"},
+			wantCode: codes.PermissionDenied,
+		},
+	}
+
+	for _, test := range tests {
+		mu.Lock()
+		seen = ""
+		mu.Unlock()
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), test.md...)
+		_, err := test.client.ListRelays(ctx, &registryv1.ListRelaysRequest{})
+		if status.Code(err) != test.wantCode {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.wantCode, err)
+		}
+
+		mu.Lock()
+		got := seen
+		mu.Unlock()
+		if got != test.want {
+			t.Fatalf("%s: expected the backend to be called in %q, got %q", test.name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// DefaultRequestTimeout bounds unary requests whose caller did not set a
+// deadline.
+const DefaultRequestTimeout = 30 * time.Second
+
+// RequestObserver is notified when every RPC completes, for example to
+// record request metrics. Implementations must be safe for concurrent use.
+type RequestObserver interface {
+	ObserveRequest(ctx context.Context, method string, code codes.Code, latency time.Duration)
+}
+
+// Option configures a Server created by New.
+type Option func(*options)
+
+type options struct {
+	serverOpts     []gogrpc.ServerOption
+	unary          []gogrpc.UnaryServerInterceptor
+	stream         []gogrpc.StreamServerInterceptor
+	observers      []RequestObserver
+	requestTimeout time.Duration
+	tracerProvider trace.TracerProvider
+}
+
+func defaultOptions() options {
+	return options{
+		requestTimeout: DefaultRequestTimeout,
+	}
+}
+
+// WithServerOptions passes opts to the underlying gRPC server, e.g. to
+// configure transport credentials. Interceptors must be supplied with
+// WithUnaryInterceptors and WithStreamInterceptors instead, so they run
+// inside the built-in chain.
+func WithServerOptions(opts ...gogrpc.ServerOption) Option {
+	return func(o *options) {
+		o.serverOpts = append(o.serverOpts, opts...)
+	}
+}
+
+// WithUnaryInterceptors appends interceptors that run after the built-in
+// logging, recovery, deadline and rate limiting interceptors.
+func WithUnaryInterceptors(interceptors ...gogrpc.UnaryServerInterceptor) Option {
+	return func(o *options) {
+		o.unary = append(o.unary, interceptors...)
+	}
+}
+
+// WithStreamInterceptors appends interceptors that run after the built-in
+// logging and recovery interceptors.
+func WithStreamInterceptors(interceptors ...gogrpc.StreamServerInterceptor) Option {
+	return func(o *options) {
+		o.stream = append(o.stream, interceptors...)
+	}
+}
+
+// WithRequestObserver registers an observer that is notified when every
+// RPC completes.
+func WithRequestObserver(observer RequestObserver) Option {
+	return func(o *options) {
+		o.observers = append(o.observers, observer)
+	}
+}
+
+// WithRequestTimeout sets the deadline applied to unary requests that
+// arrive without one. Zero leaves such requests unbounded.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.requestTimeout = timeout
+	}
+}
+
+// WithTracerProvider sets the provider RPC spans are created with. The
+// global OpenTelemetry provider and propagator are used when unset.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
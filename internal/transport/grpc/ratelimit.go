@@ -0,0 +1,228 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// rateBucketIdleTimeout is how long an unused client bucket is kept
+	// before it is discarded.
+	rateBucketIdleTimeout = 10 * time.Minute
+
+	// rateBucketPruneInterval is the minimum delay between idle bucket
+	// sweeps.
+	rateBucketPruneInterval = time.Minute
+
+	// rateBucketMaxCount bounds the number of buckets kept. When it is
+	// reached, idle buckets are pruned early and then the least recently
+	// used bucket is evicted, so a stream of new clients cannot grow the
+	// map without bound.
+	rateBucketMaxCount = 10000
+)
+
+type rateClass string
+
+const (
+	rateClassHeartbeat    rateClass = "heartbeat"
+	rateClassRegistration rateClass = "registration"
+	rateClassList         rateClass = "list"
+)
+
+// rateClasses maps rate limited methods to their limit class. Methods not
+// listed here, such as health checks and admin RPCs, are never limited.
+var rateClasses = map[string]rateClass{
+	registryv1.AeroRegistry_HeartbeatRelay_FullMethodName:    rateClassHeartbeat,
+	registryv1.AeroRegistry_HeartbeatAgent_FullMethodName:    rateClassHeartbeat,
+	registryv1.AeroRegistry_RegisterRelay_FullMethodName:     rateClassRegistration,
+	registryv1.AeroRegistry_RegisterAgent_FullMethodName:     rateClassRegistration,
+	registryv1.AeroRegistry_ListRelays_FullMethodName:        rateClassList,
+	registryv1.AeroRegistry_ListAgents_FullMethodName:        rateClassList,
+	registryv1.AeroRegistry_GetAgentPlacement_FullMethodName: rateClassList,
+}
+
+type rateBucketKey struct {
+	class  rateClass
+	client string
+}
+
+type rateBucket struct {
+	limiter  *rate.Limiter
+	limit    registry.RateLimit
+	lastUsed time.Time
+}
+
+// rateLimiter enforces per-client token buckets. Limits are read on every
+// request so configuration reloads take effect without a restart.
+type rateLimiter struct {
+	limits func() registry.RateLimitConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[rateBucketKey]*rateBucket
+	lastPrune time.Time
+}
+
+func newRateLimiter(limits func() registry.RateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		limits:  limits,
+		now:     time.Now,
+		buckets: make(map[rateBucketKey]*rateBucket),
+	}
+}
+
+func (l *rateLimiter) unaryInterceptor(ctx context.Context, req any, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (any, error) {
+	class, ok := rateClasses[info.FullMethod]
+	if !ok {
+		return handler(ctx, req)
+	}
+
+	limit := l.limitFor(class)
+	if limit.Rate <= 0 {
+		return handler(ctx, req)
+	}
+
+	client := peerIdentity(ctx)
+	if delay, ok := l.allow(rateBucketKey{class: class, client: client}, limit); !ok {
+		slog.LogAttrs(ctx, slog.LevelDebug, "request rate limited",
+			slog.String("method", info.FullMethod),
+			slog.String("client", client),
+			slog.Duration("retry_after", delay),
+		)
+		return nil, rateLimitedError(class, delay)
+	}
+
+	return handler(ctx, req)
+}
+
+func (l *rateLimiter) limitFor(class rateClass) registry.RateLimit {
+	limits := l.limits()
+	switch class {
+	case rateClassHeartbeat:
+		return limits.Heartbeat
+	case rateClassRegistration:
+		return limits.Registration
+	default:
+		return limits.List
+	}
+}
+
+// allow takes a token from the client's bucket. When the bucket is empty it
+// returns false and the delay until a token becomes available.
+func (l *rateLimiter) allow(key rateBucketKey, limit registry.RateLimit) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.pruneLocked(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= rateBucketMaxCount {
+			l.evictLocked(now)
+		}
+		b = &rateBucket{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.EffectiveBurst()), limit: limit}
+		l.buckets[key] = b
+	} else if b.limit != limit {
+		b.limiter.SetLimitAt(now, rate.Limit(limit.Rate))
+		b.limiter.SetBurstAt(now, limit.EffectiveBurst())
+		b.limit = limit
+	}
+	b.lastUsed = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return time.Duration(float64(time.Second) / limit.Rate), false
+	}
+
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay, false
+	}
+
+	return 0, true
+}
+
+func (l *rateLimiter) pruneLocked(now time.Time) {
+	if now.Sub(l.lastPrune) < rateBucketPruneInterval {
+		return
+	}
+	l.lastPrune = now
+
+	l.pruneIdleLocked(now)
+}
+
+func (l *rateLimiter) pruneIdleLocked(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.lastUsed) > rateBucketIdleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// evictLocked makes room for a new bucket once rateBucketMaxCount is
+// reached.
+func (l *rateLimiter) evictLocked(now time.Time) {
+	l.pruneIdleLocked(now)
+	if len(l.buckets) < rateBucketMaxCount {
+		return
+	}
+
+	var (
+		oldest   rateBucketKey
+		lastUsed time.Time
+	)
+	for key, b := range l.buckets {
+		if lastUsed.IsZero() || b.lastUsed.Before(lastUsed) {
+			oldest, lastUsed = key, b.lastUsed
+		}
+	}
+	delete(l.buckets, oldest)
+}
+
+// peerIdentity identifies the connected peer by its TLS client certificate
+// subject, or its host when it presents none. Rate limit buckets are keyed
+// on it rather than on IDs in the request, which the caller controls.
+func peerIdentity(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		if certs := tlsInfo.State.PeerCertificates; len(certs) > 0 && certs[0].Subject.CommonName != "" {
+			return "cert:" + certs[0].Subject.CommonName
+		}
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	return "peer:" + host
+}
+
+func rateLimitedError(class rateClass, delay time.Duration) error {
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("%s rate limit exceeded; retry after %s", class, delay.Round(time.Millisecond)))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
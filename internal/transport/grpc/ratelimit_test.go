@@ -0,0 +1,147 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(func() registry.RateLimitConfig { return registry.RateLimitConfig{} })
+	l.now = func() time.Time { return now }
+
+	limit := registry.RateLimit{Rate: 1, Burst: 2}
+	key := rateBucketKey{class: rateClassHeartbeat, client: "relay:relay-1"}
+
+	for i := range 2 {
+		if _, ok := l.allow(key, limit); !ok {
+			t.Fatalf("expected request %d within burst to be allowed", i)
+		}
+	}
+
+	delay, ok := l.allow(key, limit)
+	if ok {
+		t.Fatal("expected request beyond burst to be limited")
+	}
+	if delay <= 0 || delay > time.Second {
+		t.Fatalf("expected retry delay in (0, 1s], got %v", delay)
+	}
+
+	if _, ok := l.allow(rateBucketKey{class: rateClassHeartbeat, client: "relay:relay-2"}, limit); !ok {
+		t.Fatal("expected other clients to have their own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := l.allow(key, limit); !ok {
+		t.Fatal("expected a token after refill")
+	}
+}
+
+func TestRateLimiterPrunesIdleBuckets(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(func() registry.RateLimitConfig { return registry.RateLimitConfig{} })
+	l.now = func() time.Time { return now }
+
+	limit := registry.RateLimit{Rate: 1}
+	l.allow(rateBucketKey{class: rateClassList, client: "peer:10.0.0.1"}, limit)
+
+	now = now.Add(rateBucketIdleTimeout + time.Minute)
+	l.allow(rateBucketKey{class: rateClassList, client: "peer:10.0.0.2"}, limit)
+
+	if len(l.buckets) != 1 {
+		t.Fatalf("expected idle bucket to be pruned, got %d buckets", len(l.buckets))
+	}
+}
+
+func TestRateLimiterEvictsBeyondMaxCount(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(func() registry.RateLimitConfig { return registry.RateLimitConfig{} })
+	l.now = func() time.Time { return now }
+
+	limit := registry.RateLimit{Rate: 1}
+	first := rateBucketKey{class: rateClassList, client: "peer:first"}
+	l.allow(first, limit)
+	for i := range rateBucketMaxCount {
+		now = now.Add(time.Millisecond)
+		l.allow(rateBucketKey{class: rateClassList, client: fmt.Sprintf("peer:%d", i)}, limit)
+	}
+
+	if len(l.buckets) != rateBucketMaxCount {
+		t.Fatalf("expected %d buckets, got %d", rateBucketMaxCount, len(l.buckets))
+	}
+	if _, ok := l.buckets[first]; ok {
+		t.Fatal("expected the least recently used bucket to be evicted")
+	}
+}
+
+func TestRateLimitedRequestsReturnRetryInfo(t *testing.T) {
+	t.Parallel()
+
+	cfg := &registry.Config{
+		Backend: registry.BackendConfig{Type: registry.MemoryRegistryBackend},
+		GRPC:    registry.GRPCConfig{ListenAddress: "127.0.0.1", ListenPort: 50051},
+		TTL:     registry.TTLConfig{Relay: 5 * time.Second, Agent: 5 * time.Second},
+		RateLimit: registry.RateLimitConfig{
+			Heartbeat: registry.RateLimit{Rate: 0.001, Burst: 1},
+		},
+	}
+
+	reg, err := registry.New(cfg, &transportBackendStub{})
+	if err != nil {
+		t.Fatalf("registry.New() error = %v", err)
+	}
+
+	s, err := New(reg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(s.GracefulStop)
+
+	client := registryv1.NewAeroRegistryClient(serveBufconn(t, s))
+	ctx := context.Background()
+	req := &registryv1.HeartbeatRelayRequest{RelayId: "relay-1"}
+
+	if _, err := client.HeartbeatRelay(ctx, req); err != nil {
+		t.Fatalf("expected first heartbeat to succeed, got %v", err)
+	}
+
+	_, err = client.HeartbeatRelay(ctx, req)
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+
+	var retry *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if retry == nil || retry.GetRetryDelay().AsDuration() <= 0 {
+		t.Fatalf("expected retry info with a positive delay, got %v", st.Details())
+	}
+
+	// Buckets follow the connection, so a new relay ID gets no fresh tokens.
+	if _, err := client.HeartbeatRelay(ctx, &registryv1.HeartbeatRelayRequest{RelayId: "relay-2"}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted for a rotated relay id, got %v", err)
+	}
+
+	// List methods are limited separately and are unlimited here.
+	if _, err := client.ListRelays(ctx, &registryv1.ListRelaysRequest{}); err != nil {
+		t.Fatalf("expected list to be unaffected, got %v", err)
+	}
+}
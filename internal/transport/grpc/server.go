@@ -4,11 +4,14 @@
 package grpc
 
 import (
+	"context"
 	"net"
 
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
 	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
 	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -16,19 +19,54 @@ type Server struct {
 	registryv1.UnimplementedAeroRegistryServer
 	registry   *registry.Registry
 	grpcServer *gogrpc.Server
+	health     *health.Server
+	limiter    *rateLimiter
 }
 
 var _ registryv1.AeroRegistryServer = (*Server)(nil)
 
-func New(reg *registry.Registry, opts ...gogrpc.ServerOption) (*Server, error) {
+// New creates a registry gRPC server. Every RPC, including admin and
+// health checks, runs through the interceptor chain described in
+// interceptors.go; opts customise it and the underlying gRPC server.
+func New(reg *registry.Registry, opts ...Option) (*Server, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	s := &Server{
 		registry: reg,
 	}
 
-	s.grpcServer = gogrpc.NewServer(opts...)
+	s.limiter = newRateLimiter(func() registry.RateLimitConfig {
+		return reg.Config().RateLimit
+	})
+
+	serverOpts := append(o.serverOpts,
+		gogrpc.StatsHandler(newTracingHandler(o.tracerProvider)),
+		gogrpc.ChainUnaryInterceptor(s.unaryInterceptors(o)...),
+		gogrpc.ChainStreamInterceptor(s.streamInterceptors(o)...),
+	)
+
+	s.grpcServer = gogrpc.NewServer(serverOpts...)
 	registryv1.RegisterAeroRegistryServer(s.grpcServer, s)
 	reflection.Register(s.grpcServer)
 
+	s.health = health.NewServer()
+	s.health.SetServingStatus(registryv1.AeroRegistry_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s.grpcServer, s.health)
+
+	// The registry service reports NOT_SERVING while backend calls fail
+	// fast; the overall ("") status is left alone so the process is not
+	// restarted for an outage it cannot fix.
+	reg.OnBackendAvailabilityChange(func(available bool) {
+		status := healthpb.HealthCheckResponse_SERVING
+		if !available {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		s.health.SetServingStatus(registryv1.AeroRegistry_ServiceDesc.ServiceName, status)
+	})
+
 	return s, nil
 }
 
@@ -39,3 +77,32 @@ func (s *Server) Serve(lis net.Listener) error {
 func (s *Server) GracefulStop() {
 	s.grpcServer.GracefulStop()
 }
+
+// MarkNotServing reports NOT_SERVING for every service on the health
+// endpoint so load balancers stop routing new requests to this replica.
+// Requests keep being served until Shutdown is called.
+func (s *Server) MarkNotServing() {
+	s.health.Shutdown()
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight requests to
+// complete. If ctx expires first the remaining connections are closed
+// forcibly and ctx.Err() is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.MarkNotServing()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		<-stopped
+		return ctx.Err()
+	}
+}
@@ -1,12 +1,22 @@
 package grpc
 
 import (
+	"context"
 	"errors"
 	"net"
 	"testing"
 	"time"
 
 	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/resilience"
+	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 func TestNewServeAndGracefulStop(t *testing.T) {
@@ -28,6 +38,128 @@ func TestNewServeAndGracefulStop(t *testing.T) {
 	s.GracefulStop()
 }
 
+func TestShutdownReportsNotServing(t *testing.T) {
+	t.Parallel()
+
+	reg := newTransportTestRegistryForServer(t)
+	s, err := New(reg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	conn := serveBufconn(t, s)
+
+	client := healthpb.NewHealthClient(conn)
+	service := registryv1.AeroRegistry_ServiceDesc.ServiceName
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatalf("health check error = %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING, got %v", resp.GetStatus())
+	}
+
+	s.MarkNotServing()
+
+	resp, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatalf("health check error = %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING, got %v", resp.GetStatus())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+}
+
+func TestShutdownForcesStopAfterDeadline(t *testing.T) {
+	t.Parallel()
+
+	reg := newTransportTestRegistryForServer(t)
+	s, err := New(reg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	conn := serveBufconn(t, s)
+
+	// An open health watch stream keeps GracefulStop waiting.
+	stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("health watch error = %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("health watch recv error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestHealthFollowsBackendAvailability(t *testing.T) {
+	t.Parallel()
+
+	cfg := &registry.Config{
+		Backend: registry.BackendConfig{Type: registry.MemoryRegistryBackend},
+		GRPC: registry.GRPCConfig{
+			ListenAddress: "127.0.0.1",
+			ListenPort:    50051,
+		},
+		TTL: registry.TTLConfig{
+			Relay: 5 * time.Second,
+			Agent: 5 * time.Second,
+		},
+	}
+	backendErr := errors.New("connection refused")
+	backend := resilience.New(&transportBackendStub{
+		listRelaysFn: func(ctx context.Context) ([]registry.Relay, error) {
+			return nil, backendErr
+		},
+	}, registry.ResilienceConfig{MaxAttempts: 1, FailureThreshold: 1, OpenTimeout: time.Hour})
+
+	reg, err := registry.New(cfg, backend)
+	if err != nil {
+		t.Fatalf("registry.New() error = %v", err)
+	}
+	s, err := New(reg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(s.GracefulStop)
+
+	conn := serveBufconn(t, s)
+	service := registryv1.AeroRegistry_ServiceDesc.ServiceName
+
+	_, err = registryv1.NewAeroRegistryClient(conn).ListRelays(context.Background(), &registryv1.ListRelaysRequest{})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable, got %v", err)
+	}
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatalf("health check error = %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING, got %v", resp.GetStatus())
+	}
+
+	resp, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("health check error = %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected overall status SERVING, got %v", resp.GetStatus())
+	}
+}
+
 func newTransportTestRegistryForServer(t *testing.T) *registry.Registry {
 	t.Helper()
 
@@ -51,6 +183,34 @@ func newTransportTestRegistryForServer(t *testing.T) *registry.Registry {
 	return reg
 }
 
+// serveBufconn serves s on an in-memory listener and returns a client
+// connection to it.
+func serveBufconn(t *testing.T, s *Server) *gogrpc.ClientConn {
+	t.Helper()
+
+	return serveBufconnWithCredentials(t, s, insecure.NewCredentials())
+}
+
+func serveBufconnWithCredentials(t *testing.T, s *Server, creds credentials.TransportCredentials) *gogrpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = s.Serve(lis) }()
+
+	conn, err := gogrpc.NewClient("passthrough:///bufnet",
+		gogrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		gogrpc.WithTransportCredentials(creds),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
 type listenerStub struct {
 	acceptErr error
 }
@@ -0,0 +1,22 @@
+package grpc
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/stats"
+)
+
+// newTracingHandler creates a server span for every RPC except health
+// checks, continuing any trace propagated by the caller. The span context
+// flows through the request context into registry and backend spans.
+func newTracingHandler(tp trace.TracerProvider) stats.Handler {
+	opts := []otelgrpc.Option{
+		otelgrpc.WithFilter(filters.Not(filters.HealthCheck())),
+	}
+	if tp != nil {
+		opts = append(opts, otelgrpc.WithTracerProvider(tp))
+	}
+
+	return otelgrpc.NewServerHandler(opts...)
+}
@@ -0,0 +1,108 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/traced"
+	"github.com/Aero-Arc/aero-arc-registry/internal/telemetry"
+	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingSpanHierarchy(t *testing.T) {
+	t.Parallel()
+
+	tp, exporter := telemetry.NewInMemoryTracerProvider()
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	cfg := &registry.Config{
+		Backend: registry.BackendConfig{Type: registry.MemoryRegistryBackend},
+		GRPC: registry.GRPCConfig{
+			ListenAddress: "127.0.0.1",
+			ListenPort:    50051,
+		},
+		TTL: registry.TTLConfig{
+			Relay: 5 * time.Second,
+			Agent: 5 * time.Second,
+		},
+	}
+	backend := traced.New(&transportBackendStub{}, tp, registry.MemoryRegistryBackend)
+	reg, err := registry.New(cfg, backend, registry.WithTracerProvider(tp))
+	if err != nil {
+		t.Fatalf("registry.New() error = %v", err)
+	}
+
+	s, err := New(reg, WithTracerProvider(tp))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(s.GracefulStop)
+
+	client := registryv1.NewAeroRegistryClient(serveBufconn(t, s))
+	_, err = client.RegisterRelay(context.Background(), &registryv1.RegisterRelayRequest{
+		Relay: &registryv1.Relay{
+			RelayId:  "relay-1",
+			Address:  "127.0.0.1",
+			GrpcPort: 7000,
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterRelay() error = %v", err)
+	}
+
+	// The server span ends after the response is written, so wait for it.
+	var spans tracetest.SpanStubs
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		spans = exporter.GetSpans()
+		if findSpan(spans, "aeroarc.registry.v1.AeroRegistry/RegisterRelay") != nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	server := findSpan(spans, "aeroarc.registry.v1.AeroRegistry/RegisterRelay")
+	if server == nil {
+		t.Fatalf("expected server span, got %v", spanNames(spans))
+	}
+	regSpan := findSpan(spans, "Registry.RegisterRelay")
+	if regSpan == nil {
+		t.Fatalf("expected registry span, got %v", spanNames(spans))
+	}
+	backendSpan := findSpan(spans, "Backend.RegisterRelay")
+	if backendSpan == nil {
+		t.Fatalf("expected backend span, got %v", spanNames(spans))
+	}
+
+	if regSpan.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Fatalf("expected registry span to be a child of the server span")
+	}
+	if backendSpan.Parent.SpanID() != regSpan.SpanContext.SpanID() {
+		t.Fatalf("expected backend span to be a child of the registry span")
+	}
+	if backendSpan.SpanContext.TraceID() != server.SpanContext.TraceID() {
+		t.Fatalf("expected all spans to share one trace")
+	}
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+
+	return nil
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+
+	return names
+}
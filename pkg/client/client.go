@@ -0,0 +1,507 @@
+// Package client is a Go client for the Aero Arc relay registry. It wraps
+// the generated AeroRegistry gRPC client with the pieces every relay
+// otherwise reimplements: heartbeat loops that re-register after the
+// registry forgets a relay or agent, jittered backoff, failover across
+// registry endpoints and a local placement cache.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	registryv1 "github.com/aero-arc/aero-arc-protos/gen/go/aeroarc/registry/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// maxCachedPlacements bounds the local placement cache.
+const maxCachedPlacements = 10000
+
+// Metadata keys carrying agent metadata, which the v1 messages have no
+// fields for. They match the registry's gRPC transport.
+const (
+	agentKindMetadataKey         = "x-agent-kind"
+	agentVersionMetadataKey      = "x-agent-version"
+	agentCapabilitiesMetadataKey = "x-agent-capabilities"
+	agentSessionStartMetadataKey = "x-agent-session-start-unix-ms"
+)
+
+// namespaceMetadataKey carries the tenant namespace set by WithNamespace.
+// It matches the registry's gRPC transport.
+const namespaceMetadataKey = "x-registry-namespace"
+
+var (
+	ErrNoEndpoints   = errors.New("at least one registry endpoint is required")
+	ErrInvalidOption = errors.New("invalid client option")
+	ErrClosed        = errors.New("client is closed")
+)
+
+// Relay is a relay as known to the registry.
+type Relay struct {
+	ID       string
+	Address  string
+	GRPCPort int32
+	// LastHeartbeat is set on relays returned by ListRelays.
+	LastHeartbeat time.Time
+}
+
+// Agent is an agent as known to the registry.
+type Agent struct {
+	ID            string
+	LastHeartbeat time.Time
+}
+
+// AgentMetadata describes an agent so that callers can route around
+// incompatible agents. Every field is optional. The registry replaces it on
+// each registration.
+type AgentMetadata struct {
+	// Kind is "drone", "ground_station" or "edge".
+	Kind string
+
+	// Version is the agent's firmware or software version.
+	Version string
+
+	// Capabilities lists the features the agent supports. Capabilities
+	// must not contain commas.
+	Capabilities []string
+
+	// SessionStart is when the agent's current session started.
+	SessionStart time.Time
+}
+
+// HasCapability reports whether the agent reported capability.
+func (m AgentMetadata) HasCapability(capability string) bool {
+	return slices.Contains(m.Capabilities, capability)
+}
+
+// Placement records the relay an agent is placed on.
+type Placement struct {
+	AgentID   string
+	RelayID   string
+	UpdatedAt time.Time
+	Metadata  AgentMetadata
+}
+
+type endpoint struct {
+	target string
+	conn   *grpc.ClientConn
+	api    registryv1.AeroRegistryClient
+}
+
+type cachedPlacement struct {
+	placement Placement
+	expiresAt time.Time
+}
+
+// Client talks to one or more registry endpoints. Calls go to the current
+// endpoint and fail over to the next one when it is unreachable. A Client
+// is safe for concurrent use.
+type Client struct {
+	opts      options
+	endpoints []endpoint
+	current   atomic.Int64
+
+	// ctx is the parent of every heartbeat loop and is cancelled by Close.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+	loops  sync.WaitGroup
+
+	placementsMu sync.Mutex
+	placements   map[string]cachedPlacement
+	now          func() time.Time
+}
+
+// New returns a client for the registry replicas at endpoints, given as
+// gRPC targets such as "registry-a:50051". Connections are established
+// lazily, so New does not fail when an endpoint is down.
+func New(endpoints []string, opts ...Option) (*Client, error) {
+	if len(endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	// Plaintext unless the caller's dial options set credentials; later
+	// dial options take precedence.
+	dialOpts := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, o.dialOpts...)
+
+	c := &Client{
+		opts:       o,
+		endpoints:  make([]endpoint, 0, len(endpoints)),
+		placements: make(map[string]cachedPlacement),
+		now:        time.Now,
+	}
+	for _, target := range endpoints {
+		conn, err := grpc.NewClient(target, dialOpts...)
+		if err != nil {
+			_ = c.closeConns()
+			return nil, fmt.Errorf("connect to %s: %w", target, err)
+		}
+		c.endpoints = append(c.endpoints, endpoint{
+			target: target,
+			conn:   conn,
+			api:    registryv1.NewAeroRegistryClient(conn),
+		})
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	return c, nil
+}
+
+func (o *options) validate() error {
+	switch {
+	case o.heartbeatInterval <= 0:
+		return fmt.Errorf("%w: heartbeat interval must be positive", ErrInvalidOption)
+	case o.callTimeout <= 0:
+		return fmt.Errorf("%w: call timeout must be positive", ErrInvalidOption)
+	case o.minBackoff <= 0 || o.maxBackoff < o.minBackoff:
+		return fmt.Errorf("%w: backoff must be positive with max at least min", ErrInvalidOption)
+	case o.placementCacheTTL < 0:
+		return fmt.Errorf("%w: placement cache ttl must not be negative", ErrInvalidOption)
+	default:
+		return nil
+	}
+}
+
+// Close stops every heartbeat loop and closes the endpoint connections.
+// Relays and agents are left to expire in the registry.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.cancel()
+	c.loops.Wait()
+
+	return c.closeConns()
+}
+
+func (c *Client) closeConns() error {
+	var errs []error
+	for _, e := range c.endpoints {
+		if err := e.conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", e.target, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Endpoint returns the endpoint calls are currently sent to.
+func (c *Client) Endpoint() string {
+	return c.endpoints[c.current.Load()].target
+}
+
+// invoke runs call against the current endpoint, failing over to the
+// others in turn while the answer says the endpoint is unreachable. The
+// endpoint that answered becomes the current one.
+func (c *Client) invoke(ctx context.Context, method string, call func(context.Context, registryv1.AeroRegistryClient) error) error {
+	if c.ctx.Err() != nil {
+		return ErrClosed
+	}
+
+	if c.opts.namespace != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, namespaceMetadataKey, c.opts.namespace)
+	}
+
+	start := int(c.current.Load())
+	var err error
+	for i := range c.endpoints {
+		idx := (start + i) % len(c.endpoints)
+
+		callCtx, cancel := context.WithTimeout(ctx, c.opts.callTimeout)
+		err = call(callCtx, c.endpoints[idx].api)
+		cancel()
+
+		if !shouldFailover(ctx, err) {
+			if idx != start && c.current.CompareAndSwap(int64(start), int64(idx)) {
+				slog.LogAttrs(ctx, slog.LevelInfo, "registry endpoint failed over",
+					slog.String("method", method),
+					slog.String("from", c.endpoints[start].target),
+					slog.String("to", c.endpoints[idx].target),
+				)
+			}
+			return err
+		}
+	}
+
+	return err
+}
+
+// shouldFailover reports whether err means the endpoint could not answer,
+// rather than that it answered with an error. A caller's own cancellation
+// or deadline never fails over.
+func shouldFailover(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// RegisterRelay registers relay once. Use KeepRelayAlive to also keep it
+// registered.
+func (c *Client) RegisterRelay(ctx context.Context, relay Relay) error {
+	return c.invoke(ctx, "RegisterRelay", func(ctx context.Context, api registryv1.AeroRegistryClient) error {
+		_, err := api.RegisterRelay(ctx, &registryv1.RegisterRelayRequest{
+			Relay: &registryv1.Relay{
+				RelayId:  relay.ID,
+				Address:  relay.Address,
+				GrpcPort: relay.GRPCPort,
+			},
+		})
+		return err
+	})
+}
+
+// HeartbeatRelay renews the TTL of a registered relay. It fails with a
+// codes.NotFound status once the registry has forgotten the relay.
+func (c *Client) HeartbeatRelay(ctx context.Context, relayID string) error {
+	return c.invoke(ctx, "HeartbeatRelay", func(ctx context.Context, api registryv1.AeroRegistryClient) error {
+		_, err := api.HeartbeatRelay(ctx, &registryv1.HeartbeatRelayRequest{
+			RelayId:         relayID,
+			TimestampUnixMs: c.now().UnixMilli(),
+		})
+		return err
+	})
+}
+
+// ListRelays returns the live relays.
+func (c *Client) ListRelays(ctx context.Context) ([]Relay, error) {
+	var resp *registryv1.ListRelaysResponse
+	err := c.invoke(ctx, "ListRelays", func(ctx context.Context, api registryv1.AeroRegistryClient) (err error) {
+		resp, err = api.ListRelays(ctx, &registryv1.ListRelaysRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	relays := make([]Relay, len(resp.GetRelays()))
+	for i, relay := range resp.GetRelays() {
+		relays[i] = Relay{
+			ID:            relay.GetRelayId(),
+			Address:       relay.GetAddress(),
+			GRPCPort:      relay.GetGrpcPort(),
+			LastHeartbeat: time.UnixMilli(relay.GetLastHeartbeatUnixMs()),
+		}
+	}
+	return relays, nil
+}
+
+// RegisterAgent places agentID on relayID once and caches the placement.
+// Use KeepAgentAlive to also keep it registered.
+func (c *Client) RegisterAgent(ctx context.Context, agentID, relayID string) error {
+	return c.RegisterAgentWithMetadata(ctx, agentID, relayID, AgentMetadata{})
+}
+
+// RegisterAgentWithMetadata is RegisterAgent for an agent that reports
+// metadata.
+func (c *Client) RegisterAgentWithMetadata(ctx context.Context, agentID, relayID string, agentMetadata AgentMetadata) error {
+	md := agentMetadataPairs(agentMetadata)
+	err := c.invoke(ctx, "RegisterAgent", func(ctx context.Context, api registryv1.AeroRegistryClient) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, md...)
+		_, err := api.RegisterAgent(ctx, &registryv1.RegisterAgentRequest{
+			Agent:   &registryv1.Agent{AgentId: agentID},
+			RelayId: relayID,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	c.cachePlacement(Placement{AgentID: agentID, RelayID: relayID, UpdatedAt: c.now(), Metadata: agentMetadata})
+	return nil
+}
+
+// HeartbeatAgent renews the TTL of a registered agent. It fails with a
+// codes.NotFound status once the registry has forgotten the agent.
+func (c *Client) HeartbeatAgent(ctx context.Context, agentID string) error {
+	err := c.invoke(ctx, "HeartbeatAgent", func(ctx context.Context, api registryv1.AeroRegistryClient) error {
+		_, err := api.HeartbeatAgent(ctx, &registryv1.HeartbeatAgentRequest{
+			AgentId:         agentID,
+			TimestampUnixMs: c.now().UnixMilli(),
+		})
+		return err
+	})
+	if status.Code(err) == codes.NotFound {
+		c.InvalidatePlacement(agentID)
+	}
+	return err
+}
+
+// ListAgents returns the live agents. The v1 API has no room for agent
+// metadata in this call, so the returned agents carry none; use
+// GetAgentPlacement for one agent's metadata, or the admin ListRelayAgents
+// RPC or the HTTP gateway for many.
+func (c *Client) ListAgents(ctx context.Context) ([]Agent, error) {
+	var resp *registryv1.ListAgentsResponse
+	err := c.invoke(ctx, "ListAgents", func(ctx context.Context, api registryv1.AeroRegistryClient) (err error) {
+		resp, err = api.ListAgents(ctx, &registryv1.ListAgentsRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	agents := make([]Agent, len(resp.GetAgents()))
+	for i, agent := range resp.GetAgents() {
+		agents[i] = Agent{
+			ID:            agent.GetAgentId(),
+			LastHeartbeat: time.UnixMilli(agent.GetLastHeartbeatUnixMs()),
+		}
+	}
+	return agents, nil
+}
+
+// GetAgentPlacement returns the relay agentID is placed on. Answers are
+// served from the local cache for the placement cache TTL, so a placement
+// changed through another client may be seen late.
+func (c *Client) GetAgentPlacement(ctx context.Context, agentID string) (Placement, error) {
+	if placement, ok := c.cachedPlacement(agentID); ok {
+		return placement, nil
+	}
+
+	var (
+		resp   *registryv1.GetAgentPlacementResponse
+		header metadata.MD
+	)
+	err := c.invoke(ctx, "GetAgentPlacement", func(ctx context.Context, api registryv1.AeroRegistryClient) (err error) {
+		resp, err = api.GetAgentPlacement(ctx, &registryv1.GetAgentPlacementRequest{AgentId: agentID}, grpc.Header(&header))
+		return err
+	})
+	if status.Code(err) == codes.NotFound {
+		c.InvalidatePlacement(agentID)
+	}
+	if err != nil {
+		return Placement{}, err
+	}
+
+	placement := Placement{
+		AgentID:   resp.GetPlacement().GetAgentId(),
+		RelayID:   resp.GetPlacement().GetRelayId(),
+		UpdatedAt: time.UnixMilli(resp.GetPlacement().GetLastUpdatedUnixMs()),
+		Metadata:  agentMetadataFromHeader(header),
+	}
+	c.cachePlacement(placement)
+	return placement, nil
+}
+
+// InvalidatePlacement drops the cached placement of agentID, so the next
+// GetAgentPlacement asks the registry.
+func (c *Client) InvalidatePlacement(agentID string) {
+	c.placementsMu.Lock()
+	defer c.placementsMu.Unlock()
+
+	delete(c.placements, agentID)
+}
+
+func (c *Client) cachedPlacement(agentID string) (Placement, bool) {
+	c.placementsMu.Lock()
+	defer c.placementsMu.Unlock()
+
+	cached, ok := c.placements[agentID]
+	if !ok {
+		return Placement{}, false
+	}
+	if !c.now().Before(cached.expiresAt) {
+		delete(c.placements, agentID)
+		return Placement{}, false
+	}
+	return cached.placement, true
+}
+
+func (c *Client) cachePlacement(placement Placement) {
+	if c.opts.placementCacheTTL == 0 {
+		return
+	}
+
+	c.placementsMu.Lock()
+	defer c.placementsMu.Unlock()
+
+	now := c.now()
+	if _, ok := c.placements[placement.AgentID]; !ok && len(c.placements) >= maxCachedPlacements {
+		for agentID, cached := range c.placements {
+			if !now.Before(cached.expiresAt) {
+				delete(c.placements, agentID)
+			}
+		}
+		if len(c.placements) >= maxCachedPlacements {
+			return
+		}
+	}
+
+	c.placements[placement.AgentID] = cachedPlacement{
+		placement: placement,
+		expiresAt: now.Add(c.opts.placementCacheTTL),
+	}
+}
+
+// agentMetadataPairs returns the request metadata carrying agentMetadata,
+// as key-value pairs.
+func agentMetadataPairs(agentMetadata AgentMetadata) []string {
+	var pairs []string
+	if agentMetadata.Kind != "" {
+		pairs = append(pairs, agentKindMetadataKey, agentMetadata.Kind)
+	}
+	if agentMetadata.Version != "" {
+		pairs = append(pairs, agentVersionMetadataKey, agentMetadata.Version)
+	}
+	if len(agentMetadata.Capabilities) > 0 {
+		pairs = append(pairs, agentCapabilitiesMetadataKey, strings.Join(agentMetadata.Capabilities, ","))
+	}
+	if !agentMetadata.SessionStart.IsZero() {
+		pairs = append(pairs, agentSessionStartMetadataKey, strconv.FormatInt(agentMetadata.SessionStart.UnixMilli(), 10))
+	}
+	return pairs
+}
+
+func agentMetadataFromHeader(header metadata.MD) AgentMetadata {
+	value := func(key string) string {
+		if values := header.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	agentMetadata := AgentMetadata{
+		Kind:    value(agentKindMetadataKey),
+		Version: value(agentVersionMetadataKey),
+	}
+	if capabilities := value(agentCapabilitiesMetadataKey); capabilities != "" {
+		agentMetadata.Capabilities = strings.Split(capabilities, ",")
+	}
+	if ms, err := strconv.ParseInt(value(agentSessionStartMetadataKey), 10, 64); err == nil {
+		agentMetadata.SessionStart = time.UnixMilli(ms)
+	}
+	return agentMetadata
+}
@@ -0,0 +1,440 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry"
+	"github.com/Aero-Arc/aero-arc-registry/internal/registry/backend/memory"
+	transport "github.com/Aero-Arc/aero-arc-registry/internal/transport/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testAdminToken is the admin token of registries from newTestRegistry.
+const testAdminToken = "s3cret"
+
+// testRegistry is a registry served over an in-memory listener.
+type testRegistry struct {
+	backend *memory.Backend
+	server  *transport.Server
+	lis     *bufconn.Listener
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	t.Helper()
+
+	backend, err := memory.New(&registry.MemoryConfig{})
+	if err != nil {
+		t.Fatalf("memory.New() error = %v", err)
+	}
+
+	cfg := &registry.Config{
+		Backend: registry.BackendConfig{Type: registry.MemoryRegistryBackend},
+		GRPC: registry.GRPCConfig{
+			ListenAddress: "127.0.0.1",
+			ListenPort:    50051,
+		},
+		TTL: registry.TTLConfig{
+			Relay: 5 * time.Second,
+			Agent: 5 * time.Second,
+		},
+		Admin:   registry.AdminConfig{Token: testAdminToken},
+		Tenants: []registry.TenantConfig{{Namespace: "fleet-a"}},
+	}
+	reg, err := registry.New(cfg, backend)
+	if err != nil {
+		t.Fatalf("registry.New() error = %v", err)
+	}
+
+	server, err := transport.New(reg)
+	if err != nil {
+		t.Fatalf("transport.New() error = %v", err)
+	}
+
+	r := &testRegistry{backend: backend, server: server, lis: bufconn.Listen(1 << 20)}
+	go func() { _ = server.Serve(r.lis) }()
+	t.Cleanup(server.GracefulStop)
+
+	return r
+}
+
+// newTestClient returns a client for the named registries, dialled over
+// their in-memory listeners.
+func newTestClient(t *testing.T, registries map[string]*testRegistry, names []string, opts ...Option) *Client {
+	t.Helper()
+
+	endpoints := make([]string, len(names))
+	for i, name := range names {
+		endpoints[i] = "passthrough:///" + name
+	}
+
+	dialer := grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		return registries[addr].lis.DialContext(ctx)
+	})
+	opts = append([]Option{
+		WithDialOptions(dialer),
+		WithHeartbeatInterval(10 * time.Millisecond),
+		WithBackoff(time.Millisecond, 10*time.Millisecond),
+		WithCallTimeout(time.Second),
+	}, opts...)
+
+	c, err := New(endpoints, opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNewValidatesOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		endpoints []string
+		opts      []Option
+		wantErr   error
+	}{
+		{
+			name:    "no endpoints",
+			wantErr: ErrNoEndpoints,
+		},
+		{
+			name:      "zero heartbeat interval",
+			endpoints: []string{"localhost:50051"},
+			opts:      []Option{WithHeartbeatInterval(0)},
+			wantErr:   ErrInvalidOption,
+		},
+		{
+			name:      "max backoff below min",
+			endpoints: []string{"localhost:50051"},
+			opts:      []Option{WithBackoff(time.Second, time.Millisecond)},
+			wantErr:   ErrInvalidOption,
+		},
+		{
+			name:      "negative placement cache ttl",
+			endpoints: []string{"localhost:50051"},
+			opts:      []Option{WithPlacementCacheTTL(-time.Second)},
+			wantErr:   ErrInvalidOption,
+		},
+		{
+			name:      "defaults",
+			endpoints: []string{"localhost:50051", "localhost:50052"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := New(test.endpoints, test.opts...)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+			if c != nil {
+				_ = c.Close()
+			}
+		})
+	}
+}
+
+func TestKeepAliveHeartbeats(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRegistry(t)
+	c := newTestClient(t, map[string]*testRegistry{"registry-a": r}, []string{"registry-a"})
+	ctx := context.Background()
+
+	relayBeat, err := c.KeepRelayAlive(ctx, Relay{ID: "relay-1", Address: "10.0.0.1", GRPCPort: 7000})
+	if err != nil {
+		t.Fatalf("KeepRelayAlive() error = %v", err)
+	}
+	if _, err := c.KeepAgentAlive(ctx, "agent-1", "relay-1"); err != nil {
+		t.Fatalf("KeepAgentAlive() error = %v", err)
+	}
+
+	relays, err := c.ListRelays(ctx)
+	if err != nil {
+		t.Fatalf("ListRelays() error = %v", err)
+	}
+	if len(relays) != 1 || relays[0].Address != "10.0.0.1" || relays[0].GRPCPort != 7000 {
+		t.Fatalf("expected relay-1 to be registered, got %+v", relays)
+	}
+	registeredAt := relays[0].LastHeartbeat
+
+	waitFor(t, "relay heartbeat", func() bool {
+		relays, err := c.ListRelays(ctx)
+		return err == nil && len(relays) == 1 && relays[0].LastHeartbeat.After(registeredAt)
+	})
+
+	relayBeat.Stop()
+	select {
+	case <-relayBeat.Done():
+	default:
+		t.Fatal("expected stopped heartbeat to be done")
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := c.ListRelays(ctx); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed after Close, got %v", err)
+	}
+	if _, err := c.KeepRelayAlive(ctx, Relay{ID: "relay-2", Address: "10.0.0.2"}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestKeepAliveReRegistersAfterExpiry(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRegistry(t)
+	c := newTestClient(t, map[string]*testRegistry{"registry-a": r}, []string{"registry-a"})
+	ctx := context.Background()
+
+	if _, err := c.KeepRelayAlive(ctx, Relay{ID: "relay-1", Address: "10.0.0.1"}); err != nil {
+		t.Fatalf("KeepRelayAlive() error = %v", err)
+	}
+	if _, err := c.KeepAgentAliveWithMetadata(ctx, "agent-1", "relay-1", AgentMetadata{Kind: "drone", Version: "px4-1.14.3"}); err != nil {
+		t.Fatalf("KeepAgentAliveWithMetadata() error = %v", err)
+	}
+
+	// Forget both, as a TTL sweep or a registry restart would.
+	if err := r.backend.RemoveAgents(ctx, registry.DefaultNamespace, []string{"agent-1"}); err != nil {
+		t.Fatalf("RemoveAgents() error = %v", err)
+	}
+	if err := r.backend.RemoveRelay(ctx, registry.DefaultNamespace, "relay-1"); err != nil {
+		t.Fatalf("RemoveRelay() error = %v", err)
+	}
+
+	waitFor(t, "relay and agent to be registered again", func() bool {
+		placement, err := r.backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1")
+		return err == nil && placement.RelayID == "relay-1" && placement.Metadata.Version == "px4-1.14.3"
+	})
+	relays, err := r.backend.ListRelays(ctx, registry.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("ListRelays() error = %v", err)
+	}
+	if len(relays) != 1 || relays[0].Address != "10.0.0.1" {
+		t.Fatalf("expected relay-1 to be registered again, got %+v", relays)
+	}
+}
+
+func TestFailoverToNextEndpoint(t *testing.T) {
+	t.Parallel()
+
+	registries := map[string]*testRegistry{
+		"registry-a": newTestRegistry(t),
+		"registry-b": newTestRegistry(t),
+	}
+	c := newTestClient(t, registries, []string{"registry-a", "registry-b"})
+	ctx := context.Background()
+
+	if err := c.RegisterRelay(ctx, Relay{ID: "relay-a", Address: "10.0.0.1"}); err != nil {
+		t.Fatalf("RegisterRelay() error = %v", err)
+	}
+	if got := c.Endpoint(); got != "passthrough:///registry-a" {
+		t.Fatalf("expected registry-a to be current, got %q", got)
+	}
+
+	registries["registry-a"].server.GracefulStop()
+
+	relays, err := c.ListRelays(ctx)
+	if err != nil {
+		t.Fatalf("ListRelays() error = %v", err)
+	}
+	if len(relays) != 0 {
+		t.Fatalf("expected the empty relay list of registry-b, got %+v", relays)
+	}
+	if got := c.Endpoint(); got != "passthrough:///registry-b" {
+		t.Fatalf("expected failover to registry-b, got %q", got)
+	}
+
+	// Answers other than unreachable are returned without failing over.
+	err = c.HeartbeatRelay(ctx, "relay-a")
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound from registry-b, got %v", err)
+	}
+	if got := c.Endpoint(); got != "passthrough:///registry-b" {
+		t.Fatalf("expected registry-b to stay current, got %q", got)
+	}
+}
+
+func TestPlacementCache(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRegistry(t)
+	c := newTestClient(t, map[string]*testRegistry{"registry-a": r}, []string{"registry-a"}, WithPlacementCacheTTL(time.Minute))
+	ctx := context.Background()
+
+	for _, relayID := range []string{"relay-1", "relay-2"} {
+		if err := c.RegisterRelay(ctx, Relay{ID: relayID, Address: "10.0.0.1"}); err != nil {
+			t.Fatalf("RegisterRelay() error = %v", err)
+		}
+	}
+	if err := c.RegisterAgent(ctx, "agent-1", "relay-1"); err != nil {
+		t.Fatalf("RegisterAgent() error = %v", err)
+	}
+
+	// Moved behind the client's back.
+	if err := r.backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-2"); err != nil {
+		t.Fatalf("RegisterAgent() error = %v", err)
+	}
+
+	placement, err := c.GetAgentPlacement(ctx, "agent-1")
+	if err != nil {
+		t.Fatalf("GetAgentPlacement() error = %v", err)
+	}
+	if placement.RelayID != "relay-1" {
+		t.Fatalf("expected cached placement on relay-1, got %q", placement.RelayID)
+	}
+
+	c.InvalidatePlacement("agent-1")
+	placement, err = c.GetAgentPlacement(ctx, "agent-1")
+	if err != nil {
+		t.Fatalf("GetAgentPlacement() error = %v", err)
+	}
+	if placement.RelayID != "relay-2" {
+		t.Fatalf("expected fresh placement on relay-2, got %q", placement.RelayID)
+	}
+
+	// Expired entries are fetched again.
+	if err := r.backend.RegisterAgent(ctx, registry.DefaultNamespace, registry.Agent{ID: "agent-1"}, "relay-1"); err != nil {
+		t.Fatalf("RegisterAgent() error = %v", err)
+	}
+	c.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	placement, err = c.GetAgentPlacement(ctx, "agent-1")
+	if err != nil {
+		t.Fatalf("GetAgentPlacement() error = %v", err)
+	}
+	if placement.RelayID != "relay-1" {
+		t.Fatalf("expected expired placement to be refetched, got %q", placement.RelayID)
+	}
+
+	if _, err := c.GetAgentPlacement(ctx, "agent-unknown"); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestAgentMetadata(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRegistry(t)
+	c := newTestClient(t, map[string]*testRegistry{"registry-a": r}, []string{"registry-a"}, WithPlacementCacheTTL(time.Minute))
+	ctx := context.Background()
+
+	if err := c.RegisterRelay(ctx, Relay{ID: "relay-1", Address: "10.0.0.1"}); err != nil {
+		t.Fatalf("RegisterRelay() error = %v", err)
+	}
+	want := AgentMetadata{
+		Kind:         "ground_station",
+		Version:      "qgc-4.4",
+		Capabilities: []string{"mission_upload", "video"},
+		SessionStart: time.UnixMilli(1700000000000),
+	}
+	if err := c.RegisterAgentWithMetadata(ctx, "agent-1", "relay-1", want); err != nil {
+		t.Fatalf("RegisterAgentWithMetadata() error = %v", err)
+	}
+
+	stored, err := r.backend.GetAgentPlacement(ctx, registry.DefaultNamespace, "agent-1")
+	if err != nil {
+		t.Fatalf("GetAgentPlacement() error = %v", err)
+	}
+	if stored.Metadata.Kind != registry.AgentKindGroundStation || !stored.Metadata.HasCapability("video") {
+		t.Fatalf("expected the registry to store the metadata, got %+v", stored.Metadata)
+	}
+
+	c.InvalidatePlacement("agent-1")
+	placement, err := c.GetAgentPlacement(ctx, "agent-1")
+	if err != nil {
+		t.Fatalf("GetAgentPlacement() error = %v", err)
+	}
+	if !reflect.DeepEqual(placement.Metadata, want) {
+		t.Fatalf("unexpected metadata: got %+v want %+v", placement.Metadata, want)
+	}
+
+	if err := c.RegisterAgentWithMetadata(ctx, "agent-2", "relay-1", AgentMetadata{Kind: "submarine"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for an unknown kind, got %v", err)
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRegistry(t)
+	registries := map[string]*testRegistry{"registry-a": r}
+	ctx := context.Background()
+
+	unbound := newTestClient(t, registries, []string{"registry-a"}, WithNamespace("fleet-a"))
+	if err := unbound.RegisterRelay(ctx, Relay{ID: "relay-1", Address: "10.0.0.1"}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for an unbound caller, got %v", err)
+	}
+
+	// Callers not bound to the tenant need the admin token to use it.
+	bearer := grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+testAdminToken)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	})
+	c := newTestClient(t, registries, []string{"registry-a"}, WithNamespace("fleet-a"), WithDialOptions(bearer))
+	if err := c.RegisterRelay(ctx, Relay{ID: "relay-1", Address: "10.0.0.1"}); err != nil {
+		t.Fatalf("RegisterRelay() error = %v", err)
+	}
+
+	if relays, _ := r.backend.ListRelays(ctx, "fleet-a"); len(relays) != 1 {
+		t.Fatalf("expected the relay in fleet-a, got %+v", relays)
+	}
+	if relays, _ := r.backend.ListRelays(ctx, registry.DefaultNamespace); len(relays) != 0 {
+		t.Fatalf("expected no relays in the default namespace, got %+v", relays)
+	}
+
+	other := newTestClient(t, registries, []string{"registry-a"}, WithNamespace("fleet-z"))
+	if _, err := other.ListRelays(ctx); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for an unknown namespace, got %v", err)
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{opts: options{minBackoff: 100 * time.Millisecond, maxBackoff: time.Second}}
+
+	tests := []struct {
+		failures int
+		lower    time.Duration
+		upper    time.Duration
+	}{
+		{failures: 1, lower: 50 * time.Millisecond, upper: 100 * time.Millisecond},
+		{failures: 3, lower: 200 * time.Millisecond, upper: 400 * time.Millisecond},
+		{failures: 5, lower: 500 * time.Millisecond, upper: time.Second},
+		{failures: 100, lower: 500 * time.Millisecond, upper: time.Second},
+	}
+
+	for _, test := range tests {
+		for range 20 {
+			if got := c.backoff(test.failures); got < test.lower || got > test.upper {
+				t.Fatalf("backoff(%d) = %v, expected within [%v, %v]", test.failures, got, test.lower, test.upper)
+			}
+		}
+	}
+}
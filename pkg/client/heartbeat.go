@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Heartbeat is a running heartbeat loop started by KeepRelayAlive or
+// KeepAgentAlive.
+type Heartbeat struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Stop ends the loop and waits for it to return. The relay or agent is
+// left to expire in the registry.
+func (h *Heartbeat) Stop() {
+	h.cancel()
+	<-h.done
+}
+
+// Done is closed once the loop has returned, after Stop or Close.
+func (h *Heartbeat) Done() <-chan struct{} {
+	return h.done
+}
+
+// heartbeatTarget is what a heartbeat loop keeps registered.
+type heartbeatTarget struct {
+	method   string
+	id       string
+	beat     func(ctx context.Context) error
+	register func(ctx context.Context) error
+}
+
+// KeepRelayAlive registers relay and then heartbeats it every heartbeat
+// interval until the returned Heartbeat is stopped or the client is
+// closed. When the registry no longer knows the relay, for example after
+// its TTL expired or the registry restarted, the loop registers it again.
+// Failed attempts are retried with jittered exponential backoff. Only the
+// initial registration error is returned; later ones are logged.
+func (c *Client) KeepRelayAlive(ctx context.Context, relay Relay) (*Heartbeat, error) {
+	if err := c.RegisterRelay(ctx, relay); err != nil {
+		return nil, err
+	}
+
+	return c.startHeartbeat(heartbeatTarget{
+		method: "client.KeepRelayAlive",
+		id:     relay.ID,
+		beat: func(ctx context.Context) error {
+			return c.HeartbeatRelay(ctx, relay.ID)
+		},
+		register: func(ctx context.Context) error {
+			return c.RegisterRelay(ctx, relay)
+		},
+	})
+}
+
+// KeepAgentAlive places agentID on relayID and then keeps it registered
+// the same way KeepRelayAlive does for relays. Re-registration fails until
+// the relay itself is registered again, so agents are best kept alive
+// alongside a KeepRelayAlive loop for their relay.
+func (c *Client) KeepAgentAlive(ctx context.Context, agentID, relayID string) (*Heartbeat, error) {
+	return c.KeepAgentAliveWithMetadata(ctx, agentID, relayID, AgentMetadata{})
+}
+
+// KeepAgentAliveWithMetadata is KeepAgentAlive for an agent that reports
+// metadata. Re-registrations send the same metadata.
+func (c *Client) KeepAgentAliveWithMetadata(ctx context.Context, agentID, relayID string, agentMetadata AgentMetadata) (*Heartbeat, error) {
+	if err := c.RegisterAgentWithMetadata(ctx, agentID, relayID, agentMetadata); err != nil {
+		return nil, err
+	}
+
+	return c.startHeartbeat(heartbeatTarget{
+		method: "client.KeepAgentAlive",
+		id:     agentID,
+		beat: func(ctx context.Context) error {
+			return c.HeartbeatAgent(ctx, agentID)
+		},
+		register: func(ctx context.Context) error {
+			return c.RegisterAgentWithMetadata(ctx, agentID, relayID, agentMetadata)
+		},
+	})
+}
+
+func (c *Client) startHeartbeat(target heartbeatTarget) (*Heartbeat, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClosed
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	h := &Heartbeat{cancel: cancel, done: make(chan struct{})}
+
+	c.loops.Add(1)
+	go func() {
+		defer c.loops.Done()
+		defer close(h.done)
+		defer cancel()
+
+		c.runHeartbeat(ctx, target)
+	}()
+
+	return h, nil
+}
+
+func (c *Client) runHeartbeat(ctx context.Context, target heartbeatTarget) {
+	timer := time.NewTimer(c.opts.heartbeatInterval)
+	defer timer.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		err := target.beat(ctx)
+		if status.Code(err) == codes.NotFound {
+			slog.LogAttrs(ctx, slog.LevelInfo, "registration lost, registering again",
+				slog.String("method", target.method),
+				slog.String("id", target.id),
+			)
+			err = target.register(ctx)
+		}
+
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			failures++
+			delay := c.backoff(failures)
+			slog.LogAttrs(ctx, slog.LevelWarn, "heartbeat failed",
+				slog.String("method", target.method),
+				slog.String("id", target.id),
+				slog.Int("failures", failures),
+				slog.Duration("retry_in", delay),
+				slog.String("error", err.Error()),
+			)
+			timer.Reset(delay)
+		default:
+			if failures > 0 {
+				slog.LogAttrs(ctx, slog.LevelInfo, "heartbeat recovered",
+					slog.String("method", target.method),
+					slog.String("id", target.id),
+					slog.Int("failures", failures),
+				)
+			}
+			failures = 0
+			timer.Reset(c.opts.heartbeatInterval)
+		}
+	}
+}
+
+// backoff returns the delay after the given number of consecutive
+// failures: the minimum backoff doubled per earlier failure, capped at the
+// maximum, with jitter over its upper half.
+func (c *Client) backoff(failures int) time.Duration {
+	delay := c.opts.minBackoff << (failures - 1)
+	if delay <= 0 || delay > c.opts.maxBackoff {
+		delay = c.opts.maxBackoff
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Defaults for options left unset.
+const (
+	// DefaultHeartbeatInterval is a third of the registry's default 30s
+	// relay and agent TTL, so two heartbeats may be lost before expiry.
+	DefaultHeartbeatInterval = 10 * time.Second
+	DefaultCallTimeout       = 5 * time.Second
+	DefaultMinBackoff        = 100 * time.Millisecond
+	DefaultMaxBackoff        = 10 * time.Second
+	DefaultPlacementCacheTTL = 5 * time.Second
+)
+
+// Option configures a Client created by New.
+type Option func(*options)
+
+type options struct {
+	dialOpts          []grpc.DialOption
+	heartbeatInterval time.Duration
+	callTimeout       time.Duration
+	minBackoff        time.Duration
+	maxBackoff        time.Duration
+	placementCacheTTL time.Duration
+	namespace         string
+}
+
+func defaultOptions() options {
+	return options{
+		heartbeatInterval: DefaultHeartbeatInterval,
+		callTimeout:       DefaultCallTimeout,
+		minBackoff:        DefaultMinBackoff,
+		maxBackoff:        DefaultMaxBackoff,
+		placementCacheTTL: DefaultPlacementCacheTTL,
+	}
+}
+
+// WithDialOptions passes opts to every endpoint connection, e.g. to
+// configure transport credentials. Without transport credentials the
+// client connects in plaintext.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+// WithHeartbeatInterval sets the delay between successful heartbeats. It
+// should be well below the registry's relay and agent TTLs.
+func WithHeartbeatInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.heartbeatInterval = interval
+	}
+}
+
+// WithCallTimeout bounds each attempt against a single endpoint, so an
+// unresponsive endpoint is failed over from instead of waited on.
+func WithCallTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.callTimeout = timeout
+	}
+}
+
+// WithBackoff sets the bounds of the jittered exponential delay between
+// failed heartbeat and registration attempts.
+func WithBackoff(minDelay, maxDelay time.Duration) Option {
+	return func(o *options) {
+		o.minBackoff = minDelay
+		o.maxBackoff = maxDelay
+	}
+}
+
+// WithPlacementCacheTTL sets how long GetAgentPlacement answers are served
+// from the local cache. Zero disables the cache.
+func WithPlacementCacheTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.placementCacheTTL = ttl
+	}
+}
+
+// WithNamespace scopes every call to the tenant namespace. Without it the
+// registry uses the namespace the caller's identity is bound to, or its
+// default namespace.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}